@@ -40,6 +40,19 @@ type ClusterBootstrapConfig struct {
 	// +optional
 	APIServerEndpoint string `json:"apiServerEndpoint,omitempty"`
 
+	// CACertHash overrides the CA certificate hash computed from the cluster-info ConfigMap
+	// Set it when the control plane does not publish a usable cluster-info, e.g. managed control planes
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	// +optional
+	CACertHash string `json:"caCertHash,omitempty"`
+
+	// ClusterInfoSecretRef references a secret holding the cluster CA certificate under the
+	// ca.crt key and optionally the API server endpoint under the endpoint key, used instead
+	// of the kube-public/cluster-info ConfigMap, e.g. when the operator can't read it.
+	// APIServerEndpoint and CACertHash still take precedence
+	// +optional
+	ClusterInfoSecretRef *ClusterInfoSecretReference `json:"clusterInfoSecretRef,omitempty"`
+
 	// TokenSecretRef is a reference to a secret containing the bootstrap token
 	// The secret should have keys: token, ca-cert-hash (for kubeadm)
 	// +optional
@@ -49,11 +62,28 @@ type ClusterBootstrapConfig struct {
 	// +kubebuilder:default=true
 	AutoGenerateToken bool `json:"autoGenerateToken,omitempty"`
 
+	// JoinConfigSecretRef references the key of a secret holding a complete kubeadm
+	// JoinConfiguration (e.g. key: config). It is written to nodes verbatim and passed to
+	// kubeadm join --config instead of the endpoint, token and CA cert hash flags.
+	// The bootstrap token is still acquired and replaces __BOOTSTRAP_TOKEN__ in the file
+	// +optional
+	JoinConfigSecretRef *SecretReference `json:"joinConfigSecretRef,omitempty"`
+
 	// KubernetesVersion specifies the Kubernetes version to install (e.g., "1.29", "1.30")
 	// +kubebuilder:default="1.29"
 	// +optional
 	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
 
+	// KubeletExtraArgs are additional kubelet flags, keyed by flag name without the leading dashes
+	// (e.g. max-pods: "110"). Not applicable to Talos
+	// +optional
+	KubeletExtraArgs map[string]string `json:"kubeletExtraArgs,omitempty"`
+
+	// ContainerdConfig contains the containerd settings of kubeadm nodes
+	// Omitted settings keep the containerd defaults, with the systemd cgroup driver enabled
+	// +optional
+	ContainerdConfig *ContainerdConfig `json:"containerdConfig,omitempty"`
+
 	// K3sConfig contains k3s-specific configuration
 	// +optional
 	K3sConfig *K3sBootstrapConfig `json:"k3sConfig,omitempty"`
@@ -65,6 +95,84 @@ type ClusterBootstrapConfig struct {
 	// RKE2Config contains RKE2-specific configuration
 	// +optional
 	RKE2Config *RKE2BootstrapConfig `json:"rke2Config,omitempty"`
+
+	// SSHHardening disables SSH password authentication and restricts root login on nodes
+	// Set to {} to enable with defaults. Not applicable to Talos, which has no SSH daemon
+	// +optional
+	SSHHardening *SSHHardeningConfig `json:"sshHardening,omitempty"`
+
+	// UnattendedUpgrades enables automatic security updates on nodes
+	// Uses unattended-upgrades on apt-based images and dnf-automatic on dnf-based images
+	// +optional
+	UnattendedUpgrades bool `json:"unattendedUpgrades,omitempty"`
+
+	// UpgradeReboot controls whether nodes reboot to apply updates that require it
+	// +optional
+	UpgradeReboot *UpgradeRebootConfig `json:"upgradeReboot,omitempty"`
+}
+
+// UpgradeRebootPolicy defines when nodes reboot after automatic updates
+type UpgradeRebootPolicy string
+
+const (
+	// UpgradeRebootNever never reboots nodes automatically
+	UpgradeRebootNever UpgradeRebootPolicy = "never"
+	// UpgradeRebootScheduled reboots nodes at the scheduled time when an update requires it
+	UpgradeRebootScheduled UpgradeRebootPolicy = "scheduled"
+)
+
+// UpgradeRebootConfig contains the reboot policy for automatic updates
+type UpgradeRebootConfig struct {
+	// Policy defines when nodes reboot after automatic updates (never, scheduled)
+	// +kubebuilder:validation:Enum=never;scheduled
+	// +kubebuilder:default=never
+	// +optional
+	Policy UpgradeRebootPolicy `json:"policy,omitempty"`
+
+	// Time is the daily time (HH:MM, node local time) at which a scheduled reboot happens
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	// +kubebuilder:default="04:00"
+	// +optional
+	Time string `json:"time,omitempty"`
+}
+
+// SSHHardeningConfig contains sshd hardening configuration
+type SSHHardeningConfig struct {
+	// PermitRootLogin is the sshd PermitRootLogin setting
+	// Use prohibit-password on images that only provision SSH keys for root (e.g. Hetzner)
+	// +kubebuilder:validation:Enum=no;prohibit-password
+	// +kubebuilder:default=no
+	// +optional
+	PermitRootLogin string `json:"permitRootLogin,omitempty"`
+}
+
+// ContainerdConfig contains the settings rendered into the containerd configuration of
+// kubeadm nodes
+type ContainerdConfig struct {
+	// SandboxImage is the pause image of pod sandboxes (e.g. registry.k8s.io/pause:3.9)
+	// Defaults to the sandbox image of the installed containerd release
+	// +kubebuilder:validation:Pattern=`^[^"\s]+$`
+	// +optional
+	SandboxImage string `json:"sandboxImage,omitempty"`
+
+	// SystemdCgroup runs containers with the systemd cgroup driver, which must match the
+	// kubelet's cgroup driver
+	// +kubebuilder:default=true
+	// +optional
+	SystemdCgroup *bool `json:"systemdCgroup,omitempty"`
+
+	// RegistryConfigPath is the directory of the per-registry hosts.toml files
+	// (e.g. /etc/containerd/certs.d)
+	// +kubebuilder:validation:Pattern=`^/[^"\s]*$`
+	// +optional
+	RegistryConfigPath string `json:"registryConfigPath,omitempty"`
+}
+
+// ClusterInfoSecretReference references a secret with the cluster CA certificate and API
+// server endpoint in the same namespace
+type ClusterInfoSecretReference struct {
+	// Name is the name of the secret
+	Name string `json:"name"`
 }
 
 // SecretReference references a secret in the same namespace
@@ -84,6 +192,12 @@ type K3sBootstrapConfig struct {
 
 	// TokenSecretRef references the secret containing the k3s token
 	TokenSecretRef *SecretReference `json:"tokenSecretRef,omitempty"`
+
+	// Version pins the k3s release installed on nodes (e.g. "v1.29.4+k3s1")
+	// Defaults to the latest stable release at the time the node is created
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+\+k3s[0-9]+$`
+	// +optional
+	Version string `json:"version,omitempty"`
 }
 
 // TalosBootstrapConfig contains Talos-specific bootstrap configuration
@@ -102,4 +216,10 @@ type RKE2BootstrapConfig struct {
 
 	// TokenSecretRef references the secret containing the RKE2 token
 	TokenSecretRef *SecretReference `json:"tokenSecretRef,omitempty"`
+
+	// Version pins the RKE2 release installed on nodes (e.g. "v1.29.4+rke2r1")
+	// Defaults to the latest stable release at the time the node is created
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+\+rke2r[0-9]+$`
+	// +optional
+	Version string `json:"version,omitempty"`
 }
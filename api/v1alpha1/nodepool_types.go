@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -27,17 +28,53 @@ type CloudProvider string
 const (
 	CloudProviderHetzner  CloudProvider = "hetzner"
 	CloudProviderOVHcloud CloudProvider = "ovhcloud"
+	CloudProviderScaleway CloudProvider = "scaleway"
 	// Future providers can be added here:
 	// CloudProviderAWS     CloudProvider = "aws"
 	// CloudProviderGCP     CloudProvider = "gcp"
 	// CloudProviderAzure   CloudProvider = "azure"
 )
 
+// BootMode defines what a Hetzner Cloud server boots from
+type BootMode string
+
+// Supported boot modes
+const (
+	// BootModeImage boots servers from their image, configured by cloud-init
+	BootModeImage BootMode = "Image"
+	// BootModeISO boots servers from an ISO attached after creation, e.g. a Talos ISO
+	BootModeISO BootMode = "ISO"
+)
+
+// DrainMode defines how a node is prepared before it is deleted
+type DrainMode string
+
+// Supported drain modes
+const (
+	// DrainModeDrain cordons the node and evicts its pods
+	DrainModeDrain DrainMode = "Drain"
+	// DrainModeCordonOnly cordons the node and leaves its pods running until it is deleted
+	DrainModeCordonOnly DrainMode = "CordonOnly"
+	// DrainModeNone deletes the node as is
+	DrainModeNone DrainMode = "None"
+)
+
+// DrainFailurePolicy defines what happens to a node whose drain failed before its deletion
+type DrainFailurePolicy string
+
+// Supported drain failure policies
+const (
+	// DrainFailurePolicyProceed deletes the node anyway, along with the pods left on it
+	DrainFailurePolicyProceed DrainFailurePolicy = "Proceed"
+	// DrainFailurePolicyAbort keeps the node and retries its deletion on a later reconcile
+	DrainFailurePolicyAbort DrainFailurePolicy = "Abort"
+)
+
 // NodePoolSpec defines the desired state of NodePool
 type NodePoolSpec struct {
-	// Provider is the cloud provider (e.g., hetzner, ovhcloud)
+	// Provider is the cloud provider (e.g., hetzner, ovhcloud, scaleway)
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=hetzner;ovhcloud
+	// +kubebuilder:validation:Enum=hetzner;ovhcloud;scaleway
 	// +kubebuilder:default=hetzner
 	Provider CloudProvider `json:"provider"`
 
@@ -51,6 +88,11 @@ type NodePoolSpec struct {
 	// +optional
 	OVHcloudConfig *OVHcloudConfig `json:"ovhcloudConfig,omitempty"`
 
+	// ScalewayConfig contains Scaleway Instances specific configuration
+	// Required when provider is "scaleway"
+	// +optional
+	ScalewayConfig *ScalewayConfig `json:"scalewayConfig,omitempty"`
+
 	// MinNodes is the minimum number of nodes in the pool
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:default=1
@@ -65,18 +107,47 @@ type NodePoolSpec struct {
 	// +kubebuilder:validation:Minimum=0
 	TargetNodes int `json:"targetNodes,omitempty"`
 
-	// CloudInit is the cloud-init configuration for node initialization
+	// Priority ranks the pool against other pools that could run the same pending pods,
+	// higher first. It is exposed in the hcloud_operator_nodepool_priority metric for
+	// autoscalers choosing which pool to grow
+	// +optional
+	Priority int `json:"priority,omitempty"`
+
+	// CloudInit is the cloud-init configuration for node initialization. It is rendered as a
+	// Go template with {{.PoolName}}, {{.Namespace}}, {{.NodeName}} and {{.Provider}};
+	// cloud-init Jinja templates ("## template: jinja") are passed through unchanged
 	// +optional
 	CloudInit string `json:"cloudInit,omitempty"`
 
+	// NodeNameTemplate is a Go template for the names of the pool's servers and nodes, rendered
+	// with {{.Pool}}, {{.Namespace}}, {{.Random}} (4 random hex characters) and {{.Index}}, the
+	// lowest number from 0 giving a name no node of the pool has. Names must be lowercase DNS
+	// labels of at most 63 characters. Defaults to {{.Pool}}-{{.Random}}, not supported by
+	// OVHcloud pools, whose instances are matched to the pool by name
+	// +optional
+	NodeNameTemplate string `json:"nodeNameTemplate,omitempty"`
+
 	// SSHKeys is a list of SSH key IDs or names to add to the nodes
 	// +optional
 	SSHKeys []string `json:"sshKeys,omitempty"`
 
-	// Labels are additional labels to apply to cloud provider resources
+	// Labels are additional labels to apply to cloud provider resources and to the pool's nodes
+	// Changes are applied to existing nodes as well, labels added to nodes by others are kept
 	// +optional
 	Labels map[string]string `json:"labels,omitempty"`
 
+	// Taints are applied to the pool's nodes when they register and kept in sync on existing
+	// nodes, taints added to nodes by others are kept
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
+
+	// Annotations are free-form metadata, e.g. for cost allocation, propagated to cloud resources
+	// Hetzner stores them as server labels: keys and values are sanitized to label syntax and
+	// pairs that remain invalid are skipped. OVHcloud instances have no metadata to store them in.
+	// Labels take precedence over annotations with the same key
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
 	// AutoScalingEnabled enables automatic scaling based on cluster load
 	// +kubebuilder:default=true
 	AutoScalingEnabled bool `json:"autoScalingEnabled"`
@@ -86,6 +157,19 @@ type NodePoolSpec struct {
 	// +kubebuilder:default=5
 	ScaleUpThreshold int `json:"scaleUpThreshold,omitempty"`
 
+	// ScaleUpStep is the maximum number of nodes added by a single autoscaling scale-up
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	ScaleUpStep int `json:"scaleUpStep,omitempty"`
+
+	// PodsPerNode is the estimated number of pending pods one new node can schedule
+	// Scale-ups add one node per PodsPerNode pending pods, up to ScaleUpStep
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=10
+	// +optional
+	PodsPerNode int `json:"podsPerNode,omitempty"`
+
 	// ScaleDownThreshold is the CPU utilization percentage to trigger scale down
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
@@ -103,17 +187,129 @@ type NodePoolSpec struct {
 	// RunCmd contains commands to run after node initialization
 	// +optional
 	RunCmd []string `json:"runCmd,omitempty"`
+
+	// Files are written to nodes from ConfigMaps by the generated cloud-init
+	// +optional
+	Files []NodeFile `json:"files,omitempty"`
+
+	// DNSServers are the IP addresses of the nameservers nodes resolve names with, instead
+	// of the ones provided by the network
+	// +kubebuilder:validation:MaxItems=3
+	// +optional
+	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// NTPServers are the IP addresses or hostnames of the NTP servers nodes synchronize
+	// their clock with, instead of the distribution's defaults
+	// +optional
+	NTPServers []string `json:"ntpServers,omitempty"`
+
+	// DrainMode is how nodes are prepared before they are deleted by scale-downs and
+	// replacements: Drain cordons them and evicts their pods, CordonOnly only stops new pods
+	// from being scheduled on them and None leaves them untouched
+	// +kubebuilder:validation:Enum=Drain;CordonOnly;None
+	// +kubebuilder:default=Drain
+	// +optional
+	DrainMode DrainMode `json:"drainMode,omitempty"`
+
+	// DrainGracePeriodSeconds overrides the termination grace period of the pods evicted by
+	// a drain. Pods get their own terminationGracePeriodSeconds when unset
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DrainGracePeriodSeconds *int64 `json:"drainGracePeriodSeconds,omitempty"`
+
+	// DrainDeleteEmptyDirData evicts pods with emptyDir volumes during a drain, deleting
+	// their data. Like kubectl drain without --delete-emptydir-data, they are left running
+	// until the node is deleted otherwise
+	// +optional
+	DrainDeleteEmptyDirData bool `json:"drainDeleteEmptyDirData,omitempty"`
+
+	// DrainExcludeNamespaces are namespaces whose pods a drain leaves in place, such as the
+	// monitoring and logging agents that should keep running until the node is deleted
+	// +optional
+	DrainExcludeNamespaces []string `json:"drainExcludeNamespaces,omitempty"`
+
+	// DrainExcludePodSelectors select pods a drain leaves in place, a pod matching any of them
+	// is not evicted
+	// +optional
+	DrainExcludePodSelectors []metav1.LabelSelector `json:"drainExcludePodSelectors,omitempty"`
+
+	// DrainFailurePolicy is what happens to a node whose drain failed, e.g. because a
+	// PodDisruptionBudget blocked an eviction: Proceed deletes it anyway and Abort keeps it,
+	// records it in the dead letter queue and retries its deletion on a later reconcile
+	// +kubebuilder:validation:Enum=Proceed;Abort
+	// +kubebuilder:default=Proceed
+	// +optional
+	DrainFailurePolicy DrainFailurePolicy `json:"drainFailurePolicy,omitempty"`
+
+	// RollingUpdate replaces nodes whose server type or image differs from the pool's
+	// configuration, a few at a time. Without it existing nodes keep their configuration
+	// and only new nodes use the updated one
+	// +optional
+	RollingUpdate *RollingUpdateStrategy `json:"rollingUpdate,omitempty"`
+
+	// UnhealthyNodeTimeout is how long a node of the pool may stay NotReady before it is
+	// drained and deleted along with its server, to be replaced by a new node.
+	// Unhealthy nodes are kept when unset
+	// +optional
+	UnhealthyNodeTimeout *metav1.Duration `json:"unhealthyNodeTimeout,omitempty"`
+
+	// MaxUnhealthyReplacements caps the unhealthy nodes replaced at once. Nodes of the pool that
+	// are not ready yet, such as earlier replacements, count against it, so an outage that marks
+	// every node NotReady, e.g. of the control plane, replaces at most that many nodes
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	MaxUnhealthyReplacements int `json:"maxUnhealthyReplacements,omitempty"`
+
+	// MinNodeLifetime is how long a node of the pool runs before a scale-down may remove it,
+	// so nodes aren't deleted right after they were provisioned. Outdated nodes replaced by a
+	// rolling update are removed regardless. Nodes may be removed at any age when unset
+	// +optional
+	MinNodeLifetime *metav1.Duration `json:"minNodeLifetime,omitempty"`
+
+	// ProviderOperationTimeout bounds how long a single cloud provider operation
+	// (creating, deleting or attaching a server) may take before it fails and is retried.
+	// Overrides the operator's --provider-operation-timeout flag for this pool
+	// +optional
+	ProviderOperationTimeout *metav1.Duration `json:"providerOperationTimeout,omitempty"`
+}
+
+// RollingUpdateStrategy bounds how many nodes are replaced at once during a rolling update
+// Replacement nodes are created before outdated nodes are deleted
+type RollingUpdateStrategy struct {
+	// MaxSurge is the number of nodes that may be created above the desired node count
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// +optional
+	MaxSurge *int `json:"maxSurge,omitempty"`
+
+	// MaxUnavailable is the number of nodes the pool may run below the desired node count
+	// while outdated nodes are deleted. A pool never drops below minNodes - maxUnavailable
+	// running nodes. When both are 0, maxSurge is taken as 1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
 }
 
 // HetznerCloudConfig contains Hetzner Cloud specific configuration
+// +kubebuilder:validation:XValidation:rule="!has(self.enableIPv4) || self.enableIPv4 || !has(self.enableIPv6) || self.enableIPv6 || (has(self.network) && size(self.network) > 0)",message="network is required when both enableIPv4 and enableIPv6 are false"
+// +kubebuilder:validation:XValidation:rule="(has(self.location) && size(self.location) > 0) || (has(self.locations) && size(self.locations) > 0)",message="location or locations is required"
+// +kubebuilder:validation:XValidation:rule="!has(self.bootMode) || self.bootMode != 'ISO' || (has(self.isoName) && size(self.isoName) > 0)",message="isoName is required when bootMode is ISO"
 type HetznerCloudConfig struct {
 	// ServerType is the Hetzner Cloud server type (e.g., cx11, cpx21)
 	// +kubebuilder:validation:Required
 	ServerType string `json:"serverType"`
 
 	// Location is the Hetzner Cloud location (e.g., nbg1, fsn1, hel1)
-	// +kubebuilder:validation:Required
-	Location string `json:"location"`
+	// Required unless locations is set
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Locations spreads the pool's servers across several Hetzner Cloud locations, overriding
+	// location. New servers are created in the location with the fewest servers of the pool,
+	// ties going to the location listed first
+	// +optional
+	Locations []string `json:"locations,omitempty"`
 
 	// Image is the OS image to use for nodes (e.g., ubuntu-22.04)
 	// +kubebuilder:validation:Required
@@ -122,8 +318,118 @@ type HetznerCloudConfig struct {
 	// Network is the Hetzner Cloud network ID or name to attach nodes to
 	// +optional
 	Network string `json:"network,omitempty"`
+
+	// LoadBalancer is the Hetzner Cloud load balancer name or ID to register nodes as targets of
+	// Nodes are reached over the private network when Network is set
+	// +optional
+	LoadBalancer string `json:"loadBalancer,omitempty"`
+
+	// PlacementGroup is the Hetzner Cloud placement group name or ID to create nodes in,
+	// spreading them across physical hosts. A group given by name is created as a spread
+	// group if it does not exist, and deleted with the pool once empty.
+	// +optional
+	PlacementGroup string `json:"placementGroup,omitempty"`
+
+	// SnapshotCache caches the prepared node image as a snapshot keyed by a hash of the
+	// bootstrap configuration, so new nodes skip package installation on boot.
+	// The snapshot is rebuilt whenever the hash changes. Only supported for kubeadm bootstrap.
+	// +optional
+	SnapshotCache bool `json:"snapshotCache,omitempty"`
+
+	// EnableIPv4 assigns a public IPv4 address to nodes
+	// Disable it to provision IPv6-only nodes and save on IPv4 costs
+	// +kubebuilder:default=true
+	// +optional
+	EnableIPv4 *bool `json:"enableIPv4,omitempty"`
+
+	// EnableIPv6 assigns a public IPv6 address to nodes
+	// +kubebuilder:default=true
+	// +optional
+	EnableIPv6 *bool `json:"enableIPv6,omitempty"`
+
+	// CredentialsSecretRef references a secret holding the API token of the Hetzner Cloud
+	// project the pool's servers are created in. Defaults to the operator's global token
+	// +optional
+	CredentialsSecretRef *SecretReference `json:"credentialsSecretRef,omitempty"`
+
+	// Volumes are Hetzner Cloud volumes created for each node, attached to it at creation
+	// and deleted with it. They are mounted by the generated cloud-init
+	// +optional
+	Volumes []HetznerVolume `json:"volumes,omitempty"`
+
+	// BootMode is what servers boot from: Image boots the image configured by cloud-init,
+	// ISO attaches isoName after creation and resets the server to boot from it, for
+	// operating systems such as Talos that aren't configured by cloud-init
+	// +kubebuilder:validation:Enum=Image;ISO
+	// +kubebuilder:default=Image
+	// +optional
+	BootMode BootMode `json:"bootMode,omitempty"`
+
+	// ISOName is the name or ID of the Hetzner Cloud ISO servers boot from with bootMode ISO
+	// +optional
+	ISOName string `json:"isoName,omitempty"`
+
+	// FloatingIPs are the names or IDs of existing floating IPs assigned to the pool's servers,
+	// e.g. to give ingress nodes stable public IPs. Each new server is assigned one that isn't
+	// assigned yet, if any, and servers release theirs when they are deleted
+	// +optional
+	FloatingIPs []string `json:"floatingIPs,omitempty"`
+
+	// PrimaryIPs are the names or IDs of existing primary IPs the pool's servers take their
+	// public addresses from, so recreated nodes keep them. Each new server takes an unassigned
+	// IPv4 and IPv6 primary IP of its location, if any, and creation fails while all of those
+	// are assigned. The primary IPs need auto-delete disabled to outlive their servers
+	// +optional
+	PrimaryIPs []string `json:"primaryIPs,omitempty"`
+}
+
+// HetznerVolume defines a Hetzner Cloud volume attached to each node of a pool
+type HetznerVolume struct {
+	// Size is the volume size in GB
+	// +kubebuilder:validation:Minimum=10
+	// +kubebuilder:validation:Maximum=10240
+	Size int `json:"size"`
+
+	// Format is the filesystem the volume is formatted with
+	// +kubebuilder:validation:Enum=ext4;xfs
+	// +kubebuilder:default=ext4
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// MountPath is the absolute path the volume is mounted at on the node
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^/`
+	MountPath string `json:"mountPath"`
 }
 
+// ServerLocations returns the locations the pool's servers are spread across
+func (c *HetznerCloudConfig) ServerLocations() []string {
+	if len(c.Locations) > 0 {
+		return c.Locations
+	}
+	return []string{c.Location}
+}
+
+// PublicIPv4Enabled reports whether nodes get a public IPv4 address
+func (c *HetznerCloudConfig) PublicIPv4Enabled() bool {
+	return c.EnableIPv4 == nil || *c.EnableIPv4
+}
+
+// PublicIPv6Enabled reports whether nodes get a public IPv6 address
+func (c *HetznerCloudConfig) PublicIPv6Enabled() bool {
+	return c.EnableIPv6 == nil || *c.EnableIPv6
+}
+
+// PublicNetworkFailurePolicy defines how OVHcloud instance creation handles a failed public network lookup
+type PublicNetworkFailurePolicy string
+
+const (
+	// PublicNetworkFailurePolicyFail fails the instance creation
+	PublicNetworkFailurePolicyFail PublicNetworkFailurePolicy = "fail"
+	// PublicNetworkFailurePolicyPrivateOnly creates the instance with the private network only
+	PublicNetworkFailurePolicyPrivateOnly PublicNetworkFailurePolicy = "private-only"
+)
+
 // OVHcloudConfig contains OVHcloud Public Cloud specific configuration
 type OVHcloudConfig struct {
 	// Flavor is the flavor (instance type) name to use for instances (e.g., "b3-8", "c2-7")
@@ -160,9 +466,55 @@ type OVHcloudConfig struct {
 	// +optional
 	NetworkID string `json:"networkID,omitempty"`
 
+	// PublicNetworkFailurePolicy controls instance creation when the public network can't be
+	// resolved for an instance attached to a private network (fail, private-only)
+	// private-only instances have no internet access and can't download bootstrap packages
+	// +kubebuilder:validation:Enum=fail;private-only
+	// +kubebuilder:default=fail
+	// +optional
+	PublicNetworkFailurePolicy PublicNetworkFailurePolicy `json:"publicNetworkFailurePolicy,omitempty"`
+
+	// MonthlyBilling bills instances monthly instead of hourly, which is cheaper for
+	// long-lived instances. Changing it doesn't switch the billing of existing instances,
+	// only instances created afterwards are affected
+	// +optional
+	MonthlyBilling bool `json:"monthlyBilling,omitempty"`
+
 	// ProjectID is the OVHcloud project ID
 	// +kubebuilder:validation:Required
 	ProjectID string `json:"projectID"`
+
+	// CredentialsSecretRef references a secret holding the OVHcloud API credentials of the
+	// pool's project, with the keys endpoint, application-key, application-secret and consumer-key.
+	// Defaults to the operator's global credentials
+	// +optional
+	CredentialsSecretRef *CredentialsSecretReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// CredentialsSecretReference references a secret holding cloud provider credentials in the
+// same namespace
+type CredentialsSecretReference struct {
+	// Name is the name of the secret
+	Name string `json:"name"`
+}
+
+// ScalewayConfig contains Scaleway Instances specific configuration
+type ScalewayConfig struct {
+	// Zone is the Scaleway availability zone (e.g., fr-par-1, nl-ams-1, pl-waw-1)
+	// +kubebuilder:validation:Required
+	Zone string `json:"zone"`
+
+	// CommercialType is the Instance type (e.g., DEV1-M, PRO2-S)
+	// +kubebuilder:validation:Required
+	CommercialType string `json:"commercialType"`
+
+	// Image is the OS image label or UUID to use for instances (e.g., ubuntu_jammy)
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+
+	// ProjectID is the Scaleway project ID to create instances in
+	// +kubebuilder:validation:Required
+	ProjectID string `json:"projectID"`
 }
 
 // FirewallRule defines a single firewall rule
@@ -174,11 +526,56 @@ type FirewallRule struct {
 	// +kubebuilder:default=tcp
 	Protocol string `json:"protocol,omitempty"`
 
+	// Sources are the CIDRs allowed to reach the port (e.g. 10.0.0.0/8, 2001:db8::/32)
+	// Defaults to any IPv4 and IPv6 source
+	// +optional
+	Sources []string `json:"sources,omitempty"`
+
 	// Description is a human-readable description
 	// +optional
 	Description string `json:"description,omitempty"`
 }
 
+// NodeFile defines a file written to nodes from a ConfigMap key
+type NodeFile struct {
+	// ConfigMapRef references the ConfigMap key holding the file content
+	// The ConfigMap must be in the NodePool's namespace
+	// +kubebuilder:validation:Required
+	ConfigMapRef ConfigMapKeyReference `json:"configMapRef"`
+
+	// Path is the absolute path the file is written to on the node
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+
+	// Permissions is the octal file mode
+	// +kubebuilder:validation:Pattern=`^0[0-7]{3}$`
+	// +kubebuilder:default="0644"
+	// +optional
+	Permissions string `json:"permissions,omitempty"`
+}
+
+// ConfigMapKeyReference references a key of a ConfigMap
+type ConfigMapKeyReference struct {
+	// Name is the name of the ConfigMap
+	Name string `json:"name"`
+
+	// Key is the key in the ConfigMap
+	Key string `json:"key"`
+}
+
+// ResolvedID is a cloud resource ID resolved from its name
+type ResolvedID struct {
+	// Name is the resource name the ID was resolved from
+	Name string `json:"name"`
+
+	// Region is the region the name was resolved in
+	Region string `json:"region"`
+
+	// ID is the resolved resource ID
+	ID string `json:"id"`
+}
+
 // NodePoolStatus defines the observed state of NodePool
 type NodePoolStatus struct {
 	// CurrentNodes is the current number of nodes in the pool
@@ -190,17 +587,55 @@ type NodePoolStatus struct {
 	// Nodes is a list of node names in the pool
 	Nodes []string `json:"nodes,omitempty"`
 
+	// UpdatedNodes is the number of nodes running the pool's current server type and image
+	// +optional
+	UpdatedNodes int `json:"updatedNodes,omitempty"`
+
+	// NodeTemplateHashes maps node names to a hash of the server type and image they were
+	// created with, to find the nodes a rolling update replaces
+	// +optional
+	NodeTemplateHashes map[string]string `json:"nodeTemplateHashes,omitempty"`
+
 	// LastScaleTime is the last time the pool was scaled
 	// +optional
 	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
 
+	// ResolvedFlavorID is the OVHcloud flavor ID resolved from ovhcloudConfig.flavor, reused
+	// until the flavor name or region changes
+	// +optional
+	ResolvedFlavorID *ResolvedID `json:"resolvedFlavorID,omitempty"`
+
+	// ResolvedImageID is the OVHcloud image ID resolved from ovhcloudConfig.image, reused
+	// until the image name or region changes
+	// +optional
+	ResolvedImageID *ResolvedID `json:"resolvedImageID,omitempty"`
+
 	// Conditions represent the latest available observations of the node pool's state
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
 	// Phase represents the current phase of the node pool
+	// Ready once at least minNodes nodes are ready, Scaling until then, or the reason of
+	// the last failure
 	// +optional
 	Phase string `json:"phase,omitempty"`
+
+	// LastError is the error of the last failed reconcile, cleared by a successful one
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// FailureCount is the number of consecutive failed reconciles
+	// +optional
+	FailureCount int `json:"failureCount,omitempty"`
+
+	// CreateFailures is the number of consecutive failed server creations. Creations are
+	// suspended once it reaches the failure threshold, see the CreateBackoff condition
+	// +optional
+	CreateFailures int `json:"createFailures,omitempty"`
+
+	// ObservedGeneration is the spec generation the last successful reconcile acted on
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -213,6 +648,10 @@ type NodePoolStatus struct {
 // +kubebuilder:printcolumn:name="Max",type=integer,JSONPath=`.spec.maxNodes`
 // +kubebuilder:printcolumn:name="Current",type=integer,JSONPath=`.status.currentNodes`
 // +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyNodes`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`,priority=1
+// +kubebuilder:printcolumn:name="Failures",type=integer,JSONPath=`.status.failureCount`,priority=1
+// +kubebuilder:printcolumn:name="LastError",type=string,JSONPath=`.status.lastError`,priority=1
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // NodePool is the Schema for the nodepools API
@@ -0,0 +1,69 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the NodePool defaulting webhook with the manager
+func (r *NodePool) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&nodePoolDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-autokube-io-v1alpha1-nodepool,mutating=true,failurePolicy=fail,sideEffects=None,groups=autokube.io,resources=nodepools,verbs=create;update,versions=v1alpha1,name=mnodepool.autokube.io,admissionReviewVersions=v1
+
+// nodePoolDefaulter defaults NodePools on admission
+type nodePoolDefaulter struct{}
+
+var _ admission.CustomDefaulter = &nodePoolDefaulter{}
+
+// Default implements admission.CustomDefaulter
+func (d *nodePoolDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	nodePool, ok := obj.(*NodePool)
+	if !ok {
+		return fmt.Errorf("expected a NodePool but got a %T", obj)
+	}
+	nodePool.Default()
+	return nil
+}
+
+// Default sets TargetNodes to MinNodes for pools that don't autoscale, so the pool reports
+// the size it is kept at, and clamps a set TargetNodes into [MinNodes, MaxNodes]
+func (r *NodePool) Default() {
+	spec := &r.Spec
+	if spec.TargetNodes == 0 && !spec.AutoScalingEnabled {
+		spec.TargetNodes = spec.MinNodes
+	}
+	if spec.TargetNodes == 0 {
+		return
+	}
+	if spec.TargetNodes < spec.MinNodes {
+		spec.TargetNodes = spec.MinNodes
+	}
+	if spec.MaxNodes >= spec.MinNodes && spec.TargetNodes > spec.MaxNodes {
+		spec.TargetNodes = spec.MaxNodes
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNodePoolDefaulter(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       NodePoolSpec
+		wantTarget int
+	}{
+		{
+			name:       "no target without autoscaling",
+			spec:       NodePoolSpec{MinNodes: 3, MaxNodes: 10},
+			wantTarget: 3,
+		},
+		{
+			name:       "no target with autoscaling",
+			spec:       NodePoolSpec{MinNodes: 3, MaxNodes: 10, AutoScalingEnabled: true},
+			wantTarget: 0,
+		},
+		{
+			name:       "target within bounds",
+			spec:       NodePoolSpec{MinNodes: 3, MaxNodes: 10, TargetNodes: 5},
+			wantTarget: 5,
+		},
+		{
+			name:       "target below minNodes",
+			spec:       NodePoolSpec{MinNodes: 3, MaxNodes: 10, TargetNodes: 2, AutoScalingEnabled: true},
+			wantTarget: 3,
+		},
+		{
+			name:       "target above maxNodes",
+			spec:       NodePoolSpec{MinNodes: 3, MaxNodes: 10, TargetNodes: 12},
+			wantTarget: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodePool := &NodePool{Spec: tt.spec}
+			if err := (&nodePoolDefaulter{}).Default(context.Background(), nodePool); err != nil {
+				t.Fatalf("Default() error = %v", err)
+			}
+			if nodePool.Spec.TargetNodes != tt.wantTarget {
+				t.Errorf("TargetNodes = %d, want %d", nodePool.Spec.TargetNodes, tt.wantTarget)
+			}
+		})
+	}
+}
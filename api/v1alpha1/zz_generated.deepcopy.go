@@ -21,18 +21,41 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterBootstrapConfig) DeepCopyInto(out *ClusterBootstrapConfig) {
 	*out = *in
+	if in.ClusterInfoSecretRef != nil {
+		in, out := &in.ClusterInfoSecretRef, &out.ClusterInfoSecretRef
+		*out = new(ClusterInfoSecretReference)
+		**out = **in
+	}
 	if in.TokenSecretRef != nil {
 		in, out := &in.TokenSecretRef, &out.TokenSecretRef
 		*out = new(SecretReference)
 		**out = **in
 	}
+	if in.JoinConfigSecretRef != nil {
+		in, out := &in.JoinConfigSecretRef, &out.JoinConfigSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.KubeletExtraArgs != nil {
+		in, out := &in.KubeletExtraArgs, &out.KubeletExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ContainerdConfig != nil {
+		in, out := &in.ContainerdConfig, &out.ContainerdConfig
+		*out = new(ContainerdConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.K3sConfig != nil {
 		in, out := &in.K3sConfig, &out.K3sConfig
 		*out = new(K3sBootstrapConfig)
@@ -48,6 +71,16 @@ func (in *ClusterBootstrapConfig) DeepCopyInto(out *ClusterBootstrapConfig) {
 		*out = new(RKE2BootstrapConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SSHHardening != nil {
+		in, out := &in.SSHHardening, &out.SSHHardening
+		*out = new(SSHHardeningConfig)
+		**out = **in
+	}
+	if in.UpgradeReboot != nil {
+		in, out := &in.UpgradeReboot, &out.UpgradeReboot
+		*out = new(UpgradeRebootConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBootstrapConfig.
@@ -60,9 +93,79 @@ func (in *ClusterBootstrapConfig) DeepCopy() *ClusterBootstrapConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterInfoSecretReference) DeepCopyInto(out *ClusterInfoSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterInfoSecretReference.
+func (in *ClusterInfoSecretReference) DeepCopy() *ClusterInfoSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterInfoSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyReference) DeepCopyInto(out *ConfigMapKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyReference.
+func (in *ConfigMapKeyReference) DeepCopy() *ConfigMapKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerdConfig) DeepCopyInto(out *ContainerdConfig) {
+	*out = *in
+	if in.SystemdCgroup != nil {
+		in, out := &in.SystemdCgroup, &out.SystemdCgroup
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerdConfig.
+func (in *ContainerdConfig) DeepCopy() *ContainerdConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerdConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSecretReference) DeepCopyInto(out *CredentialsSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSecretReference.
+func (in *CredentialsSecretReference) DeepCopy() *CredentialsSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallRule) DeepCopyInto(out *FirewallRule) {
 	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRule.
@@ -78,6 +181,41 @@ func (in *FirewallRule) DeepCopy() *FirewallRule {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HetznerCloudConfig) DeepCopyInto(out *HetznerCloudConfig) {
 	*out = *in
+	if in.Locations != nil {
+		in, out := &in.Locations, &out.Locations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EnableIPv4 != nil {
+		in, out := &in.EnableIPv4, &out.EnableIPv4
+		*out = new(bool)
+		**out = **in
+	}
+	if in.EnableIPv6 != nil {
+		in, out := &in.EnableIPv6, &out.EnableIPv6
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]HetznerVolume, len(*in))
+		copy(*out, *in)
+	}
+	if in.FloatingIPs != nil {
+		in, out := &in.FloatingIPs, &out.FloatingIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PrimaryIPs != nil {
+		in, out := &in.PrimaryIPs, &out.PrimaryIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerCloudConfig.
@@ -90,6 +228,21 @@ func (in *HetznerCloudConfig) DeepCopy() *HetznerCloudConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HetznerVolume) DeepCopyInto(out *HetznerVolume) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HetznerVolume.
+func (in *HetznerVolume) DeepCopy() *HetznerVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(HetznerVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *K3sBootstrapConfig) DeepCopyInto(out *K3sBootstrapConfig) {
 	*out = *in
@@ -110,6 +263,22 @@ func (in *K3sBootstrapConfig) DeepCopy() *K3sBootstrapConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeFile) DeepCopyInto(out *NodeFile) {
+	*out = *in
+	out.ConfigMapRef = in.ConfigMapRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeFile.
+func (in *NodeFile) DeepCopy() *NodeFile {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodePool) DeepCopyInto(out *NodePool) {
 	*out = *in
@@ -175,6 +344,16 @@ func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
 	if in.HetznerConfig != nil {
 		in, out := &in.HetznerConfig, &out.HetznerConfig
 		*out = new(HetznerCloudConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OVHcloudConfig != nil {
+		in, out := &in.OVHcloudConfig, &out.OVHcloudConfig
+		*out = new(OVHcloudConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScalewayConfig != nil {
+		in, out := &in.ScalewayConfig, &out.ScalewayConfig
+		*out = new(ScalewayConfig)
 		**out = **in
 	}
 	if in.SSHKeys != nil {
@@ -189,6 +368,20 @@ func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]v1.Taint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Bootstrap != nil {
 		in, out := &in.Bootstrap, &out.Bootstrap
 		*out = new(ClusterBootstrapConfig)
@@ -197,13 +390,67 @@ func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
 	if in.FirewallRules != nil {
 		in, out := &in.FirewallRules, &out.FirewallRules
 		*out = make([]FirewallRule, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.RunCmd != nil {
 		in, out := &in.RunCmd, &out.RunCmd
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]NodeFile, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSServers != nil {
+		in, out := &in.DNSServers, &out.DNSServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DrainGracePeriodSeconds != nil {
+		in, out := &in.DrainGracePeriodSeconds, &out.DrainGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DrainExcludeNamespaces != nil {
+		in, out := &in.DrainExcludeNamespaces, &out.DrainExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DrainExcludePodSelectors != nil {
+		in, out := &in.DrainExcludePodSelectors, &out.DrainExcludePodSelectors
+		*out = make([]metav1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdateStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnhealthyNodeTimeout != nil {
+		in, out := &in.UnhealthyNodeTimeout, &out.UnhealthyNodeTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MinNodeLifetime != nil {
+		in, out := &in.MinNodeLifetime, &out.MinNodeLifetime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ProviderOperationTimeout != nil {
+		in, out := &in.ProviderOperationTimeout, &out.ProviderOperationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolSpec.
@@ -224,13 +471,30 @@ func (in *NodePoolStatus) DeepCopyInto(out *NodePoolStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NodeTemplateHashes != nil {
+		in, out := &in.NodeTemplateHashes, &out.NodeTemplateHashes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.LastScaleTime != nil {
 		in, out := &in.LastScaleTime, &out.LastScaleTime
 		*out = (*in).DeepCopy()
 	}
+	if in.ResolvedFlavorID != nil {
+		in, out := &in.ResolvedFlavorID, &out.ResolvedFlavorID
+		*out = new(ResolvedID)
+		**out = **in
+	}
+	if in.ResolvedImageID != nil {
+		in, out := &in.ResolvedImageID, &out.ResolvedImageID
+		*out = new(ResolvedID)
+		**out = **in
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -247,6 +511,26 @@ func (in *NodePoolStatus) DeepCopy() *NodePoolStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OVHcloudConfig) DeepCopyInto(out *OVHcloudConfig) {
+	*out = *in
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(CredentialsSecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OVHcloudConfig.
+func (in *OVHcloudConfig) DeepCopy() *OVHcloudConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OVHcloudConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RKE2BootstrapConfig) DeepCopyInto(out *RKE2BootstrapConfig) {
 	*out = *in
@@ -267,6 +551,71 @@ func (in *RKE2BootstrapConfig) DeepCopy() *RKE2BootstrapConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolvedID) DeepCopyInto(out *ResolvedID) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedID.
+func (in *ResolvedID) DeepCopy() *ResolvedID {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolvedID)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateStrategy) DeepCopyInto(out *RollingUpdateStrategy) {
+	*out = *in
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStrategy.
+func (in *RollingUpdateStrategy) DeepCopy() *RollingUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHHardeningConfig) DeepCopyInto(out *SSHHardeningConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHHardeningConfig.
+func (in *SSHHardeningConfig) DeepCopy() *SSHHardeningConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHHardeningConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalewayConfig) DeepCopyInto(out *ScalewayConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalewayConfig.
+func (in *ScalewayConfig) DeepCopy() *ScalewayConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ScalewayConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretReference) DeepCopyInto(out *SecretReference) {
 	*out = *in
@@ -301,3 +650,18 @@ func (in *TalosBootstrapConfig) DeepCopy() *TalosBootstrapConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRebootConfig) DeepCopyInto(out *UpgradeRebootConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRebootConfig.
+func (in *UpgradeRebootConfig) DeepCopy() *UpgradeRebootConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRebootConfig)
+	in.DeepCopyInto(out)
+	return out
+}
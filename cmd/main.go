@@ -21,6 +21,8 @@ import (
 	"context"
 	"flag"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -33,6 +35,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
@@ -42,6 +45,7 @@ import (
 	"github.com/autokubeio/autokube/internal/metrics"
 	"github.com/autokubeio/autokube/internal/ovhcloud"
 	"github.com/autokubeio/autokube/internal/reliability"
+	"github.com/autokubeio/autokube/internal/scaleway"
 	"github.com/autokubeio/autokube/internal/security"
 )
 
@@ -59,28 +63,111 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var leaderElectionNamespace string
+	var leaderElectionID string
 	var probeAddr string
 	var hcloudToken string
+	var hcloudTokenFile string
 	var useK8sSecret bool
 	var secretNamespace string
 	var secretName string
 	var encryptionKey string
+	var previousEncryptionKeys string
+	var dlqAddr string
+	var dlqFile string
+	var gracefulShutdownTimeout time.Duration
+	var maxConcurrentReconciles int
+	var maxCreatesPerReconcile int
+	var requeueJitter float64
+	var retryBudget float64
+	var retryBudgetBurst int
+	var providerOperationTimeout time.Duration
+	var ovhResolverCacheTTL time.Duration
+	var ovhUserDataEncoding string
+	var ovhEndpoint string
+	var ovhAppKey string
+	var ovhAppSecret string
+	var ovhConsumerKey string
+	var ovhProjectID string
+	var ovhRegion string
+	var enableWebhooks bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"Namespace of the leader election lease. Defaults to the namespace the operator runs in.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "nodepools.autokube.io",
+		"Name of the leader election lease.")
 	flag.StringVar(&hcloudToken, "hcloud-token", os.Getenv("HCLOUD_TOKEN"),
 		"Hetzner Cloud API token (can also be set via HCLOUD_TOKEN environment variable)")
+	flag.StringVar(&hcloudTokenFile, "hcloud-token-file", "",
+		"File holding the Hetzner Cloud API token, e.g. mounted by a CSI driver or Vault agent. "+
+			"The file is watched and a rotated token is used once it is validated. Takes precedence over --hcloud-token.")
 	flag.BoolVar(&useK8sSecret, "use-k8s-secret", false,
-		"Use Kubernetes Secret for HCLOUD_TOKEN instead of environment variable")
+		"Use Kubernetes Secret for HCLOUD_TOKEN instead of environment variable. "+
+			"The secret is watched and a rotated token is used once it is validated.")
 	flag.StringVar(&secretNamespace, "secret-namespace", "default",
 		"Namespace for the Kubernetes Secret containing HCLOUD_TOKEN")
 	flag.StringVar(&secretName, "secret-name", "hcloud-credentials",
 		"Name of the Kubernetes Secret containing HCLOUD_TOKEN")
 	flag.StringVar(&encryptionKey, "encryption-key", os.Getenv("ENCRYPTION_KEY"),
 		"Encryption key for sensitive data (can also be set via ENCRYPTION_KEY environment variable)")
+	flag.StringVar(&previousEncryptionKeys, "previous-encryption-keys", os.Getenv("PREVIOUS_ENCRYPTION_KEYS"),
+		"Comma-separated previous encryption keys, only used to decrypt data encrypted before the key was rotated "+
+			"(can also be set via PREVIOUS_ENCRYPTION_KEYS environment variable)")
+	flag.StringVar(&dlqAddr, "dlq-bind-address", "0",
+		"The address the dead letter queue endpoint binds to. Use \"0\" to disable. "+
+			"The endpoint is unauthenticated and allows deleting entries, so bind it to localhost "+
+			"or protect it with a NetworkPolicy.")
+	flag.StringVar(&dlqFile, "dlq-file", "",
+		"File the dead letter queue is saved to on shutdown and loaded from on start, "+
+			"so failed operations survive restarts. Leave empty to keep the queue in memory only.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 30*time.Second,
+		"How long the operator waits for in-flight reconciles to finish on shutdown before the dead letter "+
+			"queue is saved and the operator exits.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"Maximum number of NodePools reconciled in parallel. Values above 1 keep a slow cloud API "+
+			"call on one pool from stalling the others, but NodePools then share the cloud API rate limit "+
+			"and circuit breaker concurrently.")
+	flag.IntVar(&maxCreatesPerReconcile, "max-creates-per-reconcile", 10,
+		"Maximum number of servers created for a NodePool in one reconcile, so a misconfigured size can't "+
+			"create a runaway number of servers at once. The remaining servers are created by the following reconciles.")
+	flag.Float64Var(&requeueJitter, "requeue-jitter", 0.1,
+		"Fraction by which the interval NodePools are reconciled at is randomized either way, so pools "+
+			"created together don't call the cloud APIs in lockstep. Use 0 to disable.")
+	flag.Float64Var(&retryBudget, "retry-budget", 1,
+		"Average number of cloud API retries per second shared by all NodePools, so an outage failing every "+
+			"call doesn't multiply the load on the API. First attempts are not limited. Use 0 for unlimited retries.")
+	flag.IntVar(&retryBudgetBurst, "retry-budget-burst", 20,
+		"Number of cloud API retries allowed in a burst above --retry-budget.")
+	flag.DurationVar(&providerOperationTimeout, "provider-operation-timeout", 5*time.Minute,
+		"Maximum time a single cloud provider operation (creating, deleting or attaching a server) may take "+
+			"before it fails and is retried. NodePools can override it with spec.providerOperationTimeout.")
+	flag.StringVar(&ovhEndpoint, "ovh-endpoint", os.Getenv("OVHCLOUD_ENDPOINT"),
+		"OVHcloud API endpoint: ovh-eu, ovh-us, ovh-ca, kimsufi-eu, kimsufi-ca, soyoustart-eu or soyoustart-ca "+
+			"(can also be set via OVHCLOUD_ENDPOINT environment variable)")
+	flag.StringVar(&ovhAppKey, "ovh-application-key", os.Getenv("OVHCLOUD_APPLICATION_KEY"),
+		"OVHcloud application key (can also be set via OVHCLOUD_APPLICATION_KEY environment variable)")
+	flag.StringVar(&ovhAppSecret, "ovh-application-secret", os.Getenv("OVHCLOUD_APPLICATION_SECRET"),
+		"OVHcloud application secret (can also be set via OVHCLOUD_APPLICATION_SECRET environment variable)")
+	flag.StringVar(&ovhConsumerKey, "ovh-consumer-key", os.Getenv("OVHCLOUD_CONSUMER_KEY"),
+		"OVHcloud consumer key (can also be set via OVHCLOUD_CONSUMER_KEY environment variable)")
+	flag.StringVar(&ovhProjectID, "ovh-project-id", os.Getenv("OVHCLOUD_PROJECT_ID"),
+		"OVHcloud Public Cloud project ID (can also be set via OVHCLOUD_PROJECT_ID environment variable)")
+	flag.StringVar(&ovhRegion, "ovh-region", os.Getenv("OVHCLOUD_REGION"),
+		"Default OVHcloud region, e.g. GRA7 (can also be set via OVHCLOUD_REGION environment variable)")
+	flag.DurationVar(&ovhResolverCacheTTL, "ovh-resolver-cache-ttl", ovhcloud.DefaultResolverCacheTTL,
+		"How long OVHcloud flavor, image, SSH key and network IDs resolved from their names are cached. "+
+			"Use 0 to disable caching.")
+	flag.StringVar(&ovhUserDataEncoding, "ovh-user-data-encoding", string(ovhcloud.UserDataEncodingBase64),
+		"How user data is sent to the OVHcloud instance API: base64, or plain for API versions that take "+
+			"plain text user data.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the NodePool defaulting webhook on port 9443. Requires a serving certificate in "+
+			"/tmp/k8s-webhook-server/serving-certs and a MutatingWebhookConfiguration pointing at the operator.")
 
 	opts := zap.Options{
 		Development: true,
@@ -114,6 +201,7 @@ func main() {
 			secretNamespace,
 			security.WithSecretName(secretName),
 			security.WithEncryptionKey([]byte(encryptionKey)),
+			security.WithDecryptionKeys(splitKeys(previousEncryptionKeys)...),
 		)
 	} else {
 		secretsManager = security.NewSecretsManager(
@@ -122,8 +210,34 @@ func main() {
 			security.WithSecretName(secretName),
 		)
 	}
+	if err := ovhcloud.ValidateUserDataEncoding(ovhcloud.UserDataEncoding(ovhUserDataEncoding)); err != nil {
+		setupLog.Error(err, "invalid --ovh-user-data-encoding")
+		cancel()
+		os.Exit(1)
+	}
+	if err := secretsManager.Validate(); err != nil {
+		setupLog.Error(err, "invalid encryption key", "help", "Set ENCRYPTION_KEY to a 16, 24 or 32 byte key")
+		cancel()
+		os.Exit(1)
+	}
+
+	// Get token from a file, K8s secret or environment variable
+	if hcloudTokenFile != "" && useK8sSecret {
+		setupLog.Error(nil, "--hcloud-token-file and --use-k8s-secret are mutually exclusive")
+		cancel()
+		os.Exit(1)
+	}
+	if hcloudTokenFile != "" {
+		setupLog.Info("Loading HCLOUD_TOKEN from file", "path", hcloudTokenFile)
 
-	// Get token from K8s secret or environment variable
+		token, err := security.ReadTokenFile(hcloudTokenFile)
+		if err != nil {
+			setupLog.Error(err, "Failed to read HCLOUD_TOKEN from file", "path", hcloudTokenFile)
+			cancel()
+			os.Exit(1)
+		}
+		hcloudToken = token
+	}
 	if useK8sSecret {
 		setupLog.Info("Loading HCLOUD_TOKEN from Kubernetes Secret",
 			"namespace", secretNamespace,
@@ -144,7 +258,7 @@ func main() {
 	// Validate token
 	if hcloudToken == "" {
 		setupLog.Error(nil, "HCLOUD_TOKEN must be set",
-			"help", "Set HCLOUD_TOKEN environment variable or use --use-k8s-secret flag")
+			"help", "Set HCLOUD_TOKEN environment variable or use the --hcloud-token-file or --use-k8s-secret flag")
 		cancel()
 		os.Exit(1)
 	}
@@ -165,9 +279,11 @@ func main() {
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
 		},
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "nodepools.autokube.io",
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaderElectionID:        leaderElectionID,
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -175,32 +291,119 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Retries of every cloud API client are bounded by the same budget
+	var budget *reliability.RetryBudget
+	if retryBudget > 0 {
+		budget = reliability.NewRetryBudget(retryBudget, retryBudgetBurst)
+	}
+
 	// Initialize Hetzner Cloud client with circuit breaker
 	circuitBreaker := reliability.NewCircuitBreaker(reliability.DefaultCircuitBreakerConfig())
-	hcloudClient := hetzner.NewClient(hcloudToken, hetzner.WithCircuitBreaker(circuitBreaker))
+	hcloudClient := hetzner.NewClient(
+		hcloudToken,
+		hetzner.WithCircuitBreaker(circuitBreaker),
+		hetzner.WithOperationTimeout(providerOperationTimeout),
+		hetzner.WithRetryBudget(budget),
+	)
+
+	// Report not ready while the cloud APIs are unreachable or their circuit breakers are open
+	cloudAPICheck := reliability.NewCloudAPIHealthCheck()
+	cloudAPICheck.AddProvider("hetzner", hcloudClient, circuitBreaker)
+
+	// Switch to a rotated token from the file or secret once it is validated
+	var tokenSource security.TokenSource
+	switch {
+	case hcloudTokenFile != "":
+		tokenSource = security.FileTokenSource(hcloudTokenFile)
+	case useK8sSecret:
+		tokenSource = secretsManager.GetToken
+	}
+	if tokenSource != nil {
+		watcher := security.NewTokenWatcher(tokenSource, hcloudToken,
+			func(token string) {
+				hcloudClient.SetToken(token)
+				setupLog.Info("HCLOUD_TOKEN rotated", "sanitized_token", tokenValidator.SanitizeToken(token))
+			},
+			security.WithTokenValidation(tokenValidator.Validate),
+			security.WithTokenErrorHandler(func(err error) {
+				setupLog.Error(err, "Failed to update HCLOUD_TOKEN, keeping the current token")
+			}),
+		)
+		if err := mgr.Add(watcher); err != nil {
+			setupLog.Error(err, "unable to set up HCLOUD_TOKEN watcher")
+			cancel()
+			os.Exit(1)
+		}
+	}
 
 	// Initialize OVHcloud client if credentials are available
 	var ovhcloudClient ovhcloud.ClientInterface
-	ovhEndpoint := os.Getenv("OVHCLOUD_ENDPOINT")
-	ovhAppKey := os.Getenv("OVHCLOUD_APPLICATION_KEY")
-	ovhAppSecret := os.Getenv("OVHCLOUD_APPLICATION_SECRET")
-	ovhConsumerKey := os.Getenv("OVHCLOUD_CONSUMER_KEY")
-	ovhProjectID := os.Getenv("OVHCLOUD_PROJECT_ID")
-	ovhRegion := os.Getenv("OVHCLOUD_REGION")
-
-	if ovhEndpoint != "" && ovhAppKey != "" && ovhAppSecret != "" && ovhConsumerKey != "" {
+	ovhCredentials := map[string]string{
+		"ovh-endpoint":           ovhEndpoint,
+		"ovh-application-key":    ovhAppKey,
+		"ovh-application-secret": ovhAppSecret,
+		"ovh-consumer-key":       ovhConsumerKey,
+		"ovh-project-id":         ovhProjectID,
+	}
+	missingOVHCredentials := missingFlags(ovhCredentials)
+	switch len(missingOVHCredentials) {
+	case 0:
 		setupLog.Info("Initializing OVHcloud client", "endpoint", ovhEndpoint, "region", ovhRegion)
-		ovhcloudClient = ovhcloud.NewClient(
+		ovhCircuitBreaker := reliability.NewCircuitBreaker(reliability.DefaultCircuitBreakerConfig())
+		client, err := ovhcloud.NewClient(
 			ovhEndpoint,
 			ovhAppKey,
 			ovhAppSecret,
 			ovhConsumerKey,
 			ovhProjectID,
 			ovhRegion,
-			ovhcloud.WithCircuitBreaker(circuitBreaker),
+			ovhcloud.WithCircuitBreaker(ovhCircuitBreaker),
+			ovhcloud.WithOperationTimeout(providerOperationTimeout),
+			ovhcloud.WithResolverCacheTTL(ovhResolverCacheTTL),
+			ovhcloud.WithUserDataEncoding(ovhcloud.UserDataEncoding(ovhUserDataEncoding)),
+			ovhcloud.WithRetryBudget(budget),
 		)
-	} else {
+		if err != nil {
+			setupLog.Error(err, "unable to create OVHcloud client", "endpoint", ovhEndpoint)
+			cancel()
+			os.Exit(1)
+		}
+
+		// Validate credentials with API
+		setupLog.Info("Validating OVHcloud credentials...")
+		if err := client.Ping(ctx); err != nil {
+			setupLog.Error(err, "OVHcloud credentials validation failed",
+				"endpoint", ovhEndpoint,
+				"projectID", ovhProjectID,
+				"help", "Ensure the consumer key is valid and grants access to the project")
+			cancel()
+			os.Exit(1)
+		}
+		setupLog.Info("OVHcloud credentials validated successfully")
+
+		ovhcloudClient = client
+		cloudAPICheck.AddProvider("ovhcloud", client, ovhCircuitBreaker)
+	case len(ovhCredentials):
 		setupLog.Info("OVHcloud credentials not provided, OVHcloud provider will not be available")
+	default:
+		setupLog.Error(nil, "Incomplete OVHcloud credentials", "missing", missingOVHCredentials,
+			"help", "Set all of the OVHcloud flags or environment variables, or none of them")
+		cancel()
+		os.Exit(1)
+	}
+
+	// Initialize Scaleway client if credentials are available
+	var scalewayClient scaleway.ClientInterface
+	if scwSecretKey := os.Getenv("SCW_SECRET_KEY"); scwSecretKey != "" {
+		setupLog.Info("Initializing Scaleway client")
+		scalewayClient = scaleway.NewClient(
+			scwSecretKey,
+			scaleway.WithCircuitBreaker(reliability.NewCircuitBreaker(reliability.DefaultCircuitBreakerConfig())),
+			scaleway.WithOperationTimeout(providerOperationTimeout),
+			scaleway.WithRetryBudget(budget),
+		)
+	} else {
+		setupLog.Info("Scaleway credentials not provided, Scaleway provider will not be available")
 	}
 
 	// Initialize metrics collector
@@ -229,23 +432,92 @@ func main() {
 			"operation_type", op.OperationType,
 			"retry_count", op.RetryCount)
 	})
+	deadLetterQueue.AddSizeListener(metricsCollector.RecordDeadLetterQueueSize)
+	if dlqFile != "" {
+		if err := deadLetterQueue.LoadFile(dlqFile); err != nil {
+			setupLog.Error(err, "unable to load dead letter queue, starting with an empty queue", "path", dlqFile)
+		}
+	}
+	deadLetterQueue.AddProcessingListener(func(operationType string, duration time.Duration, err error) {
+		if err != nil {
+			setupLog.Error(err, "Dead letter queue listener failed", "operation_type", operationType)
+		}
+		metricsCollector.RecordDeadLetterQueueProcessing(operationType, duration, err)
+	})
+
+	if dlqAddr != "0" {
+		setupLog.Info("Serving dead letter queue", "address", dlqAddr, "path", reliability.DeadLetterPath)
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return reliability.ServeDeadLetterQueue(ctx, dlqAddr, deadLetterQueue)
+		})); err != nil {
+			setupLog.Error(err, "unable to set up dead letter queue endpoint")
+			cancel()
+			os.Exit(1)
+		}
+	}
 
 	if err = (&controller.NodePoolReconciler{
 		Client:             mgr.GetClient(),
 		Scheme:             mgr.GetScheme(),
 		HCloudClient:       hcloudClient,
 		OVHCloudClient:     ovhcloudClient,
+		ScalewayClient:     scalewayClient,
 		MetricsClient:      metricsCollector,
 		KubeClient:         kubeClient,
 		BootstrapManager:   bootstrapManager,
 		CloudInitGenerator: cloudInitGenerator,
 		DeadLetterQueue:    deadLetterQueue,
+		Recorder:           mgr.GetEventRecorderFor("nodepool-controller"),
+		APIReader:          mgr.GetAPIReader(),
+		SecretsManager:     secretsManager,
+
+		// Pools with their own credentials get clients with their own circuit breaker, so a
+		// failing project doesn't block the others
+		NewHetznerClient: func(token string) hetzner.ClientInterface {
+			return hetzner.NewClient(
+				token,
+				hetzner.WithCircuitBreaker(reliability.NewCircuitBreaker(reliability.DefaultCircuitBreakerConfig())),
+				hetzner.WithOperationTimeout(providerOperationTimeout),
+				hetzner.WithRetryBudget(budget),
+			)
+		},
+		NewOVHCloudClient: func(credentials ovhcloud.Credentials) (ovhcloud.ClientInterface, error) {
+			client, err := ovhcloud.NewClient(
+				credentials.Endpoint,
+				credentials.ApplicationKey,
+				credentials.ApplicationSecret,
+				credentials.ConsumerKey,
+				credentials.ProjectID,
+				ovhRegion,
+				ovhcloud.WithCircuitBreaker(reliability.NewCircuitBreaker(reliability.DefaultCircuitBreakerConfig())),
+				ovhcloud.WithOperationTimeout(providerOperationTimeout),
+				ovhcloud.WithResolverCacheTTL(ovhResolverCacheTTL),
+				ovhcloud.WithUserDataEncoding(ovhcloud.UserDataEncoding(ovhUserDataEncoding)),
+				ovhcloud.WithRetryBudget(budget),
+			)
+			if err != nil {
+				return nil, err
+			}
+			return client, nil
+		},
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		MaxCreatesPerReconcile:  maxCreatesPerReconcile,
+		RequeueJitter:           requeueJitter,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NodePool")
 		cancel()
 		os.Exit(1)
 	}
 
+	if enableWebhooks {
+		if err := (&hcloudv1alpha1.NodePool{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NodePool")
+			cancel()
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		cancel()
@@ -256,11 +528,53 @@ func main() {
 		cancel()
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("cloud-api", cloudAPICheck.Check); err != nil {
+		setupLog.Error(err, "unable to set up cloud API ready check")
+		cancel()
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
-		setupLog.Error(err, "problem running manager")
+	// On SIGTERM the manager stops starting reconciles and waits for the ones in flight
+	runErr := mgr.Start(ctrl.SetupSignalHandler())
+
+	// Log the failed operations still queued for the listener, then persist the queue
+	deadLetterQueue.Close()
+	if dlqFile != "" {
+		if err := deadLetterQueue.SaveFile(dlqFile); err != nil {
+			setupLog.Error(err, "unable to save dead letter queue", "path", dlqFile)
+		} else {
+			setupLog.Info("saved dead letter queue", "path", dlqFile, "operations", deadLetterQueue.Size())
+		}
+	}
+	setupLog.Info("shutting down", "circuitBreaker", circuitBreaker.GetState().String())
+
+	if runErr != nil {
+		setupLog.Error(runErr, "problem running manager")
 		cancel()
 		os.Exit(1)
 	}
 }
+
+// missingFlags returns the sorted names of the flags without a value
+func missingFlags(flags map[string]string) []string {
+	var missing []string
+	for name, value := range flags {
+		if value == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// splitKeys splits a comma-separated list of keys, ignoring empty entries
+func splitKeys(keys string) [][]byte {
+	var split [][]byte
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			split = append(split, []byte(key))
+		}
+	}
+	return split
+}
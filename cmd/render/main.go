@@ -0,0 +1,206 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package main renders the cloud-init the NodePool operator would generate for a new node
+// of a pool, for debugging nodes that fail to bootstrap. It needs no cloud or cluster access:
+// bootstrap tokens are generated against an in-memory cluster and never registered.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/bootstrap"
+	"github.com/autokubeio/autokube/internal/controller"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(hcloudv1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run renders the cloud-init of the pool described by the command line arguments to out
+func run(ctx context.Context, args []string, out io.Writer) error {
+	var nodePoolFile string
+	var clusterInfoFile string
+	var objectsFile string
+	var fromSnapshot bool
+
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.StringVar(&nodePoolFile, "nodepool", "", "Path to the NodePool manifest to render the cloud-init of")
+	flags.StringVar(&clusterInfoFile, "cluster-info", "",
+		"Path to the kubeconfig of the kube-public/cluster-info ConfigMap, required for kubeadm pools "+
+			"that don't set both bootstrap.apiServerEndpoint and bootstrap.caCertHash")
+	flags.StringVar(&objectsFile, "objects", "",
+		"Path to a manifest of the ConfigMaps and Secrets the pool references, e.g. files and token secrets")
+	flags.BoolVar(&fromSnapshot, "from-snapshot", false,
+		"Render the cloud-init of a node booted from the pool's bootstrap snapshot")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if nodePoolFile == "" {
+		return errors.New("--nodepool is required")
+	}
+
+	nodePool, err := readNodePool(nodePoolFile)
+	if err != nil {
+		return err
+	}
+
+	var objects []runtime.Object
+	if objectsFile != "" {
+		decoded, err := readObjects(objectsFile)
+		if err != nil {
+			return err
+		}
+		objects = referencedObjects(decoded, nodePool.Namespace)
+	}
+	if clusterInfoFile != "" {
+		kubeconfig, err := os.ReadFile(clusterInfoFile) //nolint:gosec // G304: path given on the command line
+		if err != nil {
+			return fmt.Errorf("failed to read cluster-info: %w", err)
+		}
+		objects = append(objects, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-info", Namespace: "kube-public"},
+			Data:       map[string]string{"kubeconfig": string(kubeconfig)},
+		})
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(objects...)
+	reconciler := &controller.NodePoolReconciler{
+		Client:             clientfake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		Scheme:             scheme,
+		KubeClient:         kubeClient,
+		BootstrapManager:   bootstrap.NewBootstrapTokenManager(kubeClient),
+		CloudInitGenerator: bootstrap.NewCloudInitGenerator(),
+	}
+
+	cloudInit, err := reconciler.RenderCloudInit(ctx, nodePool, fromSnapshot)
+	if err != nil {
+		return fmt.Errorf("failed to render cloud-init: %w", err)
+	}
+	if cloudInit == "" {
+		return errors.New("the pool has neither a bootstrap config nor cloud-init")
+	}
+	_, err = fmt.Fprintln(out, cloudInit)
+	return err
+}
+
+// readNodePool reads the first NodePool of a manifest and applies the defaults the API
+// server would
+func readNodePool(path string) (*hcloudv1alpha1.NodePool, error) {
+	objects, err := readObjects(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		nodePool, ok := obj.(*hcloudv1alpha1.NodePool)
+		if !ok {
+			continue
+		}
+		if nodePool.Namespace == "" {
+			nodePool.Namespace = metav1.NamespaceDefault
+		}
+		nodePool.Default()
+		if config := nodePool.Spec.Bootstrap; config != nil {
+			// The CRD defaults, an unset autoGenerateToken can't be told apart from false
+			if config.Type == "" {
+				config.Type = hcloudv1alpha1.ClusterTypeKubeadm
+			}
+			if config.TokenSecretRef == nil {
+				config.AutoGenerateToken = true
+			}
+		}
+		return nodePool, nil
+	}
+	return nil, fmt.Errorf("no NodePool found in %s", path)
+}
+
+// referencedObjects returns the ConfigMaps and Secrets among objects, placing those without
+// a namespace in the pool's namespace
+func referencedObjects(objects []runtime.Object, namespace string) []runtime.Object {
+	var referenced []runtime.Object
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *corev1.ConfigMap:
+			if o.Namespace == "" {
+				o.Namespace = namespace
+			}
+			referenced = append(referenced, o)
+		case *corev1.Secret:
+			if o.Namespace == "" {
+				o.Namespace = namespace
+			}
+			referenced = append(referenced, o)
+		}
+	}
+	return referenced
+}
+
+// readObjects decodes the objects of a multi-document YAML or JSON manifest
+func readObjects(path string) ([]runtime.Object, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path given on the command line
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var objects []runtime.Object
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		objects = append(objects, obj)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clusterInfoKubeconfig returns a cluster-info kubeconfig with a self-signed CA certificate
+func clusterInfoKubeconfig(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), IsCA: true, BasicConstraintsValid: true, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	caData := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	return `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: ` + caData + `
+    server: https://10.0.0.1:6443
+  name: ""
+`
+}
+
+// writeFile writes content to a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodePool     string
+		objects      string
+		clusterInfo  bool
+		wantContains []string
+		wantErr      bool
+	}{
+		{
+			name: "kubeadm pool",
+			nodePool: `apiVersion: autokube.io/v1alpha1
+kind: NodePool
+metadata:
+  name: workers
+spec:
+  provider: hetzner
+  minNodes: 1
+  maxNodes: 3
+  bootstrap:
+    kubernetesVersion: "1.30"
+    kubeletExtraArgs:
+      max-pods: "200"
+    containerdConfig:
+      sandboxImage: registry.example.com/pause:3.9
+`,
+			clusterInfo: true,
+			wantContains: []string{
+				"#cloud-config",
+				"kubeadm join 10.0.0.1:6443",
+				"--token ",
+				"pkgs.k8s.io/core:/stable:/v1.30/deb",
+				"--max-pods=200",
+				`sandbox_image = "registry.example.com/pause:3.9"`,
+			},
+		},
+		{
+			name: "k3s pool with a token secret",
+			nodePool: `apiVersion: autokube.io/v1alpha1
+kind: NodePool
+metadata:
+  name: workers
+  namespace: edge
+spec:
+  provider: hetzner
+  minNodes: 1
+  maxNodes: 3
+  bootstrap:
+    type: k3s
+    k3sConfig:
+      serverURL: https://10.0.0.1:6443
+      tokenSecretRef:
+        name: k3s-token
+`,
+			objects: `apiVersion: v1
+kind: Secret
+metadata:
+  name: k3s-token
+data:
+  token: azNzLXNlY3JldC10b2tlbg==
+`,
+			wantContains: []string{"https://10.0.0.1:6443", "k3s-secret-token"},
+		},
+		{
+			name: "kubeadm pool without cluster-info",
+			nodePool: `apiVersion: autokube.io/v1alpha1
+kind: NodePool
+metadata:
+  name: workers
+spec:
+  provider: hetzner
+  minNodes: 1
+  maxNodes: 3
+  bootstrap:
+    type: kubeadm
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"--nodepool", writeFile(t, "nodepool.yaml", tt.nodePool)}
+			if tt.objects != "" {
+				args = append(args, "--objects", writeFile(t, "objects.yaml", tt.objects))
+			}
+			if tt.clusterInfo {
+				args = append(args, "--cluster-info", writeFile(t, "cluster-info.yaml", clusterInfoKubeconfig(t)))
+			}
+
+			var out bytes.Buffer
+			err := run(context.Background(), args, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("run() output missing %q", want)
+				}
+			}
+		})
+	}
+}
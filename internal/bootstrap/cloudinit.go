@@ -20,18 +20,158 @@ limitations under the License.
 import (
 	"bytes"
 	"embed"
+	"encoding/base64"
 	"fmt"
+	"net"
+	"regexp"
+	"strings"
 	"text/template"
 
+	"k8s.io/apimachinery/pkg/util/validation"
+
 	"github.com/autokubeio/autokube/internal/security"
 )
 
-//go:embed templates/*.yaml
+//go:embed templates/*.yaml templates/*.tpl
 var templateFS embed.FS
 
+// nodeTemplate contains the partials shared by all cloud-init templates
+const nodeTemplate = "node.tpl"
+
+const (
+	// JoinConfigPath is where a custom kubeadm JoinConfiguration is written on nodes
+	JoinConfigPath = "/etc/kubernetes/kubeadm-join.yaml"
+	// JoinConfigTokenPlaceholder is replaced with the bootstrap token in a custom
+	// JoinConfiguration on the node
+	JoinConfigTokenPlaceholder = "__BOOTSTRAP_TOKEN__"
+)
+
+var (
+	// k3sVersionPattern matches a k3s release, e.g. v1.29.4+k3s1
+	k3sVersionPattern = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+\+k3s[0-9]+$`)
+	// rke2VersionPattern matches an RKE2 release, e.g. v1.29.4+rke2r1
+	rke2VersionPattern = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+\+rke2r[0-9]+$`)
+)
+
+// ValidateK3sVersion checks that a version names a k3s release, as expected by INSTALL_K3S_VERSION
+func ValidateK3sVersion(version string) error {
+	if !k3sVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid k3s version %q: expected v<major>.<minor>.<patch>+k3s<n>", version)
+	}
+	return nil
+}
+
+// ValidateRKE2Version checks that a version names an RKE2 release, as expected by INSTALL_RKE2_VERSION
+func ValidateRKE2Version(version string) error {
+	if !rke2VersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid rke2 version %q: expected v<major>.<minor>.<patch>+rke2r<n>", version)
+	}
+	return nil
+}
+
+// ValidateDNSServer checks that a DNS server is an IP address, as expected by resolv.conf
+func ValidateDNSServer(server string) error {
+	if net.ParseIP(server) == nil {
+		return fmt.Errorf("invalid DNS server %q: expected an IP address", server)
+	}
+	return nil
+}
+
+// ValidateNTPServer checks that an NTP server is an IP address or a hostname
+func ValidateNTPServer(server string) error {
+	if net.ParseIP(server) != nil {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.ToLower(server)); len(errs) > 0 {
+		return fmt.Errorf("invalid NTP server %q: expected an IP address or a hostname", server)
+	}
+	return nil
+}
+
 // CloudInitGenerator generates cloud-init configurations
 type CloudInitGenerator struct {
 	secretsManager *security.SecretsManager
+	node           NodeOptions
+	containerd     ContainerdOptions
+	joinConfig     string
+}
+
+// NodeOptions contains node-level settings rendered by all cloud-init templates
+type NodeOptions struct {
+	// SSHHardening disables SSH password authentication and restricts root login
+	SSHHardening bool
+	// PermitRootLogin is the sshd PermitRootLogin value applied with SSHHardening
+	PermitRootLogin string
+	// UnattendedUpgrades enables automatic security updates via unattended-upgrades or dnf-automatic
+	UnattendedUpgrades bool
+	// RebootTime is the daily HH:MM time nodes may reboot to apply updates. Empty disables reboots
+	RebootTime string
+	// Volumes are formatted block devices attached to the node and mounted before installation
+	Volumes []VolumeMount
+	// Files are written to the node
+	Files []WriteFile
+	// DNSServers are the IP addresses of the nameservers the node resolves names with
+	DNSServers []string
+	// NTPServers are the NTP servers the node synchronizes its clock with
+	NTPServers []string
+}
+
+// WriteFile is a file written to the node
+type WriteFile struct {
+	// Path is the absolute path of the file
+	Path string
+	// Permissions is the octal file mode, e.g. 0644
+	Permissions string
+	// Content is the file content
+	Content []byte
+}
+
+// EncodedContent returns the base64 encoded file content, so any content can be embedded
+// in the cloud-init YAML
+func (f WriteFile) EncodedContent() string {
+	return base64.StdEncoding.EncodeToString(f.Content)
+}
+
+// VolumeMount describes a formatted block device mounted on the node
+type VolumeMount struct {
+	// Device is the path of the block device, e.g. /dev/disk/by-id/scsi-0HC_Volume_123
+	Device string
+	// MountPath is the absolute path the device is mounted at
+	MountPath string
+	// Format is the filesystem of the device
+	Format string
+}
+
+// ContainerdOptions contains the settings rendered into /etc/containerd/config.toml on
+// kubeadm nodes. Empty settings keep the containerd defaults
+type ContainerdOptions struct {
+	// SandboxImage is the pause image of pod sandboxes
+	SandboxImage string
+	// SystemdCgroup runs containers with the systemd cgroup driver
+	SystemdCgroup bool
+	// RegistryConfigPath is the directory of the per-registry hosts.toml files
+	RegistryConfigPath string
+}
+
+// DefaultContainerdOptions returns the containerd settings of nodes without overrides,
+// the containerd defaults with the systemd cgroup driver kubeadm configures the kubelet with
+func DefaultContainerdOptions() ContainerdOptions {
+	return ContainerdOptions{SystemdCgroup: true}
+}
+
+// HasWriteFiles reports whether the options render any write_files entries
+func (o NodeOptions) HasWriteFiles() bool {
+	return o.SSHHardening || o.UnattendedUpgrades || len(o.Files) > 0 || len(o.DNSServers) > 0
+}
+
+// DNSServerList returns the DNS servers separated by spaces, as expected by systemd-resolved
+func (o NodeOptions) DNSServerList() string {
+	return strings.Join(o.DNSServers, " ")
+}
+
+// RebootScheduled reports whether nodes reboot automatically after updates
+func (o NodeOptions) RebootScheduled() bool {
+	return o.RebootTime != ""
 }
 
 // CloudInitGeneratorOption is a function that configures a CloudInitGenerator
@@ -46,20 +186,43 @@ func WithSecretsManager(sm *security.SecretsManager) CloudInitGeneratorOption {
 
 // NewCloudInitGenerator creates a new cloud-init generator
 func NewCloudInitGenerator(opts ...CloudInitGeneratorOption) *CloudInitGenerator {
-	g := &CloudInitGenerator{}
+	g := &CloudInitGenerator{containerd: DefaultContainerdOptions()}
 	for _, opt := range opts {
 		opt(g)
 	}
 	return g
 }
 
-// loadTemplate loads a template from the embedded filesystem
+// WithNodeOptions returns a copy of the generator that renders the given node options
+func (g *CloudInitGenerator) WithNodeOptions(opts NodeOptions) *CloudInitGenerator {
+	c := *g
+	c.node = opts
+	return &c
+}
+
+// WithContainerdOptions returns a copy of the generator that renders the given containerd
+// settings on kubeadm nodes
+func (g *CloudInitGenerator) WithContainerdOptions(opts ContainerdOptions) *CloudInitGenerator {
+	c := *g
+	c.containerd = opts
+	return &c
+}
+
+// WithJoinConfig returns a copy of the generator that joins kubeadm nodes with the given
+// JoinConfiguration instead of the endpoint, token and CA cert hash flags
+func (g *CloudInitGenerator) WithJoinConfig(config string) *CloudInitGenerator {
+	c := *g
+	c.joinConfig = config
+	return &c
+}
+
+// loadTemplate loads a template and the shared node partials from the embedded filesystem
 func (g *CloudInitGenerator) loadTemplate(name string) (*template.Template, error) {
-	content, err := templateFS.ReadFile("templates/" + name)
+	t, err := template.New(name).ParseFS(templateFS, "templates/"+name, "templates/"+nodeTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
 	}
-	return template.New(name).Parse(string(content))
+	return t, nil
 }
 
 // EncryptSensitiveData encrypts sensitive data if encryption is enabled
@@ -85,36 +248,103 @@ func (g *CloudInitGenerator) GenerateKubeadmCloudInitWithVersion(
 	labels map[string]string,
 	k8sVersion string,
 ) (string, error) {
-	return g.GenerateKubeadmCloudInitFull(apiServerEndpoint, token, caCertHash, labels, k8sVersion, nil, nil)
+	return g.GenerateKubeadmCloudInitFull(apiServerEndpoint, token, caCertHash, labels, nil, k8sVersion, nil, nil)
 }
 
-// GenerateKubeadmCloudInitFull generates cloud-init for kubeadm clusters with firewall and custom commands
+// GenerateKubeadmCloudInitFull generates cloud-init for kubeadm clusters with kubelet flags,
+// firewall and custom commands
 func (g *CloudInitGenerator) GenerateKubeadmCloudInitFull(
 	apiServerEndpoint, token, caCertHash string,
-	_ map[string]string,
+	_, kubeletArgs map[string]string,
 	k8sVersion string,
 	firewallRules []string,
 	runCmd []string,
 ) (string, error) {
-	t, err := g.loadTemplate("kubeadm.yaml")
-	if err != nil {
-		return "", err
-	}
+	return g.renderKubeadm(kubeadmTemplateData{
+		APIServerEndpoint:   apiServerEndpoint,
+		Token:               token,
+		CACertHash:          caCertHash,
+		K8sVersion:          k8sVersion,
+		KubeletExtraArgs:    kubeletArgs,
+		CustomFirewallRules: firewallRules,
+		RunCmd:              runCmd,
+		Node:                g.node,
+		Containerd:          g.containerd,
+		JoinConfig:          g.joinConfig,
+	})
+}
 
-	config := struct {
-		APIServerEndpoint   string
-		Token               string
-		CACertHash          string
-		K8sVersion          string
-		CustomFirewallRules []string
-		RunCmd              []string
-	}{
+// GenerateKubeadmCloudInitFromSnapshot generates cloud-init for kubeadm nodes booted from a
+// snapshot prepared by GenerateKubeadmPrepareCloudInit, skipping package installation
+func (g *CloudInitGenerator) GenerateKubeadmCloudInitFromSnapshot(
+	apiServerEndpoint, token, caCertHash string,
+	_, kubeletArgs map[string]string,
+	k8sVersion string,
+	firewallRules []string,
+	runCmd []string,
+) (string, error) {
+	return g.renderKubeadm(kubeadmTemplateData{
 		APIServerEndpoint:   apiServerEndpoint,
 		Token:               token,
 		CACertHash:          caCertHash,
 		K8sVersion:          k8sVersion,
+		KubeletExtraArgs:    kubeletArgs,
 		CustomFirewallRules: firewallRules,
 		RunCmd:              runCmd,
+		Node:                g.node,
+		Containerd:          g.containerd,
+		JoinConfig:          g.joinConfig,
+		SkipInstall:         true,
+	})
+}
+
+// GenerateKubeadmPrepareCloudInit generates cloud-init that installs the kubeadm node
+// packages without joining the cluster and powers the server off, for snapshotting.
+// The output contains no cluster credentials, so it is safe to hash and share.
+// Node and containerd options are not rendered, they are applied when nodes boot from the
+// snapshot.
+func (g *CloudInitGenerator) GenerateKubeadmPrepareCloudInit(k8sVersion string) (string, error) {
+	return g.renderKubeadm(kubeadmTemplateData{
+		K8sVersion:  k8sVersion,
+		Containerd:  DefaultContainerdOptions(),
+		PrepareOnly: true,
+	})
+}
+
+// kubeadmTemplateData is the data rendered into the kubeadm template
+type kubeadmTemplateData struct {
+	APIServerEndpoint   string
+	Token               string
+	CACertHash          string
+	K8sVersion          string
+	KubeletExtraArgs    map[string]string
+	CustomFirewallRules []string
+	RunCmd              []string
+	Node                NodeOptions
+	Containerd          ContainerdOptions
+	// JoinConfig is a kubeadm JoinConfiguration nodes join with instead of the join flags
+	JoinConfig string
+	// SkipInstall skips package installation for nodes booted from a prepared snapshot
+	SkipInstall bool
+	// PrepareOnly installs packages and powers off without joining the cluster
+	PrepareOnly bool
+}
+
+// JoinConfigFile returns the JoinConfiguration written to the node
+func (d kubeadmTemplateData) JoinConfigFile() WriteFile {
+	return WriteFile{Path: JoinConfigPath, Permissions: "0600", Content: []byte(d.JoinConfig)}
+}
+
+// JoinConfigTokenPlaceholder returns the placeholder replaced with the bootstrap token
+func (d kubeadmTemplateData) JoinConfigTokenPlaceholder() string {
+	return JoinConfigTokenPlaceholder
+}
+
+// renderKubeadm renders the kubeadm template
+func (g *CloudInitGenerator) renderKubeadm(config kubeadmTemplateData) (string, error) {
+	t, err := g.loadTemplate("kubeadm.yaml")
+	if err != nil {
+		return "", err
 	}
 
 	var buf bytes.Buffer
@@ -125,21 +355,37 @@ func (g *CloudInitGenerator) GenerateKubeadmCloudInitFull(
 	return buf.String(), nil
 }
 
-// GenerateK3sCloudInit generates cloud-init for k3s clusters
-func (g *CloudInitGenerator) GenerateK3sCloudInit(serverURL, token string, labels map[string]string) (string, error) {
+// GenerateK3sCloudInit generates cloud-init for k3s clusters. An empty version installs the
+// latest stable release
+func (g *CloudInitGenerator) GenerateK3sCloudInit(
+	serverURL, token, version string,
+	labels, kubeletArgs map[string]string,
+) (string, error) {
+	if version != "" {
+		if err := ValidateK3sVersion(version); err != nil {
+			return "", err
+		}
+	}
+
 	t, err := g.loadTemplate("k3s.yaml")
 	if err != nil {
 		return "", err
 	}
 
 	config := struct {
-		ServerURL string
-		Token     string
-		Labels    map[string]string
+		ServerURL        string
+		Token            string
+		Version          string
+		Labels           map[string]string
+		KubeletExtraArgs map[string]string
+		Node             NodeOptions
 	}{
-		ServerURL: serverURL,
-		Token:     token,
-		Labels:    labels,
+		ServerURL:        serverURL,
+		Token:            token,
+		Version:          version,
+		Labels:           labels,
+		KubeletExtraArgs: kubeletArgs,
+		Node:             g.node,
 	}
 
 	var buf bytes.Buffer
@@ -174,24 +420,37 @@ func (g *CloudInitGenerator) GenerateTalosCloudInit(controlPlaneEndpoint, machin
 	return buf.String(), nil
 }
 
-// GenerateRancherCloudInit generates cloud-init for Rancher/RKE2 clusters
+// GenerateRancherCloudInit generates cloud-init for Rancher/RKE2 clusters. An empty version
+// installs the latest stable release
 func (g *CloudInitGenerator) GenerateRancherCloudInit(
-	serverURL, token string,
-	labels map[string]string,
+	serverURL, token, version string,
+	labels, kubeletArgs map[string]string,
 ) (string, error) {
+	if version != "" {
+		if err := ValidateRKE2Version(version); err != nil {
+			return "", err
+		}
+	}
+
 	t, err := g.loadTemplate("rke2.yaml")
 	if err != nil {
 		return "", err
 	}
 
 	config := struct {
-		ServerURL string
-		Token     string
-		Labels    map[string]string
+		ServerURL        string
+		Token            string
+		Version          string
+		Labels           map[string]string
+		KubeletExtraArgs map[string]string
+		Node             NodeOptions
 	}{
-		ServerURL: serverURL,
-		Token:     token,
-		Labels:    labels,
+		ServerURL:        serverURL,
+		Token:            token,
+		Version:          version,
+		Labels:           labels,
+		KubeletExtraArgs: kubeletArgs,
+		Node:             g.node,
 	}
 
 	var buf bytes.Buffer
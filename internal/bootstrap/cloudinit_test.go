@@ -17,6 +17,7 @@ limitations under the License.
 package bootstrap
 
 import (
+	"encoding/base64"
 	"strings"
 	"testing"
 )
@@ -94,7 +95,9 @@ func TestGenerateK3sCloudInit(t *testing.T) {
 		name         string
 		serverURL    string
 		token        string
+		version      string
 		labels       map[string]string
+		kubeletArgs  map[string]string
 		wantContains []string
 	}{
 		{
@@ -112,6 +115,29 @@ func TestGenerateK3sCloudInit(t *testing.T) {
 				"node-label",
 			},
 		},
+		{
+			name:      "k3s with kubelet args",
+			serverURL: "https://10.0.0.1:6443",
+			token:     "K10abcdef1234567890::server:abcdef1234567890",
+			kubeletArgs: map[string]string{
+				"max-pods":        "200",
+				"system-reserved": "cpu=250m,memory=512Mi",
+			},
+			wantContains: []string{
+				"kubelet-arg:",
+				`- "max-pods=200"`,
+				`- "system-reserved=cpu=250m,memory=512Mi"`,
+			},
+		},
+		{
+			name:      "k3s with pinned version",
+			serverURL: "https://10.0.0.1:6443",
+			token:     "K10abcdef1234567890::server:abcdef1234567890",
+			version:   "v1.29.4+k3s1",
+			wantContains: []string{
+				`curl -sfL https://get.k3s.io | INSTALL_K3S_VERSION="v1.29.4+k3s1" sh -s - agent`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -119,7 +145,9 @@ func TestGenerateK3sCloudInit(t *testing.T) {
 			result, err := generator.GenerateK3sCloudInit(
 				tt.serverURL,
 				tt.token,
+				tt.version,
 				tt.labels,
+				tt.kubeletArgs,
 			)
 
 			if err != nil {
@@ -143,7 +171,9 @@ func TestGenerateRancherCloudInit(t *testing.T) {
 		name         string
 		serverURL    string
 		token        string
+		version      string
 		labels       map[string]string
+		kubeletArgs  map[string]string
 		wantContains []string
 	}{
 		{
@@ -162,6 +192,25 @@ func TestGenerateRancherCloudInit(t *testing.T) {
 				"rke2-agent.service",
 			},
 		},
+		{
+			name:        "rke2 with kubelet args",
+			serverURL:   "https://10.0.0.1:9345",
+			token:       "K10abcdef1234567890::server:abcdef1234567890",
+			kubeletArgs: map[string]string{"max-pods": "200"},
+			wantContains: []string{
+				"kubelet-arg:",
+				`- "max-pods=200"`,
+			},
+		},
+		{
+			name:      "rke2 with pinned version",
+			serverURL: "https://10.0.0.1:9345",
+			token:     "K10abcdef1234567890::server:abcdef1234567890",
+			version:   "v1.29.4+rke2r1",
+			wantContains: []string{
+				`INSTALL_RKE2_TYPE="agent" INSTALL_RKE2_VERSION="v1.29.4+rke2r1" sh -`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -169,7 +218,9 @@ func TestGenerateRancherCloudInit(t *testing.T) {
 			result, err := generator.GenerateRancherCloudInit(
 				tt.serverURL,
 				tt.token,
+				tt.version,
 				tt.labels,
+				tt.kubeletArgs,
 			)
 
 			if err != nil {
@@ -186,6 +237,21 @@ func TestGenerateRancherCloudInit(t *testing.T) {
 	}
 }
 
+func TestGenerateCloudInitInvalidVersion(t *testing.T) {
+	generator := NewCloudInitGenerator()
+
+	for _, version := range []string{"1.29", "v1.29", "v1.29.4", "v1.29.4+rke2r1"} {
+		if _, err := generator.GenerateK3sCloudInit("https://10.0.0.1:6443", "token", version, nil, nil); err == nil {
+			t.Errorf("GenerateK3sCloudInit() expected error for version %q", version)
+		}
+	}
+	for _, version := range []string{"1.29", "v1.29.4", "v1.29.4+k3s1", "v1.29.4+rke2r1; reboot"} {
+		if _, err := generator.GenerateRancherCloudInit("https://10.0.0.1:9345", "token", version, nil, nil); err == nil {
+			t.Errorf("GenerateRancherCloudInit() expected error for version %q", version)
+		}
+	}
+}
+
 func TestGenerateKubeadmCloudInitWithVersion(t *testing.T) {
 	generator := NewCloudInitGenerator()
 
@@ -245,6 +311,7 @@ func TestGenerateKubeadmCloudInitFull(t *testing.T) {
 		token             string
 		caCertHash        string
 		labels            map[string]string
+		kubeletArgs       map[string]string
 		k8sVersion        string
 		firewallRules     []string
 		runCmd            []string
@@ -265,6 +332,22 @@ func TestGenerateKubeadmCloudInitFull(t *testing.T) {
 				"echo 'Custom command'",
 			},
 		},
+		{
+			name:              "kubeadm with kubelet args",
+			apiServerEndpoint: "10.0.0.1:6443",
+			token:             "abcdef.0123456789abcdef",
+			caCertHash:        "sha256:1234567890abcdef",
+			kubeletArgs: map[string]string{
+				"max-pods":        "200",
+				"system-reserved": "cpu=250m,memory=512Mi",
+			},
+			k8sVersion: "1.29",
+			wantContains: []string{
+				"/etc/systemd/system/kubelet.service.d/20-nodepool-args.conf",
+				`KUBELET_NODEPOOL_ARGS= --max-pods=200 --system-reserved=cpu=250m,memory=512Mi"`,
+				"$KUBELET_EXTRA_ARGS $KUBELET_NODEPOOL_ARGS",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -274,6 +357,7 @@ func TestGenerateKubeadmCloudInitFull(t *testing.T) {
 				tt.token,
 				tt.caCertHash,
 				tt.labels,
+				tt.kubeletArgs,
 				tt.k8sVersion,
 				tt.firewallRules,
 				tt.runCmd,
@@ -292,3 +376,363 @@ func TestGenerateKubeadmCloudInitFull(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateKubeadmSnapshotCloudInit(t *testing.T) {
+	generator := NewCloudInitGenerator()
+
+	prepare, err := generator.GenerateKubeadmPrepareCloudInit("1.30")
+	if err != nil {
+		t.Fatalf("GenerateKubeadmPrepareCloudInit() error = %v", err)
+	}
+
+	for _, want := range []string{"apt-get install -y kubelet kubeadm kubectl", "v1.30", "mode: poweroff"} {
+		if !strings.Contains(prepare, want) {
+			t.Errorf("GenerateKubeadmPrepareCloudInit() result missing %q", want)
+		}
+	}
+	for _, notWant := range []string{"kubeadm join", "mode: reboot"} {
+		if strings.Contains(prepare, notWant) {
+			t.Errorf("GenerateKubeadmPrepareCloudInit() result contains unwanted %q", notWant)
+		}
+	}
+
+	join, err := generator.GenerateKubeadmCloudInitFromSnapshot(
+		"10.0.0.1:6443",
+		"abcdef.0123456789abcdef",
+		"sha256:1234567890abcdef",
+		map[string]string{},
+		nil,
+		"1.30",
+		nil,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInitFromSnapshot() error = %v", err)
+	}
+
+	for _, want := range []string{"#cloud-config", "kubeadm join 10.0.0.1:6443", "mode: reboot"} {
+		if !strings.Contains(join, want) {
+			t.Errorf("GenerateKubeadmCloudInitFromSnapshot() result missing %q", want)
+		}
+	}
+	for _, notWant := range []string{"package_update", "apt-get install"} {
+		if strings.Contains(join, notWant) {
+			t.Errorf("GenerateKubeadmCloudInitFromSnapshot() result contains unwanted %q", notWant)
+		}
+	}
+}
+
+func TestGenerateCloudInitWithSSHHardening(t *testing.T) {
+	generator := NewCloudInitGenerator().WithNodeOptions(NodeOptions{
+		SSHHardening:    true,
+		PermitRootLogin: "prohibit-password",
+	})
+
+	kubeadm, err := generator.GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:1234", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+	k3s, err := generator.GenerateK3sCloudInit("https://10.0.0.1:6443", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateK3sCloudInit() error = %v", err)
+	}
+	rke2, err := generator.GenerateRancherCloudInit("https://10.0.0.1:9345", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateRancherCloudInit() error = %v", err)
+	}
+
+	wantContains := []string{
+		"ssh_pwauth: false",
+		"/etc/ssh/sshd_config.d/01-autokube-hardening.conf",
+		"PasswordAuthentication no",
+		"PermitRootLogin prohibit-password",
+		"systemctl reload ssh",
+	}
+	for name, result := range map[string]string{"kubeadm": kubeadm, "k3s": k3s, "rke2": rke2} {
+		for _, want := range wantContains {
+			if !strings.Contains(result, want) {
+				t.Errorf("%s cloud-init missing %q", name, want)
+			}
+		}
+	}
+
+	plain, err := NewCloudInitGenerator().GenerateRancherCloudInit("https://10.0.0.1:9345", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateRancherCloudInit() error = %v", err)
+	}
+	if strings.Contains(plain, "sshd_config") || strings.Contains(plain, "write_files") {
+		t.Error("SSH hardening rendered without being enabled")
+	}
+}
+
+func TestGenerateCloudInitWithUnattendedUpgrades(t *testing.T) {
+	tests := []struct {
+		name            string
+		node            NodeOptions
+		wantContains    []string
+		wantNotContains []string
+	}{
+		{
+			name: "without reboots",
+			node: NodeOptions{UnattendedUpgrades: true},
+			wantContains: []string{
+				"/etc/apt/apt.conf.d/20auto-upgrades",
+				`APT::Periodic::Unattended-Upgrade "1";`,
+				`Unattended-Upgrade::Automatic-Reboot "false";`,
+				"reboot = never",
+				"apt-get install -y unattended-upgrades",
+				"systemctl enable --now dnf-automatic-install.timer",
+			},
+			wantNotContains: []string{
+				"Automatic-Reboot-Time",
+				"dnf-automatic-install.timer.d",
+			},
+		},
+		{
+			name: "with scheduled reboots",
+			node: NodeOptions{UnattendedUpgrades: true, RebootTime: "03:30"},
+			wantContains: []string{
+				`Unattended-Upgrade::Automatic-Reboot "true";`,
+				`Unattended-Upgrade::Automatic-Reboot-Time "03:30";`,
+				"reboot = when-needed",
+				"OnCalendar=*-*-* 03:30",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := NewCloudInitGenerator().WithNodeOptions(tt.node)
+
+			kubeadm, err := generator.GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:1234", nil)
+			if err != nil {
+				t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+			}
+			k3s, err := generator.GenerateK3sCloudInit("https://10.0.0.1:6443", "token", "", nil, nil)
+			if err != nil {
+				t.Fatalf("GenerateK3sCloudInit() error = %v", err)
+			}
+			rke2, err := generator.GenerateRancherCloudInit("https://10.0.0.1:9345", "token", "", nil, nil)
+			if err != nil {
+				t.Fatalf("GenerateRancherCloudInit() error = %v", err)
+			}
+
+			for name, result := range map[string]string{"kubeadm": kubeadm, "k3s": k3s, "rke2": rke2} {
+				for _, want := range tt.wantContains {
+					if !strings.Contains(result, want) {
+						t.Errorf("%s cloud-init missing %q", name, want)
+					}
+				}
+				for _, notWant := range tt.wantNotContains {
+					if strings.Contains(result, notWant) {
+						t.Errorf("%s cloud-init should not contain %q", name, notWant)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateCloudInitWithVolumes(t *testing.T) {
+	generator := NewCloudInitGenerator().WithNodeOptions(NodeOptions{
+		Volumes: []VolumeMount{
+			{Device: "/dev/disk/by-id/scsi-0HC_Volume_1", MountPath: "/var/lib/longhorn", Format: "ext4"},
+		},
+	})
+
+	kubeadm, err := generator.GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:1234", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+	k3s, err := generator.GenerateK3sCloudInit("https://10.0.0.1:6443", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateK3sCloudInit() error = %v", err)
+	}
+	rke2, err := generator.GenerateRancherCloudInit("https://10.0.0.1:9345", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateRancherCloudInit() error = %v", err)
+	}
+
+	wantContains := []string{
+		"mkdir -p /var/lib/longhorn",
+		"/dev/disk/by-id/scsi-0HC_Volume_1 /var/lib/longhorn ext4 discard,nofail,defaults 0 0",
+		"mount /var/lib/longhorn",
+	}
+	for name, result := range map[string]string{"kubeadm": kubeadm, "k3s": k3s, "rke2": rke2} {
+		for _, want := range wantContains {
+			if !strings.Contains(result, want) {
+				t.Errorf("%s cloud-init missing %q", name, want)
+			}
+		}
+	}
+}
+
+func TestGenerateCloudInitWithFiles(t *testing.T) {
+	generator := NewCloudInitGenerator().WithNodeOptions(NodeOptions{
+		Files: []WriteFile{
+			{Path: "/etc/node-exporter/config.yaml", Permissions: "0644", Content: []byte("collectors:\n  - cpu\n")},
+		},
+	})
+
+	kubeadm, err := generator.GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:1234", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+	k3s, err := generator.GenerateK3sCloudInit("https://10.0.0.1:6443", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateK3sCloudInit() error = %v", err)
+	}
+	rke2, err := generator.GenerateRancherCloudInit("https://10.0.0.1:9345", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateRancherCloudInit() error = %v", err)
+	}
+
+	wantContains := []string{
+		"write_files:",
+		"  - path: /etc/node-exporter/config.yaml",
+		`    permissions: "0644"`,
+		"    encoding: b64",
+		"    content: Y29sbGVjdG9yczoKICAtIGNwdQo=",
+	}
+	for name, result := range map[string]string{"kubeadm": kubeadm, "k3s": k3s, "rke2": rke2} {
+		for _, want := range wantContains {
+			if !strings.Contains(result, want) {
+				t.Errorf("%s cloud-init missing %q", name, want)
+			}
+		}
+	}
+}
+
+func TestGenerateCloudInitWithDNSAndNTPServers(t *testing.T) {
+	generator := NewCloudInitGenerator().WithNodeOptions(NodeOptions{
+		DNSServers: []string{"10.0.0.53", "10.0.1.53"},
+		NTPServers: []string{"ntp.internal.example.com", "10.0.0.123"},
+	})
+
+	kubeadm, err := generator.GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:1234", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+	k3s, err := generator.GenerateK3sCloudInit("https://10.0.0.1:6443", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateK3sCloudInit() error = %v", err)
+	}
+	rke2, err := generator.GenerateRancherCloudInit("https://10.0.0.1:9345", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateRancherCloudInit() error = %v", err)
+	}
+
+	wantContains := []string{
+		"manage_resolv_conf: true\nresolv_conf:\n  nameservers:\n    - 10.0.0.53\n    - 10.0.1.53\n",
+		"ntp:\n  enabled: true\n  servers:\n    - ntp.internal.example.com\n    - 10.0.0.123\n",
+		"/etc/systemd/resolved.conf.d/10-autokube-dns.conf",
+		"DNS=10.0.0.53 10.0.1.53",
+		"systemctl try-restart systemd-resolved",
+	}
+	for name, result := range map[string]string{"kubeadm": kubeadm, "k3s": k3s, "rke2": rke2} {
+		for _, want := range wantContains {
+			if !strings.Contains(result, want) {
+				t.Errorf("%s cloud-init missing %q", name, want)
+			}
+		}
+	}
+
+	plain, err := NewCloudInitGenerator().GenerateK3sCloudInit("https://10.0.0.1:6443", "token", "", nil, nil)
+	if err != nil {
+		t.Fatalf("GenerateK3sCloudInit() error = %v", err)
+	}
+	if strings.Contains(plain, "resolv_conf") || strings.Contains(plain, "ntp:") {
+		t.Error("DNS or NTP servers rendered without being configured")
+	}
+}
+
+func TestGenerateKubeadmCloudInitWithContainerdOptions(t *testing.T) {
+	generator := NewCloudInitGenerator().WithContainerdOptions(ContainerdOptions{
+		SandboxImage:       "registry.example.com/pause:3.9",
+		SystemdCgroup:      true,
+		RegistryConfigPath: "/etc/containerd/certs.d",
+	})
+	result, err := generator.GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:abc123", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+	for _, want := range []string{
+		"cat <<'EOF' > /etc/containerd/config.toml",
+		`sandbox_image = "registry.example.com/pause:3.9"`,
+		"SystemdCgroup = true",
+		`config_path = "/etc/containerd/certs.d"`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("GenerateKubeadmCloudInit() result missing %q", want)
+		}
+	}
+
+	// Without overrides containerd keeps its defaults, with the systemd cgroup driver
+	result, err = NewCloudInitGenerator().GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:abc123", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+	if !strings.Contains(result, "SystemdCgroup = true") {
+		t.Error("Expected the systemd cgroup driver by default")
+	}
+	if strings.Contains(result, "sandbox_image") || strings.Contains(result, "config_path") {
+		t.Error("Sandbox image or registry config path rendered without being configured")
+	}
+}
+
+func TestGenerateKubeadmCloudInitWithJoinConfig(t *testing.T) {
+	joinConfig := `apiVersion: kubeadm.k8s.io/v1beta3
+kind: JoinConfiguration
+discovery:
+  bootstrapToken:
+    apiServerEndpoint: 10.0.0.1:6443
+    token: __BOOTSTRAP_TOKEN__
+    unsafeSkipCAVerification: true
+`
+	result, err := NewCloudInitGenerator().WithJoinConfig(joinConfig).
+		GenerateKubeadmCloudInit("", "abcdef.0123456789abcdef", "", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"kubeadm join --config /etc/kubernetes/kubeadm-join.yaml --v=5",
+		`sed -i "s/__BOOTSTRAP_TOKEN__/abcdef.0123456789abcdef/g" /etc/kubernetes/kubeadm-join.yaml`,
+		"path: /etc/kubernetes/kubeadm-join.yaml",
+		"content: " + base64.StdEncoding.EncodeToString([]byte(joinConfig)),
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("GenerateKubeadmCloudInit() result missing %q", want)
+		}
+	}
+	for _, notWant := range []string{"--token", "--discovery-token-ca-cert-hash"} {
+		if strings.Contains(result, notWant) {
+			t.Errorf("GenerateKubeadmCloudInit() result contains unwanted %q", notWant)
+		}
+	}
+}
+
+func TestValidateDNSAndNTPServers(t *testing.T) {
+	tests := []struct {
+		server     string
+		wantDNSErr bool
+		wantNTPErr bool
+	}{
+		{server: "10.0.0.53"},
+		{server: "2001:db8::53"},
+		{server: "ntp.example.com", wantDNSErr: true},
+		{server: "Time.Example.com", wantDNSErr: true},
+		{server: "ntp_server", wantDNSErr: true, wantNTPErr: true},
+		{server: "10.0.0.53; rm -rf /", wantDNSErr: true, wantNTPErr: true},
+		{server: "", wantDNSErr: true, wantNTPErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateDNSServer(tt.server); (err != nil) != tt.wantDNSErr {
+			t.Errorf("ValidateDNSServer(%q) error = %v, wantErr %v", tt.server, err, tt.wantDNSErr)
+		}
+		if err := ValidateNTPServer(tt.server); (err != nil) != tt.wantNTPErr {
+			t.Errorf("ValidateNTPServer(%q) error = %v, wantErr %v", tt.server, err, tt.wantNTPErr)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+{{/* Node-level settings shared by all cloud-init templates */}}
+{{- define "node-config"}}
+{{- if .SSHHardening}}
+ssh_pwauth: false
+{{- end}}
+{{- if .DNSServers}}
+manage_resolv_conf: true
+resolv_conf:
+  nameservers:
+{{- range .DNSServers}}
+    - {{.}}
+{{- end}}
+{{- end}}
+{{- if .NTPServers}}
+ntp:
+  enabled: true
+  servers:
+{{- range .NTPServers}}
+    - {{.}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "node-write-files"}}
+{{- range .Files}}
+  - path: {{.Path}}
+    permissions: "{{.Permissions}}"
+    encoding: b64
+    content: {{.EncodedContent}}
+{{- end}}
+{{- if .DNSServers}}
+  - path: /etc/systemd/resolved.conf.d/10-autokube-dns.conf
+    permissions: "0644"
+    content: |
+      [Resolve]
+      DNS={{.DNSServerList}}
+{{- end}}
+{{- if .SSHHardening}}
+  - path: /etc/ssh/sshd_config.d/01-autokube-hardening.conf
+    permissions: "0600"
+    content: |
+      PasswordAuthentication no
+      KbdInteractiveAuthentication no
+      ChallengeResponseAuthentication no
+      PermitRootLogin {{.PermitRootLogin}}
+{{- end}}
+{{- if .UnattendedUpgrades}}
+  - path: /etc/apt/apt.conf.d/20auto-upgrades
+    permissions: "0644"
+    content: |
+      APT::Periodic::Update-Package-Lists "1";
+      APT::Periodic::Unattended-Upgrade "1";
+  - path: /etc/apt/apt.conf.d/52autokube-unattended-upgrades
+    permissions: "0644"
+    content: |
+      {{- if .RebootScheduled}}
+      Unattended-Upgrade::Automatic-Reboot "true";
+      Unattended-Upgrade::Automatic-Reboot-Time "{{.RebootTime}}";
+      {{- else}}
+      Unattended-Upgrade::Automatic-Reboot "false";
+      {{- end}}
+  - path: /etc/autokube/dnf-automatic.conf
+    permissions: "0644"
+    content: |
+      [commands]
+      upgrade_type = security
+      download_updates = yes
+      apply_updates = yes
+      reboot = {{if .RebootScheduled}}when-needed{{else}}never{{end}}
+{{- if .RebootScheduled}}
+  - path: /etc/systemd/system/dnf-automatic-install.timer.d/10-autokube.conf
+    permissions: "0644"
+    content: |
+      [Timer]
+      OnCalendar=
+      OnCalendar=*-*-* {{.RebootTime}}
+      RandomizedDelaySec=0
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "node-runcmd"}}
+{{- if .DNSServers}}
+  # Apply the DNS servers on distributions using systemd-resolved
+  - systemctl try-restart systemd-resolved
+{{- end}}
+{{- range .Volumes}}
+  # Mount volume {{.Device}}
+  - mkdir -p {{.MountPath}}
+  - echo '{{.Device}} {{.MountPath}} {{.Format}} discard,nofail,defaults 0 0' >> /etc/fstab
+  - mount {{.MountPath}}
+{{- end}}
+{{- if .SSHHardening}}
+  # Apply SSH hardening
+  - systemctl reload ssh || systemctl reload sshd
+{{- end}}
+{{- if .UnattendedUpgrades}}
+  # Enable automatic security updates
+  - |
+    if command -v apt-get >/dev/null 2>&1; then
+      DEBIAN_FRONTEND=noninteractive apt-get install -y unattended-upgrades
+      systemctl enable --now apt-daily.timer apt-daily-upgrade.timer
+    elif command -v dnf >/dev/null 2>&1; then
+      dnf install -y dnf-automatic
+      cp /etc/autokube/dnf-automatic.conf /etc/dnf/automatic.conf
+      systemctl daemon-reload
+      systemctl enable --now dnf-automatic-install.timer
+    fi
+{{- end}}
+{{- end}}
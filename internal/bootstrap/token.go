@@ -21,16 +21,21 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/x509"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"math/big"
+	"regexp"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 // BootstrapTokenManager manages Kubernetes bootstrap tokens
@@ -55,6 +60,21 @@ type ClusterInfo struct {
 	CACertHash string
 }
 
+// ErrClusterInfoNotReady is returned by GetClusterInfo while the cluster hasn't published
+// its cluster-info configmap yet, which resolves itself once kubeadm uploads it
+var ErrClusterInfoNotReady = errors.New("cluster-info is not published yet")
+
+// caCertHashPattern matches a kubeadm discovery token CA cert hash
+var caCertHashPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ValidateCACertHash checks that a CA cert hash has the sha256:<hex> format expected by kubeadm
+func ValidateCACertHash(hash string) error {
+	if !caCertHashPattern.MatchString(hash) {
+		return fmt.Errorf("invalid CA cert hash %q: expected sha256:<64 hex characters>", hash)
+	}
+	return nil
+}
+
 // NewBootstrapTokenManager creates a new bootstrap token manager
 func NewBootstrapTokenManager(client kubernetes.Interface) *BootstrapTokenManager {
 	return &BootstrapTokenManager{
@@ -143,32 +163,70 @@ func (m *BootstrapTokenManager) GenerateBootstrapToken(
 func (m *BootstrapTokenManager) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
 	// Get cluster-info configmap
 	cm, err := m.client.CoreV1().ConfigMaps("kube-public").Get(ctx, "cluster-info", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: configmap kube-public/cluster-info not found", ErrClusterInfoNotReady)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cluster-info configmap: %w", err)
 	}
 
 	kubeconfig, ok := cm.Data["kubeconfig"]
 	if !ok {
-		return nil, fmt.Errorf("kubeconfig not found in cluster-info")
+		return nil, fmt.Errorf("%w: kubeconfig not found in cluster-info", ErrClusterInfoNotReady)
+	}
+
+	cluster, err := clusterFromKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig in cluster-info: %w", err)
 	}
 
-	// Parse endpoint from kubeconfig
-	endpoint := extractServerFromKubeconfig(kubeconfig)
+	// kubeadm expects the endpoint without scheme
+	endpoint := strings.TrimPrefix(strings.TrimPrefix(cluster.Server, "https://"), "http://")
 
-	// Extract CA certificate from kubeconfig
-	caCertBase64 := extractCACertFromKubeconfig(kubeconfig)
-	if caCertBase64 == "" {
+	if len(cluster.CertificateAuthorityData) == 0 {
 		return nil, fmt.Errorf("CA certificate not found in cluster-info")
 	}
 
-	// Decode base64 CA certificate
-	caCert, err := base64.StdEncoding.DecodeString(caCertBase64)
+	// Calculate CA cert hash
+	caCertHash := calculateCACertHash(cluster.CertificateAuthorityData)
+	if caCertHash == "" {
+		return nil, fmt.Errorf("invalid CA certificate in cluster-info")
+	}
+
+	return &ClusterInfo{
+		Endpoint:   endpoint,
+		CACertHash: fmt.Sprintf("sha256:%s", caCertHash),
+	}, nil
+}
+
+// Keys of a cluster info secret
+const (
+	clusterInfoCACertKey   = "ca.crt"
+	clusterInfoEndpointKey = "endpoint"
+)
+
+// GetClusterInfoFromSecret retrieves the cluster endpoint and CA certificate hash from a secret
+// holding the PEM encoded CA certificate under the ca.crt key and the API server endpoint
+// under the endpoint key. The endpoint is empty when the secret has none
+func (m *BootstrapTokenManager) GetClusterInfoFromSecret(ctx context.Context, namespace, name string) (*ClusterInfo, error) {
+	secret, err := m.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode CA certificate: %w", err)
+		return nil, fmt.Errorf("failed to get cluster info secret %s/%s: %w", namespace, name, err)
 	}
 
-	// Calculate CA cert hash
+	caCert := secret.Data[clusterInfoCACertKey]
+	if len(caCert) == 0 {
+		return nil, fmt.Errorf("CA certificate not found in secret %s/%s, expected key %s",
+			namespace, name, clusterInfoCACertKey)
+	}
 	caCertHash := calculateCACertHash(caCert)
+	if caCertHash == "" {
+		return nil, fmt.Errorf("invalid CA certificate in secret %s/%s", namespace, name)
+	}
+
+	// kubeadm expects the endpoint without scheme
+	endpoint := strings.TrimSpace(string(secret.Data[clusterInfoEndpointKey]))
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")
 
 	return &ClusterInfo{
 		Endpoint:   endpoint,
@@ -222,57 +280,31 @@ func calculateCACertHash(caCert []byte) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// extractServerFromKubeconfig extracts the server URL from kubeconfig
-func extractServerFromKubeconfig(kubeconfig string) string {
-	const serverPrefix = "server: "
-	start := 0
-	for {
-		idx := findInString(kubeconfig[start:], serverPrefix)
-		if idx == -1 {
-			return ""
-		}
-		start += idx + len(serverPrefix)
-		end := findInString(kubeconfig[start:], "\n")
-		if end == -1 {
-			end = len(kubeconfig) - start
-		}
-		endpoint := kubeconfig[start : start+end]
-		// Remove https:// or http:// prefix for kubeadm
-		if len(endpoint) > 8 && endpoint[:8] == "https://" {
-			return endpoint[8:]
-		}
-		if len(endpoint) > 7 && endpoint[:7] == "http://" {
-			return endpoint[7:]
+// clusterFromKubeconfig returns the cluster of the kubeconfig's current context
+// kubeadm's cluster-info has no contexts, so a kubeconfig with a single cluster is used as is
+func clusterFromKubeconfig(kubeconfig string) (*clientcmdapi.Cluster, error) {
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	if config.CurrentContext != "" {
+		kubeContext, ok := config.Contexts[config.CurrentContext]
+		if !ok {
+			return nil, fmt.Errorf("current context %q not found", config.CurrentContext)
 		}
-		// If no prefix, return as-is
-		if endpoint != "" {
-			return endpoint
+		cluster, ok := config.Clusters[kubeContext.Cluster]
+		if !ok {
+			return nil, fmt.Errorf("cluster %q of context %q not found", kubeContext.Cluster, config.CurrentContext)
 		}
+		return cluster, nil
 	}
-}
 
-// extractCACertFromKubeconfig extracts the certificate-authority-data from kubeconfig
-func extractCACertFromKubeconfig(kubeconfig string) string {
-	const caPrefix = "certificate-authority-data: "
-	start := 0
-	idx := findInString(kubeconfig[start:], caPrefix)
-	if idx == -1 {
-		return ""
+	if len(config.Clusters) != 1 {
+		return nil, fmt.Errorf("no current context set and %d clusters defined", len(config.Clusters))
 	}
-	start = idx + len(caPrefix)
-	end := findInString(kubeconfig[start:], "\n")
-	if end == -1 {
-		end = len(kubeconfig) - start
-	}
-	return kubeconfig[start : start+end]
-}
-
-// findInString is a helper to find substring
-func findInString(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
-		}
+	for _, cluster := range config.Clusters {
+		return cluster, nil
 	}
-	return -1
+	return nil, nil
 }
@@ -0,0 +1,262 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestCA returns a base64 encoded self-signed CA certificate and its kubeadm CA cert hash
+func newTestCA(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kubernetes"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	pubKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	hash := sha256.Sum256(pubKeyDER)
+	return base64.StdEncoding.EncodeToString(caPEM), "sha256:" + hex.EncodeToString(hash[:])
+}
+
+func TestGetClusterInfo(t *testing.T) {
+	caData, caHash := newTestCA(t)
+	otherCAData, _ := newTestCA(t)
+
+	tests := []struct {
+		name         string
+		kubeconfig   string
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{
+			name: "kubeadm cluster-info without contexts",
+			kubeconfig: fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: https://10.0.0.1:6443
+  name: ""
+contexts: null
+current-context: ""
+preferences: {}
+users: null
+`, caData),
+			wantEndpoint: "10.0.0.1:6443",
+		},
+		{
+			name: "multiple clusters with current context",
+			kubeconfig: fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: https://staging.example.com:6443
+  name: staging
+- cluster:
+    certificate-authority-data: %s
+    server: "https://prod.example.com:6443"
+  name: prod
+contexts:
+- context:
+    cluster: staging
+  name: staging
+- context:
+    cluster: prod
+  name: prod
+current-context: prod
+`, otherCAData, caData),
+			wantEndpoint: "prod.example.com:6443",
+		},
+		{
+			name: "CRLF line endings",
+			kubeconfig: strings.ReplaceAll(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: 'https://10.0.0.1:6443'
+  name: kubernetes
+`, caData), "\n", "\r\n"),
+			wantEndpoint: "10.0.0.1:6443",
+		},
+		{
+			name: "multiple clusters without current context",
+			kubeconfig: fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: https://a.example.com:6443
+  name: a
+- cluster:
+    certificate-authority-data: %s
+    server: https://b.example.com:6443
+  name: b
+`, caData, otherCAData),
+			wantErr: true,
+		},
+		{
+			name: "CA data that is not a certificate",
+			kubeconfig: fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: https://10.0.0.1:6443
+  name: kubernetes
+`, base64.StdEncoding.EncodeToString([]byte("not a certificate"))),
+			wantErr: true,
+		},
+		{
+			name: "missing CA data",
+			kubeconfig: `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://10.0.0.1:6443
+  name: kubernetes
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-info", Namespace: "kube-public"},
+				Data:       map[string]string{"kubeconfig": tt.kubeconfig},
+			})
+
+			info, err := NewBootstrapTokenManager(client).GetClusterInfo(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetClusterInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if info.Endpoint != tt.wantEndpoint {
+				t.Errorf("GetClusterInfo() endpoint = %q, want %q", info.Endpoint, tt.wantEndpoint)
+			}
+			if info.CACertHash != caHash {
+				t.Errorf("GetClusterInfo() CA cert hash = %q, want %q", info.CACertHash, caHash)
+			}
+		})
+	}
+}
+
+func TestGetClusterInfoFromSecret(t *testing.T) {
+	caData, caHash := newTestCA(t)
+	caPEM, err := base64.StdEncoding.DecodeString(caData)
+	if err != nil {
+		t.Fatalf("Failed to decode CA: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		data         map[string][]byte
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{
+			name:         "CA and endpoint",
+			data:         map[string][]byte{"ca.crt": caPEM, "endpoint": []byte("https://10.0.0.1:6443\n")},
+			wantEndpoint: "10.0.0.1:6443",
+		},
+		{
+			name: "CA only",
+			data: map[string][]byte{"ca.crt": caPEM},
+		},
+		{
+			name:    "missing CA",
+			data:    map[string][]byte{"endpoint": []byte("10.0.0.1:6443")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid CA",
+			data:    map[string][]byte{"ca.crt": []byte("not a certificate")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-ca", Namespace: "default"},
+				Data:       tt.data,
+			})
+
+			info, err := NewBootstrapTokenManager(client).GetClusterInfoFromSecret(context.Background(), "default", "cluster-ca")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetClusterInfoFromSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if info.Endpoint != tt.wantEndpoint {
+				t.Errorf("GetClusterInfoFromSecret() endpoint = %q, want %q", info.Endpoint, tt.wantEndpoint)
+			}
+			if info.CACertHash != caHash {
+				t.Errorf("GetClusterInfoFromSecret() CA cert hash = %q, want %q", info.CACertHash, caHash)
+			}
+		})
+	}
+}
+
+func TestGetClusterInfoNotReady(t *testing.T) {
+	_, err := NewBootstrapTokenManager(fake.NewSimpleClientset()).GetClusterInfo(context.Background())
+	if !errors.Is(err, ErrClusterInfoNotReady) {
+		t.Errorf("GetClusterInfo() error = %v, want ErrClusterInfoNotReady without cluster-info", err)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// HetznerUserDataLimit is the largest user data Hetzner Cloud accepts, in bytes
+const HetznerUserDataLimit = 32 * 1024
+
+// ErrUserDataTooLarge is returned when user data exceeds the provider's limit even compressed
+var ErrUserDataTooLarge = errors.New("user data exceeds the size limit")
+
+// CompressUserData returns user data that fits in limit bytes. User data over the limit is
+// gzip compressed and base64 encoded: cloud-init decompresses gzip user data natively, and
+// datasources that only take text, such as Hetzner's, decode base64 user data first.
+// It reports whether the user data was compressed.
+func CompressUserData(userData string, limit int) (string, bool, error) {
+	if len(userData) <= limit {
+		return userData, false, nil
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create gzip writer: %w", err)
+	}
+	if _, err := zw.Write([]byte(userData)); err != nil {
+		return "", false, fmt.Errorf("failed to compress user data: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", false, fmt.Errorf("failed to compress user data: %w", err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	if len(encoded) > limit {
+		return "", false, fmt.Errorf("%w: %d bytes compressed, limit is %d", ErrUserDataTooLarge, len(encoded), limit)
+	}
+	return encoded, true, nil
+}
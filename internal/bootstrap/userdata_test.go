@@ -0,0 +1,96 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompressUserData(t *testing.T) {
+	// Registry mirror configuration pushing the rendered template over the limit
+	mirrors := strings.Repeat("[plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors.\"docker.io\"]\n", 1000)
+	generator := NewCloudInitGenerator().WithNodeOptions(NodeOptions{
+		Files: []WriteFile{{Path: "/etc/containerd/mirrors.toml", Permissions: "0644", Content: []byte(mirrors)}},
+	})
+	userData, err := generator.GenerateKubeadmCloudInit("10.0.0.1:6443", "abcdef.0123456789abcdef", "sha256:1234", nil)
+	if err != nil {
+		t.Fatalf("GenerateKubeadmCloudInit() error = %v", err)
+	}
+	if len(userData) <= HetznerUserDataLimit {
+		t.Fatalf("Rendered template is %d bytes, want over %d", len(userData), HetznerUserDataLimit)
+	}
+
+	compressed, ok, err := CompressUserData(userData, HetznerUserDataLimit)
+	if err != nil {
+		t.Fatalf("CompressUserData() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("Expected user data over the limit to be compressed")
+	}
+	if len(compressed) > HetznerUserDataLimit {
+		t.Errorf("Compressed user data is %d bytes, want at most %d", len(compressed), HetznerUserDataLimit)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(compressed)
+	if err != nil {
+		t.Fatalf("Compressed user data is not base64: %v", err)
+	}
+	if !bytes.HasPrefix(decoded, []byte{0x1f, 0x8b}) {
+		t.Errorf("Compressed user data starts with %x, want the gzip magic bytes", decoded[:2])
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(decoded))
+	if err != nil {
+		t.Fatalf("Failed to read gzip user data: %v", err)
+	}
+	decompressed, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to decompress user data: %v", err)
+	}
+	if string(decompressed) != userData {
+		t.Error("Decompressed user data differs from the rendered template")
+	}
+}
+
+func TestCompressUserDataWithinLimit(t *testing.T) {
+	userData := "#cloud-config\nruncmd:\n  - echo hello\n"
+	got, compressed, err := CompressUserData(userData, HetznerUserDataLimit)
+	if err != nil {
+		t.Fatalf("CompressUserData() error = %v", err)
+	}
+	if compressed || got != userData {
+		t.Error("Expected user data within the limit to be left as is")
+	}
+}
+
+func TestCompressUserDataTooLarge(t *testing.T) {
+	// Random data doesn't compress
+	random := make([]byte, 2*HetznerUserDataLimit)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("Failed to generate random data: %v", err)
+	}
+	_, _, err := CompressUserData(string(random), HetznerUserDataLimit)
+	if !errors.Is(err, ErrUserDataTooLarge) {
+		t.Errorf("CompressUserData() error = %v, want ErrUserDataTooLarge", err)
+	}
+}
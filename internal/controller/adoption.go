@@ -0,0 +1,81 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+)
+
+// setServersMatchSpecCondition records whether the pool's servers run the server type,
+// image and locations of its spec. Servers that carry the pool's labels are adopted by the
+// pool whoever created them, so servers created by hand may not. Outdated servers are left
+// out, the rolling update replaces them
+func setServersMatchSpecCondition(nodePool *hcloudv1alpha1.NodePool, listed *poolServers) {
+	config := nodePool.Spec.HetznerConfig
+	if nodePool.Spec.Provider != hcloudv1alpha1.CloudProviderHetzner || config == nil {
+		return
+	}
+
+	var mismatches []string
+	for _, server := range listed.hetzner {
+		if nodeOutdated(nodePool, server.Name) {
+			continue
+		}
+		if mismatch := hetznerServerMismatch(config, server); mismatch != "" {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s", server.Name, mismatch))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionServersMatchSpec,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ServersMatchSpec",
+		Message:            "All servers match the pool's configuration",
+		ObservedGeneration: nodePool.Generation,
+	}
+	if len(mismatches) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ServerConfigMismatch"
+		condition.Message = strings.Join(mismatches, "; ")
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, condition)
+}
+
+// hetznerServerMismatch describes how a server differs from the pool's configuration, empty
+// if it doesn't. Details the listing doesn't report, like the image of servers created from
+// a snapshot, aren't compared
+func hetznerServerMismatch(config *hcloudv1alpha1.HetznerCloudConfig, server hetzner.Server) string {
+	var mismatches []string
+	if server.ServerType != "" && server.ServerType != config.ServerType {
+		mismatches = append(mismatches, fmt.Sprintf("server type %s, want %s", server.ServerType, config.ServerType))
+	}
+	if server.Image != "" && config.BootMode != hcloudv1alpha1.BootModeISO && server.Image != config.Image {
+		mismatches = append(mismatches, fmt.Sprintf("image %s, want %s", server.Image, config.Image))
+	}
+	if locations := config.ServerLocations(); server.Location != "" && !slices.Contains(locations, server.Location) {
+		mismatches = append(mismatches, fmt.Sprintf("location %s, want one of %s", server.Location, strings.Join(locations, ", ")))
+	}
+	return strings.Join(mismatches, ", ")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+)
+
+// providerAnnotations returns the pool's annotations in a form the provider can store.
+// Hetzner stores them as server labels, skipping annotations that are not valid labels with
+// a warning event. Scaleway stores them as key=value instance tags, see scaleway.Tags.
+// OVHcloud instances have no metadata to store them in, so none are returned.
+func (r *NodePoolReconciler) providerAnnotations(nodePool *hcloudv1alpha1.NodePool) map[string]string {
+	if nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderScaleway {
+		return nodePool.Spec.Annotations
+	}
+	if nodePool.Spec.Provider != hcloudv1alpha1.CloudProviderHetzner {
+		return nil
+	}
+
+	annotations, skipped := hetzner.SanitizeLabels(nodePool.Spec.Annotations)
+	sort.Strings(skipped)
+	if r.annotationWarnings.changed(nodePool, skipped) && len(skipped) > 0 {
+		r.Recorder.Eventf(nodePool, corev1.EventTypeWarning, "InvalidAnnotation",
+			"Annotations %s are not valid Hetzner labels and were not applied", strings.Join(skipped, ", "))
+	}
+	return annotations
+}
+
+// annotationWarnings remembers the invalid annotations each pool was last warned about, so
+// the warning is emitted once per pool rather than for every server created.
+// It is safe for concurrent use.
+type annotationWarnings struct {
+	mu     sync.Mutex
+	warned map[string]string
+}
+
+// changed records the sorted invalid annotations of the pool and reports whether they
+// differ from the ones it was last warned about
+func (w *annotationWarnings) changed(nodePool *hcloudv1alpha1.NodePool, skipped []string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.warned == nil {
+		w.warned = make(map[string]string)
+	}
+	pool := poolKey(nodePool)
+	joined := strings.Join(skipped, ",")
+	if previous, ok := w.warned[pool]; ok && previous == joined {
+		return false
+	}
+	w.warned[pool] = joined
+	return true
+}
+
+// forget drops the warnings of a deleted pool
+func (w *annotationWarnings) forget(nodePool *hcloudv1alpha1.NodePool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.warned, poolKey(nodePool))
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+// maxFailureRequeueInterval caps the requeue interval of a NodePool whose reconciles keep failing
+const maxFailureRequeueInterval = 5 * time.Minute
+
+// failureBackoff counts the consecutive failed reconciles of each NodePool so a pool whose
+// provider keeps failing is retried less and less often, starting at reconcileInterval.
+// It is also the controller's rate limiter, since controller-runtime ignores the result
+// of a reconcile that returns an error. It is safe for concurrent use.
+type failureBackoff struct {
+	mu       sync.Mutex
+	failures map[types.NamespacedName]int
+}
+
+// failed records a failed reconcile of the pool, growing the interval When retries it after
+func (b *failureBackoff) failed(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures == nil {
+		b.failures = make(map[types.NamespacedName]int)
+	}
+	b.failures[key]++
+}
+
+// succeeded resets the pool's consecutive failures
+func (b *failureBackoff) succeeded(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.failures, key)
+}
+
+// When implements ratelimiter.RateLimiter, returning the retry interval of the pool's
+// last failed reconcile
+func (b *failureBackoff) When(item interface{}) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return requeueInterval(b.failures[requestKey(item)])
+}
+
+// Forget implements ratelimiter.RateLimiter. Failures are reset by succeeded instead,
+// since the controller also forgets requests requeued after a failure
+func (b *failureBackoff) Forget(_ interface{}) {}
+
+// NumRequeues implements ratelimiter.RateLimiter
+func (b *failureBackoff) NumRequeues(item interface{}) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.failures[requestKey(item)]
+}
+
+// requeueInterval returns the retry interval after the given number of consecutive failures
+func requeueInterval(failures int) time.Duration {
+	if failures < 1 {
+		return reconcileInterval
+	}
+	return reliability.ExponentialBackoff(failures-1, reconcileInterval, maxFailureRequeueInterval)
+}
+
+// jitterInterval randomizes a requeue interval by up to fraction of it either way
+func jitterInterval(interval time.Duration, fraction float64) time.Duration {
+	if interval <= 0 || fraction <= 0 {
+		return interval
+	}
+	//nolint:gosec // G404: spreading requeues doesn't need a secure random source
+	factor := 1 + fraction*(2*rand.Float64()-1)
+	return time.Duration(float64(interval) * factor)
+}
+
+func requestKey(item interface{}) types.NamespacedName {
+	if req, ok := item.(reconcile.Request); ok {
+		return req.NamespacedName
+	}
+	return types.NamespacedName{}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_FailureBackoff(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	client := setupStatusClient(reconciler)
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	failing := true
+	mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+		if failing {
+			return nil, errors.New("hetzner api unavailable")
+		}
+		return nil, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "backoff-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "backoff-pool", Namespace: "default"}}
+	want := []time.Duration{
+		30 * time.Second,
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		maxFailureRequeueInterval,
+		maxFailureRequeueInterval,
+	}
+	for i, wantInterval := range want {
+		if _, err := reconciler.Reconcile(ctx, req); err == nil {
+			t.Fatalf("Reconcile() #%d expected error when listing servers fails", i+1)
+		}
+		// The controller requeues failed reconciles through its rate limiter
+		if got := reconciler.failures.When(req); got != wantInterval {
+			t.Errorf("rate limiter interval after failure #%d = %v, want %v", i+1, got, wantInterval)
+		}
+	}
+
+	failing = false
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if result.RequeueAfter != reconcileInterval {
+		t.Errorf("Reconcile() RequeueAfter = %v after recovering, want %v", result.RequeueAfter, reconcileInterval)
+	}
+	if got := reconciler.failures.NumRequeues(req); got != 0 {
+		t.Errorf("Expected failures to be reset after a successful reconcile, got %d", got)
+	}
+}
+
+func TestNodePoolReconciler_LastErrorStatus(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	client := setupStatusClient(reconciler)
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	failing := true
+	mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+		if failing {
+			return nil, errors.New("hetzner api unavailable")
+		}
+		return nil, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "failing-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "failing-pool", Namespace: "default"}
+	for i := 0; i < 2; i++ {
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+			t.Fatalf("Reconcile() #%d expected error when listing servers fails", i+1)
+		}
+	}
+	if err := client.Get(ctx, key, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if nodePool.Status.LastError != "hetzner api unavailable" || nodePool.Status.FailureCount != 2 {
+		t.Errorf("Status.LastError = %q, FailureCount = %d, want the listing error and 2",
+			nodePool.Status.LastError, nodePool.Status.FailureCount)
+	}
+
+	failing = false
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := client.Get(ctx, key, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if nodePool.Status.LastError != "" || nodePool.Status.FailureCount != 0 {
+		t.Errorf("Expected a successful reconcile to clear the failure, got LastError = %q, FailureCount = %d",
+			nodePool.Status.LastError, nodePool.Status.FailureCount)
+	}
+}
+
+func TestNodePoolReconciler_RequeueJitter(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	reconciler.RequeueJitter = 0.1
+
+	client := setupStatusClient(reconciler)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "jitter-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "jitter-pool", Namespace: "default"}}
+	var intervals []time.Duration
+	for i := 0; i < 2; i++ {
+		result, err := reconciler.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		intervals = append(intervals, result.RequeueAfter)
+	}
+
+	low, high := reconcileInterval*9/10, reconcileInterval*11/10
+	for i, interval := range intervals {
+		if interval < low || interval > high {
+			t.Errorf("Reconcile() #%d RequeueAfter = %v, want within [%v, %v]", i+1, interval, low, high)
+		}
+	}
+	if intervals[0] == intervals[1] {
+		t.Errorf("Expected successive requeue intervals to differ, both are %v", intervals[0])
+	}
+
+	if got := jitterInterval(0, 0.1); got != 0 {
+		t.Errorf("jitterInterval(0) = %v, want no requeue to stay unset", got)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/bootstrap"
+)
+
+const (
+	// conditionCreateBackoff is true while server creations of the pool are suspended after
+	// too many consecutive failures
+	conditionCreateBackoff = "CreateBackoff"
+
+	// createFailureThreshold is the number of consecutive failed server creations that
+	// suspends a pool's creations
+	createFailureThreshold = 5
+	// createBackoffCooldown is how long creations stay suspended unless the spec changes
+	createBackoffCooldown = time.Hour
+)
+
+// recordCreateResult counts the pool's consecutive failed server creations and suspends its
+// creations once createFailureThreshold is reached, e.g. for an image that doesn't exist.
+// Creations waiting on cluster-info or refused for lack of quota are handled separately
+// and don't count.
+func (r *NodePoolReconciler) recordCreateResult(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, err error) {
+	switch {
+	case err == nil:
+		nodePool.Status.CreateFailures = 0
+		return
+	case stderrors.Is(err, bootstrap.ErrClusterInfoNotReady), isQuotaExceeded(err):
+		return
+	}
+
+	nodePool.Status.CreateFailures++
+	if nodePool.Status.CreateFailures < createFailureThreshold ||
+		meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionCreateBackoff) {
+		return
+	}
+
+	message := fmt.Sprintf("%d consecutive server creations failed, suspending creations for %s or until the spec changes: %v",
+		nodePool.Status.CreateFailures, createBackoffCooldown, err)
+	log.FromContext(ctx).Info("Suspending server creations", "failures", nodePool.Status.CreateFailures,
+		"cooldown", createBackoffCooldown, "reason", err.Error())
+	setCreateBackoffCondition(nodePool, metav1.ConditionTrue, "ConsecutiveFailures", message)
+	r.Recorder.Event(nodePool, corev1.EventTypeWarning, "CreateBackoff", message)
+}
+
+// createsSuspended reports whether the pool's server creations are suspended. The
+// suspension is lifted once the spec changes, which also resets the failure count, or
+// createBackoffCooldown elapsed, after which the next failure suspends creations again
+func createsSuspended(nodePool *hcloudv1alpha1.NodePool, now time.Time) bool {
+	condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionCreateBackoff)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return false
+	}
+
+	switch {
+	case condition.ObservedGeneration != nodePool.Generation:
+		nodePool.Status.CreateFailures = 0
+		setCreateBackoffCondition(nodePool, metav1.ConditionFalse, "SpecChanged", "")
+		return false
+	case now.Sub(condition.LastTransitionTime.Time) >= createBackoffCooldown:
+		setCreateBackoffCondition(nodePool, metav1.ConditionFalse, "CooldownElapsed", "")
+		return false
+	}
+	return true
+}
+
+// setCreateBackoffCondition records whether the pool's server creations are suspended
+func setCreateBackoffCondition(nodePool *hcloudv1alpha1.NodePool, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&nodePool.Status.Conditions, metav1.Condition{
+		Type:               conditionCreateBackoff,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: nodePool.Generation,
+	})
+}
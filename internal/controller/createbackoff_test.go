@@ -0,0 +1,118 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_CreateBackoff(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	client := setupStatusClient(reconciler)
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	mockHetzner.CreateServerFunc = func(_ context.Context, _ hetzner.ServerConfig) (*hetzner.Server, error) {
+		return nil, errors.New("image not found")
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "bad-image-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:    hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes:    3,
+			TargetNodes: 1,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "does-not-exist",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "bad-image-pool", Namespace: "default"}
+	for i := 0; i < createFailureThreshold; i++ {
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err == nil {
+			t.Fatalf("Reconcile() #%d expected error when creating the server fails", i+1)
+		}
+	}
+	if err := client.Get(ctx, key, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if nodePool.Status.CreateFailures != createFailureThreshold {
+		t.Errorf("Status.CreateFailures = %d, want %d", nodePool.Status.CreateFailures, createFailureThreshold)
+	}
+	if !meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionCreateBackoff) {
+		t.Fatalf("Expected %s condition to be true after %d failures", conditionCreateBackoff, createFailureThreshold)
+	}
+	if !recordedEvent(reconciler.Recorder.(*record.FakeRecorder), "Warning CreateBackoff") {
+		t.Error("Expected a CreateBackoff event")
+	}
+
+	// Suspended pools don't attempt creations
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if mockHetzner.CreateServerCalls != createFailureThreshold {
+		t.Errorf("CreateServer called %d times while suspended, want %d", mockHetzner.CreateServerCalls, createFailureThreshold)
+	}
+
+	// Creations resume after the cooldown, and the next failure suspends them again
+	if err := client.Get(ctx, key, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if !createsSuspended(nodePool, time.Now()) {
+		t.Error("Expected creations to be suspended before the cooldown elapsed")
+	}
+	if createsSuspended(nodePool, time.Now().Add(createBackoffCooldown)) {
+		t.Error("Expected creations to resume after the cooldown")
+	}
+	reconciler.recordCreateResult(ctx, nodePool, errors.New("image not found"))
+	if !meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionCreateBackoff) {
+		t.Error("Expected a failure after the cooldown to suspend creations again")
+	}
+
+	// A spec change lifts the suspension and resets the failures
+	nodePool.Generation++
+	if createsSuspended(nodePool, time.Now()) {
+		t.Error("Expected creations to resume after a spec change")
+	}
+	if nodePool.Status.CreateFailures != 0 {
+		t.Errorf("Status.CreateFailures = %d after a spec change, want 0", nodePool.Status.CreateFailures)
+	}
+}
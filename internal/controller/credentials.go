@@ -0,0 +1,245 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/ovhcloud"
+)
+
+// Keys of an OVHcloud credentials secret
+const (
+	ovhEndpointKey          = "endpoint"
+	ovhApplicationKeyKey    = "application-key"
+	ovhApplicationSecretKey = "application-secret" //nolint:gosec // G101: This is a secret key name, not a credential
+	ovhConsumerKeyKey       = "consumer-key"
+)
+
+// HetznerClientFactory creates a Hetzner Cloud client for an API token
+type HetznerClientFactory func(token string) hetzner.ClientInterface
+
+// OVHCloudClientFactory creates an OVHcloud client for a project's API credentials, failing
+// if they are unusable, e.g. name an unknown endpoint
+type OVHCloudClientFactory func(credentials ovhcloud.Credentials) (ovhcloud.ClientInterface, error)
+
+// poolClientsKey is the context key of the provider clients of the pool being reconciled
+type poolClientsKey struct{}
+
+// poolClients are the provider clients built from a pool's own credentials
+type poolClients struct {
+	hetzner  hetzner.ClientInterface
+	ovhcloud ovhcloud.ClientInterface
+}
+
+// credentialClients caches the provider clients of per-pool credentials, keyed by a hash of
+// the credentials so that a rotated secret gets a new client. Pools with the same credentials
+// share a client, which is dropped once no pool uses its credentials anymore.
+type credentialClients struct {
+	mu       sync.Mutex
+	hetzner  map[string]hetzner.ClientInterface
+	ovhcloud map[string]ovhcloud.ClientInterface
+	// pools maps each pool to the hash of the credentials it uses
+	pools map[string]string
+}
+
+// hetznerClient returns the cached client for the pool's token, creating it if needed
+func (c *credentialClients) hetznerClient(
+	nodePool *hcloudv1alpha1.NodePool,
+	token string,
+	create HetznerClientFactory,
+) hetzner.ClientInterface {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash := credentialsHash(token)
+	client, ok := c.hetzner[hash]
+	if !ok {
+		if c.hetzner == nil {
+			c.hetzner = make(map[string]hetzner.ClientInterface)
+		}
+		client = create(token)
+		c.hetzner[hash] = client
+	}
+	c.use(nodePool, hash)
+	return client
+}
+
+// ovhcloudClient returns the cached client for the pool's project credentials, creating it
+// if needed
+func (c *credentialClients) ovhcloudClient(
+	nodePool *hcloudv1alpha1.NodePool,
+	credentials ovhcloud.Credentials,
+	create OVHCloudClientFactory,
+) (ovhcloud.ClientInterface, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash := credentialsHash(credentials.Endpoint, credentials.ApplicationKey, credentials.ApplicationSecret,
+		credentials.ConsumerKey, credentials.ProjectID)
+	if client, ok := c.ovhcloud[hash]; ok {
+		c.use(nodePool, hash)
+		return client, nil
+	}
+	if c.ovhcloud == nil {
+		c.ovhcloud = make(map[string]ovhcloud.ClientInterface)
+	}
+	client, err := create(credentials)
+	if err != nil {
+		return nil, err
+	}
+	c.ovhcloud[hash] = client
+	c.use(nodePool, hash)
+	return client, nil
+}
+
+// use records that the pool uses the credentials with the given hash, dropping the client
+// of the credentials it used before if no other pool uses them. The caller holds c.mu
+func (c *credentialClients) use(nodePool *hcloudv1alpha1.NodePool, hash string) {
+	if c.pools == nil {
+		c.pools = make(map[string]string)
+	}
+	pool := poolKey(nodePool)
+	previous, ok := c.pools[pool]
+	c.pools[pool] = hash
+	if ok && previous != hash {
+		c.release(previous)
+	}
+}
+
+// forget drops the credentials of a pool that was deleted or no longer has its own
+func (c *credentialClients) forget(nodePool *hcloudv1alpha1.NodePool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pool := poolKey(nodePool)
+	hash, ok := c.pools[pool]
+	if !ok {
+		return
+	}
+	delete(c.pools, pool)
+	c.release(hash)
+}
+
+// release drops the client of credentials no pool uses anymore. The caller holds c.mu
+func (c *credentialClients) release(hash string) {
+	for _, used := range c.pools {
+		if used == hash {
+			return
+		}
+	}
+	delete(c.hetzner, hash)
+	delete(c.ovhcloud, hash)
+}
+
+// credentialsHash hashes credentials so they aren't kept as map keys in plain text
+func credentialsHash(values ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// withPoolClients returns a context carrying the provider clients of the pool's credentials
+// secret. Pools without one use the operator's global clients
+func (r *NodePoolReconciler) withPoolClients(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+) (context.Context, error) {
+	var clients poolClients
+
+	switch nodePool.Spec.Provider {
+	case hcloudv1alpha1.CloudProviderHetzner:
+		config := nodePool.Spec.HetznerConfig
+		if config == nil || config.CredentialsSecretRef == nil {
+			r.credentialClients.forget(nodePool)
+			return ctx, nil
+		}
+		if r.SecretsManager == nil || r.NewHetznerClient == nil {
+			return ctx, fmt.Errorf("per-pool Hetzner Cloud credentials are not supported by this operator")
+		}
+
+		ref := config.CredentialsSecretRef
+		token, err := r.SecretsManager.GetTokenFromSecret(ctx, nodePool.Namespace, ref.Name, ref.Key)
+		if err != nil {
+			return ctx, fmt.Errorf("failed to get Hetzner Cloud credentials: %w", err)
+		}
+		if token == "" {
+			return ctx, fmt.Errorf("Hetzner Cloud credentials secret %s has an empty token", ref.Name)
+		}
+		clients.hetzner = r.credentialClients.hetznerClient(nodePool, token, r.NewHetznerClient)
+
+	case hcloudv1alpha1.CloudProviderOVHcloud:
+		config := nodePool.Spec.OVHcloudConfig
+		if config == nil || config.CredentialsSecretRef == nil {
+			r.credentialClients.forget(nodePool)
+			return ctx, nil
+		}
+		if r.SecretsManager == nil || r.NewOVHCloudClient == nil {
+			return ctx, fmt.Errorf("per-pool OVHcloud credentials are not supported by this operator")
+		}
+
+		name := config.CredentialsSecretRef.Name
+		data, err := r.SecretsManager.GetSecretData(ctx, nodePool.Namespace, name)
+		if err != nil {
+			return ctx, fmt.Errorf("failed to get OVHcloud credentials: %w", err)
+		}
+		for _, key := range []string{ovhEndpointKey, ovhApplicationKeyKey, ovhApplicationSecretKey, ovhConsumerKeyKey} {
+			if len(data[key]) == 0 {
+				return ctx, fmt.Errorf("OVHcloud credentials secret %s is missing key %s", name, key)
+			}
+		}
+		clients.ovhcloud, err = r.credentialClients.ovhcloudClient(nodePool, ovhcloud.Credentials{
+			Endpoint:          string(data[ovhEndpointKey]),
+			ApplicationKey:    string(data[ovhApplicationKeyKey]),
+			ApplicationSecret: string(data[ovhApplicationSecretKey]),
+			ConsumerKey:       string(data[ovhConsumerKeyKey]),
+			ProjectID:         config.ProjectID,
+		}, r.NewOVHCloudClient)
+		if err != nil {
+			return ctx, fmt.Errorf("invalid OVHcloud credentials in secret %s: %w", name, err)
+		}
+
+	default:
+		r.credentialClients.forget(nodePool)
+		return ctx, nil
+	}
+
+	return context.WithValue(ctx, poolClientsKey{}, clients), nil
+}
+
+// hetznerClient returns the Hetzner Cloud client of the pool being reconciled
+func (r *NodePoolReconciler) hetznerClient(ctx context.Context) hetzner.ClientInterface {
+	if clients, ok := ctx.Value(poolClientsKey{}).(poolClients); ok && clients.hetzner != nil {
+		return clients.hetzner
+	}
+	return r.HCloudClient
+}
+
+// ovhcloudClient returns the OVHcloud client of the pool being reconciled, nil when neither
+// the pool nor the operator has OVHcloud credentials
+func (r *NodePoolReconciler) ovhcloudClient(ctx context.Context) ovhcloud.ClientInterface {
+	if clients, ok := ctx.Value(poolClientsKey{}).(poolClients); ok && clients.ovhcloud != nil {
+		return clients.ovhcloud
+	}
+	return r.OVHCloudClient
+}
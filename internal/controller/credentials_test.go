@@ -0,0 +1,160 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+	"github.com/autokubeio/autokube/internal/security"
+)
+
+func TestNodePoolReconciler_PerPoolCredentials(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+	ctx := context.Background()
+
+	globalHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	projectClients := map[string]*mock.HetznerClient{
+		"token-a": mock.NewMockHetznerClient(),
+		"token-b": mock.NewMockHetznerClient(),
+	}
+	created := map[string]int{}
+
+	reconciler.SecretsManager = security.NewSecretsManager(reconciler.KubeClient, "nodepool-system")
+	reconciler.NewHetznerClient = func(token string) hetzner.ClientInterface {
+		created[token]++
+		return projectClients[token]
+	}
+
+	for pool, token := range map[string]string{"pool-a": "token-a", "pool-b": "token-b"} {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: pool + "-credentials", Namespace: "default"},
+			Data:       map[string][]byte{"hcloud-token": []byte(token)},
+		}
+		if _, err := reconciler.KubeClient.CoreV1().Secrets("default").Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create secret: %v", err)
+		}
+
+		nodePool := &hcloudv1alpha1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       pool,
+				Namespace:  "default",
+				Finalizers: []string{nodePoolFinalizer},
+			},
+			Spec: hcloudv1alpha1.NodePoolSpec{
+				Provider:    hcloudv1alpha1.CloudProviderHetzner,
+				MinNodes:    1,
+				MaxNodes:    3,
+				TargetNodes: 1,
+				HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+					ServerType: "cx11",
+					Image:      "ubuntu-22.04",
+					Location:   "nbg1",
+					CredentialsSecretRef: &hcloudv1alpha1.SecretReference{
+						Name: pool + "-credentials",
+						Key:  "hcloud-token",
+					},
+				},
+				Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+					Type:              hcloudv1alpha1.ClusterTypeKubeadm,
+					AutoGenerateToken: true,
+				},
+			},
+		}
+		if err := client.Create(ctx, nodePool); err != nil {
+			t.Fatalf("Failed to create NodePool: %v", err)
+		}
+	}
+
+	for _, pool := range []string{"pool-a", "pool-b", "pool-a"} {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: pool, Namespace: "default"}}
+		if _, err := reconciler.Reconcile(ctx, req); err != nil && !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("Reconcile(%s) unexpected error = %v", pool, err)
+		}
+	}
+
+	for token, client := range projectClients {
+		if client.CreateServerCalls == 0 {
+			t.Errorf("Expected servers to be created with %s", token)
+		}
+		if created[token] != 1 {
+			t.Errorf("Expected one cached client for %s, created %d", token, created[token])
+		}
+	}
+	if globalHetzner.ListServersCalls != 0 || globalHetzner.CreateServerCalls != 0 {
+		t.Errorf("Expected the global client to be unused, got %d list and %d create calls",
+			globalHetzner.ListServersCalls, globalHetzner.CreateServerCalls)
+	}
+
+	// Each project only sees its own pool's servers
+	for _, server := range projectClients["token-b"].GetServers() {
+		if !strings.HasPrefix(server.Name, "pool-b-") {
+			t.Errorf("Unexpected server %s created with token-b", server.Name)
+		}
+	}
+}
+
+func TestCredentialClientsEviction(t *testing.T) {
+	var clients credentialClients
+	created := map[string]int{}
+	create := func(token string) hetzner.ClientInterface {
+		created[token]++
+		return mock.NewMockHetznerClient()
+	}
+	poolA := &hcloudv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "pool-a", Namespace: "default"}}
+	poolB := &hcloudv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "pool-b", Namespace: "default"}}
+
+	// Pools with the same credentials share a client
+	clients.hetznerClient(poolA, "token-a", create)
+	clients.hetznerClient(poolB, "token-a", create)
+	if created["token-a"] != 1 || len(clients.hetzner) != 1 {
+		t.Fatalf("Expected one shared client for token-a, created %d, cached %d", created["token-a"], len(clients.hetzner))
+	}
+
+	// The old client is kept while another pool still uses it
+	clients.hetznerClient(poolA, "token-b", create)
+	if _, ok := clients.hetzner[credentialsHash("token-a")]; !ok {
+		t.Error("Expected the token-a client to be kept for pool-b")
+	}
+
+	// and dropped once the last pool rotated its credentials
+	clients.hetznerClient(poolB, "token-c", create)
+	if _, ok := clients.hetzner[credentialsHash("token-a")]; ok {
+		t.Error("Expected the token-a client to be dropped after both pools rotated their credentials")
+	}
+	if len(clients.hetzner) != 2 {
+		t.Errorf("Expected 2 cached clients, got %d", len(clients.hetzner))
+	}
+
+	// A deleted pool's client is dropped
+	clients.forget(poolA)
+	if _, ok := clients.hetzner[credentialsHash("token-b")]; ok {
+		t.Error("Expected the token-b client to be dropped with pool-a")
+	}
+	if len(clients.hetzner) != 1 {
+		t.Errorf("Expected 1 cached client, got %d", len(clients.hetzner))
+	}
+}
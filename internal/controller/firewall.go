@@ -0,0 +1,74 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// anySource is the source of firewall rules without sources, any IPv4 and IPv6 address
+var anySource = []net.IPNet{
+	{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},  // 0.0.0.0/0
+	{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}, // ::/0
+}
+
+// firewallRuleSources returns the networks allowed by a firewall rule, any address when
+// the rule has no sources
+func firewallRuleSources(rule hcloudv1alpha1.FirewallRule) ([]net.IPNet, error) {
+	if len(rule.Sources) == 0 {
+		return anySource, nil
+	}
+
+	sources := make([]net.IPNet, 0, len(rule.Sources))
+	for _, source := range rule.Sources {
+		_, network, err := net.ParseCIDR(source)
+		if err != nil {
+			return nil, fmt.Errorf("firewall rule for port %s has invalid source %q: not a CIDR", rule.Port, source)
+		}
+		sources = append(sources, *network)
+	}
+	return sources, nil
+}
+
+// validateFirewallRules records whether the sources of the pool's firewall rules are valid
+// CIDRs in the FirewallRulesValid condition, and returns false when they aren't
+func validateFirewallRules(nodePool *hcloudv1alpha1.NodePool) bool {
+	condition := metav1.Condition{
+		Type:               conditionFirewallRulesValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "firewall rule sources are valid",
+		ObservedGeneration: nodePool.Generation,
+	}
+	for _, rule := range nodePool.Spec.FirewallRules {
+		if _, err := firewallRuleSources(rule); err != nil {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "InvalidSource"
+			condition.Message = err.Error()
+			break
+		}
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, condition)
+
+	return condition.Status == metav1.ConditionTrue
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_FirewallRuleSources(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []hcloudv1alpha1.FirewallRule
+		wantSources [][]string
+		wantValid   bool
+	}{
+		{
+			name: "restricted and world-open rules",
+			rules: []hcloudv1alpha1.FirewallRule{
+				{Port: "10250", Protocol: "tcp", Sources: []string{"10.0.0.0/8"}},
+				{Port: "443", Protocol: "tcp"},
+			},
+			wantSources: [][]string{{"10.0.0.0/8"}, {"0.0.0.0/0", "::/0"}},
+			wantValid:   true,
+		},
+		{
+			name: "invalid source",
+			rules: []hcloudv1alpha1.FirewallRule{
+				{Port: "10250", Protocol: "tcp", Sources: []string{"10.0.0.0/33"}},
+			},
+			wantValid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			ctx := context.Background()
+
+			client := setupStatusClient(reconciler)
+
+			mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+			var gotRules []hcloud.FirewallRule
+			mockHetzner.GetOrCreateFirewallFunc = func(
+				_ context.Context,
+				name string,
+				rules []hcloud.FirewallRule,
+				_ map[string]string,
+			) (*hcloud.Firewall, error) {
+				gotRules = rules
+				return &hcloud.Firewall{ID: 1, Name: name}, nil
+			}
+
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "firewall-pool",
+					Namespace:  "default",
+					Finalizers: []string{nodePoolFinalizer},
+				},
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					Provider:      hcloudv1alpha1.CloudProviderHetzner,
+					MinNodes:      1,
+					MaxNodes:      3,
+					FirewallRules: tt.rules,
+					HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+						ServerType: "cx11",
+						Image:      "ubuntu-22.04",
+						Location:   "nbg1",
+					},
+				},
+			}
+			if err := client.Create(ctx, nodePool); err != nil {
+				t.Fatalf("Failed to create NodePool: %v", err)
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "firewall-pool", Namespace: "default"}}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if err := client.Get(ctx, req.NamespacedName, nodePool); err != nil {
+				t.Fatalf("Failed to get NodePool: %v", err)
+			}
+
+			if valid := meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionFirewallRulesValid); valid != tt.wantValid {
+				t.Errorf("%s condition true = %v, want %v", conditionFirewallRulesValid, valid, tt.wantValid)
+			}
+			if !tt.wantValid {
+				if mockHetzner.CreateServerCalls != 0 {
+					t.Errorf("CreateServer called %d times, want no servers behind invalid firewall rules", mockHetzner.CreateServerCalls)
+				}
+				if nodePool.Status.Phase != "InvalidFirewallRules" {
+					t.Errorf("Status.Phase = %q, want InvalidFirewallRules", nodePool.Status.Phase)
+				}
+				return
+			}
+
+			if len(gotRules) != len(tt.wantSources) {
+				t.Fatalf("GetOrCreateFirewall() got %d rules, want %d", len(gotRules), len(tt.wantSources))
+			}
+			for i, rule := range gotRules {
+				if got := ipNetStrings(rule.SourceIPs); fmt.Sprint(got) != fmt.Sprint(tt.wantSources[i]) {
+					t.Errorf("rule %d SourceIPs = %v, want %v", i, got, tt.wantSources[i])
+				}
+			}
+		})
+	}
+}
+
+func ipNetStrings(networks []net.IPNet) []string {
+	strs := make([]string, len(networks))
+	for i, network := range networks {
+		strs[i] = network.String()
+	}
+	return strs
+}
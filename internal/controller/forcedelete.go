@@ -0,0 +1,178 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+const (
+	// forceDeleteAnnotation set to "true" removes the finalizer of a deleted NodePool even
+	// when its cloud resources can't be cleaned up, e.g. while the provider API is down
+	forceDeleteAnnotation = "autokube.io/force-delete"
+
+	// operationLeakedResource is the dead letter queue operation type of the resources a
+	// forced deletion left behind
+	operationLeakedResource = "LeakedResource"
+
+	// operationServerDeletionTimeout is the dead letter queue operation type of the servers
+	// whose cleanup timed out while their pool was deleted
+	operationServerDeletionTimeout = "ServerDeletionTimeout"
+
+	// defaultServerDeletionTimeout bounds the cleanup of each server of a deleted pool
+	defaultServerDeletionTimeout = 10 * time.Minute
+)
+
+// leakedResource is a cloud resource a forced deletion failed to delete
+type leakedResource struct {
+	Kind string
+	Name string
+	ID   string
+	Err  error
+}
+
+// cleanupFailures counts the servers a pool deletion failed to delete, so the remaining
+// servers are still deleted before the deletion is retried
+type cleanupFailures struct {
+	count int
+	last  error
+}
+
+func (f *cleanupFailures) add(err error) {
+	f.count++
+	f.last = err
+}
+
+// err returns an error wrapping the last failure if any of the total servers failed
+func (f *cleanupFailures) err(kind string, total int) error {
+	if f.count == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to delete %d of %d %s: %w", f.count, total, kind, f.last)
+}
+
+// cleanupServer runs the deletion of a server of a deleted pool bounded by the server
+// deletion timeout. A server whose deletion times out is pushed to the dead letter queue,
+// it is retried along with the pool's deletion
+func (r *NodePoolReconciler) cleanupServer(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	kind, name, id string,
+	deleteServer func(context.Context) error,
+) error {
+	timeout := r.ServerDeletionTimeout
+	if timeout <= 0 {
+		timeout = defaultServerDeletionTimeout
+	}
+	deleteCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dlqID := fmt.Sprintf("%s/%s/%s/%s", nodePool.Namespace, nodePool.Name, kind, name)
+	err := deleteServer(deleteCtx)
+	if err == nil && r.DeadLetterQueue != nil {
+		// Deleted on a retry after timing out
+		r.DeadLetterQueue.Remove(dlqID)
+	}
+	if err == nil || deleteCtx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	err = fmt.Errorf("deletion timed out after %s: %w", timeout, err)
+
+	if r.DeadLetterQueue != nil {
+		dlqErr := r.DeadLetterQueue.Add(&reliability.FailedOperation{
+			ID:            dlqID,
+			OperationType: operationServerDeletionTimeout,
+			Error:         err,
+			Metadata: map[string]string{
+				"provider":  string(nodePool.Spec.Provider),
+				"namespace": nodePool.Namespace,
+				"nodepool":  nodePool.Name,
+				"kind":      kind,
+				"name":      name,
+				"id":        id,
+			},
+		})
+		if dlqErr != nil {
+			log.FromContext(ctx).Error(dlqErr, "Failed to add timed out server deletion to the dead letter queue", "name", name)
+		}
+	}
+	return err
+}
+
+// forceDeleteRequested reports whether the pool carries the force-delete annotation
+func forceDeleteRequested(nodePool *hcloudv1alpha1.NodePool) bool {
+	return nodePool.Annotations[forceDeleteAnnotation] == "true"
+}
+
+// unlistedNodes returns the nodes recorded in the pool status as leaked, for when the
+// provider couldn't list the pool's resources
+func unlistedNodes(nodePool *hcloudv1alpha1.NodePool, err error) []leakedResource {
+	leaked := make([]leakedResource, 0, len(nodePool.Status.Nodes))
+	for _, name := range nodePool.Status.Nodes {
+		leaked = append(leaked, leakedResource{Kind: "node", Name: name, Err: err})
+	}
+	return leaked
+}
+
+// recordLeakedResources logs the resources a forced deletion left behind and pushes them
+// to the dead letter queue, so they can be cleaned up manually
+func (r *NodePoolReconciler) recordLeakedResources(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	leaked []leakedResource,
+) {
+	logger := log.FromContext(ctx)
+
+	names := make([]string, 0, len(leaked))
+	for _, resource := range leaked {
+		names = append(names, fmt.Sprintf("%s %s", resource.Kind, resource.Name))
+		logger.Error(resource.Err, "Force-deleting NodePool, leaking cloud resource",
+			"kind", resource.Kind, "name", resource.Name, "id", resource.ID)
+
+		if r.DeadLetterQueue == nil {
+			continue
+		}
+		err := r.DeadLetterQueue.Add(&reliability.FailedOperation{
+			ID:            fmt.Sprintf("%s/%s/%s/%s", nodePool.Namespace, nodePool.Name, resource.Kind, resource.Name),
+			OperationType: operationLeakedResource,
+			Error:         resource.Err,
+			Metadata: map[string]string{
+				"provider":  string(nodePool.Spec.Provider),
+				"namespace": nodePool.Namespace,
+				"nodepool":  nodePool.Name,
+				"kind":      resource.Kind,
+				"name":      resource.Name,
+				"id":        resource.ID,
+			},
+		})
+		if err != nil {
+			logger.Error(err, "Failed to add leaked resource to the dead letter queue", "name", resource.Name)
+		}
+	}
+
+	r.Recorder.Eventf(nodePool, corev1.EventTypeWarning, "ResourcesLeaked",
+		"Force-deleted with %d cloud resources left for manual cleanup: %s", len(leaked), strings.Join(names, ", "))
+}
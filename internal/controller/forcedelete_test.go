@@ -0,0 +1,165 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_ForceDelete(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		wantErr       bool
+		wantFinalizer bool
+		wantLeaked    int
+	}{
+		{name: "without annotation", wantErr: true, wantFinalizer: true},
+		{name: "annotation not true", annotations: map[string]string{forceDeleteAnnotation: "yes"}, wantErr: true, wantFinalizer: true},
+		{name: "force-delete", annotations: map[string]string{forceDeleteAnnotation: "true"}, wantLeaked: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, client := setupTestReconciler()
+
+			mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+			mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+				return nil, errors.New("hetzner API unavailable")
+			}
+
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-pool",
+					Namespace:   "default",
+					Finalizers:  []string{nodePoolFinalizer},
+					Annotations: tt.annotations,
+				},
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					Provider: hcloudv1alpha1.CloudProviderHetzner,
+					HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+						ServerType: "cx11",
+						Image:      "ubuntu-22.04",
+						Location:   "nbg1",
+					},
+				},
+				Status: hcloudv1alpha1.NodePoolStatus{
+					Nodes: []string{"test-pool-1a2b", "test-pool-3c4d"},
+				},
+			}
+			if err := client.Create(context.Background(), nodePool); err != nil {
+				t.Fatalf("Failed to create NodePool: %v", err)
+			}
+
+			_, err := reconciler.handleDeletion(context.Background(), nodePool)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleDeletion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := containsString(nodePool.Finalizers, nodePoolFinalizer); got != tt.wantFinalizer {
+				t.Errorf("finalizer present = %v, want %v", got, tt.wantFinalizer)
+			}
+
+			leaked := reconciler.DeadLetterQueue.GetByType(operationLeakedResource)
+			if len(leaked) != tt.wantLeaked {
+				t.Fatalf("Expected %d leaked resources in the dead letter queue, got %d", tt.wantLeaked, len(leaked))
+			}
+			for _, op := range leaked {
+				if op.Metadata["nodepool"] != "test-pool" || op.Metadata["kind"] != "node" || op.Error == nil {
+					t.Errorf("Unexpected leaked resource %+v", op)
+				}
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_DeletionServerTimeout(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+	reconciler.ServerDeletionTimeout = 50 * time.Millisecond
+	ctx := context.Background()
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+		return []hetzner.Server{
+			{ID: 1, Name: "test-pool-0001"},
+			{ID: 2, Name: "test-pool-0002"},
+			{ID: 3, Name: "test-pool-0003"},
+		}, nil
+	}
+	var deleted []int64
+	mockHetzner.DeleteServerFunc = func(ctx context.Context, serverID int64) error {
+		if serverID == 2 {
+			// Hangs until the deletion times out
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		deleted = append(deleted, serverID)
+		return nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	if _, err := reconciler.handleDeletion(ctx, nodePool); err == nil {
+		t.Fatal("handleDeletion() expected error when a server deletion times out")
+	}
+
+	// The servers after the slow one are still deleted
+	if len(deleted) != 2 || deleted[0] != 1 || deleted[1] != 3 {
+		t.Errorf("deleted servers = %v, want [1 3]", deleted)
+	}
+	if !containsString(nodePool.Finalizers, nodePoolFinalizer) {
+		t.Error("Expected the finalizer to be kept until every server is deleted")
+	}
+	timedOut := reconciler.DeadLetterQueue.GetByType(operationServerDeletionTimeout)
+	if len(timedOut) != 1 || timedOut[0].Metadata["name"] != "test-pool-0002" || timedOut[0].Metadata["id"] != "2" {
+		t.Fatalf("Expected the slow server in the dead letter queue, got %+v", timedOut)
+	}
+
+	// The retry deletes the slow server and clears it from the dead letter queue
+	mockHetzner.DeleteServerFunc = func(_ context.Context, _ int64) error { return nil }
+	if _, err := reconciler.handleDeletion(ctx, nodePool); err != nil {
+		t.Fatalf("handleDeletion() error = %v", err)
+	}
+	if got := reconciler.DeadLetterQueue.GetByType(operationServerDeletionTimeout); len(got) != 0 {
+		t.Errorf("Expected the dead letter queue entry to be removed, got %d", len(got))
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// supersedingNodePool reads the pool from the API server, bypassing the cache the reconcile
+// read it from, and returns it when its spec changed since, nil otherwise
+func (r *NodePoolReconciler) supersedingNodePool(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) *hcloudv1alpha1.NodePool {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+
+	latest := &hcloudv1alpha1.NodePool{}
+	if err := reader.Get(ctx, client.ObjectKeyFromObject(nodePool), latest); err != nil {
+		// Act on the spec at hand, a deleted pool is handled on its next reconcile
+		log.FromContext(ctx).Error(err, "Failed to read the latest NodePool generation")
+		return nil
+	}
+	if latest.Generation <= nodePool.Generation {
+		return nil
+	}
+	return latest
+}
+
+// updateSupersededStatus records the status observed for a superseded spec on the latest
+// version of the pool, keeping the observed generation of the last reconcile that acted
+func (r *NodePoolReconciler) updateSupersededStatus(
+	ctx context.Context,
+	nodePool, latest *hcloudv1alpha1.NodePool,
+) error {
+	nodePool.Status.DeepCopyInto(&latest.Status)
+	return r.Status().Update(ctx, latest)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+// supersedingReader reads objects one generation ahead, as if their spec changed right
+// after the reconcile read them
+type supersedingReader struct {
+	client.Reader
+}
+
+func (r supersedingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := r.Reader.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	obj.SetGeneration(obj.GetGeneration() + 1)
+	return nil
+}
+
+func TestNodePoolReconciler_ObservedGeneration(t *testing.T) {
+	tests := []struct {
+		name               string
+		superseded         bool
+		wantObserved       int64
+		wantCreatedServers int
+	}{
+		{name: "current spec", wantObserved: 3, wantCreatedServers: 2},
+		{name: "superseded spec", superseded: true, wantObserved: 0, wantCreatedServers: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			ctx := context.Background()
+
+			client := setupStatusClient(reconciler)
+			if tt.superseded {
+				reconciler.APIReader = supersedingReader{Reader: client}
+			}
+
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "generation-pool",
+					Namespace:  "default",
+					Generation: 3,
+					Finalizers: []string{nodePoolFinalizer},
+				},
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					Provider: hcloudv1alpha1.CloudProviderHetzner,
+					MinNodes: 2,
+					MaxNodes: 3,
+					HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+						ServerType: "cx11",
+						Image:      "ubuntu-22.04",
+						Location:   "nbg1",
+					},
+				},
+			}
+			if err := client.Create(ctx, nodePool); err != nil {
+				t.Fatalf("Failed to create NodePool: %v", err)
+			}
+
+			key := types.NamespacedName{Name: "generation-pool", Namespace: "default"}
+			if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if err := client.Get(ctx, key, nodePool); err != nil {
+				t.Fatalf("Failed to get NodePool: %v", err)
+			}
+
+			if nodePool.Status.ObservedGeneration != tt.wantObserved {
+				t.Errorf("Status.ObservedGeneration = %d, want %d", nodePool.Status.ObservedGeneration, tt.wantObserved)
+			}
+			mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+			if mockHetzner.CreateServerCalls != tt.wantCreatedServers {
+				t.Errorf("CreateServer called %d times, want %d", mockHetzner.CreateServerCalls, tt.wantCreatedServers)
+			}
+			// The status is recorded either way
+			if nodePool.Status.Phase == "" {
+				t.Error("Expected the status to be updated")
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// replaceUnhealthyNodes deletes the pool's nodes that have been NotReady for longer than the
+// pool's UnhealthyNodeTimeout, along with their listed servers, so scaling up replaces them.
+// At most MaxUnhealthyReplacements nodes are replaced at once, counting the pool's nodes that
+// are not ready yet. It returns the number of nodes deleted
+func (r *NodePoolReconciler) replaceUnhealthyNodes(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	listed *poolServers,
+	now time.Time,
+) (int, error) {
+	logger := log.FromContext(ctx)
+
+	if nodePool.Spec.UnhealthyNodeTimeout == nil {
+		return 0, nil
+	}
+	timeout := nodePool.Spec.UnhealthyNodeTimeout.Duration
+
+	var unhealthy []string
+	notReady := 0
+	for _, name := range nodePool.Status.Nodes {
+		node := &corev1.Node{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, node); err != nil {
+			if !errors.IsNotFound(err) {
+				return 0, fmt.Errorf("failed to get node %s: %w", name, err)
+			}
+			// Not joined the cluster yet
+			notReady++
+			continue
+		}
+		condition := nodeReadyCondition(node)
+		switch {
+		case condition != nil && condition.Status == corev1.ConditionTrue:
+		case condition != nil && now.Sub(condition.LastTransitionTime.Time) > timeout:
+			unhealthy = append(unhealthy, name)
+		default:
+			notReady++
+		}
+	}
+	if len(unhealthy) == 0 {
+		return 0, nil
+	}
+
+	replacements := max(nodePool.Spec.MaxUnhealthyReplacements, 1) - notReady
+	if replacements <= 0 {
+		logger.Info("Waiting for nodes to become ready before replacing unhealthy nodes",
+			"unhealthy", len(unhealthy), "notReady", notReady)
+		return 0, nil
+	}
+	if len(unhealthy) > replacements {
+		unhealthy = unhealthy[:replacements]
+	}
+
+	names := make(map[string]bool, len(unhealthy))
+	for _, name := range unhealthy {
+		logger.Info("Replacing unhealthy node", "node", name, "timeout", timeout)
+		r.Recorder.Eventf(nodePool, corev1.EventTypeWarning, "UnhealthyNode",
+			"Replacing node %s, NotReady for more than %s", name, timeout)
+		names[name] = true
+	}
+	return r.deleteNodes(ctx, nodePool, listed, names)
+}
+
+// nodeReadyCondition returns the node's Ready condition, nil if it has none
+func nodeReadyCondition(node *corev1.Node) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == corev1.NodeReady {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// deleteNodes drains and deletes the named nodes among the listed servers of the pool along
+// with their servers and drops them from the pool status. It returns the number of nodes deleted
+func (r *NodePoolReconciler) deleteNodes(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	listed *poolServers,
+	names map[string]bool,
+) (int, error) {
+	deleted := 0
+	forget := func(name string) {
+		nodePool.Status.Nodes = removeString(nodePool.Status.Nodes, name)
+		listed.forget(name)
+		deleted++
+	}
+
+	provider, err := r.cloudProvider(nodePool)
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range provider.Names(listed) {
+		if !names[name] {
+			continue
+		}
+		if err := provider.Delete(ctx, nodePool, listed, name); err != nil {
+			return deleted, err
+		}
+		forget(name)
+	}
+	return deleted, nil
+}
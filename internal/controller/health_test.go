@@ -0,0 +1,149 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+// setupUnhealthyNodeTest creates a server and a node with the given Ready condition for each
+// name, and a pool of that many nodes that replaces unhealthy nodes
+func setupUnhealthyNodeTest(
+	t *testing.T,
+	maxReplacements int,
+	conditions map[string]corev1.NodeCondition,
+) (*NodePoolReconciler, client.Client, *mock.HetznerClient, ctrl.Request) {
+	t.Helper()
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var objects []client.Object
+	for name, condition := range conditions {
+		if _, err := mockHetzner.CreateServer(ctx, hetzner.ServerConfig{Name: name}); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		objects = append(objects, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{condition}},
+		})
+	}
+	objects = append(objects, &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:                 hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes:                 len(conditions),
+			MaxNodes:                 len(conditions),
+			UnhealthyNodeTimeout:     &metav1.Duration{Duration: 10 * time.Minute},
+			MaxUnhealthyReplacements: maxReplacements,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	})
+
+	kubeClient := setupStatusClient(reconciler, objects...)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-pool", Namespace: "default"}}
+	return reconciler, kubeClient, mockHetzner, req
+}
+
+func readyCondition(status corev1.ConditionStatus, age time.Duration) corev1.NodeCondition {
+	return corev1.NodeCondition{
+		Type:               corev1.NodeReady,
+		Status:             status,
+		LastTransitionTime: metav1.NewTime(time.Now().Add(-age)),
+	}
+}
+
+func TestNodePoolReconciler_ReplaceUnhealthyNodes(t *testing.T) {
+	reconciler, kubeClient, mockHetzner, req := setupUnhealthyNodeTest(t, 2, map[string]corev1.NodeCondition{
+		"test-pool-0001": readyCondition(corev1.ConditionTrue, time.Hour),
+		"test-pool-0002": readyCondition(corev1.ConditionFalse, time.Hour),
+		// NotReady within the timeout, counts against the replacements
+		"test-pool-0003": readyCondition(corev1.ConditionUnknown, 5*time.Minute),
+	})
+	ctx := context.Background()
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if mockHetzner.DeleteServerCalls != 1 {
+		t.Errorf("DeleteServerCalls = %d, want 1", mockHetzner.DeleteServerCalls)
+	}
+	if server, _ := mockHetzner.GetServerByName(ctx, "test-pool-0002"); server != nil {
+		t.Error("Expected the server of the stale NotReady node to be deleted")
+	}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-0002"}, &corev1.Node{}); err == nil {
+		t.Error("Expected the stale NotReady node to be deleted")
+	}
+	for _, name := range []string{"test-pool-0001", "test-pool-0003"} {
+		if server, _ := mockHetzner.GetServerByName(ctx, name); server == nil {
+			t.Errorf("Expected server %s to be kept", name)
+		}
+	}
+
+	// The deleted node is replaced right away
+	servers, _ := mockHetzner.ListServers(ctx, "test-pool", "default")
+	if len(servers) != 3 {
+		t.Errorf("Expected 3 servers after replacing the unhealthy node, got %d", len(servers))
+	}
+}
+
+func TestNodePoolReconciler_ReplaceUnhealthyNodesCapped(t *testing.T) {
+	// Every node NotReady at once, as during a control plane outage
+	reconciler, _, mockHetzner, req := setupUnhealthyNodeTest(t, 0, map[string]corev1.NodeCondition{
+		"test-pool-0001": readyCondition(corev1.ConditionUnknown, time.Hour),
+		"test-pool-0002": readyCondition(corev1.ConditionUnknown, time.Hour),
+		"test-pool-0003": readyCondition(corev1.ConditionUnknown, time.Hour),
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() #%d error = %v", i+1, err)
+		}
+	}
+
+	// The replacement never joins, so no further node is replaced
+	if mockHetzner.DeleteServerCalls != 1 {
+		t.Errorf("DeleteServerCalls = %d, want 1", mockHetzner.DeleteServerCalls)
+	}
+	servers, _ := mockHetzner.ListServers(ctx, "test-pool", "default")
+	if len(servers) != 3 {
+		t.Errorf("Expected 3 servers, got %d", len(servers))
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// nodeFinalizer keeps a node of a pool that is deleted out of band, e.g. with kubectl
+// delete node, until its server is deleted too, so the server doesn't leak
+const nodeFinalizer = "autokube.io/server-cleanup"
+
+// deletingNodePredicate passes the events of nodes of a pool that are being deleted
+func deletingNodePredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return controllerutil.ContainsFinalizer(obj, nodeFinalizer) && !obj.GetDeletionTimestamp().IsZero()
+	})
+}
+
+// reconcileNode deletes the server of a node of a pool that was deleted out of band, then
+// removes the node's finalizer. Nodes the operator deletes itself lose the finalizer first,
+// see deleteNode
+func (r *NodePoolReconciler) reconcileNode(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("node", req.Name)
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if node.DeletionTimestamp.IsZero() || !controllerutil.ContainsFinalizer(node, nodeFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	nodePool, err := r.nodePoolOf(ctx, node)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if nodePool != nil {
+		if err := r.deleteServerOfNode(ctx, nodePool, node.Name); err != nil {
+			logger.Error(err, "Failed to delete the server of a deleted node")
+			return ctrl.Result{RequeueAfter: reconcileInterval}, err
+		}
+	}
+
+	return ctrl.Result{}, r.removeNodeFinalizer(ctx, node)
+}
+
+// nodePoolOf returns the pool the node belongs to, nil when it belongs to none anymore.
+// Pools listing a server of the node's name are skipped when the node is labeled with another
+func (r *NodePoolReconciler) nodePoolOf(ctx context.Context, node *corev1.Node) (*hcloudv1alpha1.NodePool, error) {
+	nodePools := &hcloudv1alpha1.NodePoolList{}
+	if err := r.List(ctx, nodePools); err != nil {
+		return nil, fmt.Errorf("failed to list NodePools: %w", err)
+	}
+	for i := range nodePools.Items {
+		if slices.Contains(nodePools.Items[i].Status.Nodes, node.Name) && !ownedByOtherPool(&nodePools.Items[i], node) {
+			return &nodePools.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// deleteServerOfNode deletes the pool's server of the node, if it is still listed
+func (r *NodePoolReconciler) deleteServerOfNode(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, nodeName string) error {
+	ctx, err := r.withPoolClients(ctx, nodePool)
+	if err != nil {
+		return err
+	}
+	provider, err := r.cloudProvider(nodePool)
+	if err != nil {
+		return err
+	}
+	listed, err := provider.List(ctx, nodePool)
+	if err != nil {
+		return fmt.Errorf("failed to list servers: %w", err)
+	}
+	if !slices.Contains(provider.Names(listed), nodeName) {
+		return nil
+	}
+
+	if err := provider.Delete(ctx, nodePool, listed, nodeName); err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("Deleted the server of a node deleted out of band", "node", nodeName,
+		"nodepool", nodePool.Name, "namespace", nodePool.Namespace)
+	r.Recorder.Eventf(nodePool, corev1.EventTypeNormal, "NodeServerDeleted",
+		"Deleted server %s, its node was deleted", nodeName)
+	return nil
+}
+
+// addNodeFinalizer adds the finalizer to a node of a pool and reports whether the node
+// changed. Nodes being deleted can't get new finalizers
+func addNodeFinalizer(node *corev1.Node) bool {
+	if !node.DeletionTimestamp.IsZero() {
+		return false
+	}
+	return controllerutil.AddFinalizer(node, nodeFinalizer)
+}
+
+// removeNodeFinalizer removes the finalizer from the node
+func (r *NodePoolReconciler) removeNodeFinalizer(ctx context.Context, node *corev1.Node) error {
+	if !controllerutil.ContainsFinalizer(node, nodeFinalizer) {
+		return nil
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	controllerutil.RemoveFinalizer(node, nodeFinalizer)
+	if err := r.Patch(ctx, node, patch); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove finalizer of node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// deleteNode deletes a node of a pool whose server the operator is deleting, removing the
+// finalizer first so the node doesn't wait on its own server's deletion
+func (r *NodePoolReconciler) deleteNode(ctx context.Context, node *corev1.Node) error {
+	if err := r.removeNodeFinalizer(ctx, node); err != nil {
+		return err
+	}
+	return r.Delete(ctx, node)
+}
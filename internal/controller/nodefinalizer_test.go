@@ -0,0 +1,100 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_DeletedNodeDeletesServer(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	for _, name := range []string{"test-pool-1a2b", "test-pool-3c4d"} {
+		if _, err := mockHetzner.CreateServer(ctx, hetzner.ServerConfig{Name: name}); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:  hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes:  2,
+			DrainMode: hcloudv1alpha1.DrainModeNone,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+		Status: hcloudv1alpha1.NodePoolStatus{Nodes: []string{"test-pool-1a2b", "test-pool-3c4d"}},
+	}
+	newNode := func(name string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Finalizers: []string{nodeFinalizer}}}
+	}
+	kubeClient := clientfake.NewClientBuilder().
+		WithScheme(reconciler.Scheme).
+		WithObjects(nodePool, newNode("test-pool-1a2b"), newNode("test-pool-3c4d"), newNode("unmanaged")).
+		Build()
+	reconciler.Client = kubeClient
+
+	// kubectl delete node
+	for _, name := range []string{"test-pool-1a2b", "unmanaged"} {
+		if err := kubeClient.Delete(ctx, newNode(name)); err != nil {
+			t.Fatalf("Failed to delete node: %v", err)
+		}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: name}}
+		if _, err := reconciler.reconcileNode(ctx, req); err != nil {
+			t.Fatalf("reconcileNode(%s) error = %v", name, err)
+		}
+		// The finalizer is removed once the server is deleted, completing the node's deletion
+		if err := kubeClient.Get(ctx, client.ObjectKey{Name: name}, &corev1.Node{}); !apierrors.IsNotFound(err) {
+			t.Errorf("Expected node %s to be deleted, got error %v", name, err)
+		}
+	}
+
+	if mockHetzner.DeleteServerCalls != 1 {
+		t.Errorf("DeleteServerCalls = %d, want 1 for the deleted node of the pool", mockHetzner.DeleteServerCalls)
+	}
+	servers, _ := mockHetzner.ListServers(ctx, "test-pool", "default")
+	if len(servers) != 1 || servers[0].Name != "test-pool-3c4d" {
+		t.Errorf("Remaining servers = %+v, want only test-pool-3c4d", servers)
+	}
+
+	// Nodes the operator deletes itself don't wait on the finalizer
+	if err := reconciler.deleteNode(ctx, newNode("test-pool-3c4d")); err != nil {
+		t.Fatalf("deleteNode() error = %v", err)
+	}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-3c4d"}, &corev1.Node{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected node test-pool-3c4d to be deleted, got error %v", err)
+	}
+}
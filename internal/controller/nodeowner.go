@@ -0,0 +1,149 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+const (
+	// nodePoolLabel records the name of the pool a node belongs to, and
+	// nodePoolNamespaceAnnotation its namespace, so a node named like a server of another pool
+	// isn't mistaken for one of the pool's own
+	nodePoolLabel               = "autokube.io/nodepool"
+	nodePoolNamespaceAnnotation = "autokube.io/nodepool-namespace"
+
+	// conditionNodeNameConflict is true while nodes named after servers of the pool belong to
+	// another pool
+	conditionNodeNameConflict = "NodeNameConflict"
+)
+
+// errNodeNameConflict marks servers whose node belongs to another pool, which the operator
+// refuses to drain or delete since the node isn't the server's
+var errNodeNameConflict = stderrors.New("node belongs to another pool")
+
+// syncNodeOwners labels the nodes of the pool's servers with the pool. Nodes labeled with
+// another pool are left alone and returned, sorted. Servers whose node hasn't joined the
+// cluster yet are labeled on a later reconcile
+func (r *NodePoolReconciler) syncNodeOwners(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, serverNames []string) []string {
+	logger := log.FromContext(ctx)
+
+	var conflicts []string
+	for _, name := range serverNames {
+		node := &corev1.Node{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, node); err != nil {
+			if !errors.IsNotFound(err) {
+				logger.Error(err, "Failed to get node of server", "node", name)
+			}
+			continue
+		}
+		if ownedByOtherPool(nodePool, node) {
+			logger.Error(errNodeNameConflict, "Node has the name of a server of the pool but belongs to another pool, "+
+				"leaving it alone until one of them is renamed or deleted", "node", name,
+				"ownerNamespace", node.Annotations[nodePoolNamespaceAnnotation], "ownerNodePool", node.Labels[nodePoolLabel])
+			conflicts = append(conflicts, name)
+			continue
+		}
+
+		patch := client.MergeFrom(node.DeepCopy())
+		if !setNodeOwner(node, nodePool) {
+			continue
+		}
+		if err := r.Patch(ctx, node, patch); err != nil {
+			logger.Error(err, "Failed to label node with its pool", "node", name)
+		}
+	}
+
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+// setNodeOwner labels the node with the pool it belongs to and reports whether the node changed
+func setNodeOwner(node *corev1.Node, nodePool *hcloudv1alpha1.NodePool) bool {
+	if node.Labels[nodePoolLabel] == nodePool.Name && node.Annotations[nodePoolNamespaceAnnotation] == nodePool.Namespace {
+		return false
+	}
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Labels[nodePoolLabel] = nodePool.Name
+	node.Annotations[nodePoolNamespaceAnnotation] = nodePool.Namespace
+	return true
+}
+
+// ownedByOtherPool reports whether the node is labeled with another pool than the given one.
+// Nodes not labeled yet belong to the pool whose server they are named after
+func ownedByOtherPool(nodePool *hcloudv1alpha1.NodePool, node *corev1.Node) bool {
+	name, labeled := node.Labels[nodePoolLabel]
+	if !labeled {
+		return false
+	}
+	return name != nodePool.Name || node.Annotations[nodePoolNamespaceAnnotation] != nodePool.Namespace
+}
+
+// checkNodeOwner returns an errNodeNameConflict error when the node named after a server of
+// the pool belongs to another pool, so deleting the server doesn't drain or delete the other
+// pool's node. A server without a node passes
+func (r *NodePoolReconciler) checkNodeOwner(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, nodeName string) error {
+	node := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	if !ownedByOtherPool(nodePool, node) {
+		return nil
+	}
+	owner := node.Annotations[nodePoolNamespaceAnnotation] + "/" + node.Labels[nodePoolLabel]
+	return fmt.Errorf("refusing to delete server %s: %w %s", nodeName, errNodeNameConflict, owner)
+}
+
+// setNodeNameConflictCondition records whether nodes named after servers of the pool belong
+// to another pool
+func setNodeNameConflictCondition(nodePool *hcloudv1alpha1.NodePool, conflicts []string) {
+	condition := metav1.Condition{
+		Type:               conditionNodeNameConflict,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoConflict",
+		Message:            "All nodes of the pool's servers belong to the pool",
+		ObservedGeneration: nodePool.Generation,
+	}
+	if len(conflicts) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "NodeOfAnotherPool"
+		condition.Message = fmt.Sprintf("Nodes %s belong to another pool, their servers aren't deleted until "+
+			"the conflict is resolved", strings.Join(conflicts, ", "))
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, condition)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_NodeNameConflict(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	// A node of the pool and a node of another pool named like a server of this one
+	own := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "test-pool-1a2b"}}
+	foreign := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pool-3c4d",
+			Labels:      map[string]string{nodePoolLabel: "test-pool"},
+			Annotations: map[string]string{nodePoolNamespaceAnnotation: "staging"},
+		},
+	}
+	kubeClient := clientfake.NewClientBuilder().WithScheme(reconciler.Scheme).WithObjects(own, foreign).Build()
+	reconciler.Client = kubeClient
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+		Spec:       hcloudv1alpha1.NodePoolSpec{Provider: hcloudv1alpha1.CloudProviderHetzner},
+	}
+
+	conflicts := reconciler.syncNodeOwners(ctx, nodePool, []string{"test-pool-1a2b", "test-pool-3c4d", "test-pool-5e6f"})
+	if len(conflicts) != 1 || conflicts[0] != "test-pool-3c4d" {
+		t.Fatalf("syncNodeOwners() = %v, want the node of the other pool", conflicts)
+	}
+	synced := &corev1.Node{}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-1a2b"}, synced); err != nil {
+		t.Fatalf("Failed to get node: %v", err)
+	}
+	if synced.Labels[nodePoolLabel] != "test-pool" || synced.Annotations[nodePoolNamespaceAnnotation] != "default" {
+		t.Errorf("Expected the node of the pool to be labeled with it, got %+v", synced.ObjectMeta)
+	}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-3c4d"}, synced); err != nil {
+		t.Fatalf("Failed to get node: %v", err)
+	}
+	if synced.Annotations[nodePoolNamespaceAnnotation] != "staging" {
+		t.Errorf("Expected the node of the other pool to be left alone, got %+v", synced.ObjectMeta)
+	}
+
+	setNodeNameConflictCondition(nodePool, conflicts)
+	if !meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionNodeNameConflict) {
+		t.Errorf("Expected condition %s to be true, got %v", conditionNodeNameConflict, nodePool.Status.Conditions)
+	}
+
+	// Neither the node nor the server is deleted while the name is ambiguous
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	err := reconciler.deleteServer(ctx, nodePool, hetzner.Server{ID: 42, Name: "test-pool-3c4d"})
+	if !errors.Is(err, errNodeNameConflict) {
+		t.Fatalf("deleteServer() error = %v, want %v", err, errNodeNameConflict)
+	}
+	if mockHetzner.DeleteServerCalls != 0 {
+		t.Errorf("DeleteServerCalls = %d, want 0", mockHetzner.DeleteServerCalls)
+	}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-3c4d"}, &corev1.Node{}); err != nil {
+		t.Errorf("Expected the node of the other pool to be kept, got error %v", err)
+	}
+
+	setNodeNameConflictCondition(nodePool, nil)
+	if meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionNodeNameConflict) {
+		t.Error("Expected the condition to be cleared once the conflict is resolved")
+	}
+}
@@ -19,19 +19,34 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
 	"fmt"
-	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
 	"github.com/autokubeio/autokube/internal/bootstrap"
@@ -39,12 +54,78 @@ import (
 	"github.com/autokubeio/autokube/internal/metrics"
 	"github.com/autokubeio/autokube/internal/ovhcloud"
 	"github.com/autokubeio/autokube/internal/reliability"
+	"github.com/autokubeio/autokube/internal/scaleway"
+	"github.com/autokubeio/autokube/internal/security"
 )
 
 const (
 	reconcileInterval = 30 * time.Second
 	nodePoolFinalizer = "autokube.io/finalizer"
 	defaultTokenKey   = "token"
+
+	// serverReleaseRequeueDelay is how long deletion waits for deleted servers to leave
+	// the pool's placement group and firewalls
+	serverReleaseRequeueDelay = 5 * time.Second
+
+	// serverNameSuffixBytes is the number of random bytes in a server name suffix, rendered
+	// as 4 hex characters
+	serverNameSuffixBytes = 2
+	// maxServerNameAttempts bounds how often a suffix is regenerated on a name collision
+	maxServerNameAttempts = 10
+
+	// conditionReady is true once at least minNodes of the pool's nodes are ready
+	conditionReady = "Ready"
+
+	// conditionBelowMinimum is true while the pool has fewer nodes than minNodes
+	conditionBelowMinimum = "BelowMinimum"
+
+	// conditionServerTypeValid reports whether the server type or flavor is available
+	conditionServerTypeValid = "ServerTypeValid"
+
+	// conditionPublicNetworkAvailable reports whether OVHcloud instances on a private network
+	// could be given public network access
+	conditionPublicNetworkAvailable = "PublicNetworkAvailable"
+
+	// conditionBootstrapPending is true while nodes can't be bootstrapped because the cluster
+	// hasn't published its cluster-info yet
+	conditionBootstrapPending = "BootstrapPending"
+
+	// conditionTokenGenerationFailed is true while the kubeadm bootstrap token of new nodes
+	// can't be generated or read from its secret
+	conditionTokenGenerationFailed = "TokenGenerationFailed"
+
+	// conditionServersMatchSpec reports whether the pool's servers, including servers it
+	// adopted, run the server type, image and locations of its spec
+	conditionServersMatchSpec = "ServersMatchSpec"
+
+	// conditionQuotaExceeded is true while the provider refuses to create servers because the
+	// project reached one of its quotas
+	conditionQuotaExceeded = "QuotaExceeded"
+
+	// conditionFirewallRulesValid reports whether the sources of the pool's firewall rules
+	// are valid CIDRs
+	conditionFirewallRulesValid = "FirewallRulesValid"
+
+	// operationDrainFailed is the dead letter queue operation type of the nodes whose
+	// deletion was aborted because they couldn't be drained
+	operationDrainFailed = "DrainFailed"
+
+	// defaultMaxCreatesPerReconcile bounds the servers created for a pool in one reconcile
+	defaultMaxCreatesPerReconcile = 10
+
+	// bootstrapPendingRequeueInterval is how soon a pool waiting on cluster-info is retried
+	bootstrapPendingRequeueInterval = 10 * time.Second
+)
+
+// errInvalidBootstrapConfig marks bootstrap failures that only a spec change resolves
+var errInvalidBootstrapConfig = stderrors.New("invalid bootstrap configuration")
+
+// Phases reported in the pool status besides the failure reasons set by updateStatus
+const (
+	// phaseScaling means fewer than minNodes of the pool's nodes are ready
+	phaseScaling = "Scaling"
+	// phaseReady means at least minNodes of the pool's nodes are ready
+	phaseReady = "Ready"
 )
 
 // NodePoolReconciler reconciles a NodePool object
@@ -53,30 +134,97 @@ type NodePoolReconciler struct {
 	Scheme             *runtime.Scheme
 	HCloudClient       hetzner.ClientInterface
 	OVHCloudClient     ovhcloud.ClientInterface
+	ScalewayClient     scaleway.ClientInterface
 	MetricsClient      *metrics.Collector
 	KubeClient         kubernetes.Interface
 	BootstrapManager   *bootstrap.BootstrapTokenManager
 	CloudInitGenerator *bootstrap.CloudInitGenerator
 	DeadLetterQueue    *reliability.DeadLetterQueue
+	Recorder           record.EventRecorder
+
+	// APIReader reads NodePools from the API server bypassing the cache, to find specs that
+	// were superseded during a reconcile. Defaults to the client when unset
+	APIReader client.Reader
+
+	// SecretsManager reads the credentials secrets of pools with their own cloud credentials
+	SecretsManager *security.SecretsManager
+	// NewHetznerClient creates the Hetzner Cloud client of a pool with its own API token
+	NewHetznerClient HetznerClientFactory
+	// NewOVHCloudClient creates the OVHcloud client of a pool with its own API credentials
+	NewOVHCloudClient OVHCloudClientFactory
+
+	// MaxConcurrentReconciles is the maximum number of NodePools reconciled in parallel
+	// Defaults to 1 when unset
+	MaxConcurrentReconciles int
+
+	// MaxCreatesPerReconcile bounds the servers created for a pool in one reconcile, so a
+	// misconfigured size or a metrics glitch can't create a runaway number of servers at once.
+	// The remaining servers are created by the following reconciles.
+	// Defaults to defaultMaxCreatesPerReconcile when unset
+	MaxCreatesPerReconcile int
+
+	// RequeueJitter randomizes the interval successful reconciles requeue after by up to
+	// this fraction either way, so pools created together don't reconcile in lockstep.
+	// Requeues are not randomized when unset
+	RequeueJitter float64
+
+	// ServerDeletionTimeout bounds the cleanup of each server of a deleted pool
+	// Defaults to defaultServerDeletionTimeout when unset
+	ServerDeletionTimeout time.Duration
+
+	// provisioning tracks created nodes until they are running to measure provisioning latency
+	provisioning provisionTracker
+
+	// credentialClients caches the provider clients of pools with their own credentials
+	credentialClients credentialClients
+
+	// failures backs off the requeue interval of pools whose reconciles keep failing
+	failures failureBackoff
+
+	// annotationWarnings remembers the invalid annotations each pool was warned about
+	annotationWarnings annotationWarnings
 }
 
 // +kubebuilder:rbac:groups=autokube.io,resources=nodepools,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=autokube.io,resources=nodepools/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=autokube.io,resources=nodepools/finalizers,verbs=update
-// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch;delete
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=pods/eviction,verbs=create
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop
 //
 //nolint:funlen // Core reconciliation logic requires multiple orchestration steps
-func (r *NodePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *NodePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	logger := log.FromContext(ctx)
+	nodePool := &hcloudv1alpha1.NodePool{}
+
+	start := time.Now()
+	defer func() {
+		r.MetricsClient.RecordReconcile(req.Name, req.Namespace, nodePool.Status.Phase, time.Since(start), err)
+
+		// Retry failing pools less often the longer they keep failing. controller-runtime
+		// ignores the result of a failed reconcile and asks r.failures, its rate limiter, instead
+		if err == nil {
+			r.failures.succeeded(req.NamespacedName)
+			result.RequeueAfter = jitterInterval(result.RequeueAfter, r.RequeueJitter)
+			return
+		}
+		r.failures.failed(req.NamespacedName)
+	}()
+
+	// Fail a reconcile that panics instead of losing its context, runs before the backoff above
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = r.recoverPanic(ctx, req, recovered)
+			result = ctrl.Result{RequeueAfter: reconcileInterval}
+		}
+	}()
 
 	// Fetch the NodePool instance
-	nodePool := &hcloudv1alpha1.NodePool{}
 	if err := r.Get(ctx, req.NamespacedName, nodePool); err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("NodePool resource not found. Ignoring since object must be deleted")
@@ -86,6 +234,19 @@ func (r *NodePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
+	// Use the pool's own cloud credentials, if any
+	ctx, err = r.withPoolClients(ctx, nodePool)
+	if err != nil {
+		logger.Error(err, "Failed to load the NodePool's cloud credentials")
+		if nodePool.DeletionTimestamp.IsZero() {
+			r.updateStatus(ctx, nodePool, "Error", err.Error())
+		} else if forceDeleteRequested(nodePool) && containsString(nodePool.Finalizers, nodePoolFinalizer) {
+			r.recordLeakedResources(ctx, nodePool, unlistedNodes(nodePool, err))
+			return ctrl.Result{}, r.removeFinalizer(ctx, nodePool, 0)
+		}
+		return ctrl.Result{RequeueAfter: reconcileInterval}, err
+	}
+
 	// Handle deletion
 	if !nodePool.DeletionTimestamp.IsZero() {
 		return r.handleDeletion(ctx, nodePool)
@@ -97,53 +258,61 @@ func (r *NodePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		if err := r.Update(ctx, nodePool); err != nil {
 			return ctrl.Result{}, err
 		}
+		r.Recorder.Eventf(nodePool, corev1.EventTypeNormal, "FinalizerAdded",
+			"Added finalizer %s to clean up the pool's cloud resources on deletion", nodePoolFinalizer)
 	}
 
 	// Get current state from cloud provider
-	var currentNodes int
-	var serverNames []string
-	var readyNodes int
-
-	switch nodePool.Spec.Provider {
-	case hcloudv1alpha1.CloudProviderHetzner:
-		servers, err := r.HCloudClient.ListServers(ctx, nodePool.Name, nodePool.Namespace)
-		if err != nil {
-			logger.Error(err, "Failed to list servers from Hetzner Cloud")
-			r.updateStatus(ctx, nodePool, "Error", err.Error())
-			return ctrl.Result{RequeueAfter: reconcileInterval}, err
-		}
-		currentNodes = len(servers)
-		readyNodes = r.countReadyNodes(servers)
-		serverNames = r.getServerNames(servers)
-
-	case hcloudv1alpha1.CloudProviderOVHcloud:
-		if r.OVHCloudClient == nil {
-			err := fmt.Errorf("OVHcloud client not initialized")
-			logger.Error(err, "OVHcloud provider selected but client is nil")
-			r.updateStatus(ctx, nodePool, "Error", err.Error())
-			return ctrl.Result{RequeueAfter: reconcileInterval}, err
-		}
-		instances, err := r.OVHCloudClient.ListInstances(ctx, nodePool.Name, nodePool.Namespace)
-		if err != nil {
-			logger.Error(err, "Failed to list instances from OVHcloud")
-			r.updateStatus(ctx, nodePool, "Error", err.Error())
-			return ctrl.Result{RequeueAfter: reconcileInterval}, err
-		}
-		currentNodes = len(instances)
-		readyNodes = r.countReadyOVHInstances(instances)
-		serverNames = r.getOVHInstanceNames(instances)
-
-	default:
-		err := fmt.Errorf("unsupported provider: %s", nodePool.Spec.Provider)
+	provider, err := r.cloudProvider(nodePool)
+	if err != nil {
 		logger.Error(err, "Invalid cloud provider")
 		r.updateStatus(ctx, nodePool, "Error", err.Error())
 		return ctrl.Result{RequeueAfter: reconcileInterval}, err
 	}
+	listed, err := provider.List(ctx, nodePool)
+	if err != nil {
+		logger.Error(err, "Failed to list servers from cloud provider", "provider", nodePool.Spec.Provider)
+		r.updateStatus(ctx, nodePool, "Error", err.Error())
+		return ctrl.Result{RequeueAfter: reconcileInterval}, err
+	}
+	serverNames := provider.Names(listed)
+	currentNodes := len(serverNames)
+	readyNodes := provider.CountReady(listed)
+	instanceIDs := provider.InstanceIDs(listed)
+	running := provider.Running(listed)
+	r.provisioning.observe(nodePool, running, r.MetricsClient, time.Now())
 
 	// Update status
+	r.adoptUnrecordedServers(ctx, nodePool, serverNames)
 	nodePool.Status.CurrentNodes = currentNodes
 	nodePool.Status.ReadyNodes = readyNodes
 	nodePool.Status.Nodes = serverNames
+	nodePool.Status.UpdatedNodes = recordNodeTemplates(nodePool, serverNames)
+	setServersMatchSpecCondition(nodePool, listed)
+	setNodeNameConflictCondition(nodePool, r.syncNodeOwners(ctx, nodePool, serverNames))
+	r.syncNodes(ctx, nodePool, instanceIDs)
+
+	// Don't scale for a spec that was superseded while the servers were listed, the watch
+	// reconciles the new generation next
+	if latest := r.supersedingNodePool(ctx, nodePool); latest != nil {
+		logger.Info("NodePool spec changed during reconcile, skipping scaling",
+			"generation", nodePool.Generation, "latestGeneration", latest.Generation)
+		setReadyStatus(nodePool)
+		if err := r.updateSupersededStatus(ctx, nodePool, latest); err != nil {
+			logger.Error(err, "Failed to update NodePool status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: reconcileInterval}, nil
+	}
+
+	// Delete nodes that stayed NotReady for too long, scaling up replaces them
+	replaced, err := r.replaceUnhealthyNodes(ctx, nodePool, listed, time.Now())
+	currentNodes -= replaced
+	if err != nil {
+		logger.Error(err, "Failed to replace unhealthy nodes")
+		r.updateStatus(ctx, nodePool, "UnhealthyNodeReplacementFailed", err.Error())
+		return ctrl.Result{RequeueAfter: reconcileInterval}, err
+	}
 
 	// Determine desired number of nodes
 	desiredNodes := nodePool.Spec.MinNodes // Default to min nodes
@@ -164,13 +333,82 @@ func (r *NodePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		desiredNodes = nodePool.Spec.MaxNodes
 	}
 
+	// Don't provision nodes that the provider is known to reject
+	if !r.validateInstanceType(ctx, nodePool) {
+		condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionServerTypeValid)
+		r.updateStatus(ctx, nodePool, "InvalidServerType", condition.Message)
+		return ctrl.Result{RequeueAfter: reconcileInterval}, nil
+	}
+	if !validateFirewallRules(nodePool) {
+		condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionFirewallRulesValid)
+		r.updateStatus(ctx, nodePool, "InvalidFirewallRules", condition.Message)
+		return ctrl.Result{RequeueAfter: reconcileInterval}, nil
+	}
+
+	// minNodes is a hard floor that is restored before any autoscaling
+	createBudget := r.maxCreatesPerReconcile()
+	suspended := createsSuspended(nodePool, time.Now())
+	if suspended {
+		logger.Info("Server creations are suspended after consecutive failures")
+		createBudget = 0
+	}
+	created, err := r.ensureMinNodes(ctx, nodePool, listed, currentNodes, createBudget)
+	createBudget -= created
+	if created > 0 {
+		currentNodes += created
+		now := metav1.Now()
+		nodePool.Status.LastScaleTime = &now
+		r.MetricsClient.RecordScaleUp(nodePool.Name, nodePool.Namespace, created)
+	}
+	setBelowMinimumCondition(nodePool, currentNodes, err)
+	if result, ok := r.handleBootstrapFailure(ctx, nodePool, err); ok {
+		return result, nil
+	}
+	if result, ok := r.handleQuotaExceeded(ctx, nodePool, err); ok {
+		return result, nil
+	}
+	if err != nil {
+		logger.Error(err, "Failed to restore minimum node count", "current", currentNodes, "min", nodePool.Spec.MinNodes)
+		r.updateStatus(ctx, nodePool, "BelowMinimum", err.Error())
+		// Returning the error retries with the controller's exponential backoff
+		return ctrl.Result{}, err
+	}
+
+	// Replace nodes whose server type or image is outdated, within the rolling update bounds.
+	// Outdated nodes are kept while their replacements can't be created
+	var added, removed int
+	if !suspended {
+		added, removed, err = r.rollNodes(ctx, nodePool, listed, desiredNodes, currentNodes, running, createBudget)
+	}
+	createBudget -= added
+	if added > 0 || removed > 0 {
+		currentNodes += added - removed
+		now := metav1.Now()
+		nodePool.Status.LastScaleTime = &now
+	}
+	if err != nil {
+		logger.Error(err, "Failed to replace outdated nodes")
+		r.updateStatus(ctx, nodePool, "RollingUpdateFailed", err.Error())
+		return ctrl.Result{RequeueAfter: reconcileInterval}, err
+	}
+
 	// Scale up if needed
-	if currentNodes < desiredNodes {
+	if currentNodes < desiredNodes && createBudget > 0 {
 		nodesToAdd := desiredNodes - currentNodes
+		if nodesToAdd > createBudget {
+			logger.Info("Limiting servers created in this reconcile", "missing", nodesToAdd, "limit", createBudget)
+			nodesToAdd = createBudget
+		}
 		logger.Info("Scaling up", "current", currentNodes, "desired", desiredNodes, "adding", nodesToAdd)
 
 		for i := 0; i < nodesToAdd; i++ {
-			if err := r.createServer(ctx, nodePool); err != nil {
+			if err := r.createServer(ctx, nodePool, listed); err != nil {
+				if result, ok := r.handleBootstrapFailure(ctx, nodePool, err); ok {
+					return result, nil
+				}
+				if result, ok := r.handleQuotaExceeded(ctx, nodePool, err); ok {
+					return result, nil
+				}
 				logger.Error(err, "Failed to create server")
 				r.updateStatus(ctx, nodePool, "ScaleUpFailed", err.Error())
 				return ctrl.Result{RequeueAfter: reconcileInterval}, err
@@ -182,25 +420,39 @@ func (r *NodePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		r.MetricsClient.RecordScaleUp(nodePool.Name, nodePool.Namespace, nodesToAdd)
 	}
 
-	// Scale down if needed
-	if currentNodes > desiredNodes {
-		nodesToRemove := currentNodes - desiredNodes
+	// Scale down if needed, keeping the replacement nodes of a rolling update
+	if surge := rollingUpdateSurge(nodePool); currentNodes > desiredNodes+surge {
+		nodesToRemove := currentNodes - desiredNodes - surge
 		logger.Info("Scaling down", "current", currentNodes, "desired", desiredNodes, "removing", nodesToRemove)
 
-		// Scale down logic is provider-specific
-		if err := r.scaleDown(ctx, nodePool, nodesToRemove); err != nil {
+		removed, err := r.scaleDown(ctx, nodePool, listed, nodesToRemove)
+		if err != nil {
 			logger.Error(err, "Failed to scale down")
 			r.updateStatus(ctx, nodePool, "ScaleDownFailed", err.Error())
 			return ctrl.Result{RequeueAfter: reconcileInterval}, err
 		}
+		if removed < nodesToRemove {
+			logger.Info("Keeping nodes younger than minNodeLifetime", "kept", nodesToRemove-removed)
+		}
 
-		now := metav1.Now()
-		nodePool.Status.LastScaleTime = &now
-		r.MetricsClient.RecordScaleDown(nodePool.Name, nodePool.Namespace, nodesToRemove)
+		if removed > 0 {
+			now := metav1.Now()
+			nodePool.Status.LastScaleTime = &now
+			r.MetricsClient.RecordScaleDown(nodePool.Name, nodePool.Namespace, removed)
+		}
 	}
 
 	// Update status
-	nodePool.Status.Phase = "Ready"
+	if meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionBootstrapPending) {
+		setBootstrapPendingCondition(nodePool, metav1.ConditionFalse, "ClusterInfoAvailable", "")
+	}
+	if meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionQuotaExceeded) {
+		setQuotaExceededCondition(nodePool, metav1.ConditionFalse, "WithinQuota", "")
+	}
+	setReadyStatus(nodePool)
+	nodePool.Status.LastError = ""
+	nodePool.Status.FailureCount = 0
+	nodePool.Status.ObservedGeneration = nodePool.Generation
 	if err := r.Status().Update(ctx, nodePool); err != nil {
 		logger.Error(err, "Failed to update NodePool status")
 		return ctrl.Result{}, err
@@ -210,13 +462,274 @@ func (r *NodePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	r.MetricsClient.RecordNodePoolSize(
 		nodePool.Name,
 		nodePool.Namespace,
+		desiredNodes,
 		nodePool.Status.CurrentNodes,
 		nodePool.Status.ReadyNodes,
 	)
+	r.MetricsClient.RecordNodePoolPriority(nodePool.Name, nodePool.Namespace, nodePool.Spec.Priority)
 
 	return ctrl.Result{RequeueAfter: reconcileInterval}, nil
 }
 
+// ensureMinNodes creates the nodes missing to reach the pool's minNodes, at most limit of them
+// Unlike autoscaling it keeps going after a failed creation so as much of the floor as
+// possible is restored, unless the provider's quota is exceeded. It returns the number of
+// nodes created and the last error.
+func (r *NodePoolReconciler) ensureMinNodes(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	listed *poolServers,
+	currentNodes int,
+	limit int,
+) (int, error) {
+	logger := log.FromContext(ctx)
+
+	missing := nodePool.Spec.MinNodes - currentNodes
+	if missing <= 0 {
+		return 0, nil
+	}
+	if missing > limit {
+		logger.Info("Limiting servers created in this reconcile", "missing", missing, "limit", limit)
+		missing = limit
+	}
+
+	logger.Info("Below minimum node count", "current", currentNodes, "min", nodePool.Spec.MinNodes, "adding", missing)
+
+	created := 0
+	var lastErr error
+	for i := 0; i < missing; i++ {
+		if err := r.createServer(ctx, nodePool, listed); err != nil {
+			logger.Error(err, "Failed to create server below minimum node count")
+			lastErr = err
+			// The remaining creations would be refused for the same quota, or fail like the
+			// ones that suspended creations
+			if isQuotaExceeded(err) || meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionCreateBackoff) {
+				break
+			}
+			continue
+		}
+		created++
+	}
+
+	if lastErr != nil {
+		return created, fmt.Errorf("created %d of %d nodes required by minNodes: %w", created, missing, lastErr)
+	}
+	return created, nil
+}
+
+// validateInstanceType checks the pool's server type or flavor against the provider catalog
+// and records the result in the ServerTypeValid condition. The check only runs again when
+// the spec generation changes or the previous check could not reach the provider.
+// It returns false when the type is known to be unavailable.
+func (r *NodePoolReconciler) validateInstanceType(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) bool {
+	logger := log.FromContext(ctx)
+
+	existing := meta.FindStatusCondition(nodePool.Status.Conditions, conditionServerTypeValid)
+	if existing != nil && existing.ObservedGeneration == nodePool.Generation && existing.Status != metav1.ConditionUnknown {
+		return existing.Status == metav1.ConditionTrue
+	}
+
+	var instanceType string
+	var err, errUnavailable error
+	switch nodePool.Spec.Provider {
+	case hcloudv1alpha1.CloudProviderHetzner:
+		config := nodePool.Spec.HetznerConfig
+		if config == nil {
+			return true
+		}
+		instanceType = config.ServerType
+		errUnavailable = hetzner.ErrServerTypeUnavailable
+		for _, location := range config.ServerLocations() {
+			if err = r.hetznerClient(ctx).ValidateServerType(ctx, config.ServerType, location); err != nil {
+				break
+			}
+		}
+
+	case hcloudv1alpha1.CloudProviderOVHcloud:
+		config := nodePool.Spec.OVHcloudConfig
+		if config == nil || r.ovhcloudClient(ctx) == nil {
+			return true
+		}
+		instanceType = config.Flavor
+		if instanceType == "" {
+			instanceType = config.FlavorID
+		}
+		errUnavailable = ovhcloud.ErrFlavorUnavailable
+		err = r.ovhcloudClient(ctx).ValidateFlavor(ctx, config.Region, instanceType)
+
+	default:
+		return true
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionServerTypeValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Available",
+		Message:            fmt.Sprintf("%s is available", instanceType),
+		ObservedGeneration: nodePool.Generation,
+	}
+	switch {
+	case err == nil:
+	case stderrors.Is(err, errUnavailable):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Unavailable"
+		condition.Message = err.Error()
+		r.Recorder.Event(nodePool, corev1.EventTypeWarning, "InvalidServerType", err.Error())
+	default:
+		// Provisioning proceeds and the check is retried on the next reconcile
+		logger.Error(err, "Failed to validate server type", "type", instanceType)
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "ValidationFailed"
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, condition)
+
+	return condition.Status != metav1.ConditionFalse
+}
+
+// scaleUpIncrement returns the number of nodes to add for the pending pods: one per
+// PodsPerNode pending pods, at least one and at most ScaleUpStep
+func scaleUpIncrement(nodePool *hcloudv1alpha1.NodePool, pendingPods int) int {
+	step := nodePool.Spec.ScaleUpStep
+	if step < 1 {
+		step = 1
+	}
+	podsPerNode := nodePool.Spec.PodsPerNode
+	if podsPerNode < 1 {
+		podsPerNode = 10
+	}
+
+	increment := (pendingPods + podsPerNode - 1) / podsPerNode
+	if increment < 1 {
+		increment = 1
+	}
+	if increment > step {
+		increment = step
+	}
+	return increment
+}
+
+// setBelowMinimumCondition records whether the pool is below its minNodes floor
+func setBelowMinimumCondition(nodePool *hcloudv1alpha1.NodePool, currentNodes int, err error) {
+	condition := metav1.Condition{
+		Type:               conditionBelowMinimum,
+		Status:             metav1.ConditionFalse,
+		Reason:             "MinimumSatisfied",
+		Message:            fmt.Sprintf("%d of %d minimum nodes present", currentNodes, nodePool.Spec.MinNodes),
+		ObservedGeneration: nodePool.Generation,
+	}
+	if currentNodes < nodePool.Spec.MinNodes {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ScaleUpFailed"
+		if err != nil {
+			condition.Message = fmt.Sprintf("%s: %v", condition.Message, err)
+		}
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, condition)
+}
+
+// handleBootstrapFailure reports failed server creations caused by bootstrapping in the
+// status. Nodes waiting on cluster-info are retried soon without backing off, invalid
+// bootstrap configurations at the regular interval since retrying won't fix them.
+// It returns false when err isn't a bootstrap failure.
+func (r *NodePoolReconciler) handleBootstrapFailure(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	err error,
+) (ctrl.Result, bool) {
+	logger := log.FromContext(ctx)
+
+	switch {
+	case err == nil:
+		return ctrl.Result{}, false
+	case stderrors.Is(err, bootstrap.ErrClusterInfoNotReady):
+		logger.Info("Waiting for cluster-info to bootstrap nodes", "reason", err.Error())
+		setBootstrapPendingCondition(nodePool, metav1.ConditionTrue, "ClusterInfoNotReady", err.Error())
+		r.updateStatus(ctx, nodePool, "BootstrapPending", err.Error())
+		return ctrl.Result{RequeueAfter: bootstrapPendingRequeueInterval}, true
+	case stderrors.Is(err, errInvalidBootstrapConfig):
+		logger.Error(err, "Invalid bootstrap configuration")
+		setBootstrapPendingCondition(nodePool, metav1.ConditionFalse, "InvalidConfiguration", err.Error())
+		r.updateStatus(ctx, nodePool, "InvalidBootstrapConfig", err.Error())
+		return ctrl.Result{RequeueAfter: reconcileInterval}, true
+	}
+	return ctrl.Result{}, false
+}
+
+// setBootstrapPendingCondition records whether nodes are waiting on cluster-info
+func setBootstrapPendingCondition(nodePool *hcloudv1alpha1.NodePool, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&nodePool.Status.Conditions, metav1.Condition{
+		Type:               conditionBootstrapPending,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: nodePool.Generation,
+	})
+}
+
+// kubeadmJoinConfig reads the kubeadm JoinConfiguration of a pool's nodes from its secret
+func (r *NodePoolReconciler) kubeadmJoinConfig(
+	ctx context.Context,
+	namespace string,
+	ref *hcloudv1alpha1.SecretReference,
+) (string, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, &secret); err != nil {
+		return "", fmt.Errorf("failed to get join config secret: %w", err)
+	}
+	configKey := ref.Key
+	if configKey == "" {
+		configKey = "config"
+	}
+	joinConfig := string(secret.Data[configKey])
+	if strings.TrimSpace(joinConfig) == "" {
+		return "", fmt.Errorf("%w: join config secret %s has no %s key", errInvalidBootstrapConfig, ref.Name, configKey)
+	}
+	return joinConfig, nil
+}
+
+// tokenGenerationFailed records a failure to acquire the bootstrap token of a kubeadm pool's
+// nodes in its metric and condition, and returns err
+func (r *NodePoolReconciler) tokenGenerationFailed(nodePool *hcloudv1alpha1.NodePool, err error) error {
+	r.MetricsClient.RecordBootstrapTokenError(nodePool.Name, nodePool.Namespace)
+	setTokenGenerationFailedCondition(nodePool, metav1.ConditionTrue, "TokenUnavailable", err.Error())
+	return err
+}
+
+// setTokenGenerationFailedCondition records whether the pool's bootstrap token can be acquired
+func setTokenGenerationFailedCondition(
+	nodePool *hcloudv1alpha1.NodePool,
+	status metav1.ConditionStatus,
+	reason, message string,
+) {
+	meta.SetStatusCondition(&nodePool.Status.Conditions, metav1.Condition{
+		Type:               conditionTokenGenerationFailed,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: nodePool.Generation,
+	})
+}
+
+// setReadyStatus sets the pool phase and Ready condition from its ready nodes
+// The pool is only Ready once at least minNodes of its nodes are ready, and Scaling until then
+func setReadyStatus(nodePool *hcloudv1alpha1.NodePool) {
+	condition := metav1.Condition{
+		Type:               conditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "MinimumNodesReady",
+		Message:            fmt.Sprintf("%d of %d minimum nodes ready", nodePool.Status.ReadyNodes, nodePool.Spec.MinNodes),
+		ObservedGeneration: nodePool.Generation,
+	}
+	nodePool.Status.Phase = phaseReady
+	if nodePool.Status.ReadyNodes < nodePool.Spec.MinNodes {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "WaitingForNodes"
+		nodePool.Status.Phase = phaseScaling
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, condition)
+}
+
 func (r *NodePoolReconciler) calculateDesiredNodes(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) int {
 	logger := log.FromContext(ctx)
 
@@ -236,9 +749,26 @@ func (r *NodePoolReconciler) calculateDesiredNodes(ctx context.Context, nodePool
 
 	currentNodes := nodePool.Status.CurrentNodes
 
+	// Pods can't be scheduled on a pool without nodes, so it is woken up by any pending pod
+	// that fits it regardless of the scale-up threshold
+	if currentNodes == 0 {
+		if waking := wakingPods(nodePool, podList.Items); waking > 0 {
+			desired := scaleUpIncrement(nodePool, waking)
+			if desired > nodePool.Spec.MaxNodes {
+				desired = nodePool.Spec.MaxNodes
+			}
+			logger.Info("Scaling up pool from zero nodes for pending pods", "pendingPods", waking, "desired", desired)
+			return desired
+		}
+	}
+
 	// Scale up if too many pending pods
 	if pendingPods >= nodePool.Spec.ScaleUpThreshold {
-		return currentNodes + 1
+		desired := currentNodes + scaleUpIncrement(nodePool, pendingPods)
+		if desired > nodePool.Spec.MaxNodes {
+			desired = nodePool.Spec.MaxNodes
+		}
+		return desired
 	}
 
 	// Scale down if utilization is low (simplified logic)
@@ -249,33 +779,101 @@ func (r *NodePoolReconciler) calculateDesiredNodes(ctx context.Context, nodePool
 	return currentNodes
 }
 
-func (r *NodePoolReconciler) createServer(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) error {
+// createServer creates a server for the pool. listed holds the pool's servers, Hetzner
+// servers are spread across the pool's locations based on it
+func (r *NodePoolReconciler) createServer(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers) (err error) {
 	logger := log.FromContext(ctx)
 
-	// Generate a shorter, more readable name with random suffix
-	suffix := fmt.Sprintf("%x", time.Now().UnixNano()%0xFFFF) // 4-char hex suffix
-	serverName := fmt.Sprintf("%s-%s", nodePool.Name, suffix)
+	requested := time.Now()
+	defer func() {
+		if err != nil {
+			r.MetricsClient.RecordProvisionFailure(string(nodePool.Spec.Provider), nodePool.Name)
+		}
+		r.recordCreateResult(ctx, nodePool, err)
+	}()
+
+	serverName, err := newServerName(nodePool)
+	if err != nil {
+		return err
+	}
 
 	labels := map[string]string{
 		"nodepool":   nodePool.Name,
 		"namespace":  nodePool.Namespace,
 		"managed-by": "nodepools",
 	}
+	for k, v := range r.providerAnnotations(nodePool) {
+		if _, reserved := labels[k]; !reserved {
+			labels[k] = v
+		}
+	}
 	for k, v := range nodePool.Spec.Labels {
 		labels[k] = v
 	}
 
+	// Boot from the cached bootstrap snapshot when one is available
+	var snapshotID int64
+	if snapshotCacheEnabled(nodePool) {
+		var err error
+		snapshotID, err = r.ensureBootstrapSnapshot(ctx, nodePool)
+		if err != nil {
+			// The snapshot cache is an optimization, fall back to the base image
+			logger.Error(err, "Failed to ensure bootstrap snapshot, booting from base image")
+		}
+	}
+
+	var location string
+	if nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderHetzner && nodePool.Spec.HetznerConfig != nil {
+		location = hetznerServerLocation(nodePool.Spec.HetznerConfig, listed)
+	}
+
+	// Create the server's volumes first so their devices can be mounted by cloud-init
+	var volumes []hetzner.Volume
+	var volumeMounts []bootstrap.VolumeMount
+	if nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderHetzner &&
+		nodePool.Spec.HetznerConfig != nil && len(nodePool.Spec.HetznerConfig.Volumes) > 0 {
+		volumes, err = r.createHetznerVolumes(ctx, nodePool, serverName, location, labels)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				r.deleteHetznerVolumes(ctx, volumes)
+			}
+		}()
+		for i, volume := range volumes {
+			spec := nodePool.Spec.HetznerConfig.Volumes[i]
+			volumeMounts = append(volumeMounts, bootstrap.VolumeMount{
+				Device:    volume.LinuxDevice,
+				MountPath: spec.MountPath,
+				Format:    volumeFormat(spec),
+			})
+		}
+	}
+
 	// Generate cloud-init user data if bootstrap config is provided
-	userData := nodePool.Spec.CloudInit
+	userData, err := renderCloudInit(nodePool, serverName)
+	if err != nil {
+		return err
+	}
 	if nodePool.Spec.Bootstrap != nil && userData == "" {
-		var err error
-		userData, err = r.generateCloudInit(ctx, nodePool)
+		userData, err = r.generateCloudInit(ctx, nodePool, snapshotID != 0, volumeMounts)
 		if err != nil {
 			return fmt.Errorf("failed to generate cloud-init: %w", err)
 		}
 		logger.Info("Generated cloud-init for server", "server", serverName, "cloudInitLength", len(userData))
 	}
 
+	// Hetzner rejects user data over its size limit, compress it to fit
+	if nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderHetzner && userData != "" {
+		var compressed bool
+		userData, compressed, err = bootstrap.CompressUserData(userData, bootstrap.HetznerUserDataLimit)
+		if err != nil {
+			return fmt.Errorf("failed to prepare user data: %w", err)
+		}
+		logger.Info("Prepared user data for server", "server", serverName, "size", len(userData), "compressed", compressed)
+	}
+
 	// Get or create firewall if firewall rules are specified
 	var firewallIDs []int64
 	if len(nodePool.Spec.FirewallRules) > 0 && nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderHetzner {
@@ -287,18 +885,153 @@ func (r *NodePoolReconciler) createServer(ctx context.Context, nodePool *hcloudv
 		logger.Info("Using firewall for server", "server", serverName, "firewallID", firewallID)
 	}
 
-	// Provider-specific server creation
-	switch nodePool.Spec.Provider {
-	case hcloudv1alpha1.CloudProviderHetzner:
-		return r.createHetznerServer(ctx, nodePool, serverName, labels, userData, firewallIDs)
-	case hcloudv1alpha1.CloudProviderOVHcloud:
-		return r.createOVHcloudInstance(ctx, nodePool, serverName, labels, userData)
-	default:
-		return fmt.Errorf("unsupported provider: %s", nodePool.Spec.Provider)
+	provider, err := r.cloudProvider(nodePool)
+	if err != nil {
+		return err
+	}
+	var volumeIDs []int64
+	for _, volume := range volumes {
+		volumeIDs = append(volumeIDs, volume.ID)
+	}
+	if err := provider.Create(ctx, nodePool, listed, newServer{
+		Name:        serverName,
+		Location:    location,
+		Labels:      labels,
+		UserData:    userData,
+		FirewallIDs: firewallIDs,
+		SnapshotID:  snapshotID,
+		VolumeIDs:   volumeIDs,
+	}); err != nil {
+		return err
+	}
+
+	r.provisioning.start(nodePool, serverName, requested)
+	// Record the server right away, so further servers created in this reconcile don't reuse
+	// its name and it is recovered should the next listing miss it
+	nodePool.Status.Nodes = append(nodePool.Status.Nodes, serverName)
+	recordNodeTemplate(nodePool, serverName)
+	return nil
+}
+
+// serverNameData is the data a pool's nodeNameTemplate is rendered with
+type serverNameData struct {
+	Pool      string
+	Namespace string
+	Random    string
+	Index     int
+}
+
+// newServerName returns a name for a new server of the pool with a short random suffix,
+// or rendered from the pool's nodeNameTemplate, regenerating the suffix if a server of the
+// pool from the last listing has that name
+func newServerName(nodePool *hcloudv1alpha1.NodePool) (string, error) {
+	existing := make(map[string]bool, len(nodePool.Status.Nodes))
+	for _, name := range nodePool.Status.Nodes {
+		existing[name] = true
+	}
+
+	attempts := maxServerNameAttempts
+	var nameTemplate *template.Template
+	if nodePool.Spec.NodeNameTemplate != "" {
+		if nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderOVHcloud {
+			return "", fmt.Errorf("nodeNameTemplate is not supported for OVHcloud, whose instances are matched to their pool by name")
+		}
+		var err error
+		nameTemplate, err = template.New("nodeNameTemplate").Parse(nodePool.Spec.NodeNameTemplate)
+		if err != nil {
+			return "", fmt.Errorf("invalid nodeNameTemplate: %w", err)
+		}
+		// The index counts up with the attempts, so every index up to the pool size is tried
+		attempts += len(existing)
+	}
+
+	suffix := make([]byte, serverNameSuffixBytes)
+	for attempt := 0; attempt < attempts; attempt++ {
+		if _, err := rand.Read(suffix); err != nil {
+			return "", fmt.Errorf("failed to generate server name suffix: %w", err)
+		}
+		name := fmt.Sprintf("%s-%s", nodePool.Name, hex.EncodeToString(suffix))
+		switch {
+		case nameTemplate != nil:
+			var err error
+			name, err = renderServerName(nameTemplate, serverNameData{
+				Pool:      nodePool.Name,
+				Namespace: nodePool.Namespace,
+				Random:    hex.EncodeToString(suffix),
+				Index:     attempt,
+			})
+			if err != nil {
+				return "", err
+			}
+		case nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderOVHcloud:
+			// OVHcloud instances are matched to their pool by name, see ovhcloud.InstanceNamePrefix
+			name = ovhcloud.InstanceNamePrefix(nodePool.Name, nodePool.Namespace) + hex.EncodeToString(suffix)
+		}
+		if !existing[name] {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate a unique server name for nodepool %s after %d attempts",
+		nodePool.Name, attempts)
+}
+
+// renderServerName renders a server name from a nodeNameTemplate and checks that it is a
+// valid hostname, which both the providers and Kubernetes node names require
+func renderServerName(nameTemplate *template.Template, data serverNameData) (string, error) {
+	var name strings.Builder
+	if err := nameTemplate.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("failed to render nodeNameTemplate: %w", err)
+	}
+	if errs := validation.IsDNS1123Label(name.String()); len(errs) > 0 {
+		return "", fmt.Errorf("nodeNameTemplate renders invalid name %q: %s", name.String(), strings.Join(errs, ", "))
 	}
+	return name.String(), nil
 }
 
-func (r *NodePoolReconciler) createHetznerServer(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, serverName string, labels map[string]string, userData string, firewallIDs []int64) error {
+// cloudInitData is the data a pool's raw cloudInit is rendered with
+type cloudInitData struct {
+	PoolName  string
+	Namespace string
+	NodeName  string
+	Provider  string
+}
+
+// renderCloudInit renders a pool's raw cloudInit as a Go template for the server named
+// nodeName. Cloud-init without template actions and cloud-init Jinja templates, which use
+// the same delimiters, are returned unchanged
+func renderCloudInit(nodePool *hcloudv1alpha1.NodePool, nodeName string) (string, error) {
+	cloudInit := nodePool.Spec.CloudInit
+	if !strings.Contains(cloudInit, "{{") || strings.HasPrefix(cloudInit, "## template: jinja") {
+		return cloudInit, nil
+	}
+	cloudInitTemplate, err := template.New("cloudInit").Option("missingkey=error").Parse(cloudInit)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid cloudInit template: %v", errInvalidBootstrapConfig, err)
+	}
+	var rendered strings.Builder
+	if err := cloudInitTemplate.Execute(&rendered, cloudInitData{
+		PoolName:  nodePool.Name,
+		Namespace: nodePool.Namespace,
+		NodeName:  nodeName,
+		Provider:  string(nodePool.Spec.Provider),
+	}); err != nil {
+		return "", fmt.Errorf("%w: failed to render cloudInit: %v", errInvalidBootstrapConfig, err)
+	}
+	return rendered.String(), nil
+}
+
+// createHetznerServer creates a server for the pool in the given location and adds it to listed
+func (r *NodePoolReconciler) createHetznerServer(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	listed *poolServers,
+	serverName, location string,
+	labels map[string]string,
+	userData string,
+	firewallIDs []int64,
+	imageID int64,
+	volumeIDs []int64,
+) error {
 	logger := log.FromContext(ctx)
 
 	// Get Hetzner configuration
@@ -306,26 +1039,238 @@ func (r *NodePoolReconciler) createHetznerServer(ctx context.Context, nodePool *
 		return fmt.Errorf("hetznerConfig is required when provider is hetzner")
 	}
 
-	server, err := r.HCloudClient.CreateServer(ctx, hetzner.ServerConfig{
-		Name:       serverName,
-		ServerType: nodePool.Spec.HetznerConfig.ServerType,
-		Image:      nodePool.Spec.HetznerConfig.Image,
-		Location:   nodePool.Spec.HetznerConfig.Location,
-		SSHKeys:    nodePool.Spec.SSHKeys,
-		Labels:     labels,
-		UserData:   userData,
-		Network:    nodePool.Spec.HetznerConfig.Network,
-		Firewalls:  firewallIDs,
+	config := nodePool.Spec.HetznerConfig
+	if !config.PublicIPv4Enabled() && !config.PublicIPv6Enabled() && config.Network == "" {
+		return fmt.Errorf("hetznerConfig.network is required when both enableIPv4 and enableIPv6 are false")
+	}
+
+	var placementGroupID int64
+	if config.PlacementGroup != "" {
+		placementGroup, err := r.hetznerClient(ctx).GetOrCreatePlacementGroup(ctx, config.PlacementGroup, poolResourceLabels(nodePool))
+		if err != nil {
+			return fmt.Errorf("failed to get or create placement group: %w", err)
+		}
+		placementGroupID = placementGroup.ID
+	}
+
+	opCtx, cancel := providerOperationContext(ctx, nodePool)
+	server, err := r.hetznerClient(ctx).CreateServer(opCtx, hetzner.ServerConfig{
+		Name:        serverName,
+		ServerType:  config.ServerType,
+		Image:       config.Image,
+		ImageID:     imageID,
+		Location:    location,
+		SSHKeys:     nodePool.Spec.SSHKeys,
+		Labels:      labels,
+		UserData:    userData,
+		Network:     config.Network,
+		Firewalls:   firewallIDs,
+		DisableIPv4: !config.PublicIPv4Enabled(),
+		DisableIPv6: !config.PublicIPv6Enabled(),
+		VolumeIDs:   volumeIDs,
+		PrimaryIPs:  config.PrimaryIPs,
+
+		PlacementGroupID: placementGroupID,
 	})
+	cancel()
 
 	if err != nil {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
 
-	logger.Info("Server created successfully", "server", server.Name, "id", server.ID)
+	// Register the server with the load balancer if specified
+	if lb := nodePool.Spec.HetznerConfig.LoadBalancer; lb != "" {
+		usePrivateIP := nodePool.Spec.HetznerConfig.Network != ""
+		opCtx, cancel := providerOperationContext(ctx, nodePool)
+		err := r.hetznerClient(ctx).AddServerToLoadBalancer(opCtx, lb, server.ID, usePrivateIP)
+		cancel()
+		if err != nil {
+			// Roll back so the pool doesn't keep a server that never receives traffic
+			if delErr := r.deleteServer(ctx, nodePool, *server); delErr != nil {
+				logger.Error(delErr, "Failed to delete server after load balancer registration failure", "server", server.Name)
+			}
+			return fmt.Errorf("failed to add server to load balancer: %w", err)
+		}
+		logger.Info("Server added to load balancer", "server", server.Name, "loadBalancer", lb)
+	}
+
+	// Boot the server from its ISO instead of the image it was created with
+	if config.BootMode == hcloudv1alpha1.BootModeISO {
+		opCtx, cancel := providerOperationContext(ctx, nodePool)
+		err := r.hetznerClient(ctx).AttachISO(opCtx, server.ID, config.ISOName)
+		cancel()
+		if err != nil {
+			// Roll back so the pool doesn't keep a server that never boots the ISO
+			if delErr := r.deleteServer(ctx, nodePool, *server); delErr != nil {
+				logger.Error(delErr, "Failed to delete server after ISO attachment failure", "server", server.Name)
+			}
+			return fmt.Errorf("failed to boot server from ISO %s: %w", config.ISOName, err)
+		}
+		logger.Info("Server booted from ISO", "server", server.Name, "iso", config.ISOName)
+	}
+
+	// Give the server one of the pool's floating IPs
+	if len(config.FloatingIPs) > 0 {
+		opCtx, cancel := providerOperationContext(ctx, nodePool)
+		ip, err := r.hetznerClient(ctx).AssignFloatingIP(opCtx, server.ID, config.FloatingIPs)
+		cancel()
+		switch {
+		case stderrors.Is(err, hetzner.ErrNoFloatingIPAvailable):
+			logger.Info("All floating IPs are assigned, server has no floating IP", "server", server.Name)
+		case err != nil:
+			// Roll back so the pool doesn't keep a server without its stable IP
+			if delErr := r.deleteServer(ctx, nodePool, *server); delErr != nil {
+				logger.Error(delErr, "Failed to delete server after floating IP assignment failure", "server", server.Name)
+			}
+			return fmt.Errorf("failed to assign floating IP: %w", err)
+		default:
+			logger.Info("Floating IP assigned to server", "server", server.Name, "ip", ip)
+		}
+	}
+
+	listed.hetzner = append(listed.hetzner, *server)
+	logger.Info("Server created successfully", "server", server.Name, "id", server.ID, "location", location)
 	return nil
 }
 
+// createHetznerVolumes creates the pool's volumes for a server, deleting the ones already
+// created if one fails
+func (r *NodePoolReconciler) createHetznerVolumes(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	serverName, location string,
+	labels map[string]string,
+) ([]hetzner.Volume, error) {
+	logger := log.FromContext(ctx)
+	config := nodePool.Spec.HetznerConfig
+
+	volumeLabels := map[string]string{"server": serverName}
+	for k, v := range labels {
+		volumeLabels[k] = v
+	}
+
+	volumes := make([]hetzner.Volume, 0, len(config.Volumes))
+	for i, spec := range config.Volumes {
+		opCtx, cancel := providerOperationContext(ctx, nodePool)
+		volume, err := r.hetznerClient(ctx).CreateVolume(opCtx, hetzner.VolumeConfig{
+			Name:     fmt.Sprintf("%s-%d", serverName, i),
+			Size:     spec.Size,
+			Location: location,
+			Format:   volumeFormat(spec),
+			Labels:   volumeLabels,
+		})
+		cancel()
+		if err != nil {
+			r.deleteHetznerVolumes(ctx, volumes)
+			return nil, fmt.Errorf("failed to create volume for %s: %w", spec.MountPath, err)
+		}
+		logger.Info("Volume created", "server", serverName, "volume", volume.Name, "id", volume.ID)
+		volumes = append(volumes, *volume)
+	}
+	return volumes, nil
+}
+
+// deleteHetznerVolumes deletes volumes of a server that failed to be created
+func (r *NodePoolReconciler) deleteHetznerVolumes(ctx context.Context, volumes []hetzner.Volume) {
+	logger := log.FromContext(ctx)
+	for _, volume := range volumes {
+		if err := r.hetznerClient(ctx).DeleteVolume(ctx, volume.ID); err != nil {
+			logger.Error(err, "Failed to delete volume after server creation failure", "volume", volume.Name)
+		}
+	}
+}
+
+// volumeFormat returns the filesystem of a volume
+func volumeFormat(volume hcloudv1alpha1.HetznerVolume) string {
+	if volume.Format == "" {
+		return "ext4"
+	}
+	return volume.Format
+}
+
+// providerOperationContext applies the pool's provider operation timeout override to a
+// single provider operation. Without an override the provider client's default applies
+func providerOperationContext(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) (context.Context, context.CancelFunc) {
+	if timeout := nodePool.Spec.ProviderOperationTimeout; timeout != nil && timeout.Duration > 0 {
+		return context.WithTimeout(ctx, timeout.Duration)
+	}
+	return ctx, func() {}
+}
+
+// snapshotCacheEnabled reports whether nodes of the pool should boot from a cached bootstrap snapshot
+func snapshotCacheEnabled(nodePool *hcloudv1alpha1.NodePool) bool {
+	return nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderHetzner &&
+		nodePool.Spec.HetznerConfig != nil &&
+		nodePool.Spec.HetznerConfig.SnapshotCache &&
+		nodePool.Spec.CloudInit == "" &&
+		nodePool.Spec.Bootstrap != nil &&
+		nodePool.Spec.Bootstrap.Type == hcloudv1alpha1.ClusterTypeKubeadm
+}
+
+// ensureBootstrapSnapshot returns the ID of the snapshot matching the pool's current
+// bootstrap hash, or 0 while the snapshot is still being built
+func (r *NodePoolReconciler) ensureBootstrapSnapshot(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) (int64, error) {
+	logger := log.FromContext(ctx)
+	config := nodePool.Spec.HetznerConfig
+
+	prepareCloudInit, err := r.CloudInitGenerator.GenerateKubeadmPrepareCloudInit(kubernetesVersion(nodePool.Spec.Bootstrap))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate snapshot cloud-init: %w", err)
+	}
+
+	hash := hetzner.BootstrapHash(config.Image, config.ServerType, prepareCloudInit)
+
+	snapshot, err := r.hetznerClient(ctx).EnsureSnapshot(ctx, hetzner.SnapshotConfig{
+		NodePoolName:  nodePool.Name,
+		Namespace:     nodePool.Namespace,
+		BootstrapHash: hash,
+		Builder: hetzner.ServerConfig{
+			Name:       fmt.Sprintf("%s-snapshot-%s", nodePool.Name, hash[:8]),
+			ServerType: config.ServerType,
+			Image:      config.Image,
+			Location:   config.ServerLocations()[0],
+			SSHKeys:    nodePool.Spec.SSHKeys,
+			UserData:   prepareCloudInit,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if snapshot == nil {
+		logger.Info("Bootstrap snapshot not ready yet, booting from base image", "bootstrapHash", hash)
+		return 0, nil
+	}
+
+	// Drop snapshots built for previous bootstrap configurations
+	if err := r.hetznerClient(ctx).DeleteStaleSnapshots(ctx, nodePool.Name, nodePool.Namespace, hash); err != nil {
+		logger.Error(err, "Failed to delete stale bootstrap snapshots", "bootstrapHash", hash)
+	}
+
+	return snapshot.ID, nil
+}
+
+// resolveOVHID returns the ID of the named OVHcloud resource in region. The ID recorded in
+// resolved is reused while it was resolved from the same name and region, otherwise the
+// name is resolved with lookup and the result recorded in resolved
+func resolveOVHID(
+	ctx context.Context,
+	resolved **hcloudv1alpha1.ResolvedID,
+	region, name string,
+	lookup func(ctx context.Context, region, name string) (string, error),
+) (string, error) {
+	if cached := *resolved; cached != nil && cached.Name == name && cached.Region == region && cached.ID != "" {
+		return cached.ID, nil
+	}
+
+	id, err := lookup(ctx, region, name)
+	if err != nil {
+		return "", err
+	}
+	*resolved = &hcloudv1alpha1.ResolvedID{Name: name, Region: region, ID: id}
+	log.FromContext(ctx).Info("Resolved OVHcloud name to ID", "name", name, "region", region, "id", id)
+	return id, nil
+}
+
 func (r *NodePoolReconciler) createOVHcloudInstance(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, instanceName string, labels map[string]string, userData string) error {
 	logger := log.FromContext(ctx)
 
@@ -339,12 +1284,12 @@ func (r *NodePoolReconciler) createOVHcloudInstance(ctx context.Context, nodePoo
 	// Resolve FlavorID from Flavor if needed
 	flavorID := config.FlavorID
 	if flavorID == "" && config.Flavor != "" {
-		resolvedID, err := r.OVHCloudClient.GetFlavorIDByName(ctx, config.Region, config.Flavor)
+		resolvedID, err := resolveOVHID(ctx, &nodePool.Status.ResolvedFlavorID, config.Region, config.Flavor,
+			r.ovhcloudClient(ctx).GetFlavorIDByName)
 		if err != nil {
 			return fmt.Errorf("failed to resolve flavor name '%s': %w", config.Flavor, err)
 		}
 		flavorID = resolvedID
-		logger.Info("Resolved flavor name to ID", "flavor", config.Flavor, "flavorID", flavorID)
 	}
 	if flavorID == "" {
 		return fmt.Errorf("either flavor or flavorID must be specified")
@@ -353,12 +1298,12 @@ func (r *NodePoolReconciler) createOVHcloudInstance(ctx context.Context, nodePoo
 	// Resolve ImageID from Image if needed
 	imageID := config.ImageID
 	if imageID == "" && config.Image != "" {
-		resolvedID, err := r.OVHCloudClient.GetImageIDByName(ctx, config.Region, config.Image)
+		resolvedID, err := resolveOVHID(ctx, &nodePool.Status.ResolvedImageID, config.Region, config.Image,
+			r.ovhcloudClient(ctx).GetImageIDByName)
 		if err != nil {
 			return fmt.Errorf("failed to resolve image name '%s': %w", config.Image, err)
 		}
 		imageID = resolvedID
-		logger.Info("Resolved image name to ID", "image", config.Image, "imageID", imageID)
 	}
 	if imageID == "" {
 		return fmt.Errorf("either image or imageID must be specified")
@@ -381,7 +1326,7 @@ func (r *NodePoolReconciler) createOVHcloudInstance(ctx context.Context, nodePoo
 		if sshKeyName == "" {
 			continue
 		}
-		keyID, err := r.OVHCloudClient.GetSSHKeyIDByName(ctx, sshKeyName)
+		keyID, err := r.ovhcloudClient(ctx).GetSSHKeyIDByName(ctx, sshKeyName)
 		if err != nil {
 			return fmt.Errorf("failed to resolve SSH key name '%s': %w", sshKeyName, err)
 		}
@@ -392,7 +1337,7 @@ func (r *NodePoolReconciler) createOVHcloudInstance(ctx context.Context, nodePoo
 	// Resolve NetworkID from Network if needed
 	networkID := config.NetworkID
 	if networkID == "" && config.Network != "" {
-		resolvedID, err := r.OVHCloudClient.GetNetworkIDByName(ctx, config.Region, config.Network)
+		resolvedID, err := r.ovhcloudClient(ctx).GetNetworkIDByName(ctx, config.Region, config.Network)
 		if err != nil {
 			return fmt.Errorf("failed to resolve network name '%s': %w", config.Network, err)
 		}
@@ -400,23 +1345,28 @@ func (r *NodePoolReconciler) createOVHcloudInstance(ctx context.Context, nodePoo
 		logger.Info("Resolved network name to ID", "network", config.Network, "networkID", networkID)
 	}
 
-	// Create a longer context for instance creation (OVHcloud can take 30-60s)
-	createCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	// Instance creation can take 30-60s, it is bounded by the provider operation timeout
+	opCtx, cancel := providerOperationContext(ctx, nodePool)
 	defer cancel()
 
-	instance, err := r.OVHCloudClient.CreateInstance(createCtx, ovhcloud.InstanceConfig{
-		Name:            instanceName,
-		FlavorID:        flavorID,
-		ImageID:         imageID,
-		Region:          config.Region,
-		ProjectID:       config.ProjectID,
-		NetworkID:       networkID,
-		SSHKeys:         sshKeyIDs,
-		Labels:          labels,
-		UserData:        userData,
-		SecurityGroupID: securityGroupID,
+	instance, err := r.ovhcloudClient(ctx).CreateInstance(opCtx, ovhcloud.InstanceConfig{
+		Name:             instanceName,
+		FlavorID:         flavorID,
+		ImageID:          imageID,
+		Region:           config.Region,
+		ProjectID:        config.ProjectID,
+		NetworkID:        networkID,
+		SSHKeys:          sshKeyIDs,
+		Labels:           labels,
+		UserData:         userData,
+		SecurityGroupID:  securityGroupID,
+		AllowPrivateOnly: config.PublicNetworkFailurePolicy == hcloudv1alpha1.PublicNetworkFailurePolicyPrivateOnly,
+		MonthlyBilling:   config.MonthlyBilling,
 	})
 
+	if networkID != "" {
+		r.setPublicNetworkCondition(nodePool, instanceName, instance, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create instance: %w", err)
 	}
@@ -425,12 +1375,93 @@ func (r *NodePoolReconciler) createOVHcloudInstance(ctx context.Context, nodePoo
 	return nil
 }
 
+// setPublicNetworkCondition records whether an OVHcloud instance on a private network got
+// public network access, with a warning event when it didn't
+func (r *NodePoolReconciler) setPublicNetworkCondition(
+	nodePool *hcloudv1alpha1.NodePool,
+	instanceName string,
+	instance *ovhcloud.Instance,
+	err error,
+) {
+	condition := metav1.Condition{
+		Type:               conditionPublicNetworkAvailable,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Attached",
+		Message:            "instances are attached to the public and private networks",
+		ObservedGeneration: nodePool.Generation,
+	}
+
+	switch {
+	case err != nil && stderrors.Is(err, ovhcloud.ErrPublicNetworkUnavailable):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "LookupFailed"
+		condition.Message = fmt.Sprintf("instance %s not created: %v", instanceName, err)
+	case err != nil:
+		// Unrelated creation failure, the public network state is unchanged
+		return
+	case instance.PrivateOnly:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "PrivateOnly"
+		condition.Message = fmt.Sprintf("instance %s was created without public network access and has no internet access", instanceName)
+	}
+
+	if condition.Status == metav1.ConditionFalse {
+		r.Recorder.Event(nodePool, corev1.EventTypeWarning, "PublicNetworkUnavailable", condition.Message)
+	}
+	meta.SetStatusCondition(&nodePool.Status.Conditions, condition)
+}
+
+// nodeKubeletArgs returns the pool's extra kubelet flags, registering nodes with the pool's
+// taints unless the flags already set register-with-taints
+func nodeKubeletArgs(nodePool *hcloudv1alpha1.NodePool) map[string]string {
+	args := nodePool.Spec.Bootstrap.KubeletExtraArgs
+	if len(nodePool.Spec.Taints) == 0 {
+		return args
+	}
+	if _, set := args["register-with-taints"]; set {
+		return args
+	}
+
+	withTaints := make(map[string]string, len(args)+1)
+	for k, v := range args {
+		withTaints[k] = v
+	}
+	withTaints["register-with-taints"] = registerWithTaints(nodePool.Spec.Taints)
+	return withTaints
+}
+
 // generateCloudInit generates cloud-init configuration based on cluster type
 //
 //nolint:gocyclo,funlen // Multiple bootstrap types require branching logic and configuration
-func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) (string, error) {
+func (r *NodePoolReconciler) generateCloudInit(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	fromSnapshot bool,
+	volumes []bootstrap.VolumeMount,
+) (string, error) {
 	logger := log.FromContext(ctx)
 	bootstrapConfig := nodePool.Spec.Bootstrap
+	opts := nodeOptions(bootstrapConfig)
+	opts.Volumes = volumes
+	for _, server := range nodePool.Spec.DNSServers {
+		if err := bootstrap.ValidateDNSServer(server); err != nil {
+			return "", fmt.Errorf("%w: %v", errInvalidBootstrapConfig, err)
+		}
+	}
+	for _, server := range nodePool.Spec.NTPServers {
+		if err := bootstrap.ValidateNTPServer(server); err != nil {
+			return "", fmt.Errorf("%w: %v", errInvalidBootstrapConfig, err)
+		}
+	}
+	opts.DNSServers = nodePool.Spec.DNSServers
+	opts.NTPServers = nodePool.Spec.NTPServers
+	files, err := r.nodeFiles(ctx, nodePool)
+	if err != nil {
+		return "", err
+	}
+	opts.Files = files
+	generator := r.CloudInitGenerator.WithNodeOptions(opts)
+	kubeletArgs := nodeKubeletArgs(nodePool)
 
 	switch bootstrapConfig.Type {
 	case hcloudv1alpha1.ClusterTypeKubeadm:
@@ -440,7 +1471,7 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 		if bootstrapConfig.AutoGenerateToken {
 			token, err = r.BootstrapManager.GetOrGenerateBootstrapToken(ctx, nodePool.Name, 24*time.Hour)
 			if err != nil {
-				return "", fmt.Errorf("failed to get or generate bootstrap token: %w", err)
+				return "", r.tokenGenerationFailed(nodePool, fmt.Errorf("failed to get or generate bootstrap token: %w", err))
 			}
 			logger.Info("Using bootstrap token", "nodePool", nodePool.Name, "expiresAt", token.ExpiresAt)
 		} else if bootstrapConfig.TokenSecretRef != nil {
@@ -451,7 +1482,7 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 				Namespace: nodePool.Namespace,
 			}
 			if err := r.Get(ctx, secretKey, &secret); err != nil {
-				return "", fmt.Errorf("failed to get token secret: %w", err)
+				return "", r.tokenGenerationFailed(nodePool, fmt.Errorf("failed to get token secret: %w", err))
 			}
 			tokenKey := bootstrapConfig.TokenSecretRef.Key
 			if tokenKey == "" {
@@ -459,30 +1490,66 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 			}
 			tokenValue := string(secret.Data[tokenKey])
 			if tokenValue == "" {
-				return "", fmt.Errorf("token not found in secret")
+				return "", r.tokenGenerationFailed(nodePool, fmt.Errorf("token not found in secret"))
 			}
 			token = &bootstrap.BootstrapToken{
 				Token:   tokenValue,
 				TokenID: "",
 			}
 		}
+		if token == nil {
+			return "", fmt.Errorf("%w: kubeadm bootstrap requires autoGenerateToken or tokenSecretRef", errInvalidBootstrapConfig)
+		}
+		if meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionTokenGenerationFailed) {
+			setTokenGenerationFailedCondition(nodePool, metav1.ConditionFalse, "TokenAvailable", "")
+		}
 
-		// Get cluster info
-		clusterInfo, err := r.BootstrapManager.GetClusterInfo(ctx)
-		if err != nil {
-			return "", fmt.Errorf("failed to get cluster info: %w", err)
+		if bootstrapConfig.CACertHash != "" {
+			if err := bootstrap.ValidateCACertHash(bootstrapConfig.CACertHash); err != nil {
+				return "", fmt.Errorf("%w: %v", errInvalidBootstrapConfig, err)
+			}
 		}
 
-		// Override endpoint if specified
-		if bootstrapConfig.APIServerEndpoint != "" {
-			clusterInfo.Endpoint = bootstrapConfig.APIServerEndpoint
+		var joinConfig string
+		if ref := bootstrapConfig.JoinConfigSecretRef; ref != nil {
+			if joinConfig, err = r.kubeadmJoinConfig(ctx, nodePool.Namespace, ref); err != nil {
+				return "", err
+			}
 		}
 
-		// Get Kubernetes version
-		k8sVersion := bootstrapConfig.KubernetesVersion
-		if k8sVersion == "" {
-			k8sVersion = "1.29" // default version
+		// Get cluster info, which isn't needed when both the endpoint and CA cert hash are
+		// overridden or nodes join with their own join configuration
+		clusterInfo := &bootstrap.ClusterInfo{
+			Endpoint:   bootstrapConfig.APIServerEndpoint,
+			CACertHash: bootstrapConfig.CACertHash,
 		}
+		if joinConfig == "" && (clusterInfo.Endpoint == "" || clusterInfo.CACertHash == "") {
+			var info *bootstrap.ClusterInfo
+			var err error
+			if ref := bootstrapConfig.ClusterInfoSecretRef; ref != nil {
+				info, err = r.BootstrapManager.GetClusterInfoFromSecret(ctx, nodePool.Namespace, ref.Name)
+			} else {
+				info, err = r.BootstrapManager.GetClusterInfo(ctx)
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to get cluster info: %w", err)
+			}
+
+			// Override endpoint and CA cert hash if specified
+			if clusterInfo.Endpoint == "" {
+				clusterInfo.Endpoint = info.Endpoint
+			}
+			if clusterInfo.CACertHash == "" {
+				clusterInfo.CACertHash = info.CACertHash
+			}
+			if clusterInfo.Endpoint == "" {
+				return "", fmt.Errorf("%w: no API server endpoint, set apiServerEndpoint or the endpoint key of the cluster info secret",
+					errInvalidBootstrapConfig)
+			}
+		}
+
+		// Get Kubernetes version
+		k8sVersion := kubernetesVersion(bootstrapConfig)
 
 		// Prepare firewall rules
 		var firewallRules []string
@@ -494,11 +1561,18 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 			firewallRules = append(firewallRules, fmt.Sprintf("%s/%s", rule.Port, protocol))
 		}
 
-		cloudInit, err := r.CloudInitGenerator.GenerateKubeadmCloudInitFull(
+		kubeadmGenerator := generator.WithContainerdOptions(containerdOptions(bootstrapConfig)).WithJoinConfig(joinConfig)
+		generate := kubeadmGenerator.GenerateKubeadmCloudInitFull
+		if fromSnapshot {
+			generate = kubeadmGenerator.GenerateKubeadmCloudInitFromSnapshot
+		}
+
+		cloudInit, err := generate(
 			clusterInfo.Endpoint,
 			token.Token,
 			clusterInfo.CACertHash,
 			nodePool.Spec.Labels,
+			kubeletArgs,
 			k8sVersion,
 			firewallRules,
 			nodePool.Spec.RunCmd,
@@ -510,7 +1584,7 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 
 	case hcloudv1alpha1.ClusterTypeK3s:
 		if bootstrapConfig.K3sConfig == nil {
-			return "", fmt.Errorf("k3s config is required for k3s cluster type")
+			return "", fmt.Errorf("%w: k3s config is required for k3s cluster type", errInvalidBootstrapConfig)
 		}
 
 		// Get token from secret
@@ -531,10 +1605,12 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 			token = string(secret.Data[tokenKey])
 		}
 
-		cloudInit, err := r.CloudInitGenerator.GenerateK3sCloudInit(
+		cloudInit, err := generator.GenerateK3sCloudInit(
 			bootstrapConfig.K3sConfig.ServerURL,
 			token,
+			bootstrapConfig.K3sConfig.Version,
 			nodePool.Spec.Labels,
+			kubeletArgs,
 		)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate k3s cloud-init: %w", err)
@@ -543,7 +1619,7 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 
 	case hcloudv1alpha1.ClusterTypeTalos:
 		if bootstrapConfig.TalosConfig == nil {
-			return "", fmt.Errorf("talos config is required for talos cluster type")
+			return "", fmt.Errorf("%w: talos config is required for talos cluster type", errInvalidBootstrapConfig)
 		}
 
 		// Get machine config from secret
@@ -564,7 +1640,7 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 			machineConfig = string(secret.Data[configKey])
 		}
 
-		cloudInit, err := r.CloudInitGenerator.GenerateTalosCloudInit(
+		cloudInit, err := generator.GenerateTalosCloudInit(
 			bootstrapConfig.TalosConfig.ControlPlaneEndpoint,
 			machineConfig,
 		)
@@ -575,7 +1651,7 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 
 	case hcloudv1alpha1.ClusterTypeRKE2, hcloudv1alpha1.ClusterTypeRancher:
 		if bootstrapConfig.RKE2Config == nil {
-			return "", fmt.Errorf("rke2 config is required for rke2/rancher cluster type")
+			return "", fmt.Errorf("%w: rke2 config is required for rke2/rancher cluster type", errInvalidBootstrapConfig)
 		}
 
 		// Get token from secret
@@ -596,10 +1672,12 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 			token = string(secret.Data[tokenKey])
 		}
 
-		cloudInit, err := r.CloudInitGenerator.GenerateRancherCloudInit(
+		cloudInit, err := generator.GenerateRancherCloudInit(
 			bootstrapConfig.RKE2Config.ServerURL,
 			token,
+			bootstrapConfig.RKE2Config.Version,
 			nodePool.Spec.Labels,
+			kubeletArgs,
 		)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate rke2 cloud-init: %w", err)
@@ -607,34 +1685,149 @@ func (r *NodePoolReconciler) generateCloudInit(ctx context.Context, nodePool *hc
 		return cloudInit, nil
 
 	default:
-		return "", fmt.Errorf("unsupported cluster type: %s", bootstrapConfig.Type)
+		return "", fmt.Errorf("%w: unsupported cluster type: %s", errInvalidBootstrapConfig, bootstrapConfig.Type)
+	}
+}
+
+// nodeOptions returns the node-level cloud-init options of a bootstrap config
+func nodeOptions(bootstrapConfig *hcloudv1alpha1.ClusterBootstrapConfig) bootstrap.NodeOptions {
+	var opts bootstrap.NodeOptions
+	if bootstrapConfig.SSHHardening != nil {
+		opts.SSHHardening = true
+		opts.PermitRootLogin = bootstrapConfig.SSHHardening.PermitRootLogin
+		if opts.PermitRootLogin == "" {
+			opts.PermitRootLogin = "no"
+		}
+	}
+	if bootstrapConfig.UnattendedUpgrades {
+		opts.UnattendedUpgrades = true
+		if reboot := bootstrapConfig.UpgradeReboot; reboot != nil && reboot.Policy == hcloudv1alpha1.UpgradeRebootScheduled {
+			opts.RebootTime = reboot.Time
+			if opts.RebootTime == "" {
+				opts.RebootTime = "04:00"
+			}
+		}
+	}
+	return opts
+}
+
+// containerdOptions returns the containerd settings of a bootstrap config
+func containerdOptions(bootstrapConfig *hcloudv1alpha1.ClusterBootstrapConfig) bootstrap.ContainerdOptions {
+	opts := bootstrap.DefaultContainerdOptions()
+	config := bootstrapConfig.ContainerdConfig
+	if config == nil {
+		return opts
+	}
+	opts.SandboxImage = config.SandboxImage
+	opts.RegistryConfigPath = config.RegistryConfigPath
+	if config.SystemdCgroup != nil {
+		opts.SystemdCgroup = *config.SystemdCgroup
+	}
+	return opts
+}
+
+// nodeFiles reads the content of the pool's files from their ConfigMaps
+func (r *NodePoolReconciler) nodeFiles(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) ([]bootstrap.WriteFile, error) {
+	files := make([]bootstrap.WriteFile, 0, len(nodePool.Spec.Files))
+	for _, file := range nodePool.Spec.Files {
+		ref := file.ConfigMapRef
+		configMap, err := r.KubeClient.CoreV1().ConfigMaps(nodePool.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, fmt.Errorf("configmap %s for file %s not found", ref.Name, file.Path)
+			}
+			return nil, fmt.Errorf("failed to get configmap %s for file %s: %w", ref.Name, file.Path, err)
+		}
+
+		var content []byte
+		if data, ok := configMap.Data[ref.Key]; ok {
+			content = []byte(data)
+		} else if data, ok := configMap.BinaryData[ref.Key]; ok {
+			content = data
+		} else {
+			return nil, fmt.Errorf("configmap %s for file %s has no key %s", ref.Name, file.Path, ref.Key)
+		}
+
+		permissions := file.Permissions
+		if permissions == "" {
+			permissions = "0644"
+		}
+		files = append(files, bootstrap.WriteFile{Path: file.Path, Permissions: permissions, Content: content})
+	}
+	return files, nil
+}
+
+// kubernetesVersion returns the Kubernetes version to install on nodes
+func kubernetesVersion(bootstrapConfig *hcloudv1alpha1.ClusterBootstrapConfig) string {
+	if bootstrapConfig.KubernetesVersion == "" {
+		return "1.29" // default version
 	}
+	return bootstrapConfig.KubernetesVersion
 }
 
 func (r *NodePoolReconciler) deleteServer(
 	ctx context.Context,
-	_ *hcloudv1alpha1.NodePool,
+	nodePool *hcloudv1alpha1.NodePool,
 	server hetzner.Server,
 ) error {
 	logger := log.FromContext(ctx)
 
+	if err := r.checkNodeOwner(ctx, nodePool, server.Name); err != nil {
+		return err
+	}
+
+	// Deregister from the load balancer before draining so no new traffic arrives
+	if nodePool.Spec.HetznerConfig != nil && nodePool.Spec.HetznerConfig.LoadBalancer != "" {
+		lb := nodePool.Spec.HetznerConfig.LoadBalancer
+		opCtx, cancel := providerOperationContext(ctx, nodePool)
+		err := r.hetznerClient(ctx).RemoveServerFromLoadBalancer(opCtx, lb, server.ID)
+		cancel()
+		if err != nil {
+			logger.Error(err, "Failed to remove server from load balancer, proceeding with deletion anyway",
+				"server", server.Name, "loadBalancer", lb)
+		}
+	}
+
+	// Release floating IPs so they can be assigned to the pool's other servers
+	if nodePool.Spec.HetznerConfig != nil && len(nodePool.Spec.HetznerConfig.FloatingIPs) > 0 {
+		opCtx, cancel := providerOperationContext(ctx, nodePool)
+		err := r.hetznerClient(ctx).UnassignFloatingIPs(opCtx, server.ID)
+		cancel()
+		if err != nil {
+			logger.Error(err, "Failed to unassign floating IPs, proceeding with deletion anyway", "server", server.Name)
+		}
+	}
+
 	// Drain node before deletion
-	if err := r.drainNode(ctx, server.Name); err != nil {
-		logger.Error(err, "Failed to drain node, proceeding with deletion anyway", "node", server.Name)
+	if err := r.drainBeforeDeletion(ctx, nodePool, server.Name, strconv.FormatInt(server.ID, 10)); err != nil {
+		return err
 	}
 
 	// Delete node from cluster
 	node := &corev1.Node{}
 	if err := r.Get(ctx, client.ObjectKey{Name: server.Name}, node); err == nil {
-		if err := r.Delete(ctx, node); err != nil && !errors.IsNotFound(err) {
+		if err := r.deleteNode(ctx, node); err != nil && !errors.IsNotFound(err) {
 			logger.Error(err, "Failed to delete node from cluster", "node", server.Name)
 		} else {
 			logger.Info("Node deleted from cluster", "node", server.Name)
 		}
 	}
 
+	// Detach and delete the server's volumes, they are only reachable through the server
+	for _, volumeID := range server.VolumeIDs {
+		opCtx, cancel := providerOperationContext(ctx, nodePool)
+		err := r.hetznerClient(ctx).DeleteVolume(opCtx, volumeID)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to delete volume %d: %w", volumeID, err)
+		}
+		logger.Info("Volume deleted", "server", server.Name, "id", volumeID)
+	}
+
 	// Delete from Hetzner Cloud
-	if err := r.HCloudClient.DeleteServer(ctx, server.ID); err != nil {
+	opCtx, cancel := providerOperationContext(ctx, nodePool)
+	defer cancel()
+	if err := r.hetznerClient(ctx).DeleteServer(opCtx, server.ID); err != nil {
 		return fmt.Errorf("failed to delete server: %w", err)
 	}
 
@@ -642,7 +1835,63 @@ func (r *NodePoolReconciler) deleteServer(
 	return nil
 }
 
-func (r *NodePoolReconciler) drainNode(ctx context.Context, nodeName string) error {
+// drainBeforeDeletion drains a node of the pool that is about to be deleted. A failed drain
+// is logged and the deletion proceeds, unless the pool's drain failure policy is Abort:
+// the node is then recorded in the dead letter queue and the deletion aborted with an error
+func (r *NodePoolReconciler) drainBeforeDeletion(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	nodeName, id string,
+) error {
+	logger := log.FromContext(ctx)
+
+	dlqID := fmt.Sprintf("%s/%s/node/%s", nodePool.Namespace, nodePool.Name, nodeName)
+	err := r.drainNode(ctx, nodePool, nodeName)
+	if err == nil {
+		if r.DeadLetterQueue != nil {
+			// Drained on a retry after an aborted deletion
+			r.DeadLetterQueue.Remove(dlqID)
+		}
+		return nil
+	}
+	if nodePool.Spec.DrainFailurePolicy != hcloudv1alpha1.DrainFailurePolicyAbort {
+		logger.Error(err, "Failed to drain node, proceeding with deletion anyway", "node", nodeName)
+		return nil
+	}
+
+	logger.Error(err, "Failed to drain node, keeping it until it can be drained", "node", nodeName)
+	err = fmt.Errorf("deletion of node %s aborted, drain failed: %w", nodeName, err)
+	if r.DeadLetterQueue != nil {
+		dlqErr := r.DeadLetterQueue.Add(&reliability.FailedOperation{
+			ID:            dlqID,
+			OperationType: operationDrainFailed,
+			Error:         err,
+			Metadata: map[string]string{
+				"provider":  string(nodePool.Spec.Provider),
+				"namespace": nodePool.Namespace,
+				"nodepool":  nodePool.Name,
+				"node":      nodeName,
+				"id":        id,
+			},
+		})
+		if dlqErr != nil {
+			logger.Error(dlqErr, "Failed to add aborted node deletion to the dead letter queue", "node", nodeName)
+		}
+	}
+	return err
+}
+
+// drainNode prepares a node of the pool for deletion according to the pool's drain mode
+// Like kubectl drain, DaemonSet and mirror pods are left in place, as are pods with
+// emptyDir volumes unless the pool allows deleting their data, and the pods the pool
+// excludes from drains
+func (r *NodePoolReconciler) drainNode(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, nodeName string) error {
+	logger := log.FromContext(ctx)
+
+	if nodePool.Spec.DrainMode == hcloudv1alpha1.DrainModeNone {
+		return nil
+	}
+
 	// Get the node
 	node := &corev1.Node{}
 	if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
@@ -658,20 +1907,83 @@ func (r *NodePoolReconciler) drainNode(ctx context.Context, nodeName string) err
 		return err
 	}
 
-	// Evict all pods (simplified - in production use proper drain logic)
+	if nodePool.Spec.DrainMode == hcloudv1alpha1.DrainModeCordonOnly {
+		return nil
+	}
+
+	excluded, err := drainExcludeSelectors(nodePool)
+	if err != nil {
+		return err
+	}
+
 	podList := &corev1.PodList{}
 	if err := r.List(ctx, podList, client.MatchingFields{"spec.nodeName": nodeName}); err != nil {
 		return err
 	}
 
-	for _, pod := range podList.Items {
-		pod := pod // Create a copy to avoid implicit memory aliasing
-		if err := r.Delete(ctx, &pod); err != nil && !errors.IsNotFound(err) {
-			return err
+	for _, pod := range podList.Items {
+		pod := pod // Create a copy to avoid implicit memory aliasing
+		if reason := drainSkipReason(nodePool, excluded, &pod); reason != "" {
+			logger.V(1).Info("Skipping pod during drain", "node", nodeName, "pod", client.ObjectKeyFromObject(&pod), "reason", reason)
+			continue
+		}
+
+		eviction := &policyv1.Eviction{}
+		if nodePool.Spec.DrainGracePeriodSeconds != nil {
+			eviction.DeleteOptions = &metav1.DeleteOptions{GracePeriodSeconds: nodePool.Spec.DrainGracePeriodSeconds}
+		}
+		if err := r.SubResource("eviction").Create(ctx, &pod, eviction); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// drainExcludeSelectors parses the pool's drainExcludePodSelectors
+func drainExcludeSelectors(nodePool *hcloudv1alpha1.NodePool) ([]labels.Selector, error) {
+	selectors := make([]labels.Selector, 0, len(nodePool.Spec.DrainExcludePodSelectors))
+	for i := range nodePool.Spec.DrainExcludePodSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&nodePool.Spec.DrainExcludePodSelectors[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid drainExcludePodSelectors: %w", err)
 		}
+		selectors = append(selectors, selector)
 	}
+	return selectors, nil
+}
 
-	return nil
+// drainSkipReason returns why a drain leaves the pod in place, empty when it is evicted.
+// excluded are the pool's parsed drainExcludePodSelectors
+func drainSkipReason(nodePool *hcloudv1alpha1.NodePool, excluded []labels.Selector, pod *corev1.Pod) string {
+	// Mirror pods can't be evicted, their static pod goes away with the node
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return "mirror pod"
+	}
+	// The DaemonSet controller would recreate the pod on the cordoned node right away
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+		return "DaemonSet pod"
+	}
+	if slices.Contains(nodePool.Spec.DrainExcludeNamespaces, pod.Namespace) {
+		return "excluded namespace"
+	}
+	for _, selector := range excluded {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return "excluded by selector"
+		}
+	}
+	// Finished pods have no data left to lose
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return ""
+	}
+	if !nodePool.Spec.DrainDeleteEmptyDirData {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.EmptyDir != nil {
+				return "pod with emptyDir data"
+			}
+		}
+	}
+	return ""
 }
 
 func (r *NodePoolReconciler) handleDeletion(
@@ -681,51 +1993,161 @@ func (r *NodePoolReconciler) handleDeletion(
 	logger := log.FromContext(ctx)
 
 	if containsString(nodePool.Finalizers, nodePoolFinalizer) {
-		switch nodePool.Spec.Provider {
-		case hcloudv1alpha1.CloudProviderHetzner:
-			// Delete all Hetzner servers
-			servers, err := r.HCloudClient.ListServers(ctx, nodePool.Name, nodePool.Namespace)
-			if err != nil {
-				logger.Error(err, "Failed to list servers during deletion")
+		// A forced deletion records what it fails to clean up instead of retrying
+		force := forceDeleteRequested(nodePool)
+		var leaked []leakedResource
+		// cleaned counts the servers and instances deleted
+		cleaned := 0
+
+		provider, err := r.cloudProvider(nodePool)
+		if err != nil {
+			logger.Error(err, "Unsupported provider during deletion", "provider", nodePool.Spec.Provider)
+			if !force {
 				return ctrl.Result{}, err
 			}
+		}
+		var listed *poolServers
+		if provider != nil {
+			if listed, err = provider.List(ctx, nodePool); err != nil {
+				logger.Error(err, "Failed to list servers during deletion")
+				if !force {
+					return ctrl.Result{}, err
+				}
+				leaked = append(leaked, unlistedNodes(nodePool, err)...)
+			}
+		}
+
+		switch {
+		case listed == nil:
+			// Nothing was listed to clean up
 
+		case nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderHetzner:
+			// Delete all Hetzner servers
+			servers := listed.hetzner
+			var deletedServers []hetzner.Server
+			var failed cleanupFailures
 			for _, server := range servers {
-				if err := r.deleteServer(ctx, nodePool, server); err != nil {
+				id := strconv.FormatInt(server.ID, 10)
+				err := r.cleanupServer(ctx, nodePool, "server", server.Name, id, func(ctx context.Context) error {
+					return r.deleteServer(ctx, nodePool, server)
+				})
+				if err != nil {
 					logger.Error(err, "Failed to delete server during cleanup", "server", server.Name)
-					return ctrl.Result{}, err
+					if !force {
+						failed.add(err)
+						continue
+					}
+					leaked = append(leaked, leakedResource{Kind: "server", Name: server.Name, ID: id, Err: err})
+					continue
 				}
+				deletedServers = append(deletedServers, server)
 			}
+			if err := failed.err("servers", len(servers)); err != nil {
+				return ctrl.Result{}, err
+			}
+			cleaned += len(deletedServers)
 
-		case hcloudv1alpha1.CloudProviderOVHcloud:
-			if r.OVHCloudClient == nil {
-				logger.Error(nil, "OVHcloud client not initialized")
-				return ctrl.Result{}, fmt.Errorf("OVHcloud client not initialized")
+			deleted, err := r.deletePlacementGroup(ctx, nodePool, deletedServers)
+			if err != nil {
+				logger.Error(err, "Failed to delete placement group during cleanup")
+				if !force {
+					return ctrl.Result{}, err
+				}
+				leaked = append(leaked, leakedResource{
+					Kind: "placement group", Name: nodePool.Spec.HetznerConfig.PlacementGroup, Err: err,
+				})
+			} else if !deleted {
+				// Server deletion is asynchronous, wait for the group to empty
+				return ctrl.Result{RequeueAfter: serverReleaseRequeueDelay}, nil
 			}
 
-			// Delete all OVHcloud instances
-			instances, err := r.OVHCloudClient.ListInstances(ctx, nodePool.Name, nodePool.Namespace)
+			deleted, err = r.deleteFirewalls(ctx, nodePool, deletedServers)
 			if err != nil {
-				logger.Error(err, "Failed to list instances during deletion")
-				return ctrl.Result{}, err
+				logger.Error(err, "Failed to delete firewalls during cleanup")
+				if !force {
+					return ctrl.Result{}, err
+				}
+				leaked = append(leaked, leakedResource{Kind: "firewall", Name: nodePool.Name + "-firewall", Err: err})
+			} else if !deleted {
+				// Server deletion is asynchronous, wait for the firewall to be released
+				return ctrl.Result{RequeueAfter: serverReleaseRequeueDelay}, nil
+			}
+
+			// Delete cached bootstrap snapshots and any in-progress builder
+			if err := r.hetznerClient(ctx).DeleteStaleSnapshots(ctx, nodePool.Name, nodePool.Namespace, ""); err != nil {
+				logger.Error(err, "Failed to delete bootstrap snapshots during cleanup")
+				if !force {
+					return ctrl.Result{}, err
+				}
+				leaked = append(leaked, leakedResource{Kind: "bootstrap snapshots", Name: nodePool.Name, Err: err})
 			}
 
+		case nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderOVHcloud:
+			// Delete all OVHcloud instances
+			instances := listed.ovh
+
 			logger.Info("Deleting OVHcloud instances", "count", len(instances), "nodePool", nodePool.Name)
+			var failed cleanupFailures
 			for _, instance := range instances {
-				if err := r.deleteOVHInstance(ctx, nodePool, instance); err != nil {
+				err := r.cleanupServer(ctx, nodePool, "instance", instance.Name, instance.ID, func(ctx context.Context) error {
+					return r.deleteOVHInstance(ctx, nodePool, instance)
+				})
+				if err != nil {
 					logger.Error(err, "Failed to delete instance during cleanup", "instance", instance.Name, "id", instance.ID)
+					if !force {
+						failed.add(err)
+						continue
+					}
+					leaked = append(leaked, leakedResource{Kind: "instance", Name: instance.Name, ID: instance.ID, Err: err})
+					continue
+				}
+				cleaned++
+			}
+			if err := failed.err("instances", len(instances)); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			if err := r.deleteOVHSecurityGroups(ctx, nodePool); err != nil {
+				logger.Error(err, "Failed to delete security groups during cleanup")
+				if !force {
 					return ctrl.Result{}, err
 				}
+				leaked = append(leaked, leakedResource{
+					Kind: "security group", Name: fmt.Sprintf("%s-%s", nodePool.Namespace, nodePool.Name), Err: err,
+				})
 			}
 
-		default:
-			logger.Error(nil, "Unsupported provider during deletion", "provider", nodePool.Spec.Provider)
-			return ctrl.Result{}, fmt.Errorf("unsupported provider: %s", nodePool.Spec.Provider)
+		case nodePool.Spec.Provider == hcloudv1alpha1.CloudProviderScaleway:
+			// Delete all Scaleway instances
+			instances := listed.scaleway
+
+			logger.Info("Deleting Scaleway instances", "count", len(instances), "nodePool", nodePool.Name)
+			var failed cleanupFailures
+			for _, instance := range instances {
+				err := r.cleanupServer(ctx, nodePool, "instance", instance.Name, instance.ID, func(ctx context.Context) error {
+					return r.deleteScalewayInstance(ctx, nodePool, instance)
+				})
+				if err != nil {
+					logger.Error(err, "Failed to delete instance during cleanup", "instance", instance.Name, "id", instance.ID)
+					if !force {
+						failed.add(err)
+						continue
+					}
+					leaked = append(leaked, leakedResource{Kind: "instance", Name: instance.Name, ID: instance.ID, Err: err})
+					continue
+				}
+				cleaned++
+			}
+			if err := failed.err("instances", len(instances)); err != nil {
+				return ctrl.Result{}, err
+			}
 		}
 
-		// Remove finalizer
-		nodePool.Finalizers = removeString(nodePool.Finalizers, nodePoolFinalizer)
-		if err := r.Update(ctx, nodePool); err != nil {
+		if len(leaked) > 0 {
+			r.recordLeakedResources(ctx, nodePool, leaked)
+		}
+
+		if err := r.removeFinalizer(ctx, nodePool, cleaned); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
@@ -733,61 +2155,130 @@ func (r *NodePoolReconciler) handleDeletion(
 	return ctrl.Result{}, nil
 }
 
-func (r *NodePoolReconciler) scaleDown(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, nodesToRemove int) error {
-	switch nodePool.Spec.Provider {
-	case hcloudv1alpha1.CloudProviderHetzner:
-		return r.scaleDownHetzner(ctx, nodePool, nodesToRemove)
-	case hcloudv1alpha1.CloudProviderOVHcloud:
-		return r.scaleDownOVHcloud(ctx, nodePool, nodesToRemove)
-	default:
-		return fmt.Errorf("unsupported provider: %s", nodePool.Spec.Provider)
+// removeFinalizer removes the finalizer of a deleted pool once its resources are cleaned up,
+// cleaned being the number of servers and instances deleted
+func (r *NodePoolReconciler) removeFinalizer(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, cleaned int) error {
+	nodePool.Finalizers = removeString(nodePool.Finalizers, nodePoolFinalizer)
+	if err := r.Update(ctx, nodePool); err != nil {
+		return err
 	}
+	r.provisioning.forget(nodePool)
+	r.annotationWarnings.forget(nodePool)
+	r.credentialClients.forget(nodePool)
+	r.Recorder.Eventf(nodePool, corev1.EventTypeNormal, "CleanupComplete",
+		"Deleted %d servers and instances, removed finalizer %s", cleaned, nodePoolFinalizer)
+	return nil
 }
 
-func (r *NodePoolReconciler) scaleDownHetzner(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, nodesToRemove int) error {
-	logger := log.FromContext(ctx)
-	servers, err := r.HCloudClient.ListServers(ctx, nodePool.Name, nodePool.Namespace)
-	if err != nil {
-		return err
+// poolServers holds the servers or instances of a pool listed at the start of a reconcile, so
+// the reconcile's steps share a single listing. Servers deleted by a step are forgotten and
+// Hetzner servers created by a step are added, to spread further servers across locations.
+// Pool deletion lists the servers afresh instead
+type poolServers struct {
+	hetzner  []hetzner.Server
+	ovh      []ovhcloud.Instance
+	scaleway []scaleway.Instance
+}
+
+// forget drops a deleted server or instance from the listing
+func (l *poolServers) forget(name string) {
+	for i, server := range l.hetzner {
+		if server.Name == name {
+			l.hetzner = append(l.hetzner[:i:i], l.hetzner[i+1:]...)
+			return
+		}
+	}
+	for i, instance := range l.ovh {
+		if instance.Name == name {
+			l.ovh = append(l.ovh[:i:i], l.ovh[i+1:]...)
+			return
+		}
+	}
+	for i, instance := range l.scaleway {
+		if instance.Name == name {
+			l.scaleway = append(l.scaleway[:i:i], l.scaleway[i+1:]...)
+			return
+		}
 	}
+}
 
-	for i := 0; i < nodesToRemove && i < len(servers); i++ {
-		if err := r.deleteServer(ctx, nodePool, servers[i]); err != nil {
-			logger.Error(err, "Failed to delete server")
-			return err
+// hetznerServerLocation returns the location of the pool with the fewest listed servers,
+// the first of the pool's locations on a tie
+func hetznerServerLocation(config *hcloudv1alpha1.HetznerCloudConfig, listed *poolServers) string {
+	locations := config.ServerLocations()
+	counts := make(map[string]int, len(locations))
+	for _, server := range listed.hetzner {
+		counts[server.Location]++
+	}
+	best := locations[0]
+	for _, location := range locations[1:] {
+		if counts[location] < counts[best] {
+			best = location
 		}
 	}
-	return nil
+	return best
 }
 
-func (r *NodePoolReconciler) scaleDownOVHcloud(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, nodesToRemove int) error {
+// scaleDown deletes up to nodesToRemove of the listed servers, outdated ones first, and
+// returns how many it deleted. Servers running the current configuration are kept until
+// they are older than the pool's minNodeLifetime
+func (r *NodePoolReconciler) scaleDown(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers, nodesToRemove int) (int, error) {
 	logger := log.FromContext(ctx)
-	instances, err := r.OVHCloudClient.ListInstances(ctx, nodePool.Name, nodePool.Namespace)
+
+	provider, err := r.cloudProvider(nodePool)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	running := provider.Running(listed)
+	created := provider.Created(listed)
+	var names []string
+	for _, name := range provider.Names(listed) {
+		if removalRank(nodePool, name, running[name]) == 2 && tooYoungToRemove(nodePool, created[name], time.Now()) {
+			logger.V(1).Info("Keeping server younger than minNodeLifetime", "server", name, "created", created[name])
+			continue
+		}
+		names = append(names, name)
 	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return removalRank(nodePool, names[i], running[names[i]]) < removalRank(nodePool, names[j], running[names[j]])
+	})
 
-	for i := 0; i < nodesToRemove && i < len(instances); i++ {
-		if err := r.deleteOVHInstance(ctx, nodePool, instances[i]); err != nil {
-			logger.Error(err, "Failed to delete instance")
-			return err
+	removed := 0
+	for ; removed < nodesToRemove && removed < len(names); removed++ {
+		if err := provider.Delete(ctx, nodePool, listed, names[removed]); err != nil {
+			logger.Error(err, "Failed to delete server", "server", names[removed])
+			return removed, err
 		}
+		listed.forget(names[removed])
 	}
-	return nil
+	return removed, nil
+}
+
+// tooYoungToRemove reports whether a server created at the given time is younger than the
+// pool's minNodeLifetime. Servers whose creation time is unknown are old enough
+func tooYoungToRemove(nodePool *hcloudv1alpha1.NodePool, created, now time.Time) bool {
+	if nodePool.Spec.MinNodeLifetime == nil || created.IsZero() {
+		return false
+	}
+	return now.Sub(created) < nodePool.Spec.MinNodeLifetime.Duration
 }
 
-func (r *NodePoolReconciler) deleteOVHInstance(ctx context.Context, _ *hcloudv1alpha1.NodePool, instance ovhcloud.Instance) error {
+func (r *NodePoolReconciler) deleteOVHInstance(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, instance ovhcloud.Instance) error {
 	logger := log.FromContext(ctx)
 
+	if err := r.checkNodeOwner(ctx, nodePool, instance.Name); err != nil {
+		return err
+	}
+
 	// Drain node before deletion
-	if err := r.drainNode(ctx, instance.Name); err != nil {
-		logger.Error(err, "Failed to drain node, proceeding with deletion anyway", "node", instance.Name)
+	if err := r.drainBeforeDeletion(ctx, nodePool, instance.Name, instance.ID); err != nil {
+		return err
 	}
 
 	// Delete node from cluster
 	node := &corev1.Node{}
 	if err := r.Get(ctx, client.ObjectKey{Name: instance.Name}, node); err == nil {
-		if err := r.Delete(ctx, node); err != nil && !errors.IsNotFound(err) {
+		if err := r.deleteNode(ctx, node); err != nil && !errors.IsNotFound(err) {
 			logger.Error(err, "Failed to delete node from cluster", "node", instance.Name)
 		} else {
 			logger.Info("Node deleted from cluster", "node", instance.Name)
@@ -795,7 +2286,9 @@ func (r *NodePoolReconciler) deleteOVHInstance(ctx context.Context, _ *hcloudv1a
 	}
 
 	// Delete the instance
-	if err := r.OVHCloudClient.DeleteInstance(ctx, instance.ID); err != nil {
+	opCtx, cancel := providerOperationContext(ctx, nodePool)
+	defer cancel()
+	if err := r.ovhcloudClient(ctx).DeleteInstance(opCtx, instance.ID); err != nil {
 		return fmt.Errorf("failed to delete instance %s: %w", instance.ID, err)
 	}
 
@@ -816,16 +2309,22 @@ func (r *NodePoolReconciler) getOrCreateOVHSecurityGroup(ctx context.Context, no
 			continue
 		}
 
-		rules = append(rules, ovhcloud.SecurityRule{
-			Direction:  ovhcloud.DirectionIngress,
-			Protocol:   rule.Protocol,
-			PortFrom:   port,
-			PortTo:     port,
-			SourceCIDR: "0.0.0.0/0", // Allow from any source
-		})
+		sources, err := firewallRuleSources(rule)
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			rules = append(rules, ovhcloud.SecurityRule{
+				Direction:  ovhcloud.DirectionIngress,
+				Protocol:   rule.Protocol,
+				PortFrom:   port,
+				PortTo:     port,
+				SourceCIDR: source.String(),
+			})
+		}
 	}
 
-	return r.OVHCloudClient.GetOrCreateSecurityGroup(ctx, securityGroupName, rules)
+	return r.ovhcloudClient(ctx).GetOrCreateSecurityGroup(ctx, securityGroupName, rules, poolResourceLabels(nodePool))
 }
 
 func (r *NodePoolReconciler) countReadyOVHInstances(instances []ovhcloud.Instance) int {
@@ -846,23 +2345,145 @@ func (r *NodePoolReconciler) getOVHInstanceNames(instances []ovhcloud.Instance)
 	return names
 }
 
+// updateStatus records a failed reconcile in the pool status, with phase as the reason
 func (r *NodePoolReconciler) updateStatus(
 	ctx context.Context,
 	nodePool *hcloudv1alpha1.NodePool,
 	phase, message string,
 ) {
 	nodePool.Status.Phase = phase
-	condition := metav1.Condition{
-		Type:               "Ready",
+	nodePool.Status.LastError = message
+	nodePool.Status.FailureCount++
+	meta.SetStatusCondition(&nodePool.Status.Conditions, metav1.Condition{
+		Type:               conditionReady,
 		Status:             metav1.ConditionFalse,
 		Reason:             phase,
 		Message:            message,
-		LastTransitionTime: metav1.Now(),
-	}
-	nodePool.Status.Conditions = append(nodePool.Status.Conditions, condition)
+		ObservedGeneration: nodePool.Generation,
+	})
 	_ = r.Status().Update(ctx, nodePool)
 }
 
+// recoverHetznerServers adds the servers recorded in the pool status that the label-based
+// listing misses, such as servers created moments before it, so they keep counting towards
+// the pool and are eventually scaled down instead of leaking. A server of the same name that
+// doesn't carry the pool's labels belongs to someone else and is left alone.
+func (r *NodePoolReconciler) recoverHetznerServers(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, servers []hetzner.Server) []hetzner.Server {
+	logger := log.FromContext(ctx)
+
+	listed := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		listed[server.Name] = true
+	}
+
+	for _, name := range nodePool.Status.Nodes {
+		if listed[name] {
+			continue
+		}
+		server, err := r.hetznerClient(ctx).GetServerByName(ctx, name)
+		if err != nil {
+			logger.Error(err, "Failed to look up server missing from listing", "server", name)
+			continue
+		}
+		if server == nil {
+			// The server is gone, it drops out of the status on this reconcile
+			continue
+		}
+		if !hasPoolLabels(nodePool, server.Labels) {
+			logger.Info("Ignoring server missing from listing that the pool doesn't own", "server", name, "id", server.ID)
+			continue
+		}
+		logger.Info("Recovered server missing from listing", "server", name, "id", server.ID)
+		servers = append(servers, *server)
+	}
+
+	return servers
+}
+
+// recoverOVHInstances adds the instances recorded in the pool status that the name-based
+// listing misses. Only instances named with the pool's prefix are taken, as OVHcloud has no
+// labels to tell who owns an instance.
+func (r *NodePoolReconciler) recoverOVHInstances(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, instances []ovhcloud.Instance) []ovhcloud.Instance {
+	logger := log.FromContext(ctx)
+
+	listed := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		listed[instance.Name] = true
+	}
+
+	for _, name := range nodePool.Status.Nodes {
+		if listed[name] {
+			continue
+		}
+		instance, err := r.ovhcloudClient(ctx).GetInstanceByName(ctx, name)
+		if err != nil {
+			logger.Error(err, "Failed to look up instance missing from listing", "instance", name)
+			continue
+		}
+		if instance == nil {
+			continue
+		}
+		if !ovhcloud.BelongsToNodePool(instance.Name, nodePool.Name, nodePool.Namespace) {
+			logger.Info("Ignoring instance missing from listing that the pool doesn't own", "instance", name, "id", instance.ID)
+			continue
+		}
+		logger.Info("Recovered instance missing from listing", "instance", name, "id", instance.ID)
+		instances = append(instances, *instance)
+	}
+
+	return instances
+}
+
+// adoptUnrecordedServers records the listed servers missing from the pool status, such as
+// servers created right before the operator crashed, so they are reconciled as members of
+// the pool instead of leaking
+func (r *NodePoolReconciler) adoptUnrecordedServers(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, serverNames []string) {
+	logger := log.FromContext(ctx)
+
+	recorded := make(map[string]bool, len(nodePool.Status.Nodes))
+	for _, name := range nodePool.Status.Nodes {
+		recorded[name] = true
+	}
+
+	for _, name := range serverNames {
+		if recorded[name] {
+			continue
+		}
+		logger.Info("Adopting server missing from the pool status", "server", name)
+		r.Recorder.Eventf(nodePool, corev1.EventTypeNormal, "ServerAdopted",
+			"Adopted server %s that was missing from the pool status", name)
+	}
+}
+
+// hasPoolLabels reports whether a server carries the labels the operator creates the pool's
+// servers with
+func hasPoolLabels(nodePool *hcloudv1alpha1.NodePool, labels map[string]string) bool {
+	for k, v := range poolResourceLabels(nodePool) {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// runningServers maps server names to whether the server is running
+func runningServers(servers []hetzner.Server) map[string]bool {
+	running := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		running[server.Name] = server.Status == "running"
+	}
+	return running
+}
+
+// runningOVHInstances maps instance names to whether the instance is active
+func runningOVHInstances(instances []ovhcloud.Instance) map[string]bool {
+	running := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		running[instance.Name] = instance.Status == ovhcloud.StatusActive
+	}
+	return running
+}
+
 func (r *NodePoolReconciler) countReadyNodes(servers []hetzner.Server) int {
 	ready := 0
 	for _, server := range servers {
@@ -893,19 +2514,21 @@ func (r *NodePoolReconciler) getOrCreateFirewall(
 			protocol = hcloud.FirewallRuleProtocolTCP // default to TCP
 		}
 
-		// Create rule for ingress from any source
+		sources, err := firewallRuleSources(rule)
+		if err != nil {
+			return 0, err
+		}
+
+		// Create rule for ingress from the rule's sources
 		rules = append(rules, hcloud.FirewallRule{
 			Direction: hcloud.FirewallRuleDirectionIn,
-			SourceIPs: []net.IPNet{
-				{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},  // 0.0.0.0/0
-				{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}, // ::/0
-			},
-			Protocol: protocol,
-			Port:     hcloud.Ptr(rule.Port),
+			SourceIPs: sources,
+			Protocol:  protocol,
+			Port:      hcloud.Ptr(rule.Port),
 		})
 	}
 
-	firewall, err := r.HCloudClient.GetOrCreateFirewall(ctx, firewallName, rules)
+	firewall, err := r.hetznerClient(ctx).GetOrCreateFirewall(ctx, firewallName, rules, poolResourceLabels(nodePool))
 	if err != nil {
 		return 0, fmt.Errorf("failed to get or create firewall: %w", err)
 	}
@@ -913,6 +2536,173 @@ func (r *NodePoolReconciler) getOrCreateFirewall(
 	return firewall.ID, nil
 }
 
+// poolResourceLabels marks a placement group, firewall or security group as created by the
+// operator for the pool
+func poolResourceLabels(nodePool *hcloudv1alpha1.NodePool) map[string]string {
+	return map[string]string{
+		"managed-by": "nodepools",
+		"nodepool":   nodePool.Name,
+		"namespace":  nodePool.Namespace,
+	}
+}
+
+// deletePlacementGroup deletes the pool's placement group if the operator created it for
+// the pool and it is empty. Groups created elsewhere or shared with other servers are left
+// in place. It returns false while the group still holds the pool's deleted servers.
+func (r *NodePoolReconciler) deletePlacementGroup(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	deletedServers []hetzner.Server,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if nodePool.Spec.HetznerConfig == nil || nodePool.Spec.HetznerConfig.PlacementGroup == "" {
+		return true, nil
+	}
+
+	placementGroup, err := r.hetznerClient(ctx).GetPlacementGroup(ctx, nodePool.Spec.HetznerConfig.PlacementGroup)
+	if err != nil {
+		return false, err
+	}
+	if placementGroup == nil {
+		return true, nil
+	}
+
+	for key, value := range poolResourceLabels(nodePool) {
+		if placementGroup.Labels[key] != value {
+			logger.Info("Leaving placement group not created for this pool", "placementGroup", placementGroup.Name)
+			return true, nil
+		}
+	}
+
+	if len(placementGroup.Servers) > 0 {
+		deleted := serverIDs(deletedServers)
+		for _, serverID := range placementGroup.Servers {
+			foreign, err := r.foreignServer(ctx, nodePool, serverID, deleted)
+			if err != nil {
+				return false, err
+			}
+			if foreign {
+				logger.Info("Leaving placement group in use by other servers", "placementGroup", placementGroup.Name)
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if err := r.hetznerClient(ctx).DeletePlacementGroup(ctx, placementGroup.ID); err != nil {
+		return false, err
+	}
+
+	logger.Info("Placement group deleted", "placementGroup", placementGroup.Name)
+	return true, nil
+}
+
+// deleteFirewalls deletes the firewalls the operator created for the pool. Firewalls still
+// applied to other servers are left in place. It returns false while a firewall is still
+// applied to the pool's deleted servers.
+func (r *NodePoolReconciler) deleteFirewalls(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	deletedServers []hetzner.Server,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	firewalls, err := r.hetznerClient(ctx).ListFirewalls(ctx, nodePool.Name, nodePool.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	deleted := serverIDs(deletedServers)
+
+	done := true
+	for _, firewall := range firewalls {
+		inUse, waiting := false, false
+		for _, resource := range firewall.AppliedTo {
+			if resource.Type != hcloud.FirewallResourceTypeServer || resource.Server == nil {
+				inUse = true
+				continue
+			}
+			foreign, err := r.foreignServer(ctx, nodePool, resource.Server.ID, deleted)
+			if err != nil {
+				return false, err
+			}
+			if foreign {
+				inUse = true
+			} else {
+				waiting = true
+			}
+		}
+		if inUse {
+			logger.Info("Leaving firewall in use by other servers", "firewall", firewall.Name)
+			continue
+		}
+		if waiting {
+			done = false
+			continue
+		}
+
+		if err := r.hetznerClient(ctx).DeleteFirewall(ctx, firewall.ID); err != nil {
+			return false, err
+		}
+		logger.Info("Firewall deleted", "firewall", firewall.Name)
+	}
+
+	return done, nil
+}
+
+// foreignServer reports whether a server a resource of the pool is attached to belongs to
+// someone else. The pool's own servers carry its labels or are recorded in its status, which
+// also holds for servers deleted by an earlier reconcile that are still shutting down.
+func (r *NodePoolReconciler) foreignServer(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	serverID int64,
+	deleted map[int64]bool,
+) (bool, error) {
+	if deleted[serverID] {
+		return false, nil
+	}
+
+	server, err := r.hetznerClient(ctx).GetServer(ctx, serverID)
+	if stderrors.Is(err, hetzner.ErrServerNotFound) {
+		// Deleted since the resource was read, it is released shortly
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get server %d: %w", serverID, err)
+	}
+	return !hasPoolLabels(nodePool, server.Labels) && !containsString(nodePool.Status.Nodes, server.Name), nil
+}
+
+// serverIDs returns the set of the servers' IDs
+func serverIDs(servers []hetzner.Server) map[int64]bool {
+	ids := make(map[int64]bool, len(servers))
+	for _, server := range servers {
+		ids[server.ID] = true
+	}
+	return ids
+}
+
+// deleteOVHSecurityGroups deletes the security groups the operator created for the pool
+func (r *NodePoolReconciler) deleteOVHSecurityGroups(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) error {
+	logger := log.FromContext(ctx)
+
+	securityGroups, err := r.ovhcloudClient(ctx).ListSecurityGroups(ctx, nodePool.Name, nodePool.Namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, securityGroup := range securityGroups {
+		if err := r.ovhcloudClient(ctx).DeleteSecurityGroup(ctx, securityGroup.ID); err != nil {
+			return err
+		}
+		logger.Info("Security group deleted", "securityGroup", securityGroup.Name)
+	}
+
+	return nil
+}
+
 func (r *NodePoolReconciler) getServerNames(servers []hetzner.Server) []string {
 	names := make([]string, len(servers))
 	for i, server := range servers {
@@ -940,9 +2730,35 @@ func removeString(slice []string, s string) []string {
 	return result
 }
 
+// maxCreatesPerReconcile returns the number of servers a reconcile may create for a pool
+func (r *NodePoolReconciler) maxCreatesPerReconcile() int {
+	if r.MaxCreatesPerReconcile < 1 {
+		return defaultMaxCreatesPerReconcile
+	}
+	return r.MaxCreatesPerReconcile
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *NodePoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&hcloudv1alpha1.NodePool{}).
+	maxConcurrentReconciles := r.MaxConcurrentReconciles
+	if maxConcurrentReconciles < 1 {
+		maxConcurrentReconciles = 1
+	}
+
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&hcloudv1alpha1.NodePool{}, builder.WithPredicates(nodePoolPredicate())).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: maxConcurrentReconciles,
+			RateLimiter:             &r.failures,
+		}).
 		Complete(r)
+	if err != nil {
+		return err
+	}
+
+	// Deletes the servers of nodes deleted out of band
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("node").
+		For(&corev1.Node{}, builder.WithPredicates(deletingNodePredicate())).
+		Complete(reconcile.Func(r.reconcileNode))
 }
@@ -18,30 +18,54 @@ package controller
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
 	"github.com/autokubeio/autokube/internal/bootstrap"
 	"github.com/autokubeio/autokube/internal/hetzner"
 	"github.com/autokubeio/autokube/internal/metrics"
 	"github.com/autokubeio/autokube/internal/mock"
+	"github.com/autokubeio/autokube/internal/ovhcloud"
 	"github.com/autokubeio/autokube/internal/reliability"
+	"github.com/autokubeio/autokube/internal/scaleway"
 )
 
 func setupTestReconciler() (*NodePoolReconciler, client.Client) {
 	scheme := runtime.NewScheme()
 	_ = hcloudv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
 
 	client := clientfake.NewClientBuilder().
 		WithScheme(scheme).
@@ -60,9 +84,7 @@ func setupTestReconciler() (*NodePoolReconciler, client.Client) {
 kind: Config
 clusters:
 - cluster:
-    certificate-authority-data: ` +
-				`LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUM1ekNDQWMrZ0F3SUJBZ0lCQVRBTkJna3Foa2lHOXcwQkFRc0ZBREFWTVJNd0VRWURWUVFERXdwdGFXNXAKYTNW` +
-				`aVpVTkJNQjRYRFRJME1Ea3hOakl4TlRVeE4xb1hEVE0wTURreE5ESXhOVFV4TjFvd0ZURVRNQkVHQTFVRQpBeE1LYldsdWFXdDFZbVZEUVRDQ0FTSXdEUVlKS29aSWh2Y05BUUVCQlFBRGdnRVBBRENDQVFvQ2dnRUJBTmU2Ck0zTU9JZ2s1
+    certificate-authority-data: ` + testCACertData() + `
     server: https://test-cluster:6443
   name: test-cluster
 contexts:
@@ -88,11 +110,38 @@ current-context: test-context`,
 		BootstrapManager:   bootstrapManager,
 		CloudInitGenerator: cloudInitGenerator,
 		DeadLetterQueue:    deadLetterQueue,
+		Recorder:           record.NewFakeRecorder(100),
 	}
 
 	return reconciler, client
 }
 
+// testCACertData returns a base64 encoded self-signed CA certificate for cluster-info
+func testCACertData() string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), IsCA: true, BasicConstraintsValid: true, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// setupStatusClient replaces the reconciler's client with one serving the NodePool status
+// subresource that successful reconciles update, seeded with the given objects
+func setupStatusClient(reconciler *NodePoolReconciler, objects ...client.Object) client.Client {
+	kubeClient := clientfake.NewClientBuilder().
+		WithScheme(reconciler.Scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&hcloudv1alpha1.NodePool{}).
+		Build()
+	reconciler.Client = kubeClient
+	return kubeClient
+}
+
 func TestNodePoolReconciler_BasicReconcile(t *testing.T) {
 	reconciler, client := setupTestReconciler()
 
@@ -210,6 +259,108 @@ func TestNodePoolReconciler_ScaleUp(t *testing.T) {
 	}
 }
 
+func TestNodePoolReconciler_ScaleDownListsServersOnce(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	for _, name := range []string{"test-pool-0001", "test-pool-0002", "test-pool-0003"} {
+		if _, err := mockHetzner.CreateServer(ctx, hetzner.ServerConfig{Name: name}); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+	}
+
+	kubeClient := setupStatusClient(reconciler)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 1,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := kubeClient.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if mockHetzner.DeleteServerCalls != 2 {
+		t.Errorf("DeleteServerCalls = %d, want 2", mockHetzner.DeleteServerCalls)
+	}
+	// Scaling down deletes servers from the listing taken at the start of the reconcile
+	if mockHetzner.ListServersCalls != 1 {
+		t.Errorf("ListServersCalls = %d, want 1", mockHetzner.ListServersCalls)
+	}
+}
+
+func TestNodePoolReconciler_ScaleDownMinNodeLifetime(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	now := time.Now()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+		return []hetzner.Server{
+			{ID: 1, Name: "test-pool-0001", Status: "running", CreatedAt: now.Add(-2 * time.Hour)},
+			{ID: 2, Name: "test-pool-0002", Status: "running", CreatedAt: now.Add(-30 * time.Second)},
+			{ID: 3, Name: "test-pool-0003", Status: "running", CreatedAt: now.Add(-time.Hour)},
+		}, nil
+	}
+	var deleted []int64
+	mockHetzner.DeleteServerFunc = func(_ context.Context, serverID int64) error {
+		deleted = append(deleted, serverID)
+		return nil
+	}
+
+	kubeClient := setupStatusClient(reconciler)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:        hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes:        1,
+			MaxNodes:        1,
+			MinNodeLifetime: &metav1.Duration{Duration: 10 * time.Minute},
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := kubeClient.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// The freshly created server isn't a scale-down candidate, the older ones are removed
+	if want := []int64{1, 3}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("Deleted servers %v, want %v", deleted, want)
+	}
+}
+
 func TestNodePoolReconciler_NotFound(t *testing.T) {
 	reconciler, _ := setupTestReconciler()
 
@@ -402,3 +553,2551 @@ func TestNodePoolReconciler_Deletion(t *testing.T) {
 		t.Error("Expected DeleteServer to be called during deletion")
 	}
 }
+
+func TestNodePoolReconciler_FinalizerEvents(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+	ctx := context.Background()
+	recorder := reconciler.Recorder.(*record.FakeRecorder)
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	mockHetzner.SetServers(map[int64]*hetzner.Server{
+		1: {ID: 1, Name: "events-pool-1a2b", Status: "running"},
+		2: {ID: 2, Name: "events-pool-3c4d", Status: "running"},
+	})
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "events-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 2,
+			MaxNodes: 2,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "events-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil && !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if !recordedEvent(recorder, "Normal FinalizerAdded") {
+		t.Error("Expected a FinalizerAdded event on the first reconcile")
+	}
+
+	if err := client.Get(ctx, req.NamespacedName, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if err := client.Delete(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to delete NodePool: %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v during deletion", err)
+	}
+	if !recordedEvent(recorder, "Normal CleanupComplete Deleted 2 servers and instances") {
+		t.Error("Expected a CleanupComplete event counting the 2 deleted servers")
+	}
+}
+
+// recordedEvent reports whether the recorder recorded an event starting with prefix,
+// consuming the events recorded so far
+func recordedEvent(recorder *record.FakeRecorder, prefix string) bool {
+	found := false
+	for {
+		select {
+		case event := <-recorder.Events:
+			if strings.HasPrefix(event, prefix) {
+				found = true
+			}
+		default:
+			return found
+		}
+	}
+}
+
+func TestNodePoolReconciler_DrainMode(t *testing.T) {
+	tests := []struct {
+		mode              hcloudv1alpha1.DrainMode
+		wantUnschedulable bool
+		wantPodDeleted    bool
+	}{
+		{mode: hcloudv1alpha1.DrainModeDrain, wantUnschedulable: true, wantPodDeleted: true},
+		{mode: hcloudv1alpha1.DrainModeCordonOnly, wantUnschedulable: true, wantPodDeleted: false},
+		{mode: hcloudv1alpha1.DrainModeNone, wantUnschedulable: false, wantPodDeleted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			ctx := context.Background()
+
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "test-pool-1a2b"}}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec:       corev1.PodSpec{NodeName: "test-pool-1a2b"},
+			}
+			kubeClient := clientfake.NewClientBuilder().
+				WithScheme(reconciler.Scheme).
+				WithObjects(node, pod).
+				WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+					return []string{obj.(*corev1.Pod).Spec.NodeName}
+				}).
+				Build()
+			reconciler.Client = kubeClient
+
+			nodePool := &hcloudv1alpha1.NodePool{
+				Spec: hcloudv1alpha1.NodePoolSpec{DrainMode: tt.mode},
+			}
+			if err := reconciler.drainNode(ctx, nodePool, "test-pool-1a2b"); err != nil {
+				t.Fatalf("drainNode() error = %v", err)
+			}
+
+			if err := kubeClient.Get(ctx, client.ObjectKeyFromObject(node), node); err != nil {
+				t.Fatalf("Failed to get node: %v", err)
+			}
+			if node.Spec.Unschedulable != tt.wantUnschedulable {
+				t.Errorf("node unschedulable = %v, want %v", node.Spec.Unschedulable, tt.wantUnschedulable)
+			}
+			err := kubeClient.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{})
+			if podDeleted := apierrors.IsNotFound(err); podDeleted != tt.wantPodDeleted {
+				t.Errorf("pod deleted = %v, want %v", podDeleted, tt.wantPodDeleted)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_DrainSkipsPods(t *testing.T) {
+	const nodeName = "test-pool-1a2b"
+	controller := true
+	controlledBy := func(kind string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: "owner", UID: "uid", Controller: &controller}}
+	}
+	emptyDir := []corev1.Volume{{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	newPods := func() []client.Object {
+		return []client.Object{
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "replicaset", Namespace: "default", OwnerReferences: controlledBy("ReplicaSet")},
+				Spec:       corev1.PodSpec{NodeName: nodeName},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "daemonset", Namespace: "default", OwnerReferences: controlledBy("DaemonSet")},
+				Spec:       corev1.PodSpec{NodeName: nodeName},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "mirror",
+					Namespace:   "kube-system",
+					Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "hash"},
+				},
+				Spec: corev1.PodSpec{NodeName: nodeName},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "emptydir", Namespace: "default", OwnerReferences: controlledBy("ReplicaSet")},
+				Spec:       corev1.PodSpec{NodeName: nodeName, Volumes: emptyDir},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "prometheus", Namespace: "monitoring", OwnerReferences: controlledBy("StatefulSet")},
+				Spec:       corev1.PodSpec{NodeName: nodeName},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "fluentd",
+					Namespace:       "default",
+					Labels:          map[string]string{"app": "logging"},
+					OwnerReferences: controlledBy("ReplicaSet"),
+				},
+				Spec: corev1.PodSpec{NodeName: nodeName},
+			},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		deleteEmptyDirData bool
+		excludeNamespaces  []string
+		excludeSelectors   []metav1.LabelSelector
+		wantEvicted        []string
+	}{
+		{name: "default", wantEvicted: []string{"fluentd", "prometheus", "replicaset"}},
+		{name: "delete emptyDir data", deleteEmptyDirData: true, wantEvicted: []string{"emptydir", "fluentd", "prometheus", "replicaset"}},
+		{name: "excluded namespace", excludeNamespaces: []string{"monitoring"}, wantEvicted: []string{"fluentd", "replicaset"}},
+		{
+			name:              "excluded pods",
+			excludeNamespaces: []string{"monitoring"},
+			excludeSelectors:  []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "logging"}}},
+			wantEvicted:       []string{"replicaset"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			ctx := context.Background()
+
+			var evicted []string
+			var gracePeriods []*int64
+			kubeClient := clientfake.NewClientBuilder().
+				WithScheme(reconciler.Scheme).
+				WithObjects(append(newPods(), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})...).
+				WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+					return []string{obj.(*corev1.Pod).Spec.NodeName}
+				}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					SubResourceCreate: func(ctx context.Context, c client.Client, subResource string,
+						obj, body client.Object, opts ...client.SubResourceCreateOption) error {
+						evicted = append(evicted, obj.GetName())
+						if eviction, ok := body.(*policyv1.Eviction); ok && eviction.DeleteOptions != nil {
+							gracePeriods = append(gracePeriods, eviction.DeleteOptions.GracePeriodSeconds)
+						}
+						return c.SubResource(subResource).Create(ctx, obj, body, opts...)
+					},
+				}).
+				Build()
+			reconciler.Client = kubeClient
+
+			gracePeriod := int64(30)
+			nodePool := &hcloudv1alpha1.NodePool{
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					DrainMode:                hcloudv1alpha1.DrainModeDrain,
+					DrainGracePeriodSeconds:  &gracePeriod,
+					DrainDeleteEmptyDirData:  tt.deleteEmptyDirData,
+					DrainExcludeNamespaces:   tt.excludeNamespaces,
+					DrainExcludePodSelectors: tt.excludeSelectors,
+				},
+			}
+			if err := reconciler.drainNode(ctx, nodePool, nodeName); err != nil {
+				t.Fatalf("drainNode() error = %v", err)
+			}
+
+			sort.Strings(evicted)
+			if !reflect.DeepEqual(evicted, tt.wantEvicted) {
+				t.Errorf("evicted pods = %v, want %v", evicted, tt.wantEvicted)
+			}
+			for _, gracePeriod := range gracePeriods {
+				if gracePeriod == nil || *gracePeriod != 30 {
+					t.Errorf("eviction grace period = %v, want 30", gracePeriod)
+				}
+			}
+			if len(gracePeriods) != len(tt.wantEvicted) {
+				t.Errorf("Expected every eviction to set the grace period, got %d of %d", len(gracePeriods), len(tt.wantEvicted))
+			}
+			for _, obj := range newPods() {
+				err := kubeClient.Get(ctx, client.ObjectKeyFromObject(obj), &corev1.Pod{})
+				wantDeleted := slices.Contains(tt.wantEvicted, obj.GetName())
+				if deleted := apierrors.IsNotFound(err); deleted != wantDeleted {
+					t.Errorf("pod %s deleted = %v, want %v", obj.GetName(), deleted, wantDeleted)
+				}
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_DrainFailurePolicy(t *testing.T) {
+	const nodeName = "test-pool-1a2b"
+	tests := []struct {
+		policy      hcloudv1alpha1.DrainFailurePolicy
+		wantErr     bool
+		wantDeletes int
+	}{
+		{policy: hcloudv1alpha1.DrainFailurePolicyProceed, wantErr: false, wantDeletes: 1},
+		{policy: hcloudv1alpha1.DrainFailurePolicyAbort, wantErr: true, wantDeletes: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			ctx := context.Background()
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec:       corev1.PodSpec{NodeName: nodeName},
+			}
+			reconciler.Client = clientfake.NewClientBuilder().
+				WithScheme(reconciler.Scheme).
+				WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, pod).
+				WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+					return []string{obj.(*corev1.Pod).Spec.NodeName}
+				}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					// A PodDisruptionBudget blocks the eviction
+					SubResourceCreate: func(_ context.Context, _ client.Client, _ string,
+						_, _ client.Object, _ ...client.SubResourceCreateOption) error {
+						return apierrors.NewTooManyRequests("cannot evict pod as it would violate the pod's disruption budget", 10)
+					},
+				}).
+				Build()
+			mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+			server, err := mockHetzner.CreateServer(ctx, hetzner.ServerConfig{Name: nodeName})
+			if err != nil {
+				t.Fatalf("Failed to create server: %v", err)
+			}
+
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					Provider:           hcloudv1alpha1.CloudProviderHetzner,
+					DrainMode:          hcloudv1alpha1.DrainModeDrain,
+					DrainFailurePolicy: tt.policy,
+				},
+			}
+			err = reconciler.deleteServer(ctx, nodePool, *server)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("deleteServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if mockHetzner.DeleteServerCalls != tt.wantDeletes {
+				t.Errorf("DeleteServer calls = %d, want %d", mockHetzner.DeleteServerCalls, tt.wantDeletes)
+			}
+
+			op, ok := reconciler.DeadLetterQueue.Get("default/test-pool/node/" + nodeName)
+			if ok != tt.wantErr {
+				t.Fatalf("dead letter queue entry present = %v, want %v", ok, tt.wantErr)
+			}
+			if ok && op.OperationType != operationDrainFailed {
+				t.Errorf("dead letter queue operation type = %q, want %q", op.OperationType, operationDrainFailed)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_BelowMinimum(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+
+	// Fail the first creation only
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		if mockHetzner.CreateServerCalls == 1 {
+			return nil, &hetzner.ServerCreateError{Message: "simulated error"}
+		}
+		return &hetzner.Server{ID: int64(mockHetzner.CreateServerCalls), Name: config.Name, Status: "running"}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 3,
+			MaxNodes: 5,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+			Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+				Type:              hcloudv1alpha1.ClusterTypeKubeadm,
+				AutoGenerateToken: true,
+			},
+		},
+	}
+
+	created, err := reconciler.ensureMinNodes(context.Background(), nodePool, &poolServers{}, 0, defaultMaxCreatesPerReconcile)
+	if err == nil {
+		t.Error("Expected error from failed server creation")
+	}
+	// A failed creation must not stop the remaining nodes of the floor from being created
+	if mockHetzner.CreateServerCalls != 3 {
+		t.Errorf("CreateServer called %d times, want 3", mockHetzner.CreateServerCalls)
+	}
+	if created != 2 {
+		t.Errorf("ensureMinNodes() created = %d, want 2", created)
+	}
+
+	setBelowMinimumCondition(nodePool, created, err)
+	condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionBelowMinimum)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Fatalf("Expected %s condition to be true, got %+v", conditionBelowMinimum, condition)
+	}
+
+	setBelowMinimumCondition(nodePool, 3, nil)
+	condition = meta.FindStatusCondition(nodePool.Status.Conditions, conditionBelowMinimum)
+	if condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("Expected %s condition to be false, got %+v", conditionBelowMinimum, condition)
+	}
+	if len(nodePool.Status.Conditions) != 1 {
+		t.Errorf("Expected a single %s condition, got %d conditions", conditionBelowMinimum, len(nodePool.Status.Conditions))
+	}
+}
+
+func TestNodePoolReconciler_MaxCreatesPerReconcile(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	client := setupStatusClient(reconciler)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "runaway-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:    hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes:    3,
+			MaxNodes:    1000,
+			TargetNodes: 1000,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "runaway-pool", Namespace: "default"}}
+	for i, want := range []int{defaultMaxCreatesPerReconcile, 2 * defaultMaxCreatesPerReconcile} {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() #%d error = %v", i+1, err)
+		}
+		if mockHetzner.CreateServerCalls != want {
+			t.Errorf("CreateServer called %d times after reconcile #%d, want %d", mockHetzner.CreateServerCalls, i+1, want)
+		}
+	}
+}
+
+func TestSetReadyStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		readyNodes int
+		minNodes   int
+		wantPhase  string
+		wantStatus metav1.ConditionStatus
+	}{
+		{name: "no nodes joined", readyNodes: 0, minNodes: 2, wantPhase: phaseScaling, wantStatus: metav1.ConditionFalse},
+		{name: "below minimum", readyNodes: 1, minNodes: 2, wantPhase: phaseScaling, wantStatus: metav1.ConditionFalse},
+		{name: "minimum ready", readyNodes: 2, minNodes: 2, wantPhase: phaseReady, wantStatus: metav1.ConditionTrue},
+		{name: "no minimum", readyNodes: 0, minNodes: 0, wantPhase: phaseReady, wantStatus: metav1.ConditionTrue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodePool := &hcloudv1alpha1.NodePool{
+				Spec:   hcloudv1alpha1.NodePoolSpec{MinNodes: tt.minNodes},
+				Status: hcloudv1alpha1.NodePoolStatus{ReadyNodes: tt.readyNodes},
+			}
+
+			setReadyStatus(nodePool)
+
+			if nodePool.Status.Phase != tt.wantPhase {
+				t.Errorf("Phase = %q, want %q", nodePool.Status.Phase, tt.wantPhase)
+			}
+			condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionReady)
+			if condition == nil || condition.Status != tt.wantStatus {
+				t.Errorf("Expected %s condition to be %s, got %+v", conditionReady, tt.wantStatus, condition)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_IPv6Only(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+
+	var created hetzner.ServerConfig
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		created = config
+		return &hetzner.Server{ID: 1, Name: config.Name, Status: "running"}, nil
+	}
+
+	disabled := false
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+				EnableIPv4: &disabled,
+			},
+		},
+	}
+
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if !created.DisableIPv4 || created.DisableIPv6 {
+		t.Errorf("Expected IPv6-only server, got DisableIPv4=%v DisableIPv6=%v", created.DisableIPv4, created.DisableIPv6)
+	}
+
+	// Disabling both address families requires a private network
+	nodePool.Spec.HetznerConfig.EnableIPv6 = &disabled
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err == nil {
+		t.Error("Expected error when both IPv4 and IPv6 are disabled without a network")
+	}
+
+	nodePool.Spec.HetznerConfig.Network = "private"
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err != nil {
+		t.Errorf("createServer() error = %v", err)
+	}
+}
+
+func TestNodePoolReconciler_Volumes(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var created hetzner.ServerConfig
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		created = config
+		return &hetzner.Server{ID: 1, Name: config.Name, Status: "running", VolumeIDs: config.VolumeIDs}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+				Volumes: []hcloudv1alpha1.HetznerVolume{
+					{Size: 50, MountPath: "/var/lib/longhorn"},
+				},
+			},
+			Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+				Type:              hcloudv1alpha1.ClusterTypeKubeadm,
+				AutoGenerateToken: true,
+			},
+		},
+	}
+
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	volumes := mockHetzner.GetVolumes()
+	if len(volumes) != 1 || len(created.VolumeIDs) != 1 || volumes[created.VolumeIDs[0]] == nil {
+		t.Fatalf("Expected server to be created with its volume, got %v and volumes %v", created.VolumeIDs, volumes)
+	}
+	mount := "/dev/disk/by-id/scsi-0HC_Volume_1 /var/lib/longhorn ext4"
+	if !strings.Contains(created.UserData, mount) {
+		t.Errorf("Expected cloud-init to mount the volume with %q", mount)
+	}
+
+	mockHetzner.DeleteServerFunc = func(_ context.Context, _ int64) error { return nil }
+	server := hetzner.Server{ID: 1, Name: created.Name, VolumeIDs: created.VolumeIDs}
+	if err := reconciler.deleteServer(ctx, nodePool, server); err != nil {
+		t.Fatalf("deleteServer() error = %v", err)
+	}
+	if len(mockHetzner.GetVolumes()) != 0 {
+		t.Errorf("Expected the volume to be deleted with the server, got %v", mockHetzner.GetVolumes())
+	}
+
+	// Volumes of a server that fails to be created are deleted
+	mockHetzner.CreateServerFunc = func(_ context.Context, _ hetzner.ServerConfig) (*hetzner.Server, error) {
+		return nil, &hetzner.ServerCreateError{Message: "simulated error"}
+	}
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err == nil {
+		t.Fatal("createServer() expected error")
+	}
+	if len(mockHetzner.GetVolumes()) != 0 {
+		t.Errorf("Expected volumes to be rolled back, got %v", mockHetzner.GetVolumes())
+	}
+}
+
+func TestNodePoolReconciler_CreateServerUniqueNames(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var names []string
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		names = append(names, config.Name)
+		return &hetzner.Server{ID: int64(len(names)), Name: config.Name, Status: "running"}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+			t.Fatalf("createServer() #%d error = %v", i+1, err)
+		}
+	}
+
+	if len(names) != 2 || names[0] == names[1] {
+		t.Fatalf("Expected two servers with distinct names, got %v", names)
+	}
+	for _, name := range names {
+		if !strings.HasPrefix(name, "test-pool-") || len(name) != len("test-pool-")+2*serverNameSuffixBytes {
+			t.Errorf("Server name %q doesn't match <pool>-<4 hex characters>", name)
+		}
+	}
+	if len(nodePool.Status.Nodes) != 2 {
+		t.Errorf("Expected created servers to be recorded in the status, got %v", nodePool.Status.Nodes)
+	}
+}
+
+func TestNodePoolReconciler_AdoptsLabeledServers(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	// Servers provisioned by hand that already carry the pool's labels
+	mockHetzner.SetServers(map[int64]*hetzner.Server{
+		101: {ID: 101, Name: "manual-1", Status: "running", ServerType: "cx11", Image: "ubuntu-22.04", Location: "nbg1"},
+		102: {ID: 102, Name: "manual-2", Status: "running", ServerType: "cx21", Image: "ubuntu-22.04", Location: "nbg1"},
+	})
+
+	client := setupStatusClient(reconciler)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "adopting-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:    hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes:    1,
+			MaxNodes:    5,
+			TargetNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "adopting-pool", Namespace: "default"}
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if mockHetzner.CreateServerCalls != 1 {
+		t.Errorf("CreateServer called %d times, want 1 to fill the gap to 3", mockHetzner.CreateServerCalls)
+	}
+
+	if err := client.Get(ctx, key, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	for _, name := range []string{"manual-1", "manual-2"} {
+		if !slices.Contains(nodePool.Status.Nodes, name) {
+			t.Errorf("Expected adopted server %s in Status.Nodes, got %v", name, nodePool.Status.Nodes)
+		}
+	}
+	condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionServersMatchSpec)
+	if condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Fatalf("Expected %s condition to be False, got %+v", conditionServersMatchSpec, condition)
+	}
+	if want := "manual-2: server type cx21, want cx11"; condition.Message != want {
+		t.Errorf("Condition message = %q, want %q", condition.Message, want)
+	}
+
+	// The condition clears once the mismatched server is gone
+	if err := mockHetzner.DeleteServer(ctx, 102); err != nil {
+		t.Fatalf("DeleteServer() error = %v", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := client.Get(ctx, key, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionServersMatchSpec) {
+		t.Errorf("Expected %s condition to be True, got %+v", conditionServersMatchSpec,
+			meta.FindStatusCondition(nodePool.Status.Conditions, conditionServersMatchSpec))
+	}
+}
+
+func TestNodePoolReconciler_CreateServerNameTemplate(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var names []string
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		names = append(names, config.Name)
+		return &hetzner.Server{ID: int64(len(names)), Name: config.Name, Status: "running"}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "prod",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:         hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes:         3,
+			NodeNameTemplate: "{{.Namespace}}-{{.Pool}}-{{.Index}}",
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+		Status: hcloudv1alpha1.NodePoolStatus{
+			Nodes: []string{"prod-web-1"},
+		},
+	}
+
+	// Indexes fill the gaps left by deleted nodes
+	for i := 0; i < 2; i++ {
+		if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+			t.Fatalf("createServer() #%d error = %v", i+1, err)
+		}
+	}
+	if want := []string{"prod-web-0", "prod-web-2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Server names = %v, want %v", names, want)
+	}
+
+	nodePool.Spec.NodeNameTemplate = "cmdb-{{.Random}}"
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if name := names[len(names)-1]; !strings.HasPrefix(name, "cmdb-") || len(name) != len("cmdb-")+2*serverNameSuffixBytes {
+		t.Errorf("Server name %q doesn't match cmdb-<4 hex characters>", name)
+	}
+
+	for _, invalid := range []string{
+		"{{.Pool}}_{{.Index}}",
+		"Web-{{.Random}}",
+		"{{.Pool}}-" + strings.Repeat("x", 60),
+		"{{.Pool}}-{{.Zone}}",
+		"{{.Pool",
+	} {
+		nodePool.Spec.NodeNameTemplate = invalid
+		if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err == nil {
+			t.Errorf("createServer() expected error for nodeNameTemplate %q", invalid)
+		}
+	}
+	if mockHetzner.CreateServerCalls != 3 {
+		t.Errorf("CreateServer called %d times, want 3", mockHetzner.CreateServerCalls)
+	}
+}
+
+func TestNodePoolReconciler_CreateServerCloudInitTemplate(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var config hetzner.ServerConfig
+	mockHetzner.CreateServerFunc = func(_ context.Context, c hetzner.ServerConfig) (*hetzner.Server, error) {
+		config = c
+		return &hetzner.Server{ID: 1, Name: c.Name, Status: "running"}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "prod",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:  hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes:  3,
+			CloudInit: "#cloud-config\nhostname: {{.NodeName}}\nruncmd:\n  - echo {{.PoolName}}/{{.Namespace}} on {{.Provider}}\n",
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	want := "#cloud-config\nhostname: " + config.Name + "\nruncmd:\n  - echo web/prod on hetzner\n"
+	if config.UserData != want {
+		t.Errorf("UserData = %q, want %q", config.UserData, want)
+	}
+
+	// Cloud-init without template actions and Jinja templates are passed through as is
+	for _, literal := range []string{
+		"#cloud-config\nruncmd:\n  - echo hello\n",
+		"## template: jinja\n#cloud-config\nhostname: {{ v1.local_hostname }}\n",
+	} {
+		nodePool.Spec.CloudInit = literal
+		if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+			t.Fatalf("createServer() error = %v", err)
+		}
+		if config.UserData != literal {
+			t.Errorf("UserData = %q, want %q", config.UserData, literal)
+		}
+	}
+
+	for _, invalid := range []string{"hostname: {{.NodeName", "hostname: {{.Zone}}"} {
+		nodePool.Spec.CloudInit = invalid
+		if err := reconciler.createServer(ctx, nodePool, &poolServers{}); !errors.Is(err, errInvalidBootstrapConfig) {
+			t.Errorf("createServer() error = %v for cloudInit %q, want an invalid bootstrap configuration", err, invalid)
+		}
+	}
+}
+
+func TestNodePoolReconciler_CreateServerSpreadsLocations(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Locations:  []string{"nbg1", "fsn1", "hel1"},
+			},
+		},
+	}
+
+	listed := &poolServers{}
+	for i := 0; i < 3; i++ {
+		if err := reconciler.createServer(ctx, nodePool, listed); err != nil {
+			t.Fatalf("createServer() #%d error = %v", i+1, err)
+		}
+	}
+
+	servers, err := mockHetzner.ListServers(ctx, "test-pool", "default")
+	if err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+	perLocation := map[string]int{}
+	for _, server := range servers {
+		perLocation[server.Location]++
+	}
+	for _, location := range nodePool.Spec.HetznerConfig.Locations {
+		if perLocation[location] != 1 {
+			t.Errorf("Expected one server in %s, got servers per location %v", location, perLocation)
+		}
+	}
+}
+
+func TestNodePoolReconciler_CreateServerBootsISO(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var attached []string
+	attachErr := error(nil)
+	mockHetzner.AttachISOFunc = func(_ context.Context, serverID int64, iso string) error {
+		attached = append(attached, fmt.Sprintf("%d:%s", serverID, iso))
+		return attachErr
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+				BootMode:   hcloudv1alpha1.BootModeISO,
+				ISOName:    "talos-v1.7.0",
+			},
+		},
+	}
+
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if want := []string{"1:talos-v1.7.0"}; !reflect.DeepEqual(attached, want) {
+		t.Errorf("AttachISO calls = %v, want %v", attached, want)
+	}
+
+	// A server that can't boot the ISO is deleted
+	attachErr = errors.New("iso not found")
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err == nil {
+		t.Fatal("createServer() expected error when the ISO can't be attached")
+	}
+	if mockHetzner.DeleteServerCalls != 1 {
+		t.Errorf("DeleteServer called %d times, want 1", mockHetzner.DeleteServerCalls)
+	}
+
+	// Servers booting their image don't get an ISO
+	nodePool.Spec.HetznerConfig.BootMode = hcloudv1alpha1.BootModeImage
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if mockHetzner.AttachISOCalls != 2 {
+		t.Errorf("AttachISO called %d times, want 2", mockHetzner.AttachISOCalls)
+	}
+}
+
+func TestNodePoolReconciler_LoadBalancer(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var added, removed []string
+	addErr := error(nil)
+	mockHetzner.AddServerToLoadBalancerFunc = func(_ context.Context, lb string, serverID int64, usePrivateIP bool) error {
+		added = append(added, fmt.Sprintf("%s:%d:%t", lb, serverID, usePrivateIP))
+		return addErr
+	}
+	mockHetzner.RemoveServerFromLoadBalancerFunc = func(_ context.Context, lb string, serverID int64) error {
+		removed = append(removed, fmt.Sprintf("%s:%d", lb, serverID))
+		return nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:  hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes:  3,
+			DrainMode: hcloudv1alpha1.DrainModeNone,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType:   "cx11",
+				Image:        "ubuntu-22.04",
+				Location:     "nbg1",
+				LoadBalancer: "ingress",
+			},
+		},
+	}
+
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if want := []string{"ingress:1:false"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("AddServerToLoadBalancer calls = %v, want %v", added, want)
+	}
+
+	// A server that can't be registered is deleted like any other server of the pool
+	addErr = errors.New("load balancer not found")
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err == nil {
+		t.Fatal("createServer() expected error when the server can't be added to the load balancer")
+	}
+	if mockHetzner.DeleteServerCalls != 1 {
+		t.Errorf("DeleteServer called %d times, want 1", mockHetzner.DeleteServerCalls)
+	}
+	if want := []string{"ingress:2"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("RemoveServerFromLoadBalancer calls = %v, want %v", removed, want)
+	}
+	servers, _ := mockHetzner.ListServers(ctx, "test-pool", "default")
+	if len(servers) != 1 {
+		t.Errorf("Expected only the registered server to remain, got %+v", servers)
+	}
+
+	// Deleted servers are deregistered first
+	if err := reconciler.deleteServer(ctx, nodePool, servers[0]); err != nil {
+		t.Fatalf("deleteServer() error = %v", err)
+	}
+	if want := []string{"ingress:2", fmt.Sprintf("ingress:%d", servers[0].ID)}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("RemoveServerFromLoadBalancer calls = %v, want %v", removed, want)
+	}
+
+	// Servers on a private network are registered by their private IP
+	nodePool.Spec.HetznerConfig.Network = "private"
+	addErr = nil
+	added = nil
+	if err := reconciler.createServer(ctx, nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if len(added) != 1 || !strings.HasSuffix(added[0], ":true") {
+		t.Errorf("AddServerToLoadBalancer calls = %v, want a private IP registration", added)
+	}
+}
+
+func TestNodePoolReconciler_FloatingIPs(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	var assigned []string
+	assignErr := error(nil)
+	mockHetzner.AssignFloatingIPFunc = func(_ context.Context, serverID int64, floatingIPs []string) (string, error) {
+		assigned = append(assigned, fmt.Sprintf("%d:%s", serverID, strings.Join(floatingIPs, ",")))
+		return "203.0.113.10", assignErr
+	}
+	var unassigned []int64
+	mockHetzner.UnassignFloatingIPsFunc = func(_ context.Context, serverID int64) error {
+		unassigned = append(unassigned, serverID)
+		return nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType:  "cx11",
+				Image:       "ubuntu-22.04",
+				Location:    "nbg1",
+				FloatingIPs: []string{"ingress-1", "ingress-2"},
+			},
+		},
+	}
+
+	listed := &poolServers{}
+	if err := reconciler.createServer(ctx, nodePool, listed); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if want := []string{"1:ingress-1,ingress-2"}; !reflect.DeepEqual(assigned, want) {
+		t.Errorf("AssignFloatingIP calls = %v, want %v", assigned, want)
+	}
+
+	// Servers are still created once all floating IPs are assigned
+	assignErr = hetzner.ErrNoFloatingIPAvailable
+	if err := reconciler.createServer(ctx, nodePool, listed); err != nil {
+		t.Fatalf("createServer() error = %v when no floating IP is available", err)
+	}
+	if len(listed.hetzner) != 2 {
+		t.Fatalf("Expected 2 servers, got %d", len(listed.hetzner))
+	}
+
+	// Deleted servers release their floating IP
+	if err := reconciler.deleteServer(ctx, nodePool, listed.hetzner[0]); err != nil {
+		t.Fatalf("deleteServer() error = %v", err)
+	}
+	if want := []int64{listed.hetzner[0].ID}; !reflect.DeepEqual(unassigned, want) {
+		t.Errorf("UnassignFloatingIPs calls = %v, want %v", unassigned, want)
+	}
+}
+
+func TestNodePoolReconciler_PrimaryIPs(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+				PrimaryIPs: []string{"ingress-ipv4"},
+			},
+		},
+	}
+
+	listed := &poolServers{}
+	if err := reconciler.createServer(ctx, nodePool, listed); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if want := map[string]int64{"ingress-ipv4": listed.hetzner[0].ID}; !reflect.DeepEqual(mockHetzner.GetPrimaryIPAssignments(), want) {
+		t.Errorf("primary IP assignments = %v, want %v", mockHetzner.GetPrimaryIPAssignments(), want)
+	}
+
+	// The only primary IP is taken by the first server
+	if err := reconciler.createServer(ctx, nodePool, listed); !errors.Is(err, hetzner.ErrPrimaryIPAssigned) {
+		t.Fatalf("createServer() error = %v, want ErrPrimaryIPAssigned", err)
+	}
+
+	// A replacement server reuses the primary IP of the deleted one
+	if err := reconciler.deleteServer(ctx, nodePool, listed.hetzner[0]); err != nil {
+		t.Fatalf("deleteServer() error = %v", err)
+	}
+	if err := reconciler.createServer(ctx, nodePool, listed); err != nil {
+		t.Fatalf("createServer() error = %v after the primary IP was released", err)
+	}
+	if want := map[string]int64{"ingress-ipv4": listed.hetzner[1].ID}; !reflect.DeepEqual(mockHetzner.GetPrimaryIPAssignments(), want) {
+		t.Errorf("primary IP assignments = %v, want %v", mockHetzner.GetPrimaryIPAssignments(), want)
+	}
+}
+
+func TestNodePoolReconciler_RecoverServersMissingFromListing(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Status: hcloudv1alpha1.NodePoolStatus{
+			Nodes: []string{"test-pool-1a2b", "test-pool-3c4d", "test-pool-5e6f", "test-pool-7a8b"},
+		},
+	}
+
+	mockHetzner.SetServers(map[int64]*hetzner.Server{
+		1: {ID: 1, Name: "test-pool-1a2b", Status: "running", Labels: poolResourceLabels(nodePool)},
+		2: {ID: 2, Name: "test-pool-3c4d", Status: "running", Labels: poolResourceLabels(nodePool)},
+		// A server of another pool that happens to have a recorded name
+		3: {ID: 3, Name: "test-pool-7a8b", Status: "running", Labels: map[string]string{
+			"managed-by": "nodepools",
+			"nodepool":   "test-pool",
+			"namespace":  "other",
+		}},
+	})
+
+	// Only the first server is returned by the label-based listing
+	listed := []hetzner.Server{{ID: 1, Name: "test-pool-1a2b", Status: "running"}}
+
+	servers := reconciler.recoverHetznerServers(context.Background(), nodePool, listed)
+	if len(servers) != 2 {
+		t.Fatalf("recoverHetznerServers() returned %d servers, want 2", len(servers))
+	}
+	if servers[1].ID != 2 {
+		t.Errorf("Expected server 2 to be recovered, got %+v", servers[1])
+	}
+	// The deleted and the foreign server are looked up but not recovered
+	if mockHetzner.GetServerByNameCalls != 3 {
+		t.Errorf("GetServerByName called %d times, want 3", mockHetzner.GetServerByNameCalls)
+	}
+}
+
+func TestNodePoolReconciler_RecoverInstancesMissingFromListing(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockOVH := mock.NewMockOVHcloudClient()
+	reconciler.OVHCloudClient = mockOVH
+
+	for _, name := range []string{"default-test-pool-1a2b", "default-test-pool-3c4d", "legacy-instance"} {
+		if _, err := mockOVH.CreateInstance(context.Background(), ovhcloud.InstanceConfig{Name: name}); err != nil {
+			t.Fatalf("Failed to create instance: %v", err)
+		}
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Status: hcloudv1alpha1.NodePoolStatus{
+			Nodes: []string{"default-test-pool-1a2b", "default-test-pool-3c4d", "legacy-instance"},
+		},
+	}
+
+	// Only the first instance is returned by the name-based listing
+	listed := []ovhcloud.Instance{{ID: "1", Name: "default-test-pool-1a2b"}}
+
+	instances := reconciler.recoverOVHInstances(context.Background(), nodePool, listed)
+	if len(instances) != 2 {
+		t.Fatalf("recoverOVHInstances() returned %d instances, want 2", len(instances))
+	}
+	// The instance without the pool's name prefix may belong to anyone
+	if instances[1].Name != "default-test-pool-3c4d" {
+		t.Errorf("Expected instance default-test-pool-3c4d to be recovered, got %+v", instances[1])
+	}
+}
+
+func TestNodePoolReconciler_AdoptUnrecordedServers(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Status: hcloudv1alpha1.NodePoolStatus{
+			Nodes: []string{"test-pool-1a2b"},
+		},
+	}
+
+	// The second server was created right before the operator crashed, so its name never
+	// made it into the status
+	reconciler.adoptUnrecordedServers(context.Background(), nodePool, []string{"test-pool-1a2b", "test-pool-3c4d"})
+
+	recorder := reconciler.Recorder.(*record.FakeRecorder)
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected 1 event for the unrecorded server, got %d", len(recorder.Events))
+	}
+	if !recordedEvent(recorder, "Normal ServerAdopted Adopted server test-pool-3c4d") {
+		t.Error("Expected a ServerAdopted event for the unrecorded server")
+	}
+}
+
+func TestNodePoolReconciler_AnnotationsPropagation(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+	mockOVH := mock.NewMockOVHcloudClient()
+	reconciler.OVHCloudClient = mockOVH
+
+	var serverLabels map[string]string
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		serverLabels = config.Labels
+		return &hetzner.Server{ID: 1, Name: config.Name, Status: "running"}, nil
+	}
+	var instanceLabels map[string]string
+	mockOVH.CreateInstanceFunc = func(_ context.Context, config ovhcloud.InstanceConfig) (*ovhcloud.Instance, error) {
+		instanceLabels = config.Labels
+		return &ovhcloud.Instance{ID: "instance-1", Name: config.Name}, nil
+	}
+
+	annotations := map[string]string{
+		"cost-center":         "Team A",
+		"example.com/project": "web",
+		"nodepool":            "other",
+		"!!!":                 "invalid",
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+			OVHcloudConfig: &hcloudv1alpha1.OVHcloudConfig{
+				Region:   "GRA7",
+				FlavorID: "flavor",
+				ImageID:  "image",
+			},
+			Annotations: annotations,
+		},
+	}
+
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+
+	wantHetzner := map[string]string{
+		"cost-center":         "Team-A",
+		"example.com/project": "web",
+		"nodepool":            "test-pool",
+	}
+	for k, v := range wantHetzner {
+		if serverLabels[k] != v {
+			t.Errorf("ServerConfig.Labels[%q] = %q, want %q", k, serverLabels[k], v)
+		}
+	}
+	if _, exists := serverLabels["!!!"]; exists {
+		t.Error("Invalid annotation should not be propagated to Hetzner labels")
+	}
+	recorder, ok := reconciler.Recorder.(*record.FakeRecorder)
+	if !ok {
+		t.Fatal("Failed to cast Recorder to fake recorder")
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "InvalidAnnotation") || !strings.Contains(event, "!!!") {
+			t.Errorf("Unexpected event %q", event)
+		}
+	default:
+		t.Error("Expected a warning event for the invalid annotation")
+	}
+
+	// The pool is warned about its invalid annotations once, not for every server
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if recordedEvent(recorder, "Warning InvalidAnnotation") {
+		t.Error("Expected no repeated warning event for the same invalid annotation")
+	}
+
+	// OVHcloud instances can't store annotations
+	nodePool.Spec.Provider = hcloudv1alpha1.CloudProviderOVHcloud
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+	if _, exists := instanceLabels["cost-center"]; exists {
+		t.Error("Annotations should not be propagated to OVHcloud instances")
+	}
+	if instanceLabels["nodepool"] != "test-pool" {
+		t.Errorf("InstanceConfig.Labels[nodepool] = %q, want %q", instanceLabels["nodepool"], "test-pool")
+	}
+
+	// Scaleway stores them as key=value tags
+	nodePool.Spec.Provider = hcloudv1alpha1.CloudProviderScaleway
+	if got := reconciler.providerAnnotations(nodePool); !reflect.DeepEqual(got, annotations) {
+		t.Errorf("providerAnnotations() = %v, want %v", got, annotations)
+	}
+}
+
+// metricValue returns the value of a counter or gauge, or the sample count of a histogram,
+// among the gathered metrics with the given labels
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if !hasLabels(metric, labels) {
+				continue
+			}
+			if histogram := metric.GetHistogram(); histogram != nil {
+				return float64(histogram.GetSampleCount())
+			}
+			if gauge := metric.GetGauge(); gauge != nil {
+				return gauge.GetValue()
+			}
+			return metric.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	matched := 0
+	for _, label := range metric.GetLabel() {
+		if value, ok := labels[label.GetName()]; ok && value == label.GetValue() {
+			matched++
+		}
+	}
+	return matched == len(labels)
+}
+
+func TestNodePoolReconciler_ProviderOperationTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      *metav1.Duration
+		wantDeadline bool
+	}{
+		{name: "pool override", timeout: &metav1.Duration{Duration: 50 * time.Millisecond}, wantDeadline: true},
+		// The client's default from the flag applies instead
+		{name: "no override", wantDeadline: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+
+			mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+			if !ok {
+				t.Fatal("Failed to cast HCloudClient to mock")
+			}
+
+			// A hanging API that only returns once the operation is cancelled
+			var hasDeadline bool
+			mockHetzner.CreateServerFunc = func(ctx context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+				_, hasDeadline = ctx.Deadline()
+				if !hasDeadline {
+					return &hetzner.Server{ID: 1, Name: config.Name, Status: "running"}, nil
+				}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pool",
+					Namespace: "default",
+				},
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					Provider: hcloudv1alpha1.CloudProviderHetzner,
+					HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+						ServerType: "cx11",
+						Image:      "ubuntu-22.04",
+						Location:   "nbg1",
+					},
+					ProviderOperationTimeout: tt.timeout,
+				},
+			}
+
+			err := reconciler.createServer(context.Background(), nodePool, &poolServers{})
+			if hasDeadline != tt.wantDeadline {
+				t.Errorf("CreateServer() context has deadline = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+			if tt.wantDeadline && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("createServer() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+			if !tt.wantDeadline && err != nil {
+				t.Errorf("createServer() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_RecordsReconcileErrors(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+	mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+		return nil, errors.New("hetzner api unavailable")
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "metrics-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:    hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes:    1,
+			MaxNodes:    3,
+			TargetNodes: 1,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(context.Background(), nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	labels := map[string]string{"nodepool": "metrics-pool", "namespace": "default"}
+	before := metricValue(t, "hcloud_operator_reconcile_errors_total", labels)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "metrics-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(context.Background(), req); err == nil {
+		t.Fatal("Reconcile() expected error when listing servers fails")
+	}
+
+	if got := metricValue(t, "hcloud_operator_reconcile_errors_total", labels) - before; got != 1 {
+		t.Errorf("reconcile errors = %v, want 1", got)
+	}
+}
+
+func TestNodePoolReconciler_RecordsDesiredSize(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	kubeClient := setupStatusClient(reconciler)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "desired-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 2,
+			// Clamped to maxNodes
+			TargetNodes: 5,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := kubeClient.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "desired-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	labels := map[string]string{"nodepool": "desired-pool", "namespace": "default", "status": "desired"}
+	if got := metricValue(t, "hcloud_operator_nodepool_size", labels); got != 2 {
+		t.Errorf("desired size = %v, want 2", got)
+	}
+}
+
+func TestNodePoolReconciler_RecordsPriority(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	kubeClient := setupStatusClient(reconciler)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "priority-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 2,
+			Priority: 20,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := kubeClient.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "priority-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	labels := map[string]string{"nodepool": "priority-pool", "namespace": "default"}
+	if got := metricValue(t, "hcloud_operator_nodepool_priority", labels); got != 20 {
+		t.Errorf("priority = %v, want 20", got)
+	}
+}
+
+func TestNodePoolReconciler_ValidateServerType(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+
+	validationErr := fmt.Errorf("%w: server type cx111 not found", hetzner.ErrServerTypeUnavailable)
+	mockHetzner.ValidateServerTypeFunc = func(_ context.Context, _, _ string) error {
+		return validationErr
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Generation: 1,
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx111",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+
+	if reconciler.validateInstanceType(context.Background(), nodePool) {
+		t.Error("validateInstanceType() = true, want false for an unavailable server type")
+	}
+	condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionServerTypeValid)
+	if condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Fatalf("Expected %s condition to be false, got %+v", conditionServerTypeValid, condition)
+	}
+
+	// The result is cached for the generation
+	if reconciler.validateInstanceType(context.Background(), nodePool) {
+		t.Error("validateInstanceType() = true, want cached false")
+	}
+	if mockHetzner.ValidateServerTypeCalls != 1 {
+		t.Errorf("ValidateServerType called %d times, want 1", mockHetzner.ValidateServerTypeCalls)
+	}
+
+	// A failing API call doesn't block provisioning and is retried
+	nodePool.Generation = 2
+	nodePool.Spec.HetznerConfig.ServerType = "cx11"
+	validationErr = fmt.Errorf("failed to get server type: timeout")
+	if !reconciler.validateInstanceType(context.Background(), nodePool) {
+		t.Error("validateInstanceType() = false, want true when validation could not run")
+	}
+	condition = meta.FindStatusCondition(nodePool.Status.Conditions, conditionServerTypeValid)
+	if condition == nil || condition.Status != metav1.ConditionUnknown {
+		t.Fatalf("Expected %s condition to be unknown, got %+v", conditionServerTypeValid, condition)
+	}
+
+	validationErr = nil
+	if !reconciler.validateInstanceType(context.Background(), nodePool) {
+		t.Error("validateInstanceType() = false, want true for an available server type")
+	}
+	condition = meta.FindStatusCondition(nodePool.Status.Conditions, conditionServerTypeValid)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != 2 {
+		t.Errorf("Expected %s condition to be true for generation 2, got %+v", conditionServerTypeValid, condition)
+	}
+	if mockHetzner.ValidateServerTypeCalls != 3 {
+		t.Errorf("ValidateServerType called %d times, want 3", mockHetzner.ValidateServerTypeCalls)
+	}
+}
+
+func TestNodePoolReconciler_CACertHashOverride(t *testing.T) {
+	const caCertHash = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	newNodePool := func(endpoint, hash string) *hcloudv1alpha1.NodePool {
+		return &hcloudv1alpha1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-pool",
+				Namespace: "default",
+			},
+			Spec: hcloudv1alpha1.NodePoolSpec{
+				Provider: hcloudv1alpha1.CloudProviderHetzner,
+				Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+					Type:              hcloudv1alpha1.ClusterTypeKubeadm,
+					APIServerEndpoint: endpoint,
+					CACertHash:        hash,
+					AutoGenerateToken: true,
+				},
+			},
+		}
+	}
+
+	t.Run("overrides computed hash", func(t *testing.T) {
+		reconciler, _ := setupTestReconciler()
+
+		cloudInit, err := reconciler.generateCloudInit(context.Background(), newNodePool("", caCertHash), false, nil)
+		if err != nil {
+			t.Fatalf("generateCloudInit() error = %v", err)
+		}
+		if !strings.Contains(cloudInit, "--discovery-token-ca-cert-hash "+caCertHash) {
+			t.Errorf("Expected cloud-init to use the CA cert hash override")
+		}
+		// The endpoint still comes from cluster-info
+		if !strings.Contains(cloudInit, "test-cluster:6443") {
+			t.Errorf("Expected cloud-init to use the cluster-info endpoint")
+		}
+	})
+
+	t.Run("cluster-info not needed with both overrides", func(t *testing.T) {
+		reconciler, _ := setupTestReconciler()
+		if err := reconciler.KubeClient.CoreV1().ConfigMaps("kube-public").Delete(
+			context.Background(), "cluster-info", metav1.DeleteOptions{}); err != nil {
+			t.Fatalf("Failed to delete cluster-info: %v", err)
+		}
+
+		cloudInit, err := reconciler.generateCloudInit(context.Background(), newNodePool("api.example.com:6443", caCertHash), false, nil)
+		if err != nil {
+			t.Fatalf("generateCloudInit() error = %v", err)
+		}
+		if !strings.Contains(cloudInit, "api.example.com:6443") || !strings.Contains(cloudInit, caCertHash) {
+			t.Errorf("Expected cloud-init to use the endpoint and CA cert hash overrides")
+		}
+	})
+
+	t.Run("invalid hash", func(t *testing.T) {
+		reconciler, _ := setupTestReconciler()
+
+		if _, err := reconciler.generateCloudInit(context.Background(), newNodePool("", "0123456789abcdef"), false, nil); err == nil {
+			t.Error("generateCloudInit() expected error for a CA cert hash without the sha256: prefix")
+		}
+	})
+}
+
+func TestNodePoolReconciler_ClusterInfoSecret(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	// The operator can't read cluster-info
+	if err := reconciler.KubeClient.CoreV1().ConfigMaps("kube-public").Delete(ctx, "cluster-info", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete cluster-info: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), IsCA: true, BasicConstraintsValid: true, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	if _, err := reconciler.KubeClient.CoreV1().Secrets("default").Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-ca", Namespace: "default"},
+		Data: map[string][]byte{
+			"ca.crt":   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			"endpoint": []byte("https://api.example.com:6443"),
+		},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create secret: %v", err)
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+				Type:                 hcloudv1alpha1.ClusterTypeKubeadm,
+				AutoGenerateToken:    true,
+				ClusterInfoSecretRef: &hcloudv1alpha1.ClusterInfoSecretReference{Name: "cluster-ca"},
+			},
+		},
+	}
+	cloudInit, err := reconciler.generateCloudInit(ctx, nodePool, false, nil)
+	if err != nil {
+		t.Fatalf("generateCloudInit() error = %v", err)
+	}
+	if !strings.Contains(cloudInit, "api.example.com:6443") || !strings.Contains(cloudInit, "--discovery-token-ca-cert-hash sha256:") {
+		t.Errorf("Expected cloud-init to use the endpoint and CA of the cluster info secret")
+	}
+}
+
+func TestNodePoolReconciler_JoinConfigSecret(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+	ctx := context.Background()
+
+	// Nodes joining with their own configuration don't need cluster-info
+	if err := reconciler.KubeClient.CoreV1().ConfigMaps("kube-public").Delete(ctx, "cluster-info", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete cluster-info: %v", err)
+	}
+	if err := client.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "join-config", Namespace: "default"},
+		Data:       map[string][]byte{"config": []byte("kind: JoinConfiguration\n")},
+	}); err != nil {
+		t.Fatalf("Failed to create secret: %v", err)
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+				Type:                hcloudv1alpha1.ClusterTypeKubeadm,
+				AutoGenerateToken:   true,
+				JoinConfigSecretRef: &hcloudv1alpha1.SecretReference{Name: "join-config", Key: "config"},
+			},
+		},
+	}
+	cloudInit, err := reconciler.generateCloudInit(ctx, nodePool, false, nil)
+	if err != nil {
+		t.Fatalf("generateCloudInit() error = %v", err)
+	}
+	if !strings.Contains(cloudInit, "kubeadm join --config "+bootstrap.JoinConfigPath) {
+		t.Error("Expected nodes to join with the join configuration")
+	}
+
+	nodePool.Spec.Bootstrap.JoinConfigSecretRef.Key = "missing"
+	if _, err := reconciler.generateCloudInit(ctx, nodePool, false, nil); !errors.Is(err, errInvalidBootstrapConfig) {
+		t.Errorf("generateCloudInit() error = %v, want an invalid bootstrap configuration", err)
+	}
+}
+
+func TestNodePoolReconciler_TokenGenerationFailure(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	failing := true
+	reconciler.KubeClient.(*fake.Clientset).PrependReactor("*", "secrets",
+		func(_ k8stesting.Action) (bool, runtime.Object, error) {
+			if failing {
+				return true, nil, apierrors.NewServiceUnavailable("etcd unavailable")
+			}
+			return false, nil, nil
+		})
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "token-pool", Namespace: "default"},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+				Type:              hcloudv1alpha1.ClusterTypeKubeadm,
+				AutoGenerateToken: true,
+				APIServerEndpoint: "10.0.0.1:6443",
+				CACertHash:        "sha256:" + strings.Repeat("a", 64),
+			},
+		},
+	}
+	labels := map[string]string{"nodepool": "token-pool", "namespace": "default"}
+	before := metricValue(t, "hcloud_operator_bootstrap_token_errors_total", labels)
+
+	if _, err := reconciler.generateCloudInit(ctx, nodePool, false, nil); err == nil {
+		t.Fatal("generateCloudInit() expected error when the token can't be generated")
+	}
+	if got := metricValue(t, "hcloud_operator_bootstrap_token_errors_total", labels); got != before+1 {
+		t.Errorf("bootstrap token errors = %v, want %v", got, before+1)
+	}
+	if !meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionTokenGenerationFailed) {
+		t.Errorf("Expected %s condition to be true", conditionTokenGenerationFailed)
+	}
+
+	failing = false
+	if _, err := reconciler.generateCloudInit(ctx, nodePool, false, nil); err != nil {
+		t.Fatalf("generateCloudInit() error = %v", err)
+	}
+	if meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionTokenGenerationFailed) {
+		t.Errorf("Expected %s condition to be cleared once a token is generated", conditionTokenGenerationFailed)
+	}
+}
+
+func TestNodePoolReconciler_KubeadmWithoutToken(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+				Type: hcloudv1alpha1.ClusterTypeKubeadm,
+			},
+		},
+	}
+
+	_, err := reconciler.generateCloudInit(context.Background(), nodePool, false, nil)
+	if !errors.Is(err, errInvalidBootstrapConfig) || !strings.Contains(err.Error(), "autoGenerateToken or tokenSecretRef") {
+		t.Errorf("generateCloudInit() error = %v, want an invalid bootstrap configuration error", err)
+	}
+}
+
+func TestNodePoolReconciler_BootstrapPending(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	newNodePool := func(name string, clusterType hcloudv1alpha1.ClusterType) *hcloudv1alpha1.NodePool {
+		return &hcloudv1alpha1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  "default",
+				Finalizers: []string{nodePoolFinalizer},
+			},
+			Spec: hcloudv1alpha1.NodePoolSpec{
+				Provider: hcloudv1alpha1.CloudProviderHetzner,
+				MinNodes: 1,
+				MaxNodes: 3,
+				HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+					ServerType: "cx11",
+					Image:      "ubuntu-22.04",
+					Location:   "nbg1",
+				},
+				Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+					Type:              clusterType,
+					AutoGenerateToken: true,
+				},
+			},
+		}
+	}
+	pending := newNodePool("pending-pool", hcloudv1alpha1.ClusterTypeKubeadm)
+	invalid := newNodePool("invalid-pool", "unknown")
+	client := setupStatusClient(reconciler, pending, invalid)
+
+	configMaps := reconciler.KubeClient.CoreV1().ConfigMaps("kube-public")
+	clusterInfo, err := configMaps.Get(ctx, "cluster-info", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get cluster-info: %v", err)
+	}
+	if err := configMaps.Delete(ctx, "cluster-info", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete cluster-info: %v", err)
+	}
+
+	reconcile := func(nodePool *hcloudv1alpha1.NodePool) (ctrl.Result, *hcloudv1alpha1.NodePool) {
+		t.Helper()
+		key := types.NamespacedName{Name: nodePool.Name, Namespace: nodePool.Namespace}
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		updated := &hcloudv1alpha1.NodePool{}
+		if err := client.Get(ctx, key, updated); err != nil {
+			t.Fatalf("Failed to get NodePool: %v", err)
+		}
+		return result, updated
+	}
+
+	// A missing cluster-info is retried soon
+	result, updated := reconcile(pending)
+	if result.RequeueAfter != bootstrapPendingRequeueInterval {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, bootstrapPendingRequeueInterval)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, conditionBootstrapPending)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != "ClusterInfoNotReady" {
+		t.Errorf("Expected %s condition to be true, got %+v", conditionBootstrapPending, condition)
+	}
+	if updated.Status.Phase != "BootstrapPending" {
+		t.Errorf("Phase = %q, want BootstrapPending", updated.Status.Phase)
+	}
+	if mockHetzner.CreateServerCalls != 0 {
+		t.Errorf("CreateServer called %d times while cluster-info is missing", mockHetzner.CreateServerCalls)
+	}
+
+	// An invalid cluster type won't be fixed by retrying
+	result, updated = reconcile(invalid)
+	if result.RequeueAfter != reconcileInterval {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, reconcileInterval)
+	}
+	condition = meta.FindStatusCondition(updated.Status.Conditions, conditionBootstrapPending)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "InvalidConfiguration" {
+		t.Errorf("Expected %s condition to be false for an invalid cluster type, got %+v", conditionBootstrapPending, condition)
+	}
+
+	// Once cluster-info is published the pool is bootstrapped
+	clusterInfo.ResourceVersion = ""
+	if _, err := configMaps.Create(ctx, clusterInfo, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create cluster-info: %v", err)
+	}
+	result, updated = reconcile(pending)
+	if result.RequeueAfter != reconcileInterval {
+		t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, reconcileInterval)
+	}
+	if mockHetzner.CreateServerCalls != 1 {
+		t.Errorf("CreateServer called %d times, want 1", mockHetzner.CreateServerCalls)
+	}
+	if meta.IsStatusConditionTrue(updated.Status.Conditions, conditionBootstrapPending) {
+		t.Errorf("Expected %s condition to be cleared once cluster-info is available", conditionBootstrapPending)
+	}
+}
+
+func TestNodePoolReconciler_FilesFromConfigMaps(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	const auditPolicy = "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n  - level: Metadata\n"
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-files", Namespace: "default"},
+		Data:       map[string]string{"audit-policy.yaml": auditPolicy},
+	}
+	if _, err := reconciler.KubeClient.CoreV1().ConfigMaps("default").Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create ConfigMap: %v", err)
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			Bootstrap: &hcloudv1alpha1.ClusterBootstrapConfig{
+				Type:              hcloudv1alpha1.ClusterTypeKubeadm,
+				AutoGenerateToken: true,
+			},
+			Files: []hcloudv1alpha1.NodeFile{{
+				ConfigMapRef: hcloudv1alpha1.ConfigMapKeyReference{Name: "node-files", Key: "audit-policy.yaml"},
+				Path:         "/etc/kubernetes/audit-policy.yaml",
+				Permissions:  "0600",
+			}},
+		},
+	}
+
+	cloudInit, err := reconciler.generateCloudInit(ctx, nodePool, false, nil)
+	if err != nil {
+		t.Fatalf("generateCloudInit() error = %v", err)
+	}
+	for _, want := range []string{
+		"path: /etc/kubernetes/audit-policy.yaml",
+		`permissions: "0600"`,
+		"content: " + base64.StdEncoding.EncodeToString([]byte(auditPolicy)),
+	} {
+		if !strings.Contains(cloudInit, want) {
+			t.Errorf("Expected cloud-init to contain %q", want)
+		}
+	}
+
+	nodePool.Spec.Files[0].ConfigMapRef.Name = "missing"
+	if _, err := reconciler.generateCloudInit(ctx, nodePool, false, nil); err == nil ||
+		!strings.Contains(err.Error(), "configmap missing for file /etc/kubernetes/audit-policy.yaml not found") {
+		t.Errorf("generateCloudInit() error = %v, want a missing ConfigMap error", err)
+	}
+}
+
+func TestNodePoolReconciler_OVHResolvedIDs(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	mockOVH := mock.NewMockOVHcloudClient()
+	reconciler.OVHCloudClient = mockOVH
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderOVHcloud,
+			MinNodes: 1,
+			MaxNodes: 3,
+			OVHcloudConfig: &hcloudv1alpha1.OVHcloudConfig{
+				Region: "GRA7",
+				Flavor: "b3-8",
+				Image:  "Ubuntu 22.04",
+			},
+		},
+	}
+	client := setupStatusClient(reconciler, nodePool)
+
+	key := types.NamespacedName{Name: "test-pool", Namespace: "default"}
+	// scaleTo sets the pool's minNodes and reconciles it, creating the missing instances
+	scaleTo := func(minNodes int, flavor string) *hcloudv1alpha1.NodePool {
+		t.Helper()
+		updated := &hcloudv1alpha1.NodePool{}
+		if err := client.Get(ctx, key, updated); err != nil {
+			t.Fatalf("Failed to get NodePool: %v", err)
+		}
+		updated.Spec.MinNodes = minNodes
+		updated.Spec.OVHcloudConfig.Flavor = flavor
+		if err := client.Update(ctx, updated); err != nil {
+			t.Fatalf("Failed to update NodePool: %v", err)
+		}
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if err := client.Get(ctx, key, updated); err != nil {
+			t.Fatalf("Failed to get NodePool: %v", err)
+		}
+		return updated
+	}
+
+	updated := scaleTo(1, "b3-8")
+	want := &hcloudv1alpha1.ResolvedID{Name: "b3-8", Region: "GRA7", ID: "flavor-b3-8"}
+	if got := updated.Status.ResolvedFlavorID; got == nil || *got != *want {
+		t.Errorf("Status.ResolvedFlavorID = %+v, want %+v", got, want)
+	}
+	if mockOVH.GetFlavorIDByNameCalls != 1 || mockOVH.GetImageIDByNameCalls != 1 {
+		t.Fatalf("Expected the flavor and image to be resolved once, got %d and %d lookups",
+			mockOVH.GetFlavorIDByNameCalls, mockOVH.GetImageIDByNameCalls)
+	}
+
+	// An unchanged flavor name reuses the resolved IDs
+	scaleTo(2, "b3-8")
+	if mockOVH.CreateInstanceCalls != 2 {
+		t.Fatalf("CreateInstance called %d times, want 2", mockOVH.CreateInstanceCalls)
+	}
+	if mockOVH.GetFlavorIDByNameCalls != 1 || mockOVH.GetImageIDByNameCalls != 1 {
+		t.Errorf("Expected the resolved IDs to be reused, got %d flavor and %d image lookups",
+			mockOVH.GetFlavorIDByNameCalls, mockOVH.GetImageIDByNameCalls)
+	}
+
+	// A changed flavor name is resolved again
+	updated = scaleTo(3, "c2-7")
+	if mockOVH.GetFlavorIDByNameCalls != 2 {
+		t.Errorf("GetFlavorIDByName called %d times after the flavor changed, want 2", mockOVH.GetFlavorIDByNameCalls)
+	}
+	if got := updated.Status.ResolvedFlavorID; got == nil || got.ID != "flavor-c2-7" {
+		t.Errorf("Status.ResolvedFlavorID = %+v, want the ID of c2-7", got)
+	}
+}
+
+func TestNodePoolReconciler_OVHPublicNetworkUnavailable(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockOVH := mock.NewMockOVHcloudClient()
+	reconciler.OVHCloudClient = mockOVH
+
+	var allowPrivateOnly bool
+	mockOVH.CreateInstanceFunc = func(_ context.Context, config ovhcloud.InstanceConfig) (*ovhcloud.Instance, error) {
+		allowPrivateOnly = config.AllowPrivateOnly
+		if !config.AllowPrivateOnly {
+			return nil, fmt.Errorf("%w: timeout", ovhcloud.ErrPublicNetworkUnavailable)
+		}
+		return &ovhcloud.Instance{ID: "instance-1", Name: config.Name, PrivateOnly: true}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderOVHcloud,
+			OVHcloudConfig: &hcloudv1alpha1.OVHcloudConfig{
+				Region:    "GRA7",
+				FlavorID:  "flavor",
+				ImageID:   "image",
+				NetworkID: "private-network",
+			},
+		},
+	}
+	recorder, ok := reconciler.Recorder.(*record.FakeRecorder)
+	if !ok {
+		t.Fatal("Failed to cast Recorder to fake recorder")
+	}
+
+	for _, policy := range []hcloudv1alpha1.PublicNetworkFailurePolicy{
+		hcloudv1alpha1.PublicNetworkFailurePolicyFail,
+		hcloudv1alpha1.PublicNetworkFailurePolicyPrivateOnly,
+	} {
+		nodePool.Spec.OVHcloudConfig.PublicNetworkFailurePolicy = policy
+
+		err := reconciler.createOVHcloudInstance(context.Background(), nodePool, "default-test-pool-1a2b", nil, "")
+		if (err != nil) != (policy == hcloudv1alpha1.PublicNetworkFailurePolicyFail) {
+			t.Errorf("createOVHcloudInstance() with policy %s error = %v", policy, err)
+		}
+		if allowPrivateOnly != (policy == hcloudv1alpha1.PublicNetworkFailurePolicyPrivateOnly) {
+			t.Errorf("InstanceConfig.AllowPrivateOnly = %v with policy %s", allowPrivateOnly, policy)
+		}
+
+		condition := meta.FindStatusCondition(nodePool.Status.Conditions, conditionPublicNetworkAvailable)
+		if condition == nil || condition.Status != metav1.ConditionFalse {
+			t.Errorf("Expected %s condition to be false with policy %s, got %+v", conditionPublicNetworkAvailable, policy, condition)
+		}
+		select {
+		case event := <-recorder.Events:
+			if !strings.Contains(event, "PublicNetworkUnavailable") {
+				t.Errorf("Unexpected event %q", event)
+			}
+		default:
+			t.Errorf("Expected a warning event with policy %s", policy)
+		}
+	}
+}
+
+func TestScaleUpIncrement(t *testing.T) {
+	tests := []struct {
+		name        string
+		scaleUpStep int
+		podsPerNode int
+		pendingPods int
+		want        int
+	}{
+		{name: "single step by default", pendingPods: 200, want: 1},
+		{name: "proportional to pending pods", scaleUpStep: 10, podsPerNode: 10, pendingPods: 25, want: 3},
+		{name: "clamped by step", scaleUpStep: 5, podsPerNode: 10, pendingPods: 200, want: 5},
+		{name: "at least one node", scaleUpStep: 5, podsPerNode: 10, pendingPods: 1, want: 1},
+		{name: "default pods per node", scaleUpStep: 5, pendingPods: 30, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodePool := &hcloudv1alpha1.NodePool{
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					ScaleUpStep: tt.scaleUpStep,
+					PodsPerNode: tt.podsPerNode,
+				},
+			}
+			if got := scaleUpIncrement(nodePool, tt.pendingPods); got != tt.want {
+				t.Errorf("scaleUpIncrement() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_ScaleUpBurst(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+	mockHetzner.SetServers(map[int64]*hetzner.Server{
+		100: {ID: 100, Name: "burst-pool-a", Status: "running"},
+		101: {ID: 101, Name: "burst-pool-b", Status: "running"},
+	})
+
+	for i := 0; i < 200; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pending-%d", i), Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		if err := client.Create(context.Background(), pod); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "burst-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:           hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes:           1,
+			MaxNodes:           10,
+			AutoScalingEnabled: true,
+			ScaleUpThreshold:   5,
+			ScaleUpStep:        5,
+			PodsPerNode:        10,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(context.Background(), nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "burst-pool", Namespace: "default"}}
+	_, err := reconciler.Reconcile(context.Background(), req)
+	// Allow "not found" errors as fake client behavior may vary with the status subresource
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Reconcile() unexpected error = %v", err)
+	}
+
+	// 200 pending pods at 10 pods per node need 20 nodes, clamped to a step of 5
+	if mockHetzner.CreateServerCalls != 5 {
+		t.Errorf("CreateServer called %d times in one reconcile, want 5", mockHetzner.CreateServerCalls)
+	}
+}
+
+func TestNodePoolReconciler_DeletePlacementGroup(t *testing.T) {
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType:     "cx11",
+				Location:       "nbg1",
+				Image:          "ubuntu-22.04",
+				PlacementGroup: "test-pool-spread",
+			},
+		},
+	}
+	deletedServers := []hetzner.Server{{ID: 1, Name: "test-pool-1a2b"}}
+	// Server 3 was deleted by an earlier pass and is still shutting down, server 4 is gone
+	// since, server 2 belongs to another pool
+	servers := map[int64]*hetzner.Server{
+		2: {ID: 2, Name: "other-pool-1a2b", Labels: map[string]string{"managed-by": "nodepools", "nodepool": "other-pool"}},
+		3: {ID: 3, Name: "test-pool-3c4d", Labels: poolResourceLabels(nodePool)},
+	}
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		servers     []int64
+		wantDone    bool
+		wantDeleted bool
+	}{
+		{name: "owned and empty", labels: poolResourceLabels(nodePool), wantDone: true, wantDeleted: true},
+		{name: "owned with deleted servers", labels: poolResourceLabels(nodePool), servers: []int64{1}},
+		{name: "owned with servers deleted by an earlier pass", labels: poolResourceLabels(nodePool), servers: []int64{3, 4}},
+		{name: "owned with other servers", labels: poolResourceLabels(nodePool), servers: []int64{1, 2, 3}, wantDone: true},
+		{name: "shared", wantDone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+			mockHetzner.SetServers(servers)
+			mockHetzner.GetPlacementGroupFunc = func(_ context.Context, nameOrID string) (*hcloud.PlacementGroup, error) {
+				return &hcloud.PlacementGroup{ID: 7, Name: nameOrID, Labels: tt.labels, Servers: tt.servers}, nil
+			}
+
+			done, err := reconciler.deletePlacementGroup(context.Background(), nodePool, deletedServers)
+			if err != nil {
+				t.Fatalf("deletePlacementGroup() error = %v", err)
+			}
+			if done != tt.wantDone {
+				t.Errorf("deletePlacementGroup() = %v, want %v", done, tt.wantDone)
+			}
+			if deleted := mockHetzner.DeletePlacementGroupCalls > 0; deleted != tt.wantDeleted {
+				t.Errorf("placement group deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_DeletePlacementGroupAcrossPasses(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pool",
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType:     "cx11",
+				Location:       "nbg1",
+				Image:          "ubuntu-22.04",
+				PlacementGroup: "test-pool-spread",
+			},
+		},
+	}
+
+	server := &hetzner.Server{ID: 1, Name: "test-pool-1a2b", Labels: poolResourceLabels(nodePool)}
+	mockHetzner.SetServers(map[int64]*hetzner.Server{1: server})
+	groupServers := []int64{1}
+	mockHetzner.GetPlacementGroupFunc = func(_ context.Context, nameOrID string) (*hcloud.PlacementGroup, error) {
+		return &hcloud.PlacementGroup{ID: 7, Name: nameOrID, Labels: poolResourceLabels(nodePool), Servers: groupServers}, nil
+	}
+
+	// The first pass deletes the server, which takes a while to shut down
+	done, err := reconciler.deletePlacementGroup(ctx, nodePool, []hetzner.Server{*server})
+	if err != nil || done {
+		t.Fatalf("deletePlacementGroup() = %v, %v on the first pass, want false, nil", done, err)
+	}
+
+	// The next pass no longer lists the server, it is still in the group
+	done, err = reconciler.deletePlacementGroup(ctx, nodePool, nil)
+	if err != nil || done {
+		t.Fatalf("deletePlacementGroup() = %v, %v while the server shuts down, want false, nil", done, err)
+	}
+	if mockHetzner.DeletePlacementGroupCalls != 0 {
+		t.Fatal("Expected the placement group to be kept while the server shuts down")
+	}
+
+	// Once the server is gone the group is deleted
+	mockHetzner.SetServers(map[int64]*hetzner.Server{})
+	groupServers = nil
+	done, err = reconciler.deletePlacementGroup(ctx, nodePool, nil)
+	if err != nil || !done {
+		t.Fatalf("deletePlacementGroup() = %v, %v once the server is gone, want true, nil", done, err)
+	}
+	if mockHetzner.DeletePlacementGroupCalls != 1 {
+		t.Errorf("DeletePlacementGroup called %d times, want 1", mockHetzner.DeletePlacementGroupCalls)
+	}
+}
+
+func TestNodePoolReconciler_DeleteFirewalls(t *testing.T) {
+	newNodePool := func() *hcloudv1alpha1.NodePool {
+		return &hcloudv1alpha1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "test-pool",
+				Namespace:  "default",
+				Finalizers: []string{nodePoolFinalizer},
+			},
+			Spec: hcloudv1alpha1.NodePoolSpec{
+				Provider: hcloudv1alpha1.CloudProviderHetzner,
+				HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+					ServerType: "cx11",
+					Location:   "nbg1",
+					Image:      "ubuntu-22.04",
+				},
+				FirewallRules: []hcloudv1alpha1.FirewallRule{{Protocol: "tcp", Port: "22"}},
+			},
+		}
+	}
+
+	t.Run("kept across passes until the pool's servers are gone", func(t *testing.T) {
+		reconciler, _ := setupTestReconciler()
+		ctx := context.Background()
+		mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+		nodePool := newNodePool()
+		server := &hetzner.Server{ID: 1, Name: "test-pool-1a2b", Labels: poolResourceLabels(nodePool)}
+		mockHetzner.SetServers(map[int64]*hetzner.Server{1: server})
+		appliedTo := []int64{1}
+		mockHetzner.ListFirewallsFunc = func(_ context.Context, _, _ string) ([]*hcloud.Firewall, error) {
+			firewall := &hcloud.Firewall{ID: 3, Name: "test-pool-firewall"}
+			for _, id := range appliedTo {
+				firewall.AppliedTo = append(firewall.AppliedTo, hcloud.FirewallResource{
+					Type:   hcloud.FirewallResourceTypeServer,
+					Server: &hcloud.FirewallResourceServer{ID: id},
+				})
+			}
+			return []*hcloud.Firewall{firewall}, nil
+		}
+
+		// The first pass deletes the server, which takes a while to shut down
+		done, err := reconciler.deleteFirewalls(ctx, nodePool, []hetzner.Server{*server})
+		if err != nil || done {
+			t.Fatalf("deleteFirewalls() = %v, %v on the first pass, want false, nil", done, err)
+		}
+
+		// The next pass no longer lists the server, the firewall still applies to it
+		done, err = reconciler.deleteFirewalls(ctx, nodePool, nil)
+		if err != nil || done {
+			t.Fatalf("deleteFirewalls() = %v, %v while the server shuts down, want false, nil", done, err)
+		}
+		if mockHetzner.DeleteFirewallCalls != 0 {
+			t.Fatal("Expected the firewall to be kept while the server shuts down")
+		}
+
+		// Once the server is gone the firewall is deleted
+		mockHetzner.SetServers(map[int64]*hetzner.Server{})
+		appliedTo = nil
+		done, err = reconciler.deleteFirewalls(ctx, nodePool, nil)
+		if err != nil || !done {
+			t.Fatalf("deleteFirewalls() = %v, %v once the server is gone, want true, nil", done, err)
+		}
+		if mockHetzner.DeleteFirewallCalls != 1 {
+			t.Errorf("DeleteFirewall called %d times, want 1", mockHetzner.DeleteFirewallCalls)
+		}
+	})
+
+	t.Run("deleted with the pool", func(t *testing.T) {
+		reconciler, client := setupTestReconciler()
+		ctx := context.Background()
+		mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+		nodePool := newNodePool()
+		if _, err := reconciler.getOrCreateFirewall(ctx, nodePool); err != nil {
+			t.Fatalf("getOrCreateFirewall() error = %v", err)
+		}
+		if err := client.Create(ctx, nodePool); err != nil {
+			t.Fatalf("Failed to create NodePool: %v", err)
+		}
+		if err := client.Delete(ctx, nodePool); err != nil {
+			t.Fatalf("Failed to delete NodePool: %v", err)
+		}
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: nodePool.Name, Namespace: nodePool.Namespace}}
+		if _, err := reconciler.Reconcile(ctx, req); err != nil && !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+
+		if mockHetzner.DeleteFirewallCalls != 1 {
+			t.Errorf("Expected the firewall to be deleted, got %d DeleteFirewall calls", mockHetzner.DeleteFirewallCalls)
+		}
+		firewalls, _ := mockHetzner.ListFirewalls(ctx, nodePool.Name, nodePool.Namespace)
+		if len(firewalls) != 0 {
+			t.Errorf("Expected no firewalls left, got %d", len(firewalls))
+		}
+	})
+
+	deletedServers := []hetzner.Server{{ID: 1, Name: "test-pool-1a2b"}}
+	// Server 3 was deleted by an earlier pass and is still shutting down, server 4 is gone
+	// since, server 2 belongs to another pool
+	servers := map[int64]*hetzner.Server{
+		2: {ID: 2, Name: "other-pool-1a2b", Labels: map[string]string{"managed-by": "nodepools", "nodepool": "other-pool"}},
+		3: {ID: 3, Name: "test-pool-3c4d", Labels: poolResourceLabels(newNodePool())},
+	}
+	tests := []struct {
+		name        string
+		servers     []int64
+		wantDone    bool
+		wantDeleted bool
+	}{
+		{name: "unused", wantDone: true, wantDeleted: true},
+		{name: "applied to deleted servers", servers: []int64{1}},
+		{name: "applied to servers deleted by an earlier pass", servers: []int64{3, 4}},
+		{name: "applied to other servers", servers: []int64{1, 2, 3}, wantDone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+			mockHetzner.SetServers(servers)
+			mockHetzner.ListFirewallsFunc = func(_ context.Context, _, _ string) ([]*hcloud.Firewall, error) {
+				firewall := &hcloud.Firewall{ID: 3, Name: "test-pool-firewall"}
+				for _, id := range tt.servers {
+					firewall.AppliedTo = append(firewall.AppliedTo, hcloud.FirewallResource{
+						Type:   hcloud.FirewallResourceTypeServer,
+						Server: &hcloud.FirewallResourceServer{ID: id},
+					})
+				}
+				return []*hcloud.Firewall{firewall}, nil
+			}
+
+			done, err := reconciler.deleteFirewalls(context.Background(), newNodePool(), deletedServers)
+			if err != nil {
+				t.Fatalf("deleteFirewalls() error = %v", err)
+			}
+			if done != tt.wantDone {
+				t.Errorf("deleteFirewalls() = %v, want %v", done, tt.wantDone)
+			}
+			if deleted := mockHetzner.DeleteFirewallCalls > 0; deleted != tt.wantDeleted {
+				t.Errorf("firewall deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_Scaleway(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+
+	mockScaleway := mock.NewMockScalewayClient()
+	reconciler.ScalewayClient = mockScaleway
+
+	var created []scaleway.InstanceConfig
+	mockScaleway.CreateInstanceFunc = func(_ context.Context, config scaleway.InstanceConfig) (*scaleway.Instance, error) {
+		created = append(created, config)
+		return &scaleway.Instance{ID: fmt.Sprintf("instance-%d", len(created)), Name: config.Name, Status: scaleway.StateRunning}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "scw-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderScaleway,
+			MinNodes: 2,
+			MaxNodes: 3,
+			ScalewayConfig: &hcloudv1alpha1.ScalewayConfig{
+				Zone:           "fr-par-1",
+				CommercialType: "DEV1-M",
+				Image:          "ubuntu_jammy",
+				ProjectID:      "project",
+			},
+		},
+	}
+	if err := client.Create(context.Background(), nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "scw-pool", Namespace: "default"}}
+	_, err := reconciler.Reconcile(context.Background(), req)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Reconcile() unexpected error = %v", err)
+	}
+
+	if len(created) != 2 {
+		t.Fatalf("CreateInstance called %d times, want 2", len(created))
+	}
+	for _, config := range created {
+		if config.Zone != "fr-par-1" || config.CommercialType != "DEV1-M" || config.ProjectID != "project" {
+			t.Errorf("Unexpected instance config %+v", config)
+		}
+		if config.Labels["nodepool"] != "scw-pool" || config.Labels["namespace"] != "default" {
+			t.Errorf("Expected instance to be tagged with its pool, got %v", config.Labels)
+		}
+	}
+
+	mockScaleway.ListInstancesFunc = func(_ context.Context, zone, nodePoolName, namespace string) ([]scaleway.Instance, error) {
+		if zone != "fr-par-1" || nodePoolName != "scw-pool" || namespace != "default" {
+			t.Errorf("ListInstances(%s, %s, %s) called for another pool", zone, nodePoolName, namespace)
+		}
+		return []scaleway.Instance{{ID: "instance-1", Name: created[0].Name}, {ID: "instance-2", Name: created[1].Name}}, nil
+	}
+	var deleted []string
+	mockScaleway.DeleteInstanceFunc = func(_ context.Context, _, instanceID string) error {
+		deleted = append(deleted, instanceID)
+		return nil
+	}
+
+	if _, err := reconciler.handleDeletion(context.Background(), nodePool); err != nil {
+		t.Fatalf("handleDeletion() error = %v", err)
+	}
+	if len(deleted) != 2 {
+		t.Errorf("DeleteInstance called for %v, want both instances", deleted)
+	}
+	if containsString(nodePool.Finalizers, nodePoolFinalizer) {
+		t.Error("Expected finalizer to be removed after the instances were deleted")
+	}
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/ovhcloud"
+	"github.com/autokubeio/autokube/internal/scaleway"
+)
+
+const (
+	// instanceIDAnnotation records the ID of the cloud server or instance backing a node
+	instanceIDAnnotation = "autokube.io/instance-id"
+
+	// providerAnnotation records the cloud provider of the server or instance backing a node
+	providerAnnotation = "autokube.io/provider"
+
+	// managedLabelsAnnotation lists the node labels set from the pool's labels, so labels
+	// removed from the pool are removed from the node while labels set by others are kept
+	managedLabelsAnnotation = "autokube.io/managed-labels"
+
+	// managedTaintsAnnotation lists the node taints set from the pool's taints as key:effect
+	managedTaintsAnnotation = "autokube.io/managed-taints"
+)
+
+// syncNodes keeps the nodes of the pool's servers in sync with the pool: it annotates them with
+// their instance ID and provider, so a node can be mapped back to its cloud instance, applies
+// the pool's labels and taints, and adds the finalizer that deletes the server of a node
+// deleted out of band. instanceIDs maps server names, which are also the node
+// names, to instance IDs. Servers whose node hasn't joined the cluster yet are synced on a
+// later reconcile
+func (r *NodePoolReconciler) syncNodes(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, instanceIDs map[string]string) {
+	logger := log.FromContext(ctx)
+
+	for name, id := range instanceIDs {
+		node := &corev1.Node{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, node); err != nil {
+			if !errors.IsNotFound(err) {
+				logger.Error(err, "Failed to get node to sync", "node", name)
+			}
+			continue
+		}
+		// Reported by syncNodeOwners
+		if ownedByOtherPool(nodePool, node) {
+			continue
+		}
+
+		// The optimistic lock makes the patch fail instead of overwriting changes made by
+		// others since the node was read, e.g. taints set by the node lifecycle controller.
+		// The node is synced again on the next reconcile
+		patch := client.MergeFromWithOptions(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		changed := setNodeAnnotation(node, instanceIDAnnotation, id)
+		changed = setNodeAnnotation(node, providerAnnotation, string(nodePool.Spec.Provider)) || changed
+		changed = syncNodeLabels(node, nodePool.Spec.Labels) || changed
+		changed = syncNodeTaints(node, nodePool.Spec.Taints) || changed
+		changed = addNodeFinalizer(node) || changed
+		if !changed {
+			continue
+		}
+
+		if err := r.Patch(ctx, node, patch); err != nil {
+			logger.Error(err, "Failed to sync node with its pool", "node", name, "instanceID", id)
+			continue
+		}
+		logger.V(1).Info("Synced node with its pool", "node", name, "instanceID", id)
+	}
+}
+
+// setNodeAnnotation sets an annotation on the node, or removes it for an empty value, and
+// reports whether the node changed
+func setNodeAnnotation(node *corev1.Node, key, value string) bool {
+	current, exists := node.Annotations[key]
+	if value == "" {
+		if !exists {
+			return false
+		}
+		delete(node.Annotations, key)
+		return true
+	}
+	if exists && current == value {
+		return false
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[key] = value
+	return true
+}
+
+// syncNodeLabels sets the pool's labels on the node and removes the labels it set before that
+// the pool no longer has. It reports whether the node changed
+func syncNodeLabels(node *corev1.Node, labels map[string]string) bool {
+	changed := false
+	for _, key := range managedKeys(node, managedLabelsAnnotation) {
+		if _, desired := labels[key]; !desired {
+			if _, exists := node.Labels[key]; exists {
+				delete(node.Labels, key)
+				changed = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key, value := range labels {
+		keys = append(keys, key)
+		if current, exists := node.Labels[key]; exists && current == value {
+			continue
+		}
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		node.Labels[key] = value
+		changed = true
+	}
+
+	sort.Strings(keys)
+	return setNodeAnnotation(node, managedLabelsAnnotation, strings.Join(keys, ",")) || changed
+}
+
+// syncNodeTaints sets the pool's taints on the node, matched by key and effect, and removes the
+// taints it set before that the pool no longer has. It reports whether the node changed
+func syncNodeTaints(node *corev1.Node, taints []corev1.Taint) bool {
+	desired := make(map[string]corev1.Taint, len(taints))
+	for _, taint := range taints {
+		desired[taintKey(taint)] = taint
+	}
+	managed := make(map[string]bool)
+	for _, key := range managedKeys(node, managedTaintsAnnotation) {
+		managed[key] = true
+	}
+
+	changed := false
+	synced := make([]corev1.Taint, 0, len(node.Spec.Taints)+len(taints))
+	applied := make(map[string]bool, len(taints))
+	for _, taint := range node.Spec.Taints {
+		key := taintKey(taint)
+		want, isDesired := desired[key]
+		switch {
+		case isDesired:
+			applied[key] = true
+			if taint.Value != want.Value {
+				taint.Value = want.Value
+				changed = true
+			}
+		case managed[key]:
+			// Removed from the pool
+			changed = true
+			continue
+		}
+		synced = append(synced, taint)
+	}
+	for _, taint := range taints {
+		if !applied[taintKey(taint)] {
+			synced = append(synced, corev1.Taint{Key: taint.Key, Value: taint.Value, Effect: taint.Effect})
+			changed = true
+		}
+	}
+	if changed {
+		node.Spec.Taints = synced
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return setNodeAnnotation(node, managedTaintsAnnotation, strings.Join(keys, ",")) || changed
+}
+
+// managedKeys returns the keys listed in a managed-keys annotation of the node
+func managedKeys(node *corev1.Node, annotation string) []string {
+	value := node.Annotations[annotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// taintKey identifies a taint by its key and effect, as the API server does
+func taintKey(taint corev1.Taint) string {
+	return taint.Key + ":" + string(taint.Effect)
+}
+
+// registerWithTaints renders taints as the value of the kubelet register-with-taints flag
+func registerWithTaints(taints []corev1.Taint) string {
+	rendered := make([]string, 0, len(taints))
+	for _, taint := range taints {
+		if taint.Value == "" {
+			rendered = append(rendered, taintKey(taint))
+			continue
+		}
+		rendered = append(rendered, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+	}
+	return strings.Join(rendered, ",")
+}
+
+// hetznerInstanceIDs maps server names to server IDs
+func hetznerInstanceIDs(servers []hetzner.Server) map[string]string {
+	ids := make(map[string]string, len(servers))
+	for _, server := range servers {
+		ids[server.Name] = strconv.FormatInt(server.ID, 10)
+	}
+	return ids
+}
+
+// ovhInstanceIDs maps instance names to instance IDs
+func ovhInstanceIDs(instances []ovhcloud.Instance) map[string]string {
+	ids := make(map[string]string, len(instances))
+	for _, instance := range instances {
+		ids[instance.Name] = instance.ID
+	}
+	return ids
+}
+
+// scalewayInstanceIDs maps instance names to instance IDs
+func scalewayInstanceIDs(instances []scaleway.Instance) map[string]string {
+	ids := make(map[string]string, len(instances))
+	for _, instance := range instances {
+		ids[instance.Name] = instance.ID
+	}
+	return ids
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_AnnotateNodes(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	kubeClient := setupStatusClient(reconciler)
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+		return []hetzner.Server{
+			{ID: 42, Name: "test-pool-1a2b", Status: "running"},
+			// Not joined the cluster yet
+			{ID: 43, Name: "test-pool-3c4d", Status: "running"},
+		}, nil
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pool-1a2b",
+			Annotations: map[string]string{"existing": "annotation"},
+		},
+	}
+	if err := kubeClient.Create(ctx, node); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 2,
+			MaxNodes: 2,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := kubeClient.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-1a2b"}, node); err != nil {
+		t.Fatalf("Failed to get node: %v", err)
+	}
+	want := map[string]string{
+		instanceIDAnnotation: "42",
+		providerAnnotation:   string(hcloudv1alpha1.CloudProviderHetzner),
+		"existing":           "annotation",
+	}
+	for key, value := range want {
+		if got := node.Annotations[key]; got != value {
+			t.Errorf("node annotation %s = %q, want %q", key, got, value)
+		}
+	}
+	if !controllerutil.ContainsFinalizer(node, nodeFinalizer) {
+		t.Errorf("node finalizers = %v, want %s", node.Finalizers, nodeFinalizer)
+	}
+
+	// The node of the server that hasn't joined is left alone
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-3c4d"}, &corev1.Node{}); err == nil {
+		t.Error("Expected no node to be created for a server that hasn't joined")
+	}
+}
+
+func TestNodePoolReconciler_SyncNodeLabelsAndTaints(t *testing.T) {
+	reconciler, kubeClient := setupTestReconciler()
+	ctx := context.Background()
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test-pool-1a2b",
+			Labels: map[string]string{"user": "label"},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{{Key: "node.kubernetes.io/not-ready", Effect: corev1.TaintEffectNoSchedule}},
+		},
+	}
+	if err := kubeClient.Create(ctx, node); err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			Labels:   map[string]string{"workload": "batch", "tier": "spot"},
+			Taints:   []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}},
+		},
+	}
+	instanceIDs := map[string]string{"test-pool-1a2b": "42"}
+
+	sync := func() *corev1.Node {
+		t.Helper()
+		reconciler.syncNodes(ctx, nodePool, instanceIDs)
+		synced := &corev1.Node{}
+		if err := kubeClient.Get(ctx, client.ObjectKey{Name: "test-pool-1a2b"}, synced); err != nil {
+			t.Fatalf("Failed to get node: %v", err)
+		}
+		return synced
+	}
+
+	synced := sync()
+	if synced.Labels["workload"] != "batch" || synced.Labels["tier"] != "spot" || synced.Labels["user"] != "label" {
+		t.Errorf("Expected pool labels to be added next to the user's, got %v", synced.Labels)
+	}
+	if len(synced.Spec.Taints) != 2 || synced.Spec.Taints[1].Key != "dedicated" || synced.Spec.Taints[1].Value != "batch" {
+		t.Errorf("Expected the pool taint to be added next to the existing one, got %v", synced.Spec.Taints)
+	}
+
+	// Changing a pool label updates the node, removed labels and taints are removed
+	nodePool.Spec.Labels = map[string]string{"workload": "web"}
+	nodePool.Spec.Taints = nil
+	synced = sync()
+	if synced.Labels["workload"] != "web" {
+		t.Errorf("workload label = %q, want %q", synced.Labels["workload"], "web")
+	}
+	if _, exists := synced.Labels["tier"]; exists {
+		t.Error("Expected the label removed from the pool to be removed from the node")
+	}
+	if synced.Labels["user"] != "label" {
+		t.Error("Expected the user's label to be kept")
+	}
+	if len(synced.Spec.Taints) != 1 || synced.Spec.Taints[0].Key != "node.kubernetes.io/not-ready" {
+		t.Errorf("Expected only the pool taint to be removed, got %v", synced.Spec.Taints)
+	}
+
+	// New nodes register with the pool's taints
+	nodePool.Spec.Bootstrap = &hcloudv1alpha1.ClusterBootstrapConfig{KubeletExtraArgs: map[string]string{"max-pods": "110"}}
+	nodePool.Spec.Taints = []corev1.Taint{
+		{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+	}
+	args := nodeKubeletArgs(nodePool)
+	if args["register-with-taints"] != "dedicated=batch:NoSchedule,spot:PreferNoSchedule" || args["max-pods"] != "110" {
+		t.Errorf("nodeKubeletArgs() = %v", args)
+	}
+}
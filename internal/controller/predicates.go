@@ -0,0 +1,47 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// nodePoolPredicate filters the NodePool events that trigger a reconcile. The controller's
+// own status updates don't change the generation and are ignored, so they don't trigger
+// another reconcile; scaling is driven by the RequeueAfter of each reconcile instead.
+// Annotation changes are kept for force-delete, and deletion timestamp changes so a
+// deleted pool is cleaned up right away
+func nodePoolPredicate() predicate.Predicate {
+	return predicate.Or(
+		predicate.GenerationChangedPredicate{},
+		predicate.AnnotationChangedPredicate{},
+		deletionTimestampChangedPredicate(),
+	)
+}
+
+// deletionTimestampChangedPredicate passes updates that set or clear the deletion timestamp
+func deletionTimestampChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			return e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero()
+		},
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+func TestNodePoolPredicate(t *testing.T) {
+	now := metav1.Now()
+	old := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "default", Generation: 1},
+		Spec:       hcloudv1alpha1.NodePoolSpec{MinNodes: 1, MaxNodes: 3},
+	}
+
+	tests := []struct {
+		name   string
+		update func(nodePool *hcloudv1alpha1.NodePool)
+		want   bool
+	}{
+		{
+			name: "status update",
+			update: func(nodePool *hcloudv1alpha1.NodePool) {
+				nodePool.Status.CurrentNodes = 2
+				nodePool.Status.Phase = phaseReady
+			},
+			want: false,
+		},
+		{
+			name: "spec change",
+			update: func(nodePool *hcloudv1alpha1.NodePool) {
+				nodePool.Spec.MinNodes = 2
+				nodePool.Generation = 2
+			},
+			want: true,
+		},
+		{
+			name: "force-delete annotation",
+			update: func(nodePool *hcloudv1alpha1.NodePool) {
+				nodePool.Annotations = map[string]string{forceDeleteAnnotation: "true"}
+			},
+			want: true,
+		},
+		{
+			name: "deletion",
+			update: func(nodePool *hcloudv1alpha1.NodePool) {
+				nodePool.DeletionTimestamp = &now
+			},
+			want: true,
+		},
+	}
+
+	predicate := nodePoolPredicate()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := old.DeepCopy()
+			tt.update(updated)
+			if got := predicate.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated}); got != tt.want {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !predicate.Create(event.CreateEvent{Object: old}) {
+		t.Error("Expected created pools to be reconciled")
+	}
+	if !predicate.Delete(event.DeleteEvent{Object: old}) {
+		t.Error("Expected deleted pools to be reconciled")
+	}
+}
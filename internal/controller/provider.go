@@ -0,0 +1,243 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// CloudProvider lists, creates and deletes the servers of a pool on its cloud provider, so
+// the reconciler's steps don't switch on spec.provider. The steps of a reconcile share the
+// servers listed at its start through poolServers
+type CloudProvider interface {
+	// List lists the pool's servers
+	List(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) (*poolServers, error)
+	// Create creates a server for the pool. Providers that spread servers based on the
+	// listing add it to listed
+	Create(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers, server newServer) error
+	// Delete drains the node of the listed server with the given name and deletes the server.
+	// The server is left in listed
+	Delete(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers, name string) error
+	// CountReady returns the number of listed servers that are running
+	CountReady(listed *poolServers) int
+	// Names returns the names of the listed servers in listing order
+	Names(listed *poolServers) []string
+	// InstanceIDs maps the names of the listed servers to their provider IDs
+	InstanceIDs(listed *poolServers) map[string]string
+	// Running maps the names of the listed servers to whether they are running
+	Running(listed *poolServers) map[string]bool
+	// Created maps the names of the listed servers to when they were created
+	Created(listed *poolServers) map[string]time.Time
+}
+
+// newServer describes a server to create. Image, firewalls and volumes only apply to
+// Hetzner Cloud
+type newServer struct {
+	Name     string
+	Location string
+	Labels   map[string]string
+	UserData string
+	// FirewallIDs are the firewalls the server is attached to
+	FirewallIDs []int64
+	// SnapshotID is the snapshot the server boots from instead of the pool's image, 0 for none
+	SnapshotID int64
+	// VolumeIDs are the volumes attached to the server
+	VolumeIDs []int64
+}
+
+// cloudProvider returns the CloudProvider of the pool's spec.provider
+func (r *NodePoolReconciler) cloudProvider(nodePool *hcloudv1alpha1.NodePool) (CloudProvider, error) {
+	switch nodePool.Spec.Provider {
+	case hcloudv1alpha1.CloudProviderHetzner:
+		return hetznerProvider{r}, nil
+	case hcloudv1alpha1.CloudProviderOVHcloud:
+		return ovhcloudProvider{r}, nil
+	case hcloudv1alpha1.CloudProviderScaleway:
+		return scalewayProvider{r}, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", nodePool.Spec.Provider)
+	}
+}
+
+// hetznerProvider manages the servers of Hetzner Cloud pools
+type hetznerProvider struct {
+	r *NodePoolReconciler
+}
+
+// List lists the pool's servers, including the ones recorded in its status that the
+// label-based listing misses
+func (p hetznerProvider) List(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) (*poolServers, error) {
+	servers, err := p.r.hetznerClient(ctx).ListServers(ctx, nodePool.Name, nodePool.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &poolServers{hetzner: p.r.recoverHetznerServers(ctx, nodePool, servers)}, nil
+}
+
+func (p hetznerProvider) Create(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers, server newServer) error {
+	return p.r.createHetznerServer(ctx, nodePool, listed, server.Name, server.Location, server.Labels, server.UserData,
+		server.FirewallIDs, server.SnapshotID, server.VolumeIDs)
+}
+
+func (p hetznerProvider) Delete(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers, name string) error {
+	for _, server := range listed.hetzner {
+		if server.Name == name {
+			return p.r.deleteServer(ctx, nodePool, server)
+		}
+	}
+	return fmt.Errorf("server %s is not listed", name)
+}
+
+func (p hetznerProvider) CountReady(listed *poolServers) int {
+	return p.r.countReadyNodes(listed.hetzner)
+}
+
+func (p hetznerProvider) Names(listed *poolServers) []string {
+	return p.r.getServerNames(listed.hetzner)
+}
+
+func (p hetznerProvider) InstanceIDs(listed *poolServers) map[string]string {
+	return hetznerInstanceIDs(listed.hetzner)
+}
+
+func (p hetznerProvider) Running(listed *poolServers) map[string]bool {
+	return runningServers(listed.hetzner)
+}
+
+func (p hetznerProvider) Created(listed *poolServers) map[string]time.Time {
+	created := make(map[string]time.Time, len(listed.hetzner))
+	for _, server := range listed.hetzner {
+		created[server.Name] = server.CreatedAt
+	}
+	return created
+}
+
+// ovhcloudProvider manages the instances of OVHcloud pools
+type ovhcloudProvider struct {
+	r *NodePoolReconciler
+}
+
+// List lists the pool's instances, including the ones recorded in its status that the
+// name-based listing misses
+func (p ovhcloudProvider) List(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) (*poolServers, error) {
+	client := p.r.ovhcloudClient(ctx)
+	if client == nil {
+		return nil, fmt.Errorf("OVHcloud client not initialized")
+	}
+	instances, err := client.ListInstances(ctx, nodePool.Name, nodePool.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &poolServers{ovh: p.r.recoverOVHInstances(ctx, nodePool, instances)}, nil
+}
+
+func (p ovhcloudProvider) Create(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, _ *poolServers, server newServer) error {
+	return p.r.createOVHcloudInstance(ctx, nodePool, server.Name, server.Labels, server.UserData)
+}
+
+func (p ovhcloudProvider) Delete(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers, name string) error {
+	for _, instance := range listed.ovh {
+		if instance.Name == name {
+			return p.r.deleteOVHInstance(ctx, nodePool, instance)
+		}
+	}
+	return fmt.Errorf("instance %s is not listed", name)
+}
+
+func (p ovhcloudProvider) CountReady(listed *poolServers) int {
+	return p.r.countReadyOVHInstances(listed.ovh)
+}
+
+func (p ovhcloudProvider) Names(listed *poolServers) []string {
+	return p.r.getOVHInstanceNames(listed.ovh)
+}
+
+func (p ovhcloudProvider) InstanceIDs(listed *poolServers) map[string]string {
+	return ovhInstanceIDs(listed.ovh)
+}
+
+func (p ovhcloudProvider) Running(listed *poolServers) map[string]bool {
+	return runningOVHInstances(listed.ovh)
+}
+
+func (p ovhcloudProvider) Created(listed *poolServers) map[string]time.Time {
+	created := make(map[string]time.Time, len(listed.ovh))
+	for _, instance := range listed.ovh {
+		created[instance.Name] = instance.CreatedAt
+	}
+	return created
+}
+
+// scalewayProvider manages the instances of Scaleway pools
+type scalewayProvider struct {
+	r *NodePoolReconciler
+}
+
+func (p scalewayProvider) List(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) (*poolServers, error) {
+	instances, err := p.r.listScalewayInstances(ctx, nodePool)
+	if err != nil {
+		return nil, err
+	}
+	return &poolServers{scaleway: instances}, nil
+}
+
+func (p scalewayProvider) Create(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, _ *poolServers, server newServer) error {
+	return p.r.createScalewayInstance(ctx, nodePool, server.Name, server.Labels, server.UserData)
+}
+
+func (p scalewayProvider) Delete(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, listed *poolServers, name string) error {
+	for _, instance := range listed.scaleway {
+		if instance.Name == name {
+			return p.r.deleteScalewayInstance(ctx, nodePool, instance)
+		}
+	}
+	return fmt.Errorf("instance %s is not listed", name)
+}
+
+func (p scalewayProvider) CountReady(listed *poolServers) int {
+	return p.r.countReadyScalewayInstances(listed.scaleway)
+}
+
+func (p scalewayProvider) Names(listed *poolServers) []string {
+	return p.r.getScalewayInstanceNames(listed.scaleway)
+}
+
+func (p scalewayProvider) InstanceIDs(listed *poolServers) map[string]string {
+	return scalewayInstanceIDs(listed.scaleway)
+}
+
+func (p scalewayProvider) Running(listed *poolServers) map[string]bool {
+	return runningScalewayInstances(listed.scaleway)
+}
+
+func (p scalewayProvider) Created(listed *poolServers) map[string]time.Time {
+	created := make(map[string]time.Time, len(listed.scaleway))
+	for _, instance := range listed.scaleway {
+		created[instance.Name] = instance.CreatedAt
+	}
+	return created
+}
+
+var (
+	_ CloudProvider = hetznerProvider{}
+	_ CloudProvider = ovhcloudProvider{}
+	_ CloudProvider = scalewayProvider{}
+)
@@ -0,0 +1,139 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestCloudProvider(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+		Spec:       hcloudv1alpha1.NodePoolSpec{Provider: "digitalocean"},
+	}
+	if _, err := reconciler.cloudProvider(nodePool); err == nil {
+		t.Error("cloudProvider() expected error for an unsupported provider")
+	}
+
+	nodePool.Spec.Provider = hcloudv1alpha1.CloudProviderOVHcloud
+	provider, err := reconciler.cloudProvider(nodePool)
+	if err != nil {
+		t.Fatalf("cloudProvider() error = %v", err)
+	}
+	if _, err := provider.List(ctx, nodePool); err == nil {
+		t.Error("List() expected error without an OVHcloud client")
+	}
+
+	tests := []struct {
+		name     string
+		provider hcloudv1alpha1.CloudProvider
+		setup    func(*NodePoolReconciler, *hcloudv1alpha1.NodePool)
+	}{
+		{
+			name:     "hetzner",
+			provider: hcloudv1alpha1.CloudProviderHetzner,
+			setup: func(_ *NodePoolReconciler, nodePool *hcloudv1alpha1.NodePool) {
+				nodePool.Spec.HetznerConfig = &hcloudv1alpha1.HetznerCloudConfig{
+					ServerType: "cx11",
+					Image:      "ubuntu-22.04",
+					Location:   "nbg1",
+				}
+			},
+		},
+		{
+			name:     "ovhcloud",
+			provider: hcloudv1alpha1.CloudProviderOVHcloud,
+			setup: func(reconciler *NodePoolReconciler, nodePool *hcloudv1alpha1.NodePool) {
+				reconciler.OVHCloudClient = mock.NewMockOVHcloudClient()
+				nodePool.Spec.OVHcloudConfig = &hcloudv1alpha1.OVHcloudConfig{
+					FlavorID: "b2-7",
+					ImageID:  "ubuntu-22.04",
+					Region:   "GRA7",
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default"},
+				Spec:       hcloudv1alpha1.NodePoolSpec{Provider: tt.provider},
+			}
+			tt.setup(reconciler, nodePool)
+
+			provider, err := reconciler.cloudProvider(nodePool)
+			if err != nil {
+				t.Fatalf("cloudProvider() error = %v", err)
+			}
+			listed := &poolServers{}
+			for _, name := range []string{"test-pool-a", "test-pool-b"} {
+				err := provider.Create(ctx, nodePool, listed, newServer{
+					Name:     name,
+					Location: "nbg1",
+					Labels:   map[string]string{"nodepool": "test-pool"},
+				})
+				if err != nil {
+					t.Fatalf("Create(%s) error = %v", name, err)
+				}
+			}
+
+			listed, err = provider.List(ctx, nodePool)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			names := provider.Names(listed)
+			if len(names) != 2 {
+				t.Fatalf("Names() = %v, want 2 servers", names)
+			}
+			if got := provider.CountReady(listed); got != 2 {
+				t.Errorf("CountReady() = %d, want 2", got)
+			}
+			ids := provider.InstanceIDs(listed)
+			running := provider.Running(listed)
+			for _, name := range names {
+				if ids[name] == "" || !running[name] {
+					t.Errorf("Server %s has ID %q and running %v, want an ID and running", name, ids[name], running[name])
+				}
+			}
+
+			if err := provider.Delete(ctx, nodePool, listed, names[0]); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := provider.Delete(ctx, nodePool, listed, "unknown"); err == nil {
+				t.Error("Delete() expected error for a server that isn't listed")
+			}
+			listed, err = provider.List(ctx, nodePool)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if names := provider.Names(listed); len(names) != 1 {
+				t.Errorf("Names() = %v after deleting a server, want 1 server", names)
+			}
+		})
+	}
+}
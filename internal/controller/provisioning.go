@@ -0,0 +1,108 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/metrics"
+)
+
+// provisionTimeout is how long a created node may take to become running before it is
+// counted as a failed provisioning
+const provisionTimeout = 30 * time.Minute
+
+// provisionTracker remembers when nodes were requested until they are seen running, so
+// provisioning latency is measured without blocking reconciles on the provider.
+// It is safe for concurrent use. Nodes still provisioning when the operator restarts
+// are not measured.
+type provisionTracker struct {
+	mu      sync.Mutex
+	pending map[string]provisioningNode
+}
+
+// provisioningNode is a node whose creation was requested but that is not running yet
+type provisioningNode struct {
+	pool      string
+	name      string
+	requested time.Time
+}
+
+// start records that a node of the pool was requested at the given time
+func (t *provisionTracker) start(nodePool *hcloudv1alpha1.NodePool, nodeName string, requested time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == nil {
+		t.pending = make(map[string]provisioningNode)
+	}
+	pool := poolKey(nodePool)
+	t.pending[pool+"/"+nodeName] = provisioningNode{pool: pool, name: nodeName, requested: requested}
+}
+
+// observe records the provisioning latency of the pool's nodes that are now running and
+// counts nodes that did not become running within provisionTimeout as failures.
+// running maps the names of the nodes listed by the provider to whether they are running.
+func (t *provisionTracker) observe(
+	nodePool *hcloudv1alpha1.NodePool,
+	running map[string]bool,
+	collector *metrics.Collector,
+	now time.Time,
+) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pool := poolKey(nodePool)
+	provider := string(nodePool.Spec.Provider)
+	for key, node := range t.pending {
+		if node.pool != pool {
+			continue
+		}
+
+		isRunning, listed := running[node.name]
+		switch {
+		case isRunning:
+			collector.RecordProvisionDuration(provider, nodePool.Name, now.Sub(node.requested))
+			delete(t.pending, key)
+		case now.Sub(node.requested) > provisionTimeout:
+			// A node that is no longer listed was deleted, e.g. by a scale down, and didn't fail
+			if listed {
+				collector.RecordProvisionFailure(provider, nodePool.Name)
+			}
+			delete(t.pending, key)
+		}
+	}
+}
+
+// forget drops the pool's pending nodes, e.g. when the pool is deleted
+func (t *provisionTracker) forget(nodePool *hcloudv1alpha1.NodePool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pool := poolKey(nodePool)
+	for key, node := range t.pending {
+		if node.pool == pool {
+			delete(t.pending, key)
+		}
+	}
+}
+
+func poolKey(nodePool *hcloudv1alpha1.NodePool) string {
+	return nodePool.Namespace + "/" + nodePool.Name
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+const (
+	provisionSecondsMetric  = "hcloud_operator_node_provision_seconds"
+	provisionFailuresMetric = "hcloud_operator_node_provision_failures_total"
+)
+
+func newProvisioningTestPool(name string) *hcloudv1alpha1.NodePool {
+	return &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+}
+
+func TestNodePoolReconciler_ProvisionDuration(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+	var serverName string
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		serverName = config.Name
+		return &hetzner.Server{ID: 1, Name: config.Name, Status: "initializing"}, nil
+	}
+
+	nodePool := newProvisioningTestPool("provision-pool")
+	labels := map[string]string{"provider": "hetzner", "nodepool": "provision-pool"}
+
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err != nil {
+		t.Fatalf("createServer() error = %v", err)
+	}
+
+	// Not measured while the server is still starting
+	reconciler.provisioning.observe(nodePool, map[string]bool{serverName: false}, reconciler.MetricsClient, time.Now())
+	if got := metricValue(t, provisionSecondsMetric, labels); got != 0 {
+		t.Errorf("%s count = %v before the server is running, want 0", provisionSecondsMetric, got)
+	}
+
+	reconciler.provisioning.observe(nodePool, map[string]bool{serverName: true}, reconciler.MetricsClient, time.Now())
+	if got := metricValue(t, provisionSecondsMetric, labels); got != 1 {
+		t.Errorf("%s count = %v, want 1", provisionSecondsMetric, got)
+	}
+
+	// Each node is measured once
+	reconciler.provisioning.observe(nodePool, map[string]bool{serverName: true}, reconciler.MetricsClient, time.Now())
+	if got := metricValue(t, provisionSecondsMetric, labels); got != 1 {
+		t.Errorf("%s count = %v after observing again, want 1", provisionSecondsMetric, got)
+	}
+	if got := metricValue(t, provisionFailuresMetric, labels); got != 0 {
+		t.Errorf("%s = %v, want 0", provisionFailuresMetric, got)
+	}
+}
+
+func TestNodePoolReconciler_ProvisionFailures(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+
+	mockHetzner, ok := reconciler.HCloudClient.(*mock.HetznerClient)
+	if !ok {
+		t.Fatal("Failed to cast HCloudClient to mock")
+	}
+	mockHetzner.CreateServerFunc = func(_ context.Context, _ hetzner.ServerConfig) (*hetzner.Server, error) {
+		return nil, &hetzner.ServerCreateError{Message: "simulated error"}
+	}
+
+	nodePool := newProvisioningTestPool("failing-pool")
+	labels := map[string]string{"provider": "hetzner", "nodepool": "failing-pool"}
+
+	// Failed creations are counted as failures
+	if err := reconciler.createServer(context.Background(), nodePool, &poolServers{}); err == nil {
+		t.Fatal("createServer() expected error")
+	}
+	if got := metricValue(t, provisionFailuresMetric, labels); got != 1 {
+		t.Errorf("%s = %v after a failed creation, want 1", provisionFailuresMetric, got)
+	}
+
+	// So are nodes that don't become running in time, without polluting the histogram
+	requested := time.Now().Add(-provisionTimeout - time.Minute)
+	reconciler.provisioning.start(nodePool, "failing-pool-stuck", requested)
+	reconciler.provisioning.start(nodePool, "failing-pool-deleted", requested)
+	reconciler.provisioning.observe(nodePool, map[string]bool{"failing-pool-stuck": false}, reconciler.MetricsClient, time.Now())
+
+	if got := metricValue(t, provisionFailuresMetric, labels); got != 2 {
+		t.Errorf("%s = %v after a provisioning timeout, want 2", provisionFailuresMetric, got)
+	}
+	if got := metricValue(t, provisionSecondsMetric, labels); got != 0 {
+		t.Errorf("%s count = %v, want 0", provisionSecondsMetric, got)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/ovhcloud"
+)
+
+// quotaExceededRequeueInterval is how long a pool whose provider refused to create a server
+// for lack of quota waits before trying again, since only raising the quota or freeing
+// resources resolves it
+const quotaExceededRequeueInterval = 15 * time.Minute
+
+// isQuotaExceeded reports whether err is a provider refusing to create a resource because
+// the project reached one of its quotas or resource limits
+func isQuotaExceeded(err error) bool {
+	return hetzner.IsQuotaExceededError(err) || ovhcloud.IsQuotaExceededError(err)
+}
+
+// handleQuotaExceeded reports failed server creations caused by the provider's quota in the
+// status and an event, and retries them after quotaExceededRequeueInterval instead of
+// backing off from the regular interval.
+// It returns false when err isn't a quota error.
+func (r *NodePoolReconciler) handleQuotaExceeded(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	err error,
+) (ctrl.Result, bool) {
+	if err == nil || !isQuotaExceeded(err) {
+		return ctrl.Result{}, false
+	}
+
+	log.FromContext(ctx).Info("Provider quota exceeded, retrying later",
+		"retryAfter", quotaExceededRequeueInterval, "reason", err.Error())
+	setQuotaExceededCondition(nodePool, metav1.ConditionTrue, "QuotaExceeded", err.Error())
+	r.Recorder.Event(nodePool, corev1.EventTypeWarning, "QuotaExceeded", err.Error())
+	r.updateStatus(ctx, nodePool, "QuotaExceeded", err.Error())
+	return ctrl.Result{RequeueAfter: quotaExceededRequeueInterval}, true
+}
+
+// setQuotaExceededCondition records whether server creations are refused for lack of quota
+func setQuotaExceededCondition(nodePool *hcloudv1alpha1.NodePool, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&nodePool.Status.Conditions, metav1.Condition{
+		Type:               conditionQuotaExceeded,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: nodePool.Generation,
+	})
+}
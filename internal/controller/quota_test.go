@@ -0,0 +1,126 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_QuotaExceeded(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+
+	client := setupStatusClient(reconciler)
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	quotaExceeded := true
+	mockHetzner.CreateServerFunc = func(_ context.Context, config hetzner.ServerConfig) (*hetzner.Server, error) {
+		if quotaExceeded {
+			return nil, fmt.Errorf("failed to create server: %w", hcloud.Error{
+				Code:    hcloud.ErrorCodeResourceLimitExceeded,
+				Message: "server limit exceeded",
+			})
+		}
+		return &hetzner.Server{ID: 1, Name: config.Name, Status: "running"}, nil
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "quota-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 2,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "quota-pool", Namespace: "default"}}
+	result, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v, want the quota error to be reported in the status", err)
+	}
+	if result.RequeueAfter != quotaExceededRequeueInterval {
+		t.Errorf("Reconcile() RequeueAfter = %v, want %v", result.RequeueAfter, quotaExceededRequeueInterval)
+	}
+	if mockHetzner.CreateServerCalls != 1 {
+		t.Errorf("CreateServer called %d times, want creations to stop at the first quota error", mockHetzner.CreateServerCalls)
+	}
+
+	if err := client.Get(ctx, req.NamespacedName, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionQuotaExceeded) {
+		t.Errorf("Expected the %s condition to be true, got %v", conditionQuotaExceeded, nodePool.Status.Conditions)
+	}
+	if nodePool.Status.Phase != "QuotaExceeded" {
+		t.Errorf("Status.Phase = %q, want QuotaExceeded", nodePool.Status.Phase)
+	}
+
+	recorder, ok := reconciler.Recorder.(*record.FakeRecorder)
+	if !ok {
+		t.Fatal("Failed to cast Recorder to fake recorder")
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "QuotaExceeded") {
+			t.Errorf("Unexpected event %q", event)
+		}
+	default:
+		t.Error("Expected a warning event for the exceeded quota")
+	}
+
+	// The condition clears once servers can be created again
+	quotaExceeded = false
+	result, err = reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if result.RequeueAfter != reconcileInterval {
+		t.Errorf("Reconcile() RequeueAfter = %v, want %v", result.RequeueAfter, reconcileInterval)
+	}
+	if err := client.Get(ctx, req.NamespacedName, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if meta.IsStatusConditionTrue(nodePool.Status.Conditions, conditionQuotaExceeded) {
+		t.Errorf("Expected the %s condition to clear, got %v", conditionQuotaExceeded, nodePool.Status.Conditions)
+	}
+}
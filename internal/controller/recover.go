@@ -0,0 +1,59 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+// operationReconcilePanic is the dead letter queue operation type of reconciles that panicked
+const operationReconcilePanic = "ReconcilePanic"
+
+// recoverPanic reports a panic recovered from the reconcile of a pool: its stack is logged,
+// counted in the panics metric and the last panic of each pool is kept in the dead letter
+// queue. It returns the error the reconcile fails with, so the pool is retried with backoff
+func (r *NodePoolReconciler) recoverPanic(ctx context.Context, req ctrl.Request, recovered interface{}) error {
+	err := fmt.Errorf("reconcile panicked: %v", recovered)
+	stack := string(debug.Stack())
+	log.FromContext(ctx).Error(err, "Recovered panic in NodePool reconcile", "stack", stack)
+
+	r.MetricsClient.RecordReconcilePanic(req.Name, req.Namespace)
+
+	if r.DeadLetterQueue != nil {
+		dlqErr := r.DeadLetterQueue.Add(&reliability.FailedOperation{
+			ID:            fmt.Sprintf("%s/%s/panic", req.Namespace, req.Name),
+			OperationType: operationReconcilePanic,
+			Error:         err,
+			Metadata: map[string]string{
+				"namespace": req.Namespace,
+				"nodepool":  req.Name,
+				"stack":     stack,
+			},
+		})
+		if dlqErr != nil {
+			log.FromContext(ctx).Error(dlqErr, "Failed to add reconcile panic to the dead letter queue")
+		}
+	}
+	return err
+}
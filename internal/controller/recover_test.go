@@ -0,0 +1,85 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_RecoversPanics(t *testing.T) {
+	reconciler, client := setupTestReconciler()
+	ctx := context.Background()
+
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+	mockHetzner.ListServersFunc = func(_ context.Context, _, _ string) ([]hetzner.Server, error) {
+		panic("simulated provider bug")
+	}
+
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "panic-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MaxNodes: 3,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			},
+		},
+	}
+	if err := client.Create(ctx, nodePool); err != nil {
+		t.Fatalf("Failed to create NodePool: %v", err)
+	}
+
+	labels := map[string]string{"nodepool": "panic-pool", "namespace": "default"}
+	before := metricValue(t, "hcloud_operator_reconcile_panics_total", labels)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "panic-pool", Namespace: "default"}}
+	result, err := reconciler.Reconcile(ctx, req)
+	if err == nil {
+		t.Fatal("Reconcile() expected error after a panic")
+	}
+	if result.RequeueAfter != reconcileInterval {
+		t.Errorf("Reconcile() RequeueAfter = %v, want %v", result.RequeueAfter, reconcileInterval)
+	}
+	if got := reconciler.failures.NumRequeues(req); got != 1 {
+		t.Errorf("Expected the panic to count as a failure, got %d", got)
+	}
+	if got := metricValue(t, "hcloud_operator_reconcile_panics_total", labels) - before; got != 1 {
+		t.Errorf("hcloud_operator_reconcile_panics_total increased by %v, want 1", got)
+	}
+	op, ok := reconciler.DeadLetterQueue.Get("default/panic-pool/panic")
+	if !ok {
+		t.Fatal("Expected the panic to be pushed to the dead letter queue")
+	}
+	if op.OperationType != operationReconcilePanic || op.Metadata["stack"] == "" {
+		t.Errorf("Unexpected dead letter queue entry %+v", op)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// RenderCloudInit returns the user data a new server of the pool would be created with,
+// without creating anything in the cloud, for debugging nodes that fail to bootstrap.
+// Volume mounts are left out since their devices are only known once the volumes are
+// created, and the user data is returned before it is compressed for the provider.
+// A raw cloudInit is rendered for a new server name
+func (r *NodePoolReconciler) RenderCloudInit(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	fromSnapshot bool,
+) (string, error) {
+	if nodePool.Spec.CloudInit != "" {
+		nodeName, err := newServerName(nodePool)
+		if err != nil {
+			return "", err
+		}
+		return renderCloudInit(nodePool, nodeName)
+	}
+	if nodePool.Spec.Bootstrap == nil {
+		return "", nil
+	}
+	return r.generateCloudInit(ctx, nodePool, fromSnapshot, nil)
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// defaultMaxSurge is the number of nodes created above the desired count during a rolling
+// update when the strategy doesn't set it
+const defaultMaxSurge = 1
+
+// nodeTemplateHash returns a hash of the pool's configuration that a node has to be replaced
+// to pick up: the provider, server type and image
+func nodeTemplateHash(nodePool *hcloudv1alpha1.NodePool) string {
+	fields := []string{string(nodePool.Spec.Provider)}
+	switch nodePool.Spec.Provider {
+	case hcloudv1alpha1.CloudProviderHetzner:
+		if config := nodePool.Spec.HetznerConfig; config != nil {
+			fields = append(fields, config.ServerType, config.Image)
+		}
+	case hcloudv1alpha1.CloudProviderOVHcloud:
+		if config := nodePool.Spec.OVHcloudConfig; config != nil {
+			fields = append(fields, config.Flavor, config.FlavorID, config.Image, config.ImageID)
+		}
+	case hcloudv1alpha1.CloudProviderScaleway:
+		if config := nodePool.Spec.ScalewayConfig; config != nil {
+			fields = append(fields, config.CommercialType, config.Image)
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+// recordNodeTemplates keeps the pool's node template hashes in sync with the listed nodes
+// Nodes without a hash, such as nodes created before hashes were recorded, are taken to run
+// the current configuration. It returns the number of nodes that do
+func recordNodeTemplates(nodePool *hcloudv1alpha1.NodePool, names []string) int {
+	current := nodeTemplateHash(nodePool)
+	hashes := make(map[string]string, len(names))
+	updated := 0
+	for _, name := range names {
+		hash, ok := nodePool.Status.NodeTemplateHashes[name]
+		if !ok {
+			hash = current
+		}
+		hashes[name] = hash
+		if hash == current {
+			updated++
+		}
+	}
+	nodePool.Status.NodeTemplateHashes = hashes
+	return updated
+}
+
+// recordNodeTemplate records that the named node was created with the pool's current configuration
+func recordNodeTemplate(nodePool *hcloudv1alpha1.NodePool, name string) {
+	if nodePool.Status.NodeTemplateHashes == nil {
+		nodePool.Status.NodeTemplateHashes = make(map[string]string)
+	}
+	nodePool.Status.NodeTemplateHashes[name] = nodeTemplateHash(nodePool)
+}
+
+// nodeOutdated reports whether the named node was created with a server type or image the
+// pool no longer uses
+func nodeOutdated(nodePool *hcloudv1alpha1.NodePool, name string) bool {
+	hash, ok := nodePool.Status.NodeTemplateHashes[name]
+	return ok && hash != nodeTemplateHash(nodePool)
+}
+
+// outdatedNodes returns the pool's outdated nodes, those that aren't running first
+func outdatedNodes(nodePool *hcloudv1alpha1.NodePool, running map[string]bool) []string {
+	var outdated []string
+	for _, name := range nodePool.Status.Nodes {
+		if nodeOutdated(nodePool, name) {
+			outdated = append(outdated, name)
+		}
+	}
+	sort.SliceStable(outdated, func(i, j int) bool {
+		return !running[outdated[i]] && running[outdated[j]]
+	})
+	return outdated
+}
+
+// removalRank orders the pool's nodes for removal: outdated nodes that aren't running come
+// first, then the other outdated nodes, then nodes running the current configuration
+func removalRank(nodePool *hcloudv1alpha1.NodePool, name string, running bool) int {
+	switch {
+	case !nodeOutdated(nodePool, name):
+		return 2
+	case running:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// rollingUpdateBounds returns the pool's maximum surge and unavailability
+func rollingUpdateBounds(strategy *hcloudv1alpha1.RollingUpdateStrategy) (maxSurge, maxUnavailable int) {
+	maxSurge = defaultMaxSurge
+	if strategy.MaxSurge != nil {
+		maxSurge = *strategy.MaxSurge
+	}
+	maxUnavailable = strategy.MaxUnavailable
+	if maxSurge <= 0 && maxUnavailable <= 0 {
+		// Nothing could be replaced without either bound
+		maxSurge = 1
+	}
+	return max(maxSurge, 0), max(maxUnavailable, 0)
+}
+
+// rollingUpdateSurge returns the number of nodes the pool keeps above the desired count while
+// its outdated nodes are replaced
+func rollingUpdateSurge(nodePool *hcloudv1alpha1.NodePool) int {
+	if nodePool.Spec.RollingUpdate == nil {
+		return 0
+	}
+	maxSurge, _ := rollingUpdateBounds(nodePool.Spec.RollingUpdate)
+	return min(maxSurge, len(outdatedNodes(nodePool, nil)))
+}
+
+// rollNodes takes one step of the pool's rolling update: it deletes the outdated nodes that
+// can go while keeping desiredNodes - maxUnavailable nodes running, then creates replacements
+// up to desiredNodes + maxSurge nodes. Replacements only count as running, letting further
+// outdated nodes go, once they are running. listed holds the pool's servers, currentNodes is
+// the number of nodes the pool has and running maps node names to whether they are running.
+// At most createBudget replacements are created. It returns the number of nodes created
+// and deleted.
+func (r *NodePoolReconciler) rollNodes(
+	ctx context.Context,
+	nodePool *hcloudv1alpha1.NodePool,
+	listed *poolServers,
+	desiredNodes, currentNodes int,
+	running map[string]bool,
+	createBudget int,
+) (created, deleted int, err error) {
+	logger := log.FromContext(ctx)
+
+	outdated := outdatedNodes(nodePool, running)
+	if nodePool.Spec.RollingUpdate == nil || len(outdated) == 0 {
+		return 0, 0, nil
+	}
+	maxSurge, maxUnavailable := rollingUpdateBounds(nodePool.Spec.RollingUpdate)
+
+	available := 0
+	for _, name := range nodePool.Status.Nodes {
+		if running[name] {
+			available++
+		}
+	}
+	minAvailable := desiredNodes - maxUnavailable
+	for _, name := range outdated {
+		if running[name] {
+			if available-1 < minAvailable {
+				break
+			}
+			available--
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		logger.Info("Deleting outdated nodes", "outdated", len(outdated), "deleting", deleted)
+		// Scale-downs remove outdated nodes first, in the order above
+		if _, err := r.scaleDown(ctx, nodePool, listed, deleted); err != nil {
+			return 0, 0, err
+		}
+		r.Recorder.Eventf(nodePool, corev1.EventTypeNormal, "RollingUpdate",
+			"Deleted %d of %d outdated nodes", deleted, len(outdated))
+	}
+
+	currentNodes -= deleted
+	updatedNodes := currentNodes - (len(outdated) - deleted)
+	toCreate := min(desiredNodes+maxSurge-currentNodes, desiredNodes-updatedNodes)
+	if toCreate > createBudget {
+		logger.Info("Limiting replacement nodes created in this reconcile", "missing", toCreate, "limit", createBudget)
+		toCreate = createBudget
+	}
+	if toCreate > 0 {
+		logger.Info("Creating replacement nodes", "outdated", len(outdated)-deleted, "creating", toCreate)
+	}
+	for ; created < toCreate; created++ {
+		if err := r.createServer(ctx, nodePool, listed); err != nil {
+			return created, deleted, err
+		}
+	}
+	return created, deleted, nil
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/hetzner"
+	"github.com/autokubeio/autokube/internal/mock"
+)
+
+func TestNodePoolReconciler_RollingUpdate(t *testing.T) {
+	tests := []struct {
+		name           string
+		strategy       hcloudv1alpha1.RollingUpdateStrategy
+		maxServers     int
+		minServers     int
+		firstReconcile int
+	}{
+		{
+			name:       "surge",
+			strategy:   hcloudv1alpha1.RollingUpdateStrategy{},
+			maxServers: 4,
+			minServers: 3,
+			// The replacement is created before any outdated node is deleted
+			firstReconcile: 4,
+		},
+		{
+			name:           "unavailable",
+			strategy:       hcloudv1alpha1.RollingUpdateStrategy{MaxSurge: new(int), MaxUnavailable: 1},
+			maxServers:     3,
+			minServers:     2,
+			firstReconcile: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, _ := setupTestReconciler()
+			ctx := context.Background()
+			mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-pool",
+					Namespace:  "default",
+					Finalizers: []string{nodePoolFinalizer},
+				},
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					Provider:      hcloudv1alpha1.CloudProviderHetzner,
+					MinNodes:      3,
+					MaxNodes:      5,
+					RollingUpdate: &tt.strategy,
+					HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+						ServerType: "cx11",
+						Image:      "ubuntu-22.04",
+						Location:   "nbg1",
+					},
+				},
+			}
+
+			// Three nodes created with the old image
+			oldHash := nodeTemplateHash(nodePool)
+			nodePool.Status.NodeTemplateHashes = make(map[string]string)
+			for _, name := range []string{"test-pool-0001", "test-pool-0002", "test-pool-0003"} {
+				if _, err := mockHetzner.CreateServer(ctx, hetzner.ServerConfig{Name: name}); err != nil {
+					t.Fatalf("Failed to create server: %v", err)
+				}
+				nodePool.Status.Nodes = append(nodePool.Status.Nodes, name)
+				nodePool.Status.NodeTemplateHashes[name] = oldHash
+			}
+			nodePool.Spec.HetznerConfig.Image = "ubuntu-24.04"
+			newHash := nodeTemplateHash(nodePool)
+
+			kubeClient := setupStatusClient(reconciler, nodePool)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-pool", Namespace: "default"}}
+			for i := 0; i < 10; i++ {
+				if _, err := reconciler.Reconcile(ctx, req); err != nil {
+					t.Fatalf("Reconcile() #%d error = %v", i+1, err)
+				}
+				servers, _ := mockHetzner.ListServers(ctx, "test-pool", "default")
+				if len(servers) > tt.maxServers || len(servers) < tt.minServers {
+					t.Fatalf("Reconcile() #%d left %d servers, want between %d and %d",
+						i+1, len(servers), tt.minServers, tt.maxServers)
+				}
+				if i == 0 && len(servers) != tt.firstReconcile {
+					t.Errorf("First reconcile left %d servers, want %d", len(servers), tt.firstReconcile)
+				}
+			}
+
+			if err := kubeClient.Get(ctx, req.NamespacedName, nodePool); err != nil {
+				t.Fatalf("Failed to get NodePool: %v", err)
+			}
+			servers, _ := mockHetzner.ListServers(ctx, "test-pool", "default")
+			if len(servers) != 3 {
+				t.Fatalf("Expected 3 servers after the rolling update, got %d", len(servers))
+			}
+			for _, server := range servers {
+				if hash := nodePool.Status.NodeTemplateHashes[server.Name]; hash != newHash {
+					t.Errorf("Server %s has template hash %q, want %q", server.Name, hash, newHash)
+				}
+			}
+			if nodePool.Status.UpdatedNodes != 3 {
+				t.Errorf("UpdatedNodes = %d, want 3", nodePool.Status.UpdatedNodes)
+			}
+			if mockHetzner.DeleteServerCalls != 3 {
+				t.Errorf("DeleteServerCalls = %d, want 3", mockHetzner.DeleteServerCalls)
+			}
+		})
+	}
+}
+
+func TestNodePoolReconciler_RollingUpdateMaxCreatesPerReconcile(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	reconciler.MaxCreatesPerReconcile = 2
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	maxSurge := 4
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider:      hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes:      4,
+			MaxNodes:      8,
+			RollingUpdate: &hcloudv1alpha1.RollingUpdateStrategy{MaxSurge: &maxSurge},
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-24.04",
+				Location:   "nbg1",
+			},
+		},
+		Status: hcloudv1alpha1.NodePoolStatus{NodeTemplateHashes: make(map[string]string)},
+	}
+	for _, name := range []string{"test-pool-0001", "test-pool-0002", "test-pool-0003", "test-pool-0004"} {
+		if _, err := mockHetzner.CreateServer(ctx, hetzner.ServerConfig{Name: name}); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		nodePool.Status.Nodes = append(nodePool.Status.Nodes, name)
+		nodePool.Status.NodeTemplateHashes[name] = "outdated"
+	}
+	setupStatusClient(reconciler, nodePool)
+
+	// maxSurge allows all four replacements at once, but only two are created per reconcile
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if created := mockHetzner.CreateServerCalls - 4; created != 2 {
+		t.Errorf("Created %d replacement nodes, want 2", created)
+	}
+}
+
+func TestNodePoolReconciler_RollingUpdateDisabled(t *testing.T) {
+	reconciler, _ := setupTestReconciler()
+	ctx := context.Background()
+	mockHetzner := reconciler.HCloudClient.(*mock.HetznerClient)
+
+	if _, err := mockHetzner.CreateServer(ctx, hetzner.ServerConfig{Name: "test-pool-0001"}); err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	nodePool := &hcloudv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pool",
+			Namespace:  "default",
+			Finalizers: []string{nodePoolFinalizer},
+		},
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Provider: hcloudv1alpha1.CloudProviderHetzner,
+			MinNodes: 1,
+			MaxNodes: 1,
+			HetznerConfig: &hcloudv1alpha1.HetznerCloudConfig{
+				ServerType: "cx11",
+				Image:      "ubuntu-24.04",
+				Location:   "nbg1",
+			},
+		},
+		Status: hcloudv1alpha1.NodePoolStatus{
+			Nodes:              []string{"test-pool-0001"},
+			NodeTemplateHashes: map[string]string{"test-pool-0001": "outdated"},
+		},
+	}
+	kubeClient := setupStatusClient(reconciler, nodePool)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-pool", Namespace: "default"}}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// Without a rolling update strategy outdated nodes are kept
+	if mockHetzner.CreateServerCalls != 1 || mockHetzner.DeleteServerCalls != 0 {
+		t.Errorf("Expected no nodes to be replaced, got %d created and %d deleted",
+			mockHetzner.CreateServerCalls-1, mockHetzner.DeleteServerCalls)
+	}
+	if err := kubeClient.Get(ctx, req.NamespacedName, nodePool); err != nil {
+		t.Fatalf("Failed to get NodePool: %v", err)
+	}
+	if nodePool.Status.UpdatedNodes != 0 {
+		t.Errorf("UpdatedNodes = %d, want 0", nodePool.Status.UpdatedNodes)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+// nodeSelectorOperators maps node selector operators to label selector operators
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// wakingPods counts the unscheduled pending pods that could run on a node of the pool, which
+// a pool without nodes is scaled up for
+func wakingPods(nodePool *hcloudv1alpha1.NodePool, pods []corev1.Pod) int {
+	count := 0
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodPending && pod.Spec.NodeName == "" && podFitsPool(nodePool, pod) {
+			count++
+		}
+	}
+	return count
+}
+
+// podFitsPool reports whether the pod's node selector, required node affinity and tolerations
+// allow it to be scheduled on a node of the pool, judging by the labels and taints the pool's
+// nodes get
+func podFitsPool(nodePool *hcloudv1alpha1.NodePool, pod *corev1.Pod) bool {
+	nodeLabels := labels.Set(nodePool.Spec.Labels)
+	if !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(nodeLabels) {
+		return false
+	}
+
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil &&
+			!nodeSelectorMatches(required, nodeLabels) {
+			return false
+		}
+	}
+
+	for i := range nodePool.Spec.Taints {
+		taint := &nodePool.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(pod.Spec.Tolerations, taint) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeSelectorMatches reports whether any of the node selector's terms matches the labels.
+// Terms selecting fields such as the node name can't be matched before the node exists
+func nodeSelectorMatches(nodeSelector *corev1.NodeSelector, nodeLabels labels.Set) bool {
+	for _, term := range nodeSelector.NodeSelectorTerms {
+		if len(term.MatchFields) > 0 || len(term.MatchExpressions) == 0 {
+			continue
+		}
+		selector := labels.NewSelector()
+		valid := true
+		for _, expression := range term.MatchExpressions {
+			requirement, err := labels.NewRequirement(expression.Key, nodeSelectorOperators[expression.Operator], expression.Values)
+			if err != nil {
+				valid = false
+				break
+			}
+			selector = selector.Add(*requirement)
+		}
+		if valid && selector.Matches(nodeLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+// toleratesTaint reports whether any of the tolerations tolerates the taint
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+)
+
+func TestNodePoolReconciler_ScaleFromZero(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeSelector map[string]string
+		want         int
+	}{
+		{name: "matching pending pod", nodeSelector: map[string]string{"pool": "dev"}, want: 1},
+		{name: "pending pod of another pool", nodeSelector: map[string]string{"pool": "ci"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler, client := setupTestReconciler()
+			ctx := context.Background()
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+				Spec:       corev1.PodSpec{NodeSelector: tt.nodeSelector},
+				Status:     corev1.PodStatus{Phase: corev1.PodPending},
+			}
+			if err := client.Create(ctx, pod); err != nil {
+				t.Fatalf("Failed to create pod: %v", err)
+			}
+
+			// A single pending pod is below the scale-up threshold
+			nodePool := &hcloudv1alpha1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "default"},
+				Spec: hcloudv1alpha1.NodePoolSpec{
+					MinNodes:           0,
+					MaxNodes:           5,
+					AutoScalingEnabled: true,
+					ScaleUpThreshold:   5,
+					Labels:             map[string]string{"pool": "dev"},
+				},
+			}
+			if got := reconciler.calculateDesiredNodes(ctx, nodePool); got != tt.want {
+				t.Errorf("calculateDesiredNodes() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodFitsPool(t *testing.T) {
+	nodePool := &hcloudv1alpha1.NodePool{
+		Spec: hcloudv1alpha1.NodePoolSpec{
+			Labels: map[string]string{"pool": "gpu", "zone": "nbg1"},
+			Taints: []corev1.Taint{
+				{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+			},
+		},
+	}
+	tolerateGPU := []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule}}
+	requiredAffinity := func(expressions ...corev1.NodeSelectorRequirement) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: expressions}},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name string
+		spec corev1.PodSpec
+		want bool
+	}{
+		{name: "untolerated taint", spec: corev1.PodSpec{}, want: false},
+		{name: "tolerated taint", spec: corev1.PodSpec{Tolerations: tolerateGPU}, want: true},
+		{
+			name: "matching node selector",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, NodeSelector: map[string]string{"pool": "gpu"}},
+			want: true,
+		},
+		{
+			name: "node selector of another pool",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, NodeSelector: map[string]string{"pool": "web"}},
+			want: false,
+		},
+		{
+			name: "matching node affinity",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, Affinity: requiredAffinity(corev1.NodeSelectorRequirement{
+				Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"nbg1", "fsn1"},
+			})},
+			want: true,
+		},
+		{
+			name: "node affinity excluding the pool",
+			spec: corev1.PodSpec{Tolerations: tolerateGPU, Affinity: requiredAffinity(corev1.NodeSelectorRequirement{
+				Key: "pool", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"gpu"},
+			})},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podFitsPool(nodePool, &corev1.Pod{Spec: tt.spec}); got != tt.want {
+				t.Errorf("podFitsPool() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hcloudv1alpha1 "github.com/autokubeio/autokube/api/v1alpha1"
+	"github.com/autokubeio/autokube/internal/scaleway"
+)
+
+// listScalewayInstances lists the instances of a Scaleway pool
+func (r *NodePoolReconciler) listScalewayInstances(ctx context.Context, nodePool *hcloudv1alpha1.NodePool) ([]scaleway.Instance, error) {
+	if r.ScalewayClient == nil {
+		return nil, fmt.Errorf("scaleway client not initialized")
+	}
+	if nodePool.Spec.ScalewayConfig == nil {
+		return nil, fmt.Errorf("scalewayConfig is required when provider is scaleway")
+	}
+
+	return r.ScalewayClient.ListInstances(ctx, nodePool.Spec.ScalewayConfig.Zone, nodePool.Name, nodePool.Namespace)
+}
+
+func (r *NodePoolReconciler) createScalewayInstance(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, instanceName string, labels map[string]string, userData string) error {
+	logger := log.FromContext(ctx)
+
+	if r.ScalewayClient == nil {
+		return fmt.Errorf("scaleway client not initialized")
+	}
+	if nodePool.Spec.ScalewayConfig == nil {
+		return fmt.Errorf("scalewayConfig is required when provider is scaleway")
+	}
+
+	config := nodePool.Spec.ScalewayConfig
+
+	// Pool membership is stored in the instance tags, see scaleway.Tags
+	opCtx, cancel := providerOperationContext(ctx, nodePool)
+	defer cancel()
+
+	instance, err := r.ScalewayClient.CreateInstance(opCtx, scaleway.InstanceConfig{
+		Name:           instanceName,
+		Zone:           config.Zone,
+		CommercialType: config.CommercialType,
+		Image:          config.Image,
+		ProjectID:      config.ProjectID,
+		Labels:         labels,
+		UserData:       userData,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create instance: %w", err)
+	}
+
+	logger.Info("Instance created successfully", "instance", instance.Name, "id", instance.ID, "zone", config.Zone)
+	return nil
+}
+
+func (r *NodePoolReconciler) deleteScalewayInstance(ctx context.Context, nodePool *hcloudv1alpha1.NodePool, instance scaleway.Instance) error {
+	logger := log.FromContext(ctx)
+
+	if err := r.checkNodeOwner(ctx, nodePool, instance.Name); err != nil {
+		return err
+	}
+
+	// Drain node before deletion
+	if err := r.drainNode(ctx, nodePool, instance.Name); err != nil {
+		logger.Error(err, "Failed to drain node, proceeding with deletion anyway", "node", instance.Name)
+	}
+
+	// Delete node from cluster
+	node := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: instance.Name}, node); err == nil {
+		if err := r.deleteNode(ctx, node); err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Failed to delete node from cluster", "node", instance.Name)
+		} else {
+			logger.Info("Node deleted from cluster", "node", instance.Name)
+		}
+	}
+
+	// Delete the instance along with its volumes
+	opCtx, cancel := providerOperationContext(ctx, nodePool)
+	defer cancel()
+	if err := r.ScalewayClient.DeleteInstance(opCtx, nodePool.Spec.ScalewayConfig.Zone, instance.ID); err != nil {
+		return fmt.Errorf("failed to delete instance %s: %w", instance.ID, err)
+	}
+
+	logger.Info("Instance deleted successfully", "instance", instance.Name, "id", instance.ID)
+	return nil
+}
+
+// runningScalewayInstances maps instance names to whether the instance is running
+func runningScalewayInstances(instances []scaleway.Instance) map[string]bool {
+	running := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		running[instance.Name] = instance.Status == scaleway.StateRunning
+	}
+	return running
+}
+
+func (r *NodePoolReconciler) countReadyScalewayInstances(instances []scaleway.Instance) int {
+	ready := 0
+	for _, instance := range instances {
+		if instance.Status == scaleway.StateRunning {
+			ready++
+		}
+	}
+	return ready
+}
+
+func (r *NodePoolReconciler) getScalewayInstanceNames(instances []scaleway.Instance) []string {
+	names := make([]string, len(instances))
+	for i, instance := range instances {
+		names[i] = instance.Name
+	}
+	return names
+}
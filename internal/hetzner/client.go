@@ -19,22 +19,54 @@ package hetzner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 
 	"github.com/autokubeio/autokube/internal/reliability"
 )
 
+// rollbackTimeout bounds deleting a server after its creation failed half-way
+const rollbackTimeout = time.Minute
+
+// ErrServerTypeUnavailable is returned when a server type does not exist or cannot be
+// ordered in the requested location
+var ErrServerTypeUnavailable = errors.New("server type unavailable")
+
+// ErrServerNotFound is returned when a server does not exist
+var ErrServerNotFound = errors.New("server not found")
+
 // ClientInterface defines the interface for interacting with Hetzner Cloud
 type ClientInterface interface {
 	ListServers(ctx context.Context, nodePoolName, namespace string) ([]Server, error)
 	CreateServer(ctx context.Context, config ServerConfig) (*Server, error)
 	DeleteServer(ctx context.Context, serverID int64) error
 	GetServer(ctx context.Context, serverID int64) (*Server, error)
-	GetOrCreateFirewall(ctx context.Context, name string, rules []hcloud.FirewallRule) (*hcloud.Firewall, error)
+	GetServerByName(ctx context.Context, name string) (*Server, error)
+	ValidateServerType(ctx context.Context, serverType, location string) error
+	GetOrCreateFirewall(ctx context.Context, name string, rules []hcloud.FirewallRule, labels map[string]string) (*hcloud.Firewall, error)
+	ListFirewalls(ctx context.Context, nodePoolName, namespace string) ([]*hcloud.Firewall, error)
 	DeleteFirewall(ctx context.Context, firewallID int64) error
+	GetOrCreatePlacementGroup(ctx context.Context, nameOrID string, labels map[string]string) (*hcloud.PlacementGroup, error)
+	GetPlacementGroup(ctx context.Context, nameOrID string) (*hcloud.PlacementGroup, error)
+	DeletePlacementGroup(ctx context.Context, placementGroupID int64) error
+	CreateVolume(ctx context.Context, config VolumeConfig) (*Volume, error)
+	DeleteVolume(ctx context.Context, volumeID int64) error
+	AttachISO(ctx context.Context, serverID int64, iso string) error
+	AssignFloatingIP(ctx context.Context, serverID int64, floatingIPs []string) (string, error)
+	UnassignFloatingIPs(ctx context.Context, serverID int64) error
+	AddServerToLoadBalancer(ctx context.Context, loadBalancer string, serverID int64, usePrivateIP bool) error
+	RemoveServerFromLoadBalancer(ctx context.Context, loadBalancer string, serverID int64) error
+	EnsureSnapshot(ctx context.Context, config SnapshotConfig) (*Snapshot, error)
+	DeleteStaleSnapshots(ctx context.Context, nodePoolName, namespace, keepHash string) error
 }
 
 // ServerCreateError is a custom error type for server creation failures
@@ -48,9 +80,38 @@ func (e *ServerCreateError) Error() string {
 
 // Client wraps the Hetzner Cloud API client
 type Client struct {
-	client         *hcloud.Client
-	retryConfig    reliability.RetryConfig
-	circuitBreaker *reliability.CircuitBreaker
+	// mu guards client, which is replaced when the token is rotated
+	mu               sync.RWMutex
+	client           *hcloud.Client
+	apiOptions       []hcloud.ClientOption
+	retryConfig      reliability.RetryConfig
+	circuitBreaker   *reliability.CircuitBreaker
+	operationTimeout time.Duration
+}
+
+// SetToken replaces the API client with one using the given token, e.g. after the token was
+// rotated. Operations already running finish with the previous token
+func (c *Client) SetToken(token string) {
+	client := c.newAPIClient(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.client = client
+}
+
+// newAPIClient creates a Hetzner Cloud API client for a token
+func (c *Client) newAPIClient(token string) *hcloud.Client {
+	opts := append([]hcloud.ClientOption{hcloud.WithToken(token)}, c.apiOptions...)
+	return hcloud.NewClient(opts...)
+}
+
+// api returns the Hetzner Cloud API client
+func (c *Client) api() *hcloud.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client
 }
 
 // ClientOption is a function that configures a Client
@@ -63,6 +124,30 @@ func WithRetryConfig(config reliability.RetryConfig) ClientOption {
 	}
 }
 
+// WithRetryableErrors sets the predicate deciding which errors are retried, replacing the
+// default IsRetryableError
+func WithRetryableErrors(retryable func(error) bool) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.RetryableErrors = retryable
+	}
+}
+
+// WithRetryBudget sets a retry budget shared with other clients, bounding their retries
+// together during an outage
+func WithRetryBudget(budget *reliability.RetryBudget) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.Budget = budget
+	}
+}
+
+// WithOperationTimeout bounds create, delete and attach operations that are
+// called without a deadline of their own
+func WithOperationTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.operationTimeout = timeout
+	}
+}
+
 // WithCircuitBreaker sets a circuit breaker
 func WithCircuitBreaker(cb *reliability.CircuitBreaker) ClientOption {
 	return func(c *Client) {
@@ -70,6 +155,13 @@ func WithCircuitBreaker(cb *reliability.CircuitBreaker) ClientOption {
 	}
 }
 
+// WithAPIOptions sets options of the underlying Hetzner Cloud API client, such as its endpoint
+func WithAPIOptions(opts ...hcloud.ClientOption) ClientOption {
+	return func(c *Client) {
+		c.apiOptions = append(c.apiOptions, opts...)
+	}
+}
+
 // Server represents a Hetzner Cloud server
 type Server struct {
 	ID        int64
@@ -78,18 +170,32 @@ type Server struct {
 	IPv4      string
 	IPv6      string
 	PrivateIP string
+	// Location is the name of the location the server runs in
+	Location string
+	// ServerType is the name of the server's type
+	ServerType string
+	// Image is the name of the system image the server was created from, empty for servers
+	// created from a snapshot
+	Image string
+	// VolumeIDs are the volumes attached to the server
+	VolumeIDs []int64
+	// CreatedAt is when the server was created
+	CreatedAt time.Time
+	// Labels are the server's labels
+	Labels map[string]string
 }
 
 // NewClient creates a new Hetzner Cloud client
 func NewClient(token string, opts ...ClientOption) *Client {
 	c := &Client{
-		client:      hcloud.NewClient(hcloud.WithToken(token)),
 		retryConfig: reliability.DefaultRetryConfig(),
 	}
+	c.retryConfig.RetryableErrors = IsRetryableError
 
 	for _, opt := range opts {
 		opt(c)
 	}
+	c.client = c.newAPIClient(token)
 
 	return c
 }
@@ -99,12 +205,24 @@ type ServerConfig struct {
 	Name       string
 	ServerType string
 	Image      string
+	ImageID    int64 // Image or snapshot ID, takes precedence over Image
 	Location   string
 	SSHKeys    []string
 	Labels     map[string]string
 	UserData   string
 	Network    string
 	Firewalls  []int64 // Firewall IDs to attach to the server
+	// PlacementGroupID is the placement group to create the server in, zero for none
+	PlacementGroupID int64
+	// VolumeIDs are volumes to attach on creation, so they are available when cloud-init runs
+	VolumeIDs []int64
+	// DisableIPv4 and DisableIPv6 skip assigning the public address of that family
+	// Disabling both requires Network, the server is then only reachable privately
+	DisableIPv4 bool
+	DisableIPv6 bool
+	// PrimaryIPs are the names or IDs of existing primary IPs the server takes its public
+	// addresses from, the first unassigned one of each enabled family in its location
+	PrimaryIPs []string
 }
 
 // ListServers lists all servers for a given node pool
@@ -115,22 +233,19 @@ func (c *Client) ListServers(ctx context.Context, nodePoolName, namespace string
 		},
 	}
 
-	servers, err := c.client.Server.AllWithOpts(ctx, opts)
+	var servers []*hcloud.Server
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		servers, err = c.api().Server.AllWithOpts(ctx, opts)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list servers: %w", err)
 	}
 
 	result := make([]Server, len(servers))
 	for i, s := range servers {
-		result[i] = Server{
-			ID:     s.ID,
-			Name:   s.Name,
-			Status: string(s.Status),
-			IPv4:   s.PublicNet.IPv4.IP.String(),
-		}
-		if s.PublicNet.IPv6.Network != nil {
-			result[i].IPv6 = s.PublicNet.IPv6.Network.String()
-		}
+		result[i] = serverFromHCloud(s)
 	}
 
 	return result, nil
@@ -140,8 +255,16 @@ func (c *Client) ListServers(ctx context.Context, nodePoolName, namespace string
 //
 //nolint:funlen,gocyclo // Server creation involves multiple API calls and configuration steps
 func (c *Client) CreateServer(ctx context.Context, config ServerConfig) (*Server, error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
 	// Get server type
-	serverType, _, err := c.client.ServerType.GetByName(ctx, config.ServerType)
+	var serverType *hcloud.ServerType
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		serverType, _, err = c.api().ServerType.GetByName(ctx, config.ServerType)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get server type: %w", err)
 	}
@@ -150,16 +273,46 @@ func (c *Client) CreateServer(ctx context.Context, config ServerConfig) (*Server
 	}
 
 	// Get image
-	image, _, err := c.client.Image.GetByNameAndArchitecture(ctx, config.Image, hcloud.ArchitectureX86)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get image: %w", err)
-	}
-	if image == nil {
-		return nil, fmt.Errorf("image %s not found", config.Image)
+	var image *hcloud.Image
+	if config.ImageID != 0 {
+		err = c.executeWithRetry(ctx, func() error {
+			var err error
+			image, _, err = c.api().Image.GetByID(ctx, config.ImageID)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image: %w", err)
+		}
+		if image == nil {
+			return nil, fmt.Errorf("image %d not found", config.ImageID)
+		}
+	} else {
+		// Resolve the image built for the server type's CPU architecture (x86 or arm)
+		architecture := serverType.Architecture
+		if architecture == "" {
+			architecture = hcloud.ArchitectureX86
+		}
+		err = c.executeWithRetry(ctx, func() error {
+			var err error
+			image, _, err = c.api().Image.GetByNameAndArchitecture(ctx, config.Image, architecture)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image: %w", err)
+		}
+		if image == nil {
+			return nil, fmt.Errorf("image %s not found for architecture %s of server type %s",
+				config.Image, architecture, config.ServerType)
+		}
 	}
 
 	// Get location
-	location, _, err := c.client.Location.GetByName(ctx, config.Location)
+	var location *hcloud.Location
+	err = c.executeWithRetry(ctx, func() error {
+		var err error
+		location, _, err = c.api().Location.GetByName(ctx, config.Location)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get location: %w", err)
 	}
@@ -170,7 +323,12 @@ func (c *Client) CreateServer(ctx context.Context, config ServerConfig) (*Server
 	// Get SSH keys
 	var sshKeys []*hcloud.SSHKey
 	for _, keyName := range config.SSHKeys {
-		key, _, err := c.client.SSHKey.GetByName(ctx, keyName)
+		var key *hcloud.SSHKey
+		err := c.executeWithRetry(ctx, func() error {
+			var err error
+			key, _, err = c.api().SSHKey.GetByName(ctx, keyName)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to get SSH key %s: %w", keyName, err)
 		}
@@ -191,6 +349,25 @@ func (c *Client) CreateServer(ctx context.Context, config ServerConfig) (*Server
 		UserData:   config.UserData,
 	}
 
+	// Reuse reserved primary IPs instead of assigning new addresses
+	primaryIPv4, primaryIPv6, err := c.serverPrimaryIPs(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only request the public addresses that are enabled
+	if config.DisableIPv4 || config.DisableIPv6 || primaryIPv4 != nil || primaryIPv6 != nil {
+		if config.DisableIPv4 && config.DisableIPv6 && config.Network == "" {
+			return nil, fmt.Errorf("a network is required when both public IPv4 and IPv6 are disabled")
+		}
+		createOpts.PublicNet = &hcloud.ServerCreatePublicNet{
+			EnableIPv4: !config.DisableIPv4,
+			EnableIPv6: !config.DisableIPv6,
+			IPv4:       primaryIPv4,
+			IPv6:       primaryIPv6,
+		}
+	}
+
 	// Get network if specified (will attach after server creation)
 	var network *hcloud.Network
 	if config.Network != "" {
@@ -199,13 +376,21 @@ func (c *Client) CreateServer(ctx context.Context, config ServerConfig) (*Server
 		// Check if it's a numeric ID
 		if networkID, parseErr := strconv.ParseInt(config.Network, 10, 64); parseErr == nil {
 			// It's an ID
-			network, _, err = c.client.Network.GetByID(ctx, networkID)
+			err = c.executeWithRetry(ctx, func() error {
+				var err error
+				network, _, err = c.api().Network.GetByID(ctx, networkID)
+				return err
+			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to get network by ID: %w", err)
 			}
 		} else {
 			// It's a name
-			network, _, err = c.client.Network.GetByName(ctx, config.Network)
+			err = c.executeWithRetry(ctx, func() error {
+				var err error
+				network, _, err = c.api().Network.GetByName(ctx, config.Network)
+				return err
+			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to get network by name: %w", err)
 			}
@@ -214,6 +399,11 @@ func (c *Client) CreateServer(ctx context.Context, config ServerConfig) (*Server
 		if network == nil {
 			return nil, fmt.Errorf("network %s not found", config.Network)
 		}
+
+		// A server without any public address must be attached to a network on creation
+		if config.DisableIPv4 && config.DisableIPv6 {
+			createOpts.Networks = []*hcloud.Network{network}
+		}
 	}
 
 	// Attach firewalls if specified
@@ -227,70 +417,128 @@ func (c *Client) CreateServer(ctx context.Context, config ServerConfig) (*Server
 		createOpts.Firewalls = firewalls
 	}
 
-	result, _, err := c.client.Server.Create(ctx, createOpts)
+	if config.PlacementGroupID != 0 {
+		createOpts.PlacementGroup = &hcloud.PlacementGroup{ID: config.PlacementGroupID}
+	}
+
+	// Volumes are mounted by cloud-init, not by the Hetzner automount
+	for _, volumeID := range config.VolumeIDs {
+		createOpts.Volumes = append(createOpts.Volumes, &hcloud.Volume{ID: volumeID})
+	}
+	if len(createOpts.Volumes) > 0 {
+		createOpts.Automount = hcloud.Ptr(false)
+	}
+
+	result, _, err := c.api().Server.Create(ctx, createOpts)
+	if isPrimaryIPAssignedError(err) {
+		// Another server took the primary IP since it was picked
+		return nil, fmt.Errorf("failed to create server: %w: %v", ErrPrimaryIPAssigned, err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create server: %w", err)
 	}
 
 	server := &Server{
-		ID:     result.Server.ID,
-		Name:   result.Server.Name,
-		Status: string(result.Server.Status),
+		ID:         result.Server.ID,
+		Name:       result.Server.Name,
+		Status:     string(result.Server.Status),
+		Location:   config.Location,
+		ServerType: config.ServerType,
+		VolumeIDs:  config.VolumeIDs,
+		CreatedAt:  result.Server.Created,
+	}
+	if result.Server.Image != nil {
+		server.Image = result.Server.Image.Name
 	}
 
-	if result.Server.PublicNet.IPv4.IP != nil {
+	if !result.Server.PublicNet.IPv4.IsUnspecified() {
 		server.IPv4 = result.Server.PublicNet.IPv4.IP.String()
 	}
+	if result.Server.PublicNet.IPv6.Network != nil {
+		server.IPv6 = result.Server.PublicNet.IPv6.Network.String()
+	}
+	if len(result.Server.PrivateNet) > 0 {
+		server.PrivateIP = result.Server.PrivateNet[0].IP.String()
+	}
 
 	// Attach to network after server creation if network was specified
-	if network != nil {
-		attachOpts := hcloud.ServerAttachToNetworkOpts{
-			Network: network,
-		}
-		action, _, err := c.client.Server.AttachToNetwork(ctx, result.Server, attachOpts)
+	if network != nil && len(createOpts.Networks) == 0 {
+		privateIP, err := c.attachToNetwork(ctx, result.Server, network)
 		if err != nil {
-			return nil, fmt.Errorf("failed to attach server to network: %w", err)
+			// Roll back so a failed attach doesn't leave a billed server without a private IP
+			if delErr := c.rollbackServer(ctx, result.Server.ID); delErr != nil {
+				return nil, fmt.Errorf("%w (rollback of server %d failed: %v)", err, result.Server.ID, delErr)
+			}
+			return nil, err
 		}
+		server.PrivateIP = privateIP
+	}
 
-		// Wait for the action to complete
-		_, errCh := c.client.Action.WatchProgress(ctx, action)
-		if err := <-errCh; err != nil {
-			return nil, fmt.Errorf("failed to wait for network attachment: %w", err)
-		}
+	return server, nil
+}
 
-		// Refresh server data to get the assigned private IP
-		var updatedServer *hcloud.Server
+// attachToNetwork attaches a server to a network and returns its private IP
+func (c *Client) attachToNetwork(ctx context.Context, server *hcloud.Server, network *hcloud.Network) (string, error) {
+	attachOpts := hcloud.ServerAttachToNetworkOpts{
+		Network: network,
+	}
+	action, _, err := c.api().Server.AttachToNetwork(ctx, server, attachOpts)
+	if err != nil {
+		return "", fmt.Errorf("failed to attach server to network: %w", err)
+	}
 
-		err = c.executeWithRetry(ctx, func() error {
-			var err error
-			updatedServer, _, err = c.client.Server.GetByID(ctx, result.Server.ID)
-			if err != nil {
-				return fmt.Errorf("failed to get server: %w", err)
-			}
+	// Wait for the action to complete
+	_, errCh := c.api().Action.WatchProgress(ctx, action)
+	if err := <-errCh; err != nil {
+		return "", fmt.Errorf("failed to wait for network attachment: %w", err)
+	}
 
-			if updatedServer == nil {
-				return fmt.Errorf("server not found")
-			}
-			return nil
-		})
+	// Refresh server data to get the assigned private IP
+	var updatedServer *hcloud.Server
 
+	err = c.executeWithRetry(ctx, func() error {
+		var err error
+		updatedServer, _, err = c.api().Server.GetByID(ctx, server.ID)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("failed to get server: %w", err)
 		}
 
-		if len(updatedServer.PrivateNet) > 0 {
-			server.PrivateIP = updatedServer.PrivateNet[0].IP.String()
+		if updatedServer == nil {
+			return fmt.Errorf("server not found")
 		}
+		return nil
+	})
+
+	if err != nil {
+		return "", err
 	}
 
-	return server, nil
+	if len(updatedServer.PrivateNet) > 0 {
+		return updatedServer.PrivateNet[0].IP.String(), nil
+	}
+	return "", nil
+}
+
+// rollbackServer deletes a server whose creation failed half-way
+// It runs on a fresh context so it still happens when ctx timed out
+func (c *Client) rollbackServer(ctx context.Context, serverID int64) error {
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	return c.DeleteServer(rollbackCtx, serverID)
 }
 
 // DeleteServer deletes a server from Hetzner Cloud
 func (c *Client) DeleteServer(ctx context.Context, serverID int64) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
 	server := &hcloud.Server{ID: serverID}
 
-	_, _, err := c.client.Server.DeleteWithResult(ctx, server)
+	err := c.executeWithRetry(ctx, func() error {
+		_, _, err := c.api().Server.DeleteWithResult(ctx, server)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete server: %w", err)
 	}
@@ -300,58 +548,176 @@ func (c *Client) DeleteServer(ctx context.Context, serverID int64) error {
 
 // GetServer gets a server by ID
 func (c *Client) GetServer(ctx context.Context, serverID int64) (*Server, error) {
-	server, _, err := c.client.Server.GetByID(ctx, serverID)
+	var server *hcloud.Server
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		server, _, err = c.api().Server.GetByID(ctx, serverID)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get server: %w", err)
 	}
 
 	if server == nil {
-		return nil, fmt.Errorf("server not found")
+		return nil, ErrServerNotFound
 	}
 
-	result := &Server{
-		ID:     server.ID,
-		Name:   server.Name,
-		Status: string(server.Status),
+	result := serverFromHCloud(server)
+	return &result, nil
+}
+
+// GetServerByName gets a server by name, returning nil if it does not exist
+func (c *Client) GetServerByName(ctx context.Context, name string) (*Server, error) {
+	var server *hcloud.Server
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		server, _, err = c.api().Server.GetByName(ctx, name)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server %s: %w", name, err)
 	}
 
-	if server.PublicNet.IPv4.IP != nil {
-		result.IPv4 = server.PublicNet.IPv4.IP.String()
+	if server == nil {
+		return nil, nil
 	}
-	if server.PublicNet.IPv6.Network != nil {
-		result.IPv6 = server.PublicNet.IPv6.Network.String()
+
+	result := serverFromHCloud(server)
+	return &result, nil
+}
+
+// ValidateServerType checks that a server type exists, is not past its deprecation
+// and can be ordered in the given location. An empty location skips the location check.
+// Unavailable types are reported as ErrServerTypeUnavailable, API failures as other errors.
+func (c *Client) ValidateServerType(ctx context.Context, serverType, location string) error {
+	var st *hcloud.ServerType
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		st, _, err = c.api().ServerType.GetByName(ctx, serverType)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get server type: %w", err)
+	}
+	if st == nil {
+		return fmt.Errorf("%w: server type %s not found", ErrServerTypeUnavailable, serverType)
 	}
 
-	return result, nil
+	if st.IsDeprecated() && time.Now().After(st.UnavailableAfter()) {
+		return fmt.Errorf("%w: server type %s is no longer available since %s",
+			ErrServerTypeUnavailable, serverType, st.UnavailableAfter().Format(time.DateOnly))
+	}
+
+	if location == "" {
+		return nil
+	}
+	for _, pricing := range st.Pricings {
+		if pricing.Location != nil && pricing.Location.Name == location {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: server type %s is not available in location %s", ErrServerTypeUnavailable, serverType, location)
+}
+
+// Ping checks that the API is reachable and accepts the token with a single lightweight
+// request. It bypasses retries and the circuit breaker, so health checks don't affect them
+func (c *Client) Ping(ctx context.Context) error {
+	_, _, err := c.api().Location.List(ctx, hcloud.LocationListOpts{ListOpts: hcloud.ListOpts{PerPage: 1}})
+	if err != nil {
+		return fmt.Errorf("failed to list locations: %w", err)
+	}
+	return nil
+}
+
+// serverFromHCloud converts an hcloud server to a Server
+func serverFromHCloud(s *hcloud.Server) Server {
+	server := Server{
+		ID:        s.ID,
+		Name:      s.Name,
+		Status:    string(s.Status),
+		CreatedAt: s.Created,
+		Labels:    s.Labels,
+	}
+	if !s.PublicNet.IPv4.IsUnspecified() {
+		server.IPv4 = s.PublicNet.IPv4.IP.String()
+	}
+	if s.PublicNet.IPv6.Network != nil {
+		server.IPv6 = s.PublicNet.IPv6.Network.String()
+	}
+	if len(s.PrivateNet) > 0 {
+		server.PrivateIP = s.PrivateNet[0].IP.String()
+	}
+	if s.Datacenter != nil && s.Datacenter.Location != nil {
+		server.Location = s.Datacenter.Location.Name
+	}
+	if s.ServerType != nil {
+		server.ServerType = s.ServerType.Name
+	}
+	if s.Image != nil {
+		server.Image = s.Image.Name
+	}
+	for _, volume := range s.Volumes {
+		server.VolumeIDs = append(server.VolumeIDs, volume.ID)
+	}
+	return server
 }
 
 // GetOrCreateFirewall creates or retrieves a Hetzner Cloud Firewall
+// An existing firewall gets the given labels added so it can be found by ListFirewalls
 func (c *Client) GetOrCreateFirewall(
 	ctx context.Context,
 	name string,
 	rules []hcloud.FirewallRule,
+	labels map[string]string,
 ) (*hcloud.Firewall, error) {
 	// Try to find existing firewall
-	firewall, _, err := c.client.Firewall.GetByName(ctx, name)
+	var firewall *hcloud.Firewall
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		firewall, _, err = c.api().Firewall.GetByName(ctx, name)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get firewall: %w", err)
 	}
 
 	if firewall != nil {
-		// Update rules if they differ
-		_, _, err := c.client.Firewall.SetRules(ctx, firewall, hcloud.FirewallSetRulesOpts{
-			Rules: rules,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to update firewall rules: %w", err)
+		// Update rules if they differ, setting them triggers an action even when unchanged
+		if !firewallRulesEqual(firewall.Rules, rules) {
+			_, _, err := c.api().Firewall.SetRules(ctx, firewall, hcloud.FirewallSetRulesOpts{
+				Rules: rules,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to update firewall rules: %w", err)
+			}
+		}
+
+		// Label firewalls created before they were labeled
+		merged := make(map[string]string, len(firewall.Labels)+len(labels))
+		missing := false
+		for k, v := range firewall.Labels {
+			merged[k] = v
+		}
+		for k, v := range labels {
+			if merged[k] != v {
+				missing = true
+			}
+			merged[k] = v
+		}
+		if missing {
+			firewall, _, err = c.api().Firewall.Update(ctx, firewall, hcloud.FirewallUpdateOpts{Labels: merged})
+			if err != nil {
+				return nil, fmt.Errorf("failed to update firewall labels: %w", err)
+			}
 		}
 		return firewall, nil
 	}
 
 	// Create new firewall
-	result, _, err := c.client.Firewall.Create(ctx, hcloud.FirewallCreateOpts{
-		Name:  name,
-		Rules: rules,
+	result, _, err := c.api().Firewall.Create(ctx, hcloud.FirewallCreateOpts{
+		Name:   name,
+		Rules:  rules,
+		Labels: labels,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create firewall: %w", err)
@@ -360,11 +726,78 @@ func (c *Client) GetOrCreateFirewall(
 	return result.Firewall, nil
 }
 
+// firewallRulesEqual reports whether two sets of firewall rules are the same, regardless
+// of the order of the rules and of their IP ranges
+func firewallRulesEqual(a, b []hcloud.FirewallRule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	keys := make(map[string]int, len(a))
+	for _, rule := range a {
+		keys[firewallRuleKey(rule)]++
+	}
+	for _, rule := range b {
+		key := firewallRuleKey(rule)
+		if keys[key] == 0 {
+			return false
+		}
+		keys[key]--
+	}
+	return true
+}
+
+// firewallRuleKey renders a firewall rule in a canonical form
+func firewallRuleKey(rule hcloud.FirewallRule) string {
+	ipNets := func(nets []net.IPNet) string {
+		ranges := make([]string, len(nets))
+		for i, ipNet := range nets {
+			ranges[i] = ipNet.String()
+		}
+		sort.Strings(ranges)
+		return strings.Join(ranges, ",")
+	}
+	var port, description string
+	if rule.Port != nil {
+		port = *rule.Port
+	}
+	if rule.Description != nil {
+		description = *rule.Description
+	}
+	return strings.Join([]string{
+		string(rule.Direction), string(rule.Protocol), port,
+		ipNets(rule.SourceIPs), ipNets(rule.DestinationIPs), description,
+	}, "|")
+}
+
+// ListFirewalls lists the firewalls created for a given node pool
+func (c *Client) ListFirewalls(ctx context.Context, nodePoolName, namespace string) ([]*hcloud.Firewall, error) {
+	opts := hcloud.FirewallListOpts{
+		ListOpts: hcloud.ListOpts{
+			LabelSelector: fmt.Sprintf("managed-by=nodepools,nodepool=%s,namespace=%s", nodePoolName, namespace),
+		},
+	}
+
+	var firewalls []*hcloud.Firewall
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		firewalls, err = c.api().Firewall.AllWithOpts(ctx, opts)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firewalls: %w", err)
+	}
+
+	return firewalls, nil
+}
+
 // DeleteFirewall deletes a Hetzner Cloud Firewall
 func (c *Client) DeleteFirewall(ctx context.Context, firewallID int64) error {
 	firewall := &hcloud.Firewall{ID: firewallID}
 
-	_, err := c.client.Firewall.Delete(ctx, firewall)
+	err := c.executeWithRetry(ctx, func() error {
+		_, err := c.api().Firewall.Delete(ctx, firewall)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete firewall: %w", err)
 	}
@@ -372,12 +805,181 @@ func (c *Client) DeleteFirewall(ctx context.Context, firewallID int64) error {
 	return nil
 }
 
-// executeWithRetry executes an operation with retry logic
+// GetOrCreatePlacementGroup retrieves a Hetzner Cloud Placement Group by name or ID
+// A group given by name is created as a spread group with the given labels if it does not exist
+func (c *Client) GetOrCreatePlacementGroup(
+	ctx context.Context,
+	nameOrID string,
+	labels map[string]string,
+) (*hcloud.PlacementGroup, error) {
+	placementGroup, err := c.GetPlacementGroup(ctx, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	if placementGroup != nil {
+		return placementGroup, nil
+	}
+
+	if _, err := strconv.ParseInt(nameOrID, 10, 64); err == nil {
+		return nil, fmt.Errorf("placement group %s not found", nameOrID)
+	}
+
+	result, _, err := c.api().PlacementGroup.Create(ctx, hcloud.PlacementGroupCreateOpts{
+		Name:   nameOrID,
+		Labels: labels,
+		Type:   hcloud.PlacementGroupTypeSpread,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create placement group: %w", err)
+	}
+
+	return result.PlacementGroup, nil
+}
+
+// GetPlacementGroup gets a Hetzner Cloud Placement Group by name or ID, nil if it does not exist
+func (c *Client) GetPlacementGroup(ctx context.Context, nameOrID string) (*hcloud.PlacementGroup, error) {
+	var placementGroup *hcloud.PlacementGroup
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		placementGroup, _, err = c.api().PlacementGroup.Get(ctx, nameOrID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get placement group: %w", err)
+	}
+
+	return placementGroup, nil
+}
+
+// DeletePlacementGroup deletes a Hetzner Cloud Placement Group
+func (c *Client) DeletePlacementGroup(ctx context.Context, placementGroupID int64) error {
+	placementGroup := &hcloud.PlacementGroup{ID: placementGroupID}
+
+	err := c.executeWithRetry(ctx, func() error {
+		_, err := c.api().PlacementGroup.Delete(ctx, placementGroup)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete placement group: %w", err)
+	}
+
+	return nil
+}
+
+// AddServerToLoadBalancer adds a server as a target of a Hetzner Cloud Load Balancer
+// The load balancer may be given by name or ID
+func (c *Client) AddServerToLoadBalancer(ctx context.Context, loadBalancer string, serverID int64, usePrivateIP bool) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	lb, err := c.getLoadBalancer(ctx, loadBalancer)
+	if err != nil {
+		return err
+	}
+
+	action, _, err := c.api().LoadBalancer.AddServerTarget(ctx, lb, hcloud.LoadBalancerAddServerTargetOpts{
+		Server:       &hcloud.Server{ID: serverID},
+		UsePrivateIP: hcloud.Ptr(usePrivateIP),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add server %d to load balancer %s: %w", serverID, loadBalancer, err)
+	}
+
+	// Wait for the action to complete
+	_, errCh := c.api().Action.WatchProgress(ctx, action)
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("failed to wait for load balancer target: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveServerFromLoadBalancer removes a server target from a Hetzner Cloud Load Balancer
+// The load balancer may be given by name or ID
+func (c *Client) RemoveServerFromLoadBalancer(ctx context.Context, loadBalancer string, serverID int64) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	lb, err := c.getLoadBalancer(ctx, loadBalancer)
+	if err != nil {
+		return err
+	}
+
+	action, _, err := c.api().LoadBalancer.RemoveServerTarget(ctx, lb, &hcloud.Server{ID: serverID})
+	if err != nil {
+		return fmt.Errorf("failed to remove server %d from load balancer %s: %w", serverID, loadBalancer, err)
+	}
+
+	// Wait for the action to complete
+	_, errCh := c.api().Action.WatchProgress(ctx, action)
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("failed to wait for load balancer target removal: %w", err)
+	}
+
+	return nil
+}
+
+// getLoadBalancer resolves a load balancer by name or ID
+func (c *Client) getLoadBalancer(ctx context.Context, loadBalancer string) (*hcloud.LoadBalancer, error) {
+	var lb *hcloud.LoadBalancer
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		lb, _, err = c.api().LoadBalancer.Get(ctx, loadBalancer)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get load balancer: %w", err)
+	}
+	if lb == nil {
+		return nil, fmt.Errorf("load balancer %s not found", loadBalancer)
+	}
+	return lb, nil
+}
+
+// executeWithRetry executes an operation with retry logic, bounded by the retry budget and
+// the circuit breaker. Lookups, listings and deletes go through it; creations and server
+// actions are sent once, since retrying them after a lost response could repeat them
 func (c *Client) executeWithRetry(ctx context.Context, operation func() error) error {
-	if c.circuitBreaker != nil {
-		return c.circuitBreaker.Execute(func() error {
-			return reliability.RetryOperation(ctx, c.retryConfig, operation)
-		})
+	config := c.rateLimitAwareRetryConfig()
+	if c.circuitBreaker == nil {
+		return reliability.RetryOperation(ctx, config, operation)
+	}
+
+	// Requests the API rejected, such as deletes of servers that are already gone, show it
+	// is reachable and don't count towards opening the circuit
+	var rejected error
+	err := c.circuitBreaker.Execute(func() error {
+		err := reliability.RetryOperation(ctx, config, operation)
+		if isRejected(err) {
+			rejected = err
+			return nil
+		}
+		return err
+	})
+	if rejected != nil {
+		return rejected
+	}
+	return err
+}
+
+// isRejected reports whether err is the Hetzner Cloud API refusing a request, as opposed to
+// failing to serve it
+func isRejected(err error) bool {
+	var apiErr hcloud.Error
+	if !errors.As(err, &apiErr) || apiErr.Response() == nil || apiErr.Response().Response == nil {
+		return false
+	}
+	status := apiErr.Response().StatusCode
+	return status < http.StatusInternalServerError &&
+		status != http.StatusRequestTimeout &&
+		status != http.StatusTooManyRequests
+}
+
+// operationContext bounds a provider operation by the client's operation timeout,
+// unless the caller already set a deadline (e.g. a per-pool override)
+func (c *Client) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.operationTimeout <= 0 {
+		return ctx, func() {}
 	}
-	return reliability.RetryOperation(ctx, c.retryConfig, operation)
+	return context.WithTimeout(ctx, c.operationTimeout)
 }
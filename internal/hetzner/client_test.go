@@ -0,0 +1,679 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+const testServerID = 42
+
+// fakeAPI is a minimal Hetzner Cloud API serving the calls made by CreateServer
+type fakeAPI struct {
+	mu       sync.Mutex
+	handlers map[string]string
+	// failures are the API error codes requests fail with instead of their handler response
+	failures map[string]string
+	// delays hold requests back before they are answered, or until the client gives up
+	delays map[string]time.Duration
+	// limited are the number of times requests are rejected by the rate limit before they
+	// are answered
+	limited  map[string]int
+	created  []string
+	deleted  []string
+	requests []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *Client) {
+	t.Helper()
+
+	api := &fakeAPI{
+		handlers: map[string]string{
+			"GET /server_types":            `{"server_types": [{"id": 1, "name": "cx11", "architecture": "x86"}]}`,
+			"GET /images?architecture=x86": `{"images": [{"id": 1, "name": "ubuntu-22.04", "type": "system", "status": "available", "architecture": "x86"}]}`,
+			"GET /locations":               `{"locations": [{"id": 1, "name": "nbg1"}]}`,
+			"GET /networks":                `{"networks": [{"id": 1, "name": "private", "ip_range": "10.0.0.0/16"}]}`,
+			"POST /servers": fmt.Sprintf(`{"server": {"id": %d, "name": "test-pool-1a2b", "status": "initializing",
+				"public_net": {"ipv4": {"ip": "192.0.2.1"}}}}`, testServerID),
+			fmt.Sprintf("DELETE /servers/%d", testServerID): `{"action": {"id": 2, "command": "delete_server", "status": "running"}}`,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		if arch := r.URL.Query().Get("architecture"); arch != "" {
+			key += "?architecture=" + arch
+		}
+
+		request, _ := io.ReadAll(r.Body)
+
+		api.mu.Lock()
+		body, ok := api.handlers[key]
+		failure := api.failures[key]
+		delay := api.delays[key]
+		limited := api.limited[key] > 0
+		if limited {
+			api.limited[key]--
+		}
+		api.requests = append(api.requests, key)
+		if key == "POST /servers" {
+			api.created = append(api.created, string(request))
+		}
+		if r.Method == http.MethodDelete {
+			api.deleted = append(api.deleted, r.URL.Path)
+		}
+		api.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if limited {
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": {"code": "rate_limit_exceeded", "message": "limit of 3600 requests per hour reached"}}`)
+			return
+		}
+		if failure != "" {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprintf(w, `{"error": {"code": %q, "message": "request %s failed"}}`, failure, key)
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprintf(w, `{"error": {"code": "invalid_input", "message": "unexpected request %s"}}`, key)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{
+		client:      hcloud.NewClient(hcloud.WithEndpoint(server.URL), hcloud.WithToken("token")),
+		retryConfig: reliability.DefaultRetryConfig(),
+	}
+	return api, client
+}
+
+// set overrides the response for a request
+func (api *fakeAPI) set(key, body string) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.handlers[key] = body
+}
+
+// fail makes a request fail with an API error code
+func (api *fakeAPI) fail(key, code string) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.failures == nil {
+		api.failures = make(map[string]string)
+	}
+	api.failures[key] = code
+}
+
+// delay holds a request back for the given duration before answering it
+func (api *fakeAPI) delay(key string, d time.Duration) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.delays == nil {
+		api.delays = make(map[string]time.Duration)
+	}
+	api.delays[key] = d
+}
+
+// limit makes a request hit the rate limit the given number of times before it is answered
+func (api *fakeAPI) limit(key string, times int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.limited == nil {
+		api.limited = make(map[string]int)
+	}
+	api.limited[key] = times
+}
+
+func TestListServersRetriesRateLimitedRequests(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /servers", fmt.Sprintf(`{"servers": [{"id": %d, "name": "test-pool-1a2b", "status": "running"}]}`, testServerID))
+	api.limit("GET /servers", 1)
+
+	// Rate limit errors are retried once the limit resets, even if the client retries
+	// nothing else
+	client.retryConfig.MaxRetries = 1
+	client.retryConfig.RetryableErrors = func(error) bool { return false }
+
+	servers, err := client.ListServers(context.Background(), "test-pool", "default")
+	if err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+	if len(servers) != 1 || servers[0].ID != testServerID {
+		t.Errorf("ListServers() = %+v, want server %d", servers, testServerID)
+	}
+	if got := strings.Count(strings.Join(api.requests, "\n"), "GET /servers"); got != 2 {
+		t.Errorf("listed servers %d times, want 2", got)
+	}
+}
+
+func TestDeleteFirewallRetriesWithinBudget(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.fail("DELETE /firewalls/7", string(hcloud.ErrorCodeServiceError))
+	client.retryConfig.InitialBackoff = time.Millisecond
+	client.retryConfig.MaxBackoff = time.Millisecond
+	client.retryConfig.RetryableErrors = IsRetryableError
+	client.retryConfig.Budget = reliability.NewRetryBudget(0, 1)
+
+	err := client.DeleteFirewall(context.Background(), 7)
+	if !errors.Is(err, reliability.ErrRetryBudgetExhausted) {
+		t.Fatalf("DeleteFirewall() error = %v, want %v", err, reliability.ErrRetryBudgetExhausted)
+	}
+	if got := strings.Count(strings.Join(api.requests, "\n"), "DELETE /firewalls/7"); got != 2 {
+		t.Errorf("deleted the firewall %d times, want 2", got)
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	key := fmt.Sprintf("DELETE /servers/%d", testServerID)
+
+	t.Run("client timeout cancels a hanging operation", func(t *testing.T) {
+		api, client := newFakeAPI(t)
+		client.operationTimeout = 50 * time.Millisecond
+		api.delay(key, time.Minute)
+
+		start := time.Now()
+		err := client.DeleteServer(context.Background(), testServerID)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("DeleteServer() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("DeleteServer() returned after %v, want the operation timeout to cancel it", elapsed)
+		}
+	})
+
+	t.Run("caller deadline takes precedence over the client timeout", func(t *testing.T) {
+		api, client := newFakeAPI(t)
+		client.operationTimeout = 50 * time.Millisecond
+		api.delay(key, 200*time.Millisecond)
+
+		// A pool override reaches the client as a deadline on the context
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := client.DeleteServer(ctx, testServerID); err != nil {
+			t.Fatalf("DeleteServer() error = %v, want the caller's longer deadline to apply", err)
+		}
+	})
+}
+
+func TestCreateServerRollsBackOnNetworkAttachFailure(t *testing.T) {
+	api, client := newFakeAPI(t)
+
+	// No handler for attach_to_network, so attaching fails
+	_, err := client.CreateServer(context.Background(), ServerConfig{
+		Name:       "test-pool-1a2b",
+		ServerType: "cx11",
+		Image:      "ubuntu-22.04",
+		Location:   "nbg1",
+		Network:    "private",
+	})
+	if err == nil {
+		t.Fatal("CreateServer() expected error when network attach fails")
+	}
+
+	want := fmt.Sprintf("/servers/%d", testServerID)
+	if len(api.deleted) != 1 || api.deleted[0] != want {
+		t.Errorf("Expected rollback DELETE %s, got %v", want, api.deleted)
+	}
+}
+
+func TestCreateServerWithoutNetwork(t *testing.T) {
+	api, client := newFakeAPI(t)
+
+	server, err := client.CreateServer(context.Background(), ServerConfig{
+		Name:       "test-pool-1a2b",
+		ServerType: "cx11",
+		Image:      "ubuntu-22.04",
+		Location:   "nbg1",
+	})
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if server.ID != testServerID || server.IPv4 != "192.0.2.1" {
+		t.Errorf("CreateServer() = %+v", server)
+	}
+	if len(api.deleted) != 0 {
+		t.Errorf("Expected no rollback, got %v", api.deleted)
+	}
+}
+
+func TestServerCreatedAt(t *testing.T) {
+	api, client := newFakeAPI(t)
+	server := fmt.Sprintf(`{"id": %d, "name": "test-pool-1a2b", "status": "running", "created": "2024-05-01T12:00:00+00:00"}`, testServerID)
+	api.set("GET /servers", `{"servers": [`+server+`]}`)
+	api.set(fmt.Sprintf("GET /servers/%d", testServerID), `{"server": `+server+`}`)
+	want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	servers, err := client.ListServers(context.Background(), "test-pool", "default")
+	if err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+	if len(servers) != 1 || !servers[0].CreatedAt.Equal(want) {
+		t.Errorf("ListServers() = %+v, want a server created at %v", servers, want)
+	}
+
+	got, err := client.GetServer(context.Background(), testServerID)
+	if err != nil {
+		t.Fatalf("GetServer() error = %v", err)
+	}
+	if !got.CreatedAt.Equal(want) {
+		t.Errorf("GetServer() CreatedAt = %v, want %v", got.CreatedAt, want)
+	}
+}
+
+func TestCreateServerImageArchitecture(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverType   string
+		architecture string
+		imageID      int
+	}{
+		{name: "x86", serverType: "cx11", architecture: "x86", imageID: 1},
+		{name: "arm", serverType: "cax11", architecture: "arm", imageID: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, client := newFakeAPI(t)
+			api.set("GET /server_types", fmt.Sprintf(
+				`{"server_types": [{"id": 1, "name": %q, "architecture": %q}]}`, tt.serverType, tt.architecture))
+			api.set("GET /images?architecture=arm",
+				`{"images": [{"id": 2, "name": "ubuntu-22.04", "type": "system", "status": "available", "architecture": "arm"}]}`)
+
+			if _, err := client.CreateServer(context.Background(), ServerConfig{
+				Name:       "test-pool-1a2b",
+				ServerType: tt.serverType,
+				Image:      "ubuntu-22.04",
+				Location:   "nbg1",
+			}); err != nil {
+				t.Fatalf("CreateServer() error = %v", err)
+			}
+
+			want := fmt.Sprintf(`"image":%d`, tt.imageID)
+			if len(api.created) != 1 || !strings.Contains(api.created[0], want) {
+				t.Errorf("Expected server to be created with %s, got %v", want, api.created)
+			}
+		})
+	}
+}
+
+func TestCreateServerImageNotAvailableForArchitecture(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /server_types", `{"server_types": [{"id": 1, "name": "cax11", "architecture": "arm"}]}`)
+	api.set("GET /images?architecture=arm", `{"images": []}`)
+
+	_, err := client.CreateServer(context.Background(), ServerConfig{
+		Name:       "test-pool-1a2b",
+		ServerType: "cax11",
+		Image:      "ubuntu-22.04",
+		Location:   "nbg1",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not found for architecture arm") {
+		t.Errorf("CreateServer() error = %v, want image not found for architecture arm", err)
+	}
+}
+
+func TestCreateServerInPlacementGroup(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /placement_groups", `{"placement_groups": []}`)
+	api.set("POST /placement_groups", `{"placement_group": {"id": 7, "name": "web-spread", "type": "spread", "servers": []}}`)
+
+	placementGroup, err := client.GetOrCreatePlacementGroup(context.Background(), "web-spread", map[string]string{"nodepool": "web"})
+	if err != nil {
+		t.Fatalf("GetOrCreatePlacementGroup() error = %v", err)
+	}
+	if placementGroup.ID != 7 {
+		t.Errorf("GetOrCreatePlacementGroup() = %+v, want ID 7", placementGroup)
+	}
+
+	if _, err := client.CreateServer(context.Background(), ServerConfig{
+		Name:             "test-pool-1a2b",
+		ServerType:       "cx11",
+		Image:            "ubuntu-22.04",
+		Location:         "nbg1",
+		PlacementGroupID: placementGroup.ID,
+	}); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	want := `"placement_group":7`
+	if len(api.created) != 1 || !strings.Contains(api.created[0], want) {
+		t.Errorf("Expected server to be created with %s, got %v", want, api.created)
+	}
+}
+
+func TestCreateServerWithVolumes(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("POST /volumes", `{"volume": {"id": 9, "name": "test-pool-1a2b-0", "size": 50,
+		"linux_device": "/dev/disk/by-id/scsi-0HC_Volume_9"},
+		"action": {"id": 3, "command": "create_volume", "status": "running"}}`)
+	api.set("GET /actions/3", `{"action": {"id": 3, "command": "create_volume", "status": "success"}}`)
+
+	volume, err := client.CreateVolume(context.Background(), VolumeConfig{
+		Name:     "test-pool-1a2b-0",
+		Size:     50,
+		Location: "nbg1",
+		Format:   "ext4",
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume() error = %v", err)
+	}
+	if volume.ID != 9 || volume.LinuxDevice != "/dev/disk/by-id/scsi-0HC_Volume_9" {
+		t.Errorf("CreateVolume() = %+v", volume)
+	}
+
+	server, err := client.CreateServer(context.Background(), ServerConfig{
+		Name:       "test-pool-1a2b",
+		ServerType: "cx11",
+		Image:      "ubuntu-22.04",
+		Location:   "nbg1",
+		VolumeIDs:  []int64{volume.ID},
+	})
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if len(server.VolumeIDs) != 1 || server.VolumeIDs[0] != volume.ID {
+		t.Errorf("CreateServer() volumes = %v, want [%d]", server.VolumeIDs, volume.ID)
+	}
+
+	// Volumes are mounted by cloud-init, not by the Hetzner automount
+	for _, want := range []string{`"volumes":[9]`, `"automount":false`} {
+		if len(api.created) != 1 || !strings.Contains(api.created[0], want) {
+			t.Errorf("Expected server to be created with %s, got %v", want, api.created)
+		}
+	}
+}
+
+func TestValidateServerType(t *testing.T) {
+	tests := []struct {
+		name            string
+		serverTypes     string
+		location        string
+		wantErr         bool
+		wantUnavailable bool
+	}{
+		{
+			name:        "available in location",
+			serverTypes: `[{"id": 1, "name": "cx11", "prices": [{"location": "fsn1"}, {"location": "nbg1"}]}]`,
+			location:    "nbg1",
+		},
+		{
+			name:        "location not checked",
+			serverTypes: `[{"id": 1, "name": "cx11", "prices": [{"location": "fsn1"}]}]`,
+		},
+		{
+			name:            "not found",
+			serverTypes:     `[]`,
+			location:        "nbg1",
+			wantErr:         true,
+			wantUnavailable: true,
+		},
+		{
+			name:            "not offered in location",
+			serverTypes:     `[{"id": 1, "name": "cx11", "prices": [{"location": "fsn1"}]}]`,
+			location:        "nbg1",
+			wantErr:         true,
+			wantUnavailable: true,
+		},
+		{
+			name: "past deprecation",
+			serverTypes: `[{"id": 1, "name": "cx11", "prices": [{"location": "nbg1"}],
+				"deprecation": {"announced": "2020-01-01T00:00:00Z", "unavailable_after": "2020-04-01T00:00:00Z"}}]`,
+			location:        "nbg1",
+			wantErr:         true,
+			wantUnavailable: true,
+		},
+		{
+			name: "deprecated but still orderable",
+			serverTypes: `[{"id": 1, "name": "cx11", "prices": [{"location": "nbg1"}],
+				"deprecation": {"announced": "2020-01-01T00:00:00Z", "unavailable_after": "2999-01-01T00:00:00Z"}}]`,
+			location: "nbg1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, client := newFakeAPI(t)
+			api.set("GET /server_types", fmt.Sprintf(`{"server_types": %s}`, tt.serverTypes))
+
+			err := client.ValidateServerType(context.Background(), "cx11", tt.location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateServerType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrServerTypeUnavailable) != tt.wantUnavailable {
+				t.Errorf("ValidateServerType() error = %v, want ErrServerTypeUnavailable %v", err, tt.wantUnavailable)
+			}
+		})
+	}
+}
+
+func TestValidateServerTypeAPIError(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.mu.Lock()
+	delete(api.handlers, "GET /server_types")
+	api.mu.Unlock()
+
+	err := client.ValidateServerType(context.Background(), "cx11", "nbg1")
+	if err == nil || errors.Is(err, ErrServerTypeUnavailable) {
+		t.Errorf("ValidateServerType() error = %v, want API error", err)
+	}
+}
+
+func TestGetOrCreateFirewallRules(t *testing.T) {
+	rule := func(port string, sourceIPs ...string) hcloud.FirewallRule {
+		r := hcloud.FirewallRule{
+			Direction: hcloud.FirewallRuleDirectionIn,
+			Protocol:  hcloud.FirewallRuleProtocolTCP,
+			Port:      hcloud.Ptr(port),
+		}
+		for _, ip := range sourceIPs {
+			_, ipNet, _ := net.ParseCIDR(ip)
+			r.SourceIPs = append(r.SourceIPs, *ipNet)
+		}
+		return r
+	}
+	const setRules = "POST /firewalls/5/actions/set_rules"
+
+	tests := []struct {
+		name        string
+		rules       []hcloud.FirewallRule
+		wantUpdated bool
+	}{
+		{
+			name:  "same rules in another order",
+			rules: []hcloud.FirewallRule{rule("443", "::/0", "0.0.0.0/0"), rule("80", "0.0.0.0/0", "::/0")},
+		},
+		{
+			name:        "rule added",
+			rules:       []hcloud.FirewallRule{rule("80", "0.0.0.0/0", "::/0"), rule("443", "0.0.0.0/0", "::/0"), rule("22", "10.0.0.0/8")},
+			wantUpdated: true,
+		},
+		{
+			name:        "source IPs changed",
+			rules:       []hcloud.FirewallRule{rule("80", "0.0.0.0/0"), rule("443", "0.0.0.0/0", "::/0")},
+			wantUpdated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, client := newFakeAPI(t)
+			api.set("GET /firewalls", `{"firewalls": [{"id": 5, "name": "test-pool", "labels": {"pool": "test-pool"}, "rules": [
+				{"direction": "in", "protocol": "tcp", "port": "80", "source_ips": ["0.0.0.0/0", "::/0"], "destination_ips": []},
+				{"direction": "in", "protocol": "tcp", "port": "443", "source_ips": ["0.0.0.0/0", "::/0"], "destination_ips": []}
+			]}]}`)
+			api.set(setRules, `{"actions": []}`)
+
+			firewall, err := client.GetOrCreateFirewall(context.Background(), "test-pool", tt.rules,
+				map[string]string{"pool": "test-pool"})
+			if err != nil {
+				t.Fatalf("GetOrCreateFirewall() error = %v", err)
+			}
+			if firewall.ID != 5 {
+				t.Errorf("GetOrCreateFirewall() returned firewall %d, want 5", firewall.ID)
+			}
+			if updated := slices.Contains(api.requests, setRules); updated != tt.wantUpdated {
+				t.Errorf("firewall rules updated = %v, want %v (requests %v)", updated, tt.wantUpdated, api.requests)
+			}
+		})
+	}
+}
+
+func TestAssignFloatingIP(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /floating_ips/1", `{"floating_ip": {"id": 1, "ip": "203.0.113.1", "type": "ipv4", "server": 7}}`)
+	api.set("GET /floating_ips/2", `{"floating_ip": {"id": 2, "ip": "203.0.113.2", "type": "ipv4", "server": null}}`)
+	api.set("POST /floating_ips/2/actions/assign", `{"action": {"id": 4, "command": "assign_floating_ip", "status": "running"}}`)
+	api.set("GET /actions/4", `{"action": {"id": 4, "command": "assign_floating_ip", "status": "success"}}`)
+
+	// The first floating IP is already assigned to another server
+	ip, err := client.AssignFloatingIP(context.Background(), testServerID, []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("AssignFloatingIP() error = %v", err)
+	}
+	if ip != "203.0.113.2" {
+		t.Errorf("AssignFloatingIP() = %s, want 203.0.113.2", ip)
+	}
+	if slices.Contains(api.requests, "POST /floating_ips/1/actions/assign") {
+		t.Errorf("Expected assigned floating IP to be skipped, got requests %v", api.requests)
+	}
+
+	api.set("GET /floating_ips/2", `{"floating_ip": {"id": 2, "ip": "203.0.113.2", "type": "ipv4", "server": 8}}`)
+	if _, err := client.AssignFloatingIP(context.Background(), testServerID, []string{"1", "2"}); !errors.Is(err, ErrNoFloatingIPAvailable) {
+		t.Errorf("AssignFloatingIP() error = %v, want ErrNoFloatingIPAvailable", err)
+	}
+}
+
+func TestCreateServerWithPrimaryIPs(t *testing.T) {
+	api, client := newFakeAPI(t)
+	primaryIP := func(id int, ip, ipType, location string, assignee string) string {
+		return fmt.Sprintf(`{"primary_ip": {"id": %d, "ip": %q, "type": %q, "assignee_id": %s,
+			"datacenter": {"id": 1, "name": "%s-dc3", "location": {"id": 1, "name": %q}}}}`,
+			id, ip, ipType, assignee, location, location)
+	}
+	api.set("GET /primary_ips/1", primaryIP(1, "203.0.113.1", "ipv4", "nbg1", "7"))
+	api.set("GET /primary_ips/2", primaryIP(2, "203.0.113.2", "ipv4", "fsn1", "null"))
+	api.set("GET /primary_ips/3", primaryIP(3, "203.0.113.3", "ipv4", "nbg1", "null"))
+	api.set("GET /primary_ips/4", primaryIP(4, "2001:db8::", "ipv6", "nbg1", "null"))
+
+	config := ServerConfig{
+		Name:       "test-pool-1a2b",
+		ServerType: "cx11",
+		Image:      "ubuntu-22.04",
+		Location:   "nbg1",
+		PrimaryIPs: []string{"1", "2", "3", "4"},
+	}
+
+	// The first primary IP is assigned to another server, the second is in another location
+	if _, err := client.CreateServer(context.Background(), config); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	want := `"public_net":{"enable_ipv4":true,"enable_ipv6":true,"ipv4":3,"ipv6":4}`
+	if len(api.created) != 1 || !strings.Contains(api.created[0], want) {
+		t.Errorf("Expected server to be created with %s, got %v", want, api.created)
+	}
+
+	// A disabled family doesn't take a primary IP
+	config.DisableIPv6 = true
+	if _, err := client.CreateServer(context.Background(), config); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	want = `"public_net":{"enable_ipv4":true,"enable_ipv6":false,"ipv4":3}`
+	if len(api.created) != 2 || !strings.Contains(api.created[1], want) {
+		t.Errorf("Expected server to be created with %s, got %v", want, api.created)
+	}
+
+	api.set("GET /primary_ips/3", primaryIP(3, "203.0.113.3", "ipv4", "nbg1", "8"))
+	if _, err := client.CreateServer(context.Background(), config); !errors.Is(err, ErrPrimaryIPAssigned) {
+		t.Errorf("CreateServer() error = %v, want ErrPrimaryIPAssigned", err)
+	}
+	if len(api.created) != 2 {
+		t.Errorf("Expected no server to be created when all primary IPs are assigned, got %v", api.created)
+	}
+}
+
+func TestCreateServerPrimaryIPAssignedConcurrently(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /primary_ips/3", `{"primary_ip": {"id": 3, "ip": "203.0.113.3", "type": "ipv4", "assignee_id": null,
+		"datacenter": {"id": 1, "name": "nbg1-dc3", "location": {"id": 1, "name": "nbg1"}}}}`)
+	api.fail("POST /servers", "primary_ip_assigned")
+
+	_, err := client.CreateServer(context.Background(), ServerConfig{
+		Name:       "test-pool-1a2b",
+		ServerType: "cx11",
+		Image:      "ubuntu-22.04",
+		Location:   "nbg1",
+		PrimaryIPs: []string{"3"},
+	})
+	if !errors.Is(err, ErrPrimaryIPAssigned) {
+		t.Errorf("CreateServer() error = %v, want ErrPrimaryIPAssigned", err)
+	}
+}
+
+func TestClientSetToken(t *testing.T) {
+	var mu sync.Mutex
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens = append(tokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"servers": [], "meta": {"pagination": {"page": 1, "per_page": 50}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient("initial", WithAPIOptions(hcloud.WithEndpoint(server.URL)))
+	if _, err := client.ListServers(context.Background(), "test-pool", "default"); err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+
+	// Requests after the token was rotated use the new token, against the same endpoint
+	client.SetToken("rotated")
+	if _, err := client.ListServers(context.Background(), "test-pool", "default"); err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+
+	if want := []string{"initial", "rotated"}; !slices.Equal(tokens, want) {
+		t.Errorf("request tokens = %v, want %v", tokens, want)
+	}
+}
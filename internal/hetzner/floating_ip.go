@@ -0,0 +1,96 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// ErrNoFloatingIPAvailable is returned when all of the given floating IPs are assigned
+var ErrNoFloatingIPAvailable = errors.New("no floating IP available")
+
+// AssignFloatingIP assigns the first unassigned of the given floating IPs to a server and
+// returns its address. The floating IPs may be given by name or ID
+func (c *Client) AssignFloatingIP(ctx context.Context, serverID int64, floatingIPs []string) (string, error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	for _, idOrName := range floatingIPs {
+		var floatingIP *hcloud.FloatingIP
+		err := c.executeWithRetry(ctx, func() error {
+			var err error
+			floatingIP, _, err = c.api().FloatingIP.Get(ctx, idOrName)
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to get floating IP %s: %w", idOrName, err)
+		}
+		if floatingIP == nil {
+			return "", fmt.Errorf("floating IP %s not found", idOrName)
+		}
+		if floatingIP.Server != nil {
+			continue
+		}
+
+		action, _, err := c.api().FloatingIP.Assign(ctx, floatingIP, &hcloud.Server{ID: serverID})
+		if err != nil {
+			return "", fmt.Errorf("failed to assign floating IP %s to server %d: %w", idOrName, serverID, err)
+		}
+		_, errCh := c.api().Action.WatchProgress(ctx, action)
+		if err := <-errCh; err != nil {
+			return "", fmt.Errorf("failed to wait for floating IP assignment: %w", err)
+		}
+		return floatingIP.IP.String(), nil
+	}
+	return "", ErrNoFloatingIPAvailable
+}
+
+// UnassignFloatingIPs unassigns the floating IPs assigned to a server so they can be
+// assigned to other servers. A server that no longer exists has none
+func (c *Client) UnassignFloatingIPs(ctx context.Context, serverID int64) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	var server *hcloud.Server
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		server, _, err = c.api().Server.GetByID(ctx, serverID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil
+	}
+
+	for _, floatingIP := range server.PublicNet.FloatingIPs {
+		action, _, err := c.api().FloatingIP.Unassign(ctx, floatingIP)
+		if err != nil {
+			return fmt.Errorf("failed to unassign floating IP %d from server %d: %w", floatingIP.ID, serverID, err)
+		}
+		_, errCh := c.api().Action.WatchProgress(ctx, action)
+		if err := <-errCh; err != nil {
+			return fmt.Errorf("failed to wait for floating IP unassignment: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// AttachISO attaches an ISO to a server and resets the server so it boots from the ISO
+// The ISO may be given by name or ID
+func (c *Client) AttachISO(ctx context.Context, serverID int64, iso string) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	var result *hcloud.ISO
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		result, _, err = c.api().ISO.Get(ctx, iso)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get ISO: %w", err)
+	}
+	if result == nil {
+		return fmt.Errorf("ISO %s not found", iso)
+	}
+
+	server := &hcloud.Server{ID: serverID}
+	action, _, err := c.api().Server.AttachISO(ctx, server, result)
+	if err != nil {
+		return fmt.Errorf("failed to attach ISO %s to server %d: %w", iso, serverID, err)
+	}
+	_, errCh := c.api().Action.WatchProgress(ctx, action)
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("failed to wait for ISO attachment: %w", err)
+	}
+
+	// The server already booted its image, reset it to boot from the ISO
+	action, _, err = c.api().Server.Reset(ctx, server)
+	if err != nil {
+		return fmt.Errorf("failed to reset server %d: %w", serverID, err)
+	}
+	_, errCh = c.api().Action.WatchProgress(ctx, action)
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("failed to wait for server reset: %w", err)
+	}
+
+	return nil
+}
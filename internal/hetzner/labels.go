@@ -0,0 +1,68 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxLabelLength is the maximum length of a label value and of the name part of a label key
+const maxLabelLength = 63
+
+// invalidLabelChars matches characters not allowed in label names and values
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// SanitizeLabels converts free-form key/value pairs to valid Hetzner Cloud labels
+//
+// Hetzner label keys and values follow the Kubernetes label syntax. Invalid characters
+// are replaced with dashes and overlong values are truncated. Pairs that are still
+// invalid afterwards are left out and their keys returned as skipped.
+func SanitizeLabels(in map[string]string) (labels map[string]string, skipped []string) {
+	labels = make(map[string]string, len(in))
+	for key, value := range in {
+		sanitizedKey := sanitizeLabelKey(key)
+		sanitizedValue := sanitizeLabelValue(value)
+		if len(validation.IsQualifiedName(sanitizedKey)) > 0 || len(validation.IsValidLabelValue(sanitizedValue)) > 0 {
+			skipped = append(skipped, key)
+			continue
+		}
+		labels[sanitizedKey] = sanitizedValue
+	}
+	return labels, skipped
+}
+
+// sanitizeLabelKey sanitizes the name part of a label key, keeping an optional prefix as is
+func sanitizeLabelKey(key string) string {
+	prefix, name := "", key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix, name = key[:i+1], key[i+1:]
+	}
+	return prefix + sanitizeLabelValue(name)
+}
+
+// sanitizeLabelValue replaces invalid characters and trims the value to a valid label value
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	if len(value) > maxLabelLength {
+		value = value[:maxLabelLength]
+	}
+	// Label values must begin and end with an alphanumeric character
+	return strings.Trim(value, "-_.")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// ErrPrimaryIPAssigned is returned when all of the given primary IPs of an address family
+// that can be used in the server's location are assigned to other servers
+var ErrPrimaryIPAssigned = errors.New("primary IP assigned to another server")
+
+// errorCodePrimaryIPAssigned is the API error code of a server creation referencing a primary
+// IP that was assigned to another server in the meantime
+const errorCodePrimaryIPAssigned hcloud.ErrorCode = "primary_ip_assigned"
+
+// serverPrimaryIPs picks the first unassigned primary IP of each enabled address family for
+// the server. The primary IPs may be given by name or ID, those of another location than the
+// server's are ignored. A family without any given primary IP in the location gets none
+func (c *Client) serverPrimaryIPs(ctx context.Context, config ServerConfig) (ipv4, ipv6 *hcloud.PrimaryIP, err error) {
+	var assignedIPv4, assignedIPv6 bool
+	for _, idOrName := range config.PrimaryIPs {
+		var primaryIP *hcloud.PrimaryIP
+		err := c.executeWithRetry(ctx, func() error {
+			var err error
+			primaryIP, _, err = c.api().PrimaryIP.Get(ctx, idOrName)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get primary IP %s: %w", idOrName, err)
+		}
+		if primaryIP == nil {
+			return nil, nil, fmt.Errorf("primary IP %s not found", idOrName)
+		}
+		if primaryIP.Datacenter == nil || primaryIP.Datacenter.Location == nil ||
+			primaryIP.Datacenter.Location.Name != config.Location {
+			continue
+		}
+
+		switch {
+		case primaryIP.Type == hcloud.PrimaryIPTypeIPv4 && !config.DisableIPv4 && ipv4 == nil:
+			if primaryIP.AssigneeID != 0 {
+				assignedIPv4 = true
+				continue
+			}
+			ipv4 = primaryIP
+		case primaryIP.Type == hcloud.PrimaryIPTypeIPv6 && !config.DisableIPv6 && ipv6 == nil:
+			if primaryIP.AssigneeID != 0 {
+				assignedIPv6 = true
+				continue
+			}
+			ipv6 = primaryIP
+		}
+	}
+
+	if ipv4 == nil && assignedIPv4 {
+		return nil, nil, fmt.Errorf("%w: all IPv4 primary IPs in %s are assigned", ErrPrimaryIPAssigned, config.Location)
+	}
+	if ipv6 == nil && assignedIPv6 {
+		return nil, nil, fmt.Errorf("%w: all IPv6 primary IPs in %s are assigned", ErrPrimaryIPAssigned, config.Location)
+	}
+	return ipv4, ipv6, nil
+}
+
+// isPrimaryIPAssignedError reports whether err is the API refusing to create a server with a
+// primary IP assigned to another server
+func isPrimaryIPAssignedError(err error) bool {
+	var apiErr hcloud.Error
+	return errors.As(err, &apiErr) && apiErr.Code == errorCodePrimaryIPAssigned
+}
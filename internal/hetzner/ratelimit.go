@@ -0,0 +1,72 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+const (
+	// minRateLimitWait is the wait used when the API doesn't report when the limit resets
+	minRateLimitWait = time.Second
+
+	// maxRateLimitWait caps the wait for a rate limit reset. The reset time is when the
+	// request bucket is full again, but it refills continuously, so retrying earlier succeeds.
+	maxRateLimitWait = time.Minute
+)
+
+// RateLimitWait reports whether err is a Hetzner Cloud rate limit error and, if so, how
+// long to wait before retrying, based on the RateLimit-Reset time of the response
+func RateLimitWait(err error) (time.Duration, bool) {
+	var apiErr hcloud.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != hcloud.ErrorCodeRateLimitExceeded {
+		return 0, false
+	}
+
+	wait := minRateLimitWait
+	if resp := apiErr.Response(); resp != nil && !resp.Meta.Ratelimit.Reset.IsZero() {
+		wait = time.Until(resp.Meta.Ratelimit.Reset)
+	}
+
+	if wait < minRateLimitWait {
+		wait = minRateLimitWait
+	}
+	if wait > maxRateLimitWait {
+		wait = maxRateLimitWait
+	}
+	return wait, true
+}
+
+// rateLimitAwareRetryConfig returns the client's retry configuration extended to retry rate limit
+// errors once the limit resets instead of on the exponential schedule
+func (c *Client) rateLimitAwareRetryConfig() reliability.RetryConfig {
+	config := c.retryConfig
+	retryable := config.RetryableErrors
+	config.RetryableErrors = func(err error) bool {
+		if _, ok := RateLimitWait(err); ok {
+			return true
+		}
+		return retryable == nil || retryable(err)
+	}
+	config.RetryAfter = RateLimitWait
+	return config
+}
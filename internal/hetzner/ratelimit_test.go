@@ -0,0 +1,144 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+// rateLimitError returns the error the SDK reports for a rate limited request whose
+// limit resets at the given time, or without a reset header when reset is zero
+func rateLimitError(t *testing.T, reset time.Time) error {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !reset.IsZero() {
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error": {"code": "rate_limit_exceeded", "message": "limit of 3600 requests per hour reached"}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client := hcloud.NewClient(hcloud.WithEndpoint(server.URL), hcloud.WithToken("token"))
+	_, _, err := client.ServerType.GetByName(context.Background(), "cx11")
+	if err == nil {
+		t.Fatal("Expected rate limit error")
+	}
+	return err
+}
+
+func TestRateLimitWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     func(t *testing.T) error
+		wantOK  bool
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name: "waits until reset",
+			err: func(t *testing.T) error {
+				return rateLimitError(t, time.Now().Add(20*time.Second))
+			},
+			wantOK:  true,
+			wantMin: 18 * time.Second,
+			wantMax: 20 * time.Second,
+		},
+		{
+			name: "wrapped error",
+			err: func(t *testing.T) error {
+				return fmt.Errorf("failed to get server type: %w", rateLimitError(t, time.Now().Add(20*time.Second)))
+			},
+			wantOK:  true,
+			wantMin: 18 * time.Second,
+			wantMax: 20 * time.Second,
+		},
+		{
+			name: "reset far in the future is capped",
+			err: func(t *testing.T) error {
+				return rateLimitError(t, time.Now().Add(time.Hour))
+			},
+			wantOK:  true,
+			wantMin: maxRateLimitWait,
+			wantMax: maxRateLimitWait,
+		},
+		{
+			name: "no reset header",
+			err: func(t *testing.T) error {
+				return rateLimitError(t, time.Time{})
+			},
+			wantOK:  true,
+			wantMin: minRateLimitWait,
+			wantMax: minRateLimitWait,
+		},
+		{
+			name: "other API error",
+			err: func(_ *testing.T) error {
+				return hcloud.Error{Code: hcloud.ErrorCodeConflict, Message: "conflict"}
+			},
+		},
+		{
+			name: "rate limit message without API error",
+			err: func(_ *testing.T) error {
+				return errors.New("429 rate limit")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, ok := RateLimitWait(tt.err(t))
+			if ok != tt.wantOK {
+				t.Fatalf("RateLimitWait() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if wait < tt.wantMin || wait > tt.wantMax {
+				t.Errorf("RateLimitWait() = %v, want between %v and %v", wait, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRateLimitAwareRetryConfig(t *testing.T) {
+	retryConfig := reliability.DefaultRetryConfig()
+	retryConfig.RetryableErrors = func(error) bool { return false }
+	client := &Client{retryConfig: retryConfig}
+	config := client.rateLimitAwareRetryConfig()
+
+	err := rateLimitError(t, time.Now().Add(20*time.Second))
+	if !config.RetryableErrors(err) {
+		t.Error("Expected rate limit errors to be retryable")
+	}
+	if config.RetryableErrors(errors.New("invalid input")) {
+		t.Error("Expected the client's retryable errors to be kept for other errors")
+	}
+	if wait, ok := config.RetryAfter(err); !ok || wait < 18*time.Second {
+		t.Errorf("RetryAfter() = %v, %v, want the time until the rate limit resets", wait, ok)
+	}
+}
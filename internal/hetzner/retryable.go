@@ -0,0 +1,56 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"errors"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+// retryableErrorCodes are the Hetzner Cloud API error codes of conditions that clear up on
+// their own, such as a locked resource or an API outage
+var retryableErrorCodes = map[hcloud.ErrorCode]bool{
+	hcloud.ErrorCodeServiceError:      true,
+	hcloud.ErrorCodeUnknownError:      true,
+	hcloud.ErrorCodeRateLimitExceeded: true,
+	hcloud.ErrorCodeLocked:            true,
+	hcloud.ErrorCodeConflict:          true,
+	hcloud.ErrorCodeMaintenance:       true,
+	hcloud.ErrorCodeRobotUnavailable:  true,
+}
+
+// IsRetryableError reports whether an operation that failed with err may succeed when retried.
+// Hetzner Cloud API errors are retried based on their error code, other errors such as
+// network failures fall back to reliability.IsRetryableError
+func IsRetryableError(err error) bool {
+	var apiErr hcloud.Error
+	if errors.As(err, &apiErr) {
+		return retryableErrorCodes[apiErr.Code]
+	}
+	return reliability.IsRetryableError(err)
+}
+
+// IsQuotaExceededError reports whether err is the Hetzner Cloud API refusing to create a
+// resource because the project reached one of its resource limits, which retrying won't
+// fix until the limit is raised or resources are freed
+func IsQuotaExceededError(err error) bool {
+	var apiErr hcloud.Error
+	return errors.As(err, &apiErr) && apiErr.Code == hcloud.ErrorCodeResourceLimitExceeded
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  func(t *testing.T) error
+		want bool
+	}{
+		{
+			name: "rate limit response",
+			err:  func(t *testing.T) error { return rateLimitError(t, time.Time{}) },
+			want: true,
+		},
+		{
+			name: "locked",
+			err: func(_ *testing.T) error {
+				return fmt.Errorf("failed to create server: %w", hcloud.Error{Code: hcloud.ErrorCodeLocked, Message: "server is locked"})
+			},
+			want: true,
+		},
+		{
+			name: "service error",
+			err:  func(_ *testing.T) error { return hcloud.Error{Code: hcloud.ErrorCodeServiceError} },
+			want: true,
+		},
+		{
+			name: "invalid input",
+			err: func(_ *testing.T) error {
+				return hcloud.Error{Code: hcloud.ErrorCodeInvalidInput, Message: "timeout is invalid"}
+			},
+			want: false,
+		},
+		{
+			name: "resource limit exceeded",
+			err:  func(_ *testing.T) error { return hcloud.Error{Code: hcloud.ErrorCodeResourceLimitExceeded} },
+			want: false,
+		},
+		{
+			name: "unauthorized",
+			err:  func(_ *testing.T) error { return hcloud.Error{Code: hcloud.ErrorCodeUnauthorized} },
+			want: false,
+		},
+		{
+			name: "network error",
+			err:  func(_ *testing.T) error { return errors.New("dial tcp: connection refused") },
+			want: true,
+		},
+		{
+			name: "other error",
+			err:  func(_ *testing.T) error { return errors.New("server type not found") },
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err(t)); got != tt.want {
+				t.Errorf("IsRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetryableErrors(t *testing.T) {
+	client := NewClient("token")
+	if client.retryConfig.RetryableErrors == nil || !client.retryConfig.RetryableErrors(hcloud.Error{Code: hcloud.ErrorCodeLocked}) {
+		t.Error("Expected the client to retry with IsRetryableError by default")
+	}
+
+	client = NewClient("token", WithRetryableErrors(func(error) bool { return false }))
+	if client.retryConfig.RetryableErrors(hcloud.Error{Code: hcloud.ErrorCodeLocked}) {
+		t.Error("Expected the client to use the given predicate")
+	}
+}
+
+func TestIsQuotaExceededError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "resource limit exceeded",
+			err: fmt.Errorf("failed to create server: %w", hcloud.Error{
+				Code:    hcloud.ErrorCodeResourceLimitExceeded,
+				Message: "server limit exceeded",
+			}),
+			want: true,
+		},
+		{
+			name: "resource limit exceeded after retries",
+			err: fmt.Errorf("non-retryable error: %w",
+				hcloud.Error{Code: hcloud.ErrorCodeResourceLimitExceeded}),
+			want: true,
+		},
+		{name: "rate limit", err: hcloud.Error{Code: hcloud.ErrorCodeRateLimitExceeded}, want: false},
+		{name: "invalid input", err: hcloud.Error{Code: hcloud.ErrorCodeInvalidInput}, want: false},
+		{name: "other error", err: errors.New("limit exceeded"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsQuotaExceededError(tt.err); got != tt.want {
+				t.Errorf("IsQuotaExceededError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
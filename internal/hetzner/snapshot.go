@@ -0,0 +1,229 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	// LabelBootstrapHash is the label holding the bootstrap hash of a snapshot or builder server
+	LabelBootstrapHash = "bootstrap-hash"
+	// LabelSnapshotBuilder is the label identifying the node pool a snapshot builder server belongs to
+	LabelSnapshotBuilder = "snapshot-builder"
+
+	// bootstrapHashLength keeps the hash short enough for label values and server names
+	bootstrapHashLength = 16
+)
+
+// Snapshot represents a Hetzner Cloud snapshot image
+type Snapshot struct {
+	ID            int64
+	Description   string
+	BootstrapHash string
+	Status        string
+}
+
+// SnapshotConfig contains the configuration for building a bootstrap snapshot
+type SnapshotConfig struct {
+	NodePoolName  string
+	Namespace     string
+	BootstrapHash string
+	// Builder is the temporary server the snapshot is taken from. Its user data
+	// must prepare the node and power it off once done.
+	Builder ServerConfig
+}
+
+// BootstrapHash computes a stable, label-safe hash of the given bootstrap inputs
+func BootstrapHash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:bootstrapHashLength]
+}
+
+// EnsureSnapshot returns the snapshot matching config.BootstrapHash once it is available.
+// Until then it drives the snapshot build across calls: it creates a builder server,
+// snapshots it once the builder has powered itself off, and removes the builder after
+// the snapshot becomes available. It returns nil while the snapshot is still being built.
+func (c *Client) EnsureSnapshot(ctx context.Context, config SnapshotConfig) (*Snapshot, error) {
+	var images []*hcloud.Image
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		images, err = c.api().Image.AllWithOpts(ctx, hcloud.ImageListOpts{
+			ListOpts: hcloud.ListOpts{
+				LabelSelector: fmt.Sprintf("nodepool=%s,namespace=%s,%s=%s",
+					config.NodePoolName, config.Namespace, LabelBootstrapHash, config.BootstrapHash),
+			},
+			Type: []hcloud.ImageType{hcloud.ImageTypeSnapshot},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	builders, err := c.listSnapshotBuilders(ctx, config.NodePoolName, config.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		if image.Status != hcloud.ImageStatusAvailable {
+			// Snapshot is still being created
+			return nil, nil
+		}
+
+		// Snapshot is ready, the builder is no longer needed
+		for _, builder := range builders {
+			if builder.Labels[LabelBootstrapHash] == config.BootstrapHash {
+				if err := c.DeleteServer(ctx, builder.ID); err != nil {
+					return nil, fmt.Errorf("failed to delete snapshot builder: %w", err)
+				}
+			}
+		}
+
+		return snapshotFromImage(image), nil
+	}
+
+	var builder *hcloud.Server
+	for _, s := range builders {
+		if s.Labels[LabelBootstrapHash] == config.BootstrapHash {
+			builder = s
+			break
+		}
+	}
+
+	if builder == nil {
+		builderConfig := config.Builder
+		builderConfig.Labels = map[string]string{
+			LabelSnapshotBuilder: config.NodePoolName,
+			"namespace":          config.Namespace,
+			LabelBootstrapHash:   config.BootstrapHash,
+			"managed-by":         "nodepools",
+		}
+		if _, err := c.CreateServer(ctx, builderConfig); err != nil {
+			return nil, fmt.Errorf("failed to create snapshot builder: %w", err)
+		}
+		return nil, nil
+	}
+
+	// Wait for the builder to finish preparing and power itself off
+	if builder.Status != hcloud.ServerStatusOff {
+		return nil, nil
+	}
+
+	description := fmt.Sprintf("%s/%s bootstrap %s", config.Namespace, config.NodePoolName, config.BootstrapHash)
+	_, _, err = c.api().Server.CreateImage(ctx, builder, &hcloud.ServerCreateImageOpts{
+		Type:        hcloud.ImageTypeSnapshot,
+		Description: hcloud.Ptr(description),
+		Labels: map[string]string{
+			"nodepool":         config.NodePoolName,
+			"namespace":        config.Namespace,
+			LabelBootstrapHash: config.BootstrapHash,
+			"managed-by":       "nodepools",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	return nil, nil
+}
+
+// DeleteStaleSnapshots deletes the node pool's snapshots and builder servers whose
+// bootstrap hash differs from keepHash. An empty keepHash deletes all of them.
+func (c *Client) DeleteStaleSnapshots(ctx context.Context, nodePoolName, namespace, keepHash string) error {
+	var images []*hcloud.Image
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		images, err = c.api().Image.AllWithOpts(ctx, hcloud.ImageListOpts{
+			ListOpts: hcloud.ListOpts{
+				LabelSelector: fmt.Sprintf("nodepool=%s,namespace=%s,%s", nodePoolName, namespace, LabelBootstrapHash),
+			},
+			Type: []hcloud.ImageType{hcloud.ImageTypeSnapshot},
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	for _, image := range images {
+		if keepHash != "" && image.Labels[LabelBootstrapHash] == keepHash {
+			continue
+		}
+		err := c.executeWithRetry(ctx, func() error {
+			_, err := c.api().Image.Delete(ctx, image)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete snapshot %d: %w", image.ID, err)
+		}
+	}
+
+	builders, err := c.listSnapshotBuilders(ctx, nodePoolName, namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, builder := range builders {
+		if keepHash != "" && builder.Labels[LabelBootstrapHash] == keepHash {
+			continue
+		}
+		if err := c.DeleteServer(ctx, builder.ID); err != nil {
+			return fmt.Errorf("failed to delete snapshot builder %d: %w", builder.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// listSnapshotBuilders lists the snapshot builder servers of a node pool
+func (c *Client) listSnapshotBuilders(ctx context.Context, nodePoolName, namespace string) ([]*hcloud.Server, error) {
+	var servers []*hcloud.Server
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		servers, err = c.api().Server.AllWithOpts(ctx, hcloud.ServerListOpts{
+			ListOpts: hcloud.ListOpts{
+				LabelSelector: fmt.Sprintf("%s=%s,namespace=%s", LabelSnapshotBuilder, nodePoolName, namespace),
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot builders: %w", err)
+	}
+	return servers, nil
+}
+
+// snapshotFromImage converts an hcloud image to a Snapshot
+func snapshotFromImage(image *hcloud.Image) *Snapshot {
+	return &Snapshot{
+		ID:            image.ID,
+		Description:   image.Description,
+		BootstrapHash: image.Labels[LabelBootstrapHash],
+		Status:        string(image.Status),
+	}
+}
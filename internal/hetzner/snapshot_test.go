@@ -0,0 +1,148 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testSnapshotConfig() SnapshotConfig {
+	return SnapshotConfig{
+		NodePoolName:  "test-pool",
+		Namespace:     "default",
+		BootstrapHash: "1234abcd",
+		Builder: ServerConfig{
+			Name:       "test-pool-builder",
+			ServerType: "cx11",
+			Image:      "ubuntu-22.04",
+			Location:   "nbg1",
+		},
+	}
+}
+
+// builderJSON renders a snapshot builder server of the test pool
+func builderJSON(hash, status string) string {
+	return fmt.Sprintf(`{"servers": [{"id": %d, "name": "test-pool-builder", "status": %q,
+		"labels": {"snapshot-builder": "test-pool", "namespace": "default", "bootstrap-hash": %q}}]}`,
+		testServerID, status, hash)
+}
+
+func TestEnsureSnapshotReusesAvailableSnapshot(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /images", `{"images": [{"id": 10, "type": "snapshot", "status": "available",
+		"description": "default/test-pool bootstrap 1234abcd", "labels": {"bootstrap-hash": "1234abcd"}}]}`)
+	api.set("GET /servers", builderJSON("1234abcd", "off"))
+
+	snapshot, err := client.EnsureSnapshot(context.Background(), testSnapshotConfig())
+	if err != nil {
+		t.Fatalf("EnsureSnapshot() error = %v", err)
+	}
+	if snapshot == nil || snapshot.ID != 10 || snapshot.BootstrapHash != "1234abcd" {
+		t.Fatalf("EnsureSnapshot() = %+v, want snapshot 10", snapshot)
+	}
+	if len(api.created) != 0 {
+		t.Errorf("Expected no builder to be created, got %v", api.created)
+	}
+	// The builder is no longer needed once its snapshot is available
+	if want := fmt.Sprintf("/servers/%d", testServerID); len(api.deleted) != 1 || api.deleted[0] != want {
+		t.Errorf("Expected the builder to be deleted, got %v", api.deleted)
+	}
+}
+
+func TestEnsureSnapshotBuildsSnapshot(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /images", `{"images": []}`)
+	api.set("GET /servers", `{"servers": []}`)
+	ctx := context.Background()
+
+	// No snapshot and no builder: a builder is created
+	snapshot, err := client.EnsureSnapshot(ctx, testSnapshotConfig())
+	if err != nil || snapshot != nil {
+		t.Fatalf("EnsureSnapshot() = %+v, %v, want nil while building", snapshot, err)
+	}
+	if len(api.created) != 1 || !strings.Contains(api.created[0], `"bootstrap-hash":"1234abcd"`) ||
+		!strings.Contains(api.created[0], `"snapshot-builder":"test-pool"`) {
+		t.Fatalf("Expected a labeled builder to be created, got %v", api.created)
+	}
+
+	// The builder is still preparing the node
+	createImage := fmt.Sprintf("POST /servers/%d/actions/create_image", testServerID)
+	api.set("GET /servers", builderJSON("1234abcd", "running"))
+	if snapshot, err := client.EnsureSnapshot(ctx, testSnapshotConfig()); err != nil || snapshot != nil {
+		t.Fatalf("EnsureSnapshot() = %+v, %v, want nil while the builder runs", snapshot, err)
+	}
+	if slices.Contains(api.requests, createImage) {
+		t.Fatal("Expected no snapshot to be taken of a running builder")
+	}
+
+	// The builder powered itself off: it is snapshotted
+	api.set("GET /servers", builderJSON("1234abcd", "off"))
+	api.set(createImage, `{"image": {"id": 10, "type": "snapshot", "status": "creating"},
+		"action": {"id": 3, "command": "create_image", "status": "running"}}`)
+	if snapshot, err := client.EnsureSnapshot(ctx, testSnapshotConfig()); err != nil || snapshot != nil {
+		t.Fatalf("EnsureSnapshot() = %+v, %v, want nil while the snapshot is created", snapshot, err)
+	}
+	if !slices.Contains(api.requests, createImage) {
+		t.Errorf("Expected the builder to be snapshotted, got requests %v", api.requests)
+	}
+	if len(api.created) != 1 {
+		t.Errorf("Expected a single builder to be created, got %d", len(api.created))
+	}
+
+	// The snapshot is still being created
+	api.set("GET /images", `{"images": [{"id": 10, "type": "snapshot", "status": "creating",
+		"labels": {"bootstrap-hash": "1234abcd"}}]}`)
+	if snapshot, err := client.EnsureSnapshot(ctx, testSnapshotConfig()); err != nil || snapshot != nil {
+		t.Fatalf("EnsureSnapshot() = %+v, %v, want nil while the snapshot is created", snapshot, err)
+	}
+	if len(api.deleted) != 0 {
+		t.Errorf("Expected the builder to be kept until the snapshot is available, got %v", api.deleted)
+	}
+}
+
+func TestDeleteStaleSnapshots(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.set("GET /images", `{"images": [
+		{"id": 10, "type": "snapshot", "status": "available", "labels": {"bootstrap-hash": "0000aaaa"}},
+		{"id": 11, "type": "snapshot", "status": "available", "labels": {"bootstrap-hash": "1234abcd"}}
+	]}`)
+	api.set("GET /servers", builderJSON("0000aaaa", "running"))
+	api.set("DELETE /images/10", `{}`)
+	api.set("DELETE /images/11", `{}`)
+
+	if err := client.DeleteStaleSnapshots(context.Background(), "test-pool", "default", "1234abcd"); err != nil {
+		t.Fatalf("DeleteStaleSnapshots() error = %v", err)
+	}
+	want := []string{"/images/10", fmt.Sprintf("/servers/%d", testServerID)}
+	if !slices.Equal(api.deleted, want) {
+		t.Errorf("Deleted %v, want the stale snapshot and builder %v", api.deleted, want)
+	}
+
+	// An empty hash to keep deletes all of them, e.g. when the pool is deleted
+	api.deleted = nil
+	api.set("GET /servers", `{"servers": []}`)
+	if err := client.DeleteStaleSnapshots(context.Background(), "test-pool", "default", ""); err != nil {
+		t.Fatalf("DeleteStaleSnapshots() error = %v", err)
+	}
+	if want := []string{"/images/10", "/images/11"}; !slices.Equal(api.deleted, want) {
+		t.Errorf("Deleted %v, want %v", api.deleted, want)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// Volume represents a Hetzner Cloud volume
+type Volume struct {
+	ID   int64
+	Name string
+	// LinuxDevice is the device path of the volume on the server it is attached to
+	LinuxDevice string
+}
+
+// VolumeConfig contains the configuration for creating a volume
+type VolumeConfig struct {
+	Name string
+	// Size is the volume size in GB
+	Size     int
+	Location string
+	// Format is the filesystem the volume is formatted with (ext4, xfs), empty for none
+	Format string
+	Labels map[string]string
+}
+
+// CreateVolume creates an unattached volume, to be attached by passing its ID in
+// ServerConfig.VolumeIDs
+func (c *Client) CreateVolume(ctx context.Context, config VolumeConfig) (*Volume, error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	var location *hcloud.Location
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		location, _, err = c.api().Location.GetByName(ctx, config.Location)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+	if location == nil {
+		return nil, fmt.Errorf("location %s not found", config.Location)
+	}
+
+	opts := hcloud.VolumeCreateOpts{
+		Name:     config.Name,
+		Size:     config.Size,
+		Location: location,
+		Labels:   config.Labels,
+	}
+	if config.Format != "" {
+		opts.Format = hcloud.Ptr(config.Format)
+	}
+
+	result, _, err := c.api().Volume.Create(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create volume: %w", err)
+	}
+
+	// Wait for the volume to be formatted before it is attached
+	if result.Action != nil {
+		_, errCh := c.api().Action.WatchProgress(ctx, result.Action)
+		if err := <-errCh; err != nil {
+			return nil, fmt.Errorf("failed to wait for volume creation: %w", err)
+		}
+	}
+
+	return &Volume{
+		ID:          result.Volume.ID,
+		Name:        result.Volume.Name,
+		LinuxDevice: result.Volume.LinuxDevice,
+	}, nil
+}
+
+// DeleteVolume detaches a volume from its server, if any, and deletes it
+// A volume that no longer exists is considered deleted
+func (c *Client) DeleteVolume(ctx context.Context, volumeID int64) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	var volume *hcloud.Volume
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		volume, _, err = c.api().Volume.GetByID(ctx, volumeID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get volume: %w", err)
+	}
+	if volume == nil {
+		return nil
+	}
+
+	if volume.Server != nil {
+		action, _, err := c.api().Volume.Detach(ctx, volume)
+		if err != nil {
+			return fmt.Errorf("failed to detach volume: %w", err)
+		}
+		_, errCh := c.api().Action.WatchProgress(ctx, action)
+		if err := <-errCh; err != nil {
+			return fmt.Errorf("failed to wait for volume detachment: %w", err)
+		}
+	}
+
+	err = c.executeWithRetry(ctx, func() error {
+		_, err := c.api().Volume.Delete(ctx, volume)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete volume: %w", err)
+	}
+	return nil
+}
@@ -18,6 +18,9 @@ limitations under the License.
 package metrics
 
 import (
+	"sync"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -26,11 +29,19 @@ var (
 	nodePoolSize = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "hcloud_operator_nodepool_size",
-			Help: "Current size of the node pool",
+			Help: "Size of the node pool by status: desired, current or ready",
 		},
 		[]string{"nodepool", "namespace", "status"},
 	)
 
+	nodePoolPriority = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hcloud_operator_nodepool_priority",
+			Help: "Priority of the node pool among pools that could run the same pending pods",
+		},
+		[]string{"nodepool", "namespace"},
+	)
+
 	nodePoolScaleUps = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "hcloud_operator_nodepool_scale_ups_total",
@@ -54,32 +65,133 @@ var (
 		},
 		[]string{"nodepool", "namespace"},
 	)
+
+	reconcilePanics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hcloud_operator_reconcile_panics_total",
+			Help: "Total number of reconciliations that panicked",
+		},
+		[]string{"nodepool", "namespace"},
+	)
+
+	bootstrapTokenErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hcloud_operator_bootstrap_token_errors_total",
+			Help: "Total number of failures to generate or read the kubeadm bootstrap token of a node",
+		},
+		[]string{"nodepool", "namespace"},
+	)
+
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hcloud_operator_reconcile_duration_seconds",
+			Help:    "Duration of NodePool reconciliations",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		},
+		[]string{"result"},
+	)
+
+	reconcilePhases = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hcloud_operator_reconciles_total",
+			Help: "Total number of reconciliations by resulting NodePool phase",
+		},
+		[]string{"nodepool", "namespace", "phase"},
+	)
+
+	nodeProvisionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hcloud_operator_node_provision_seconds",
+			Help:    "Time from requesting a node until the provider reports it running",
+			Buckets: []float64{15, 30, 60, 90, 120, 180, 300, 600, 900, 1800},
+		},
+		[]string{"provider", "nodepool"},
+	)
+
+	nodeProvisionFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hcloud_operator_node_provision_failures_total",
+			Help: "Total number of nodes that failed to be created or did not become running in time",
+		},
+		[]string{"provider", "nodepool"},
+	)
+
+	deadLetterQueueSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hcloud_operator_dlq_size",
+			Help: "Number of failed operations in the dead letter queue by operation type",
+		},
+		[]string{"operation_type"},
+	)
+
+	deadLetterQueueProcessingErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hcloud_operator_dlq_processing_errors_total",
+			Help: "Total number of dead letter queue listeners that failed to process an operation",
+		},
+		[]string{"operation_type"},
+	)
+
+	deadLetterQueueProcessingDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hcloud_operator_dlq_processing_duration_seconds",
+			Help:    "Duration of dead letter queue listeners processing an operation",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30},
+		},
+		[]string{"operation_type"},
+	)
+)
+
+// Reconcile results
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
 )
 
 func init() {
 	// Register custom metrics with the global prometheus registry
 	metrics.Registry.MustRegister(
 		nodePoolSize,
+		nodePoolPriority,
 		nodePoolScaleUps,
 		nodePoolScaleDowns,
 		reconcileErrors,
+		reconcilePanics,
+		bootstrapTokenErrors,
+		reconcileDuration,
+		reconcilePhases,
+		nodeProvisionDuration,
+		nodeProvisionFailures,
+		deadLetterQueueSize,
+		deadLetterQueueProcessingErrors,
+		deadLetterQueueProcessingDuration,
 	)
 }
 
 // Collector handles Prometheus metrics collection
-type Collector struct{}
+type Collector struct {
+	mu sync.Mutex
+	// deadLetterTypes are the operation types reported in the dead letter queue size so far
+	deadLetterTypes map[string]struct{}
+}
 
 // NewCollector creates a new metrics collector
 func NewCollector() *Collector {
 	return &Collector{}
 }
 
-// RecordNodePoolSize records the current size of a node pool
-func (c *Collector) RecordNodePoolSize(nodePool, namespace string, current, ready int) {
+// RecordNodePoolSize records the desired, current and ready size of a node pool
+func (c *Collector) RecordNodePoolSize(nodePool, namespace string, desired, current, ready int) {
+	nodePoolSize.WithLabelValues(nodePool, namespace, "desired").Set(float64(desired))
 	nodePoolSize.WithLabelValues(nodePool, namespace, "current").Set(float64(current))
 	nodePoolSize.WithLabelValues(nodePool, namespace, "ready").Set(float64(ready))
 }
 
+// RecordNodePoolPriority records the priority of a node pool
+func (c *Collector) RecordNodePoolPriority(nodePool, namespace string, priority int) {
+	nodePoolPriority.WithLabelValues(nodePool, namespace).Set(float64(priority))
+}
+
 // RecordScaleUp records a scale up operation
 func (c *Collector) RecordScaleUp(nodePool, namespace string, count int) {
 	nodePoolScaleUps.WithLabelValues(nodePool, namespace).Add(float64(count))
@@ -94,3 +206,69 @@ func (c *Collector) RecordScaleDown(nodePool, namespace string, count int) {
 func (c *Collector) RecordReconcileError(nodePool, namespace string) {
 	reconcileErrors.WithLabelValues(nodePool, namespace).Inc()
 }
+
+// RecordReconcilePanic records a reconciliation that panicked
+func (c *Collector) RecordReconcilePanic(nodePool, namespace string) {
+	reconcilePanics.WithLabelValues(nodePool, namespace).Inc()
+}
+
+// RecordBootstrapTokenError records a failure to acquire the bootstrap token of a node
+func (c *Collector) RecordBootstrapTokenError(nodePool, namespace string) {
+	bootstrapTokenErrors.WithLabelValues(nodePool, namespace).Inc()
+}
+
+// RecordReconcile records the duration and outcome of a reconciliation
+// An empty phase, e.g. for a NodePool that no longer exists, is not counted by phase
+func (c *Collector) RecordReconcile(nodePool, namespace, phase string, duration time.Duration, err error) {
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+		c.RecordReconcileError(nodePool, namespace)
+	}
+	reconcileDuration.WithLabelValues(result).Observe(duration.Seconds())
+
+	if phase != "" {
+		reconcilePhases.WithLabelValues(nodePool, namespace, phase).Inc()
+	}
+}
+
+// RecordProvisionDuration records how long a node took from creation request to running
+func (c *Collector) RecordProvisionDuration(provider, nodePool string, duration time.Duration) {
+	nodeProvisionDuration.WithLabelValues(provider, nodePool).Observe(duration.Seconds())
+}
+
+// RecordProvisionFailure records a node that failed to be created or to become running
+func (c *Collector) RecordProvisionFailure(provider, nodePool string) {
+	nodeProvisionFailures.WithLabelValues(provider, nodePool).Inc()
+}
+
+// RecordDeadLetterQueueSize records the number of failed operations in the dead letter queue
+// by operation type. Types reported before that are no longer queued are reset to zero.
+// It is meant to be registered with reliability.DeadLetterQueue.AddSizeListener
+func (c *Collector) RecordDeadLetterQueueSize(counts map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.deadLetterTypes == nil {
+		c.deadLetterTypes = make(map[string]struct{})
+	}
+	for operationType := range c.deadLetterTypes {
+		if _, queued := counts[operationType]; !queued {
+			deadLetterQueueSize.WithLabelValues(operationType).Set(0)
+		}
+	}
+	for operationType, count := range counts {
+		c.deadLetterTypes[operationType] = struct{}{}
+		deadLetterQueueSize.WithLabelValues(operationType).Set(float64(count))
+	}
+}
+
+// RecordDeadLetterQueueProcessing records how long a dead letter queue listener took to
+// process an operation and whether it failed.
+// It is meant to be registered with reliability.DeadLetterQueue.AddProcessingListener
+func (c *Collector) RecordDeadLetterQueueProcessing(operationType string, duration time.Duration, err error) {
+	if err != nil {
+		deadLetterQueueProcessingErrors.WithLabelValues(operationType).Inc()
+	}
+	deadLetterQueueProcessingDuration.WithLabelValues(operationType).Observe(duration.Seconds())
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+func TestRecordDeadLetterQueueSize(t *testing.T) {
+	collector := NewCollector()
+	dlq := reliability.NewDeadLetterQueue(10)
+	dlq.AddSizeListener(collector.RecordDeadLetterQueueSize)
+
+	for _, id := range []string{"create-1", "create-2"} {
+		if err := dlq.Add(&reliability.FailedOperation{ID: id, OperationType: "CreateServer"}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if err := dlq.Add(&reliability.FailedOperation{ID: "delete-1", OperationType: "DeleteServer"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if got := testutil.ToFloat64(deadLetterQueueSize.WithLabelValues("CreateServer")); got != 2 {
+		t.Errorf("dlq size for CreateServer = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(deadLetterQueueSize.WithLabelValues("DeleteServer")); got != 1 {
+		t.Errorf("dlq size for DeleteServer = %v, want 1", got)
+	}
+
+	dlq.Remove("create-1")
+	if got := testutil.ToFloat64(deadLetterQueueSize.WithLabelValues("CreateServer")); got != 1 {
+		t.Errorf("dlq size for CreateServer after Remove = %v, want 1", got)
+	}
+
+	dlq.Clear()
+	for _, operationType := range []string{"CreateServer", "DeleteServer"} {
+		if got := testutil.ToFloat64(deadLetterQueueSize.WithLabelValues(operationType)); got != 0 {
+			t.Errorf("dlq size for %s after Clear = %v, want 0", operationType, got)
+		}
+	}
+}
+
+func TestRecordDeadLetterQueueProcessing(t *testing.T) {
+	collector := NewCollector()
+	dlq := reliability.NewDeadLetterQueue(10)
+	dlq.AddProcessingListener(collector.RecordDeadLetterQueueProcessing)
+
+	var processed []string
+	dlq.AddListener(func(op *reliability.FailedOperation) {
+		if op.OperationType == "RetryServer" {
+			panic("retry processor failed")
+		}
+		processed = append(processed, op.ID)
+	})
+
+	for _, op := range []*reliability.FailedOperation{
+		{ID: "retry-1", OperationType: "RetryServer"},
+		{ID: "delete-1", OperationType: "DeleteVolume"},
+	} {
+		if err := dlq.Add(op); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	dlq.Close()
+
+	if got := testutil.ToFloat64(deadLetterQueueProcessingErrors.WithLabelValues("RetryServer")); got != 1 {
+		t.Errorf("dlq processing errors for RetryServer = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(deadLetterQueueProcessingErrors.WithLabelValues("DeleteVolume")); got != 0 {
+		t.Errorf("dlq processing errors for DeleteVolume = %v, want 0", got)
+	}
+	// The worker keeps dispatching after a listener panicked
+	if len(processed) != 1 || processed[0] != "delete-1" {
+		t.Errorf("Expected delete-1 to be processed after the panic, got %v", processed)
+	}
+	if got := testutil.CollectAndCount(deadLetterQueueProcessingDuration); got != 2 {
+		t.Errorf("dlq processing duration series = %d, want 2", got)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/autokubeio/autokube/internal/hetzner"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
@@ -28,28 +29,74 @@ import (
 
 // HetznerClient is a mock implementation of the Hetzner Cloud client for testing
 type HetznerClient struct {
-	mu      sync.RWMutex
-	servers map[int64]*hetzner.Server
-	nextID  int64
+	mu           sync.RWMutex
+	servers      map[int64]*hetzner.Server
+	nextID       int64
+	volumes      map[int64]*hetzner.Volume
+	nextVolumeID int64
+	firewalls    map[string]*hcloud.Firewall
+	// primaryIPs maps the assigned primary IPs to the ID of their server
+	primaryIPs map[string]int64
 
 	// Configurable behaviors for testing
-	ListServersFunc  func(ctx context.Context, nodePoolName, namespace string) ([]hetzner.Server, error)
-	CreateServerFunc func(ctx context.Context, config hetzner.ServerConfig) (*hetzner.Server, error)
-	DeleteServerFunc func(ctx context.Context, serverID int64) error
-	GetServerFunc    func(ctx context.Context, serverID int64) (*hetzner.Server, error)
+	ListServersFunc        func(ctx context.Context, nodePoolName, namespace string) ([]hetzner.Server, error)
+	CreateServerFunc       func(ctx context.Context, config hetzner.ServerConfig) (*hetzner.Server, error)
+	DeleteServerFunc       func(ctx context.Context, serverID int64) error
+	GetServerFunc          func(ctx context.Context, serverID int64) (*hetzner.Server, error)
+	GetServerByNameFunc    func(ctx context.Context, name string) (*hetzner.Server, error)
+	ValidateServerTypeFunc func(ctx context.Context, serverType, location string) error
+
+	GetOrCreatePlacementGroupFunc func(ctx context.Context, nameOrID string, labels map[string]string) (*hcloud.PlacementGroup, error)
+	GetPlacementGroupFunc         func(ctx context.Context, nameOrID string) (*hcloud.PlacementGroup, error)
+	DeletePlacementGroupFunc      func(ctx context.Context, placementGroupID int64) error
+
+	GetOrCreateFirewallFunc func(ctx context.Context, name string, rules []hcloud.FirewallRule, labels map[string]string) (*hcloud.Firewall, error)
+	ListFirewallsFunc       func(ctx context.Context, nodePoolName, namespace string) ([]*hcloud.Firewall, error)
+
+	CreateVolumeFunc func(ctx context.Context, config hetzner.VolumeConfig) (*hetzner.Volume, error)
+	DeleteVolumeFunc func(ctx context.Context, volumeID int64) error
+
+	AttachISOFunc func(ctx context.Context, serverID int64, iso string) error
+
+	AddServerToLoadBalancerFunc      func(ctx context.Context, loadBalancer string, serverID int64, usePrivateIP bool) error
+	RemoveServerFromLoadBalancerFunc func(ctx context.Context, loadBalancer string, serverID int64) error
+
+	AssignFloatingIPFunc    func(ctx context.Context, serverID int64, floatingIPs []string) (string, error)
+	UnassignFloatingIPsFunc func(ctx context.Context, serverID int64) error
 
 	// Call tracking for assertions
-	ListServersCalls  int
-	CreateServerCalls int
-	DeleteServerCalls int
-	GetServerCalls    int
+	ListServersCalls        int
+	CreateServerCalls       int
+	DeleteServerCalls       int
+	GetServerCalls          int
+	GetServerByNameCalls    int
+	ValidateServerTypeCalls int
+
+	DeletePlacementGroupCalls int
+
+	DeleteFirewallCalls int
+
+	CreateVolumeCalls int
+	DeleteVolumeCalls int
+
+	AttachISOCalls int
+
+	AddServerToLoadBalancerCalls      int
+	RemoveServerFromLoadBalancerCalls int
+
+	AssignFloatingIPCalls    int
+	UnassignFloatingIPsCalls int
 }
 
 // NewMockHetznerClient creates a new mock Hetzner client
 func NewMockHetznerClient() *HetznerClient {
 	return &HetznerClient{
-		servers: make(map[int64]*hetzner.Server),
-		nextID:  1,
+		servers:      make(map[int64]*hetzner.Server),
+		nextID:       1,
+		volumes:      make(map[int64]*hetzner.Volume),
+		nextVolumeID: 1,
+		firewalls:    make(map[string]*hcloud.Firewall),
+		primaryIPs:   make(map[string]int64),
 	}
 }
 
@@ -85,12 +132,33 @@ func (m *HetznerClient) CreateServer(ctx context.Context, config hetzner.ServerC
 		return m.CreateServerFunc(ctx, config)
 	}
 
+	// Servers take the first unassigned primary IP, like a single address family
+	if len(config.PrimaryIPs) > 0 {
+		assigned := false
+		for _, primaryIP := range config.PrimaryIPs {
+			if _, ok := m.primaryIPs[primaryIP]; !ok {
+				m.primaryIPs[primaryIP] = m.nextID
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			return nil, fmt.Errorf("failed to create server: %w", hetzner.ErrPrimaryIPAssigned)
+		}
+	}
+
 	server := &hetzner.Server{
-		ID:     m.nextID,
-		Name:   config.Name,
-		Status: "running",
-		IPv4:   fmt.Sprintf("192.0.2.%d", m.nextID), // TEST-NET-1 address
-		IPv6:   fmt.Sprintf("2001:db8::%d", m.nextID),
+		ID:         m.nextID,
+		Name:       config.Name,
+		Status:     "running",
+		IPv4:       fmt.Sprintf("192.0.2.%d", m.nextID), // TEST-NET-1 address
+		IPv6:       fmt.Sprintf("2001:db8::%d", m.nextID),
+		Location:   config.Location,
+		ServerType: config.ServerType,
+		Image:      config.Image,
+		VolumeIDs:  config.VolumeIDs,
+		CreatedAt:  time.Now(),
+		Labels:     config.Labels,
 	}
 
 	m.servers[m.nextID] = server
@@ -115,6 +183,11 @@ func (m *HetznerClient) DeleteServer(ctx context.Context, serverID int64) error
 	}
 
 	delete(m.servers, serverID)
+	for primaryIP, assignee := range m.primaryIPs {
+		if assignee == serverID {
+			delete(m.primaryIPs, primaryIP)
+		}
+	}
 	return nil
 }
 
@@ -133,12 +206,46 @@ func (m *HetznerClient) GetServer(ctx context.Context, serverID int64) (*hetzner
 
 	server, exists := m.servers[serverID]
 	if !exists {
-		return nil, fmt.Errorf("server %d not found", serverID)
+		return nil, fmt.Errorf("server %d: %w", serverID, hetzner.ErrServerNotFound)
 	}
 
 	return server, nil
 }
 
+// GetServerByName gets a server by name, returning nil if it does not exist
+func (m *HetznerClient) GetServerByName(ctx context.Context, name string) (*hetzner.Server, error) {
+	m.mu.Lock()
+	m.GetServerByNameCalls++
+	m.mu.Unlock()
+
+	if m.GetServerByNameFunc != nil {
+		return m.GetServerByNameFunc(ctx, name)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, server := range m.servers {
+		if server.Name == name {
+			return server, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ValidateServerType validates a server type, accepting every type by default
+func (m *HetznerClient) ValidateServerType(ctx context.Context, serverType, location string) error {
+	m.mu.Lock()
+	m.ValidateServerTypeCalls++
+	m.mu.Unlock()
+
+	if m.ValidateServerTypeFunc != nil {
+		return m.ValidateServerTypeFunc(ctx, serverType, location)
+	}
+	return nil
+}
+
 // Reset resets the mock state for a new test
 func (m *HetznerClient) Reset() {
 	m.mu.Lock()
@@ -150,6 +257,16 @@ func (m *HetznerClient) Reset() {
 	m.CreateServerCalls = 0
 	m.DeleteServerCalls = 0
 	m.GetServerCalls = 0
+	m.GetServerByNameCalls = 0
+	m.ValidateServerTypeCalls = 0
+	m.DeletePlacementGroupCalls = 0
+	m.firewalls = make(map[string]*hcloud.Firewall)
+	m.DeleteFirewallCalls = 0
+	m.volumes = make(map[int64]*hetzner.Volume)
+	m.nextVolumeID = 1
+	m.CreateVolumeCalls = 0
+	m.DeleteVolumeCalls = 0
+	m.primaryIPs = make(map[string]int64)
 }
 
 // SetServers sets the servers for testing
@@ -173,17 +290,231 @@ func (m *HetznerClient) GetServers() map[int64]*hetzner.Server {
 	return servers
 }
 
+// GetPrimaryIPAssignments returns the assigned primary IPs and the IDs of their servers
+func (m *HetznerClient) GetPrimaryIPAssignments() map[string]int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	assignments := make(map[string]int64)
+	for k, v := range m.primaryIPs {
+		assignments[k] = v
+	}
+	return assignments
+}
+
 // GetOrCreateFirewall mock implementation
-func (m *HetznerClient) GetOrCreateFirewall(_ context.Context, name string, _ []hcloud.FirewallRule) (*hcloud.Firewall, error) {
-	// Simple mock implementation that returns a firewall
-	return &hcloud.Firewall{
-		ID:   1,
-		Name: name,
-	}, nil
+func (m *HetznerClient) GetOrCreateFirewall(
+	ctx context.Context,
+	name string,
+	rules []hcloud.FirewallRule,
+	labels map[string]string,
+) (*hcloud.Firewall, error) {
+	if m.GetOrCreateFirewallFunc != nil {
+		return m.GetOrCreateFirewallFunc(ctx, name, rules, labels)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if firewall, ok := m.firewalls[name]; ok {
+		return firewall, nil
+	}
+
+	firewall := &hcloud.Firewall{
+		ID:     int64(len(m.firewalls) + 1),
+		Name:   name,
+		Labels: labels,
+	}
+	m.firewalls[name] = firewall
+	return firewall, nil
+}
+
+// ListFirewalls mock implementation
+func (m *HetznerClient) ListFirewalls(ctx context.Context, nodePoolName, namespace string) ([]*hcloud.Firewall, error) {
+	if m.ListFirewallsFunc != nil {
+		return m.ListFirewallsFunc(ctx, nodePoolName, namespace)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var firewalls []*hcloud.Firewall
+	for _, firewall := range m.firewalls {
+		if firewall.Labels["nodepool"] == nodePoolName && firewall.Labels["namespace"] == namespace {
+			firewalls = append(firewalls, firewall)
+		}
+	}
+	return firewalls, nil
 }
 
 // DeleteFirewall mock implementation
-func (m *HetznerClient) DeleteFirewall(_ context.Context, _ int64) error {
+func (m *HetznerClient) DeleteFirewall(_ context.Context, firewallID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.DeleteFirewallCalls++
+	for name, firewall := range m.firewalls {
+		if firewall.ID == firewallID {
+			delete(m.firewalls, name)
+		}
+	}
+	return nil
+}
+
+// GetOrCreatePlacementGroup mock implementation
+func (m *HetznerClient) GetOrCreatePlacementGroup(ctx context.Context, nameOrID string, labels map[string]string) (*hcloud.PlacementGroup, error) {
+	if m.GetOrCreatePlacementGroupFunc != nil {
+		return m.GetOrCreatePlacementGroupFunc(ctx, nameOrID, labels)
+	}
+
+	return &hcloud.PlacementGroup{
+		ID:     1,
+		Name:   nameOrID,
+		Labels: labels,
+		Type:   hcloud.PlacementGroupTypeSpread,
+	}, nil
+}
+
+// GetPlacementGroup mock implementation
+func (m *HetznerClient) GetPlacementGroup(ctx context.Context, nameOrID string) (*hcloud.PlacementGroup, error) {
+	if m.GetPlacementGroupFunc != nil {
+		return m.GetPlacementGroupFunc(ctx, nameOrID)
+	}
+
+	return nil, nil
+}
+
+// DeletePlacementGroup mock implementation
+func (m *HetznerClient) DeletePlacementGroup(ctx context.Context, placementGroupID int64) error {
+	m.mu.Lock()
+	m.DeletePlacementGroupCalls++
+	m.mu.Unlock()
+
+	if m.DeletePlacementGroupFunc != nil {
+		return m.DeletePlacementGroupFunc(ctx, placementGroupID)
+	}
+
+	return nil
+}
+
+// AttachISO mock implementation
+func (m *HetznerClient) AttachISO(ctx context.Context, serverID int64, iso string) error {
+	m.mu.Lock()
+	m.AttachISOCalls++
+	m.mu.Unlock()
+
+	if m.AttachISOFunc != nil {
+		return m.AttachISOFunc(ctx, serverID, iso)
+	}
+	return nil
+}
+
+// AssignFloatingIP mock implementation
+func (m *HetznerClient) AssignFloatingIP(ctx context.Context, serverID int64, floatingIPs []string) (string, error) {
+	m.mu.Lock()
+	m.AssignFloatingIPCalls++
+	m.mu.Unlock()
+
+	if m.AssignFloatingIPFunc != nil {
+		return m.AssignFloatingIPFunc(ctx, serverID, floatingIPs)
+	}
+	return "", nil
+}
+
+// UnassignFloatingIPs mock implementation
+func (m *HetznerClient) UnassignFloatingIPs(ctx context.Context, serverID int64) error {
+	m.mu.Lock()
+	m.UnassignFloatingIPsCalls++
+	m.mu.Unlock()
+
+	if m.UnassignFloatingIPsFunc != nil {
+		return m.UnassignFloatingIPsFunc(ctx, serverID)
+	}
+	return nil
+}
+
+// AddServerToLoadBalancer mock implementation
+func (m *HetznerClient) AddServerToLoadBalancer(ctx context.Context, loadBalancer string, serverID int64, usePrivateIP bool) error {
+	m.mu.Lock()
+	m.AddServerToLoadBalancerCalls++
+	m.mu.Unlock()
+
+	if m.AddServerToLoadBalancerFunc != nil {
+		return m.AddServerToLoadBalancerFunc(ctx, loadBalancer, serverID, usePrivateIP)
+	}
+	return nil
+}
+
+// RemoveServerFromLoadBalancer mock implementation
+func (m *HetznerClient) RemoveServerFromLoadBalancer(ctx context.Context, loadBalancer string, serverID int64) error {
+	m.mu.Lock()
+	m.RemoveServerFromLoadBalancerCalls++
+	m.mu.Unlock()
+
+	if m.RemoveServerFromLoadBalancerFunc != nil {
+		return m.RemoveServerFromLoadBalancerFunc(ctx, loadBalancer, serverID)
+	}
+	return nil
+}
+
+// EnsureSnapshot mock implementation
+func (m *HetznerClient) EnsureSnapshot(_ context.Context, _ hetzner.SnapshotConfig) (*hetzner.Snapshot, error) {
+	// Simple mock implementation, the snapshot is never ready
+	return nil, nil
+}
+
+// DeleteStaleSnapshots mock implementation
+func (m *HetznerClient) DeleteStaleSnapshots(_ context.Context, _, _, _ string) error {
 	// Simple mock implementation
 	return nil
 }
+
+// CreateVolume creates a new volume
+func (m *HetznerClient) CreateVolume(ctx context.Context, config hetzner.VolumeConfig) (*hetzner.Volume, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.CreateVolumeCalls++
+
+	if m.CreateVolumeFunc != nil {
+		return m.CreateVolumeFunc(ctx, config)
+	}
+
+	volume := &hetzner.Volume{
+		ID:          m.nextVolumeID,
+		Name:        config.Name,
+		LinuxDevice: fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", m.nextVolumeID),
+	}
+
+	m.volumes[m.nextVolumeID] = volume
+	m.nextVolumeID++
+
+	return volume, nil
+}
+
+// DeleteVolume deletes a volume
+func (m *HetznerClient) DeleteVolume(ctx context.Context, volumeID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.DeleteVolumeCalls++
+
+	if m.DeleteVolumeFunc != nil {
+		return m.DeleteVolumeFunc(ctx, volumeID)
+	}
+
+	delete(m.volumes, volumeID)
+	return nil
+}
+
+// GetVolumes returns all volumes for assertions
+func (m *HetznerClient) GetVolumes() map[int64]*hetzner.Volume {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	volumes := make(map[int64]*hetzner.Volume)
+	for k, v := range m.volumes {
+		volumes[k] = v
+	}
+	return volumes
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/autokubeio/autokube/internal/ovhcloud"
+)
+
+// OVHcloudClient is a mock implementation of the OVHcloud client for testing
+type OVHcloudClient struct {
+	mu        sync.RWMutex
+	instances map[string]*ovhcloud.Instance
+	nextID    int
+
+	securityGroups map[string]*ovhcloud.SecurityGroup
+
+	// Configurable behaviors for testing
+	ListInstancesFunc  func(ctx context.Context, nodePoolName, namespace string) ([]ovhcloud.Instance, error)
+	CreateInstanceFunc func(ctx context.Context, config ovhcloud.InstanceConfig) (*ovhcloud.Instance, error)
+	DeleteInstanceFunc func(ctx context.Context, instanceID string) error
+	ValidateFlavorFunc func(ctx context.Context, region, flavor string) error
+
+	// Call tracking for assertions
+	ListInstancesCalls  int
+	CreateInstanceCalls int
+	DeleteInstanceCalls int
+
+	DeleteSecurityGroupCalls int
+
+	GetFlavorIDByNameCalls int
+	GetImageIDByNameCalls  int
+}
+
+// NewMockOVHcloudClient creates a new mock OVHcloud client
+func NewMockOVHcloudClient() *OVHcloudClient {
+	return &OVHcloudClient{
+		instances: make(map[string]*ovhcloud.Instance),
+		nextID:    1,
+
+		securityGroups: make(map[string]*ovhcloud.SecurityGroup),
+	}
+}
+
+// ListInstances lists all instances for a given node pool
+func (m *OVHcloudClient) ListInstances(ctx context.Context, nodePoolName, namespace string) ([]ovhcloud.Instance, error) {
+	m.mu.Lock()
+	m.ListInstancesCalls++
+	m.mu.Unlock()
+
+	if m.ListInstancesFunc != nil {
+		return m.ListInstancesFunc(ctx, nodePoolName, namespace)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var instances []ovhcloud.Instance
+	for _, instance := range m.instances {
+		instances = append(instances, *instance)
+	}
+
+	return instances, nil
+}
+
+// CreateInstance creates a new instance
+func (m *OVHcloudClient) CreateInstance(ctx context.Context, config ovhcloud.InstanceConfig) (*ovhcloud.Instance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.CreateInstanceCalls++
+
+	if m.CreateInstanceFunc != nil {
+		return m.CreateInstanceFunc(ctx, config)
+	}
+
+	instance := &ovhcloud.Instance{
+		ID:        fmt.Sprintf("instance-%d", m.nextID),
+		Name:      config.Name,
+		Status:    ovhcloud.StatusActive,
+		IPv4:      fmt.Sprintf("192.0.2.%d", m.nextID), // TEST-NET-1 address
+		CreatedAt: time.Now(),
+	}
+
+	m.instances[instance.ID] = instance
+	m.nextID++
+
+	return instance, nil
+}
+
+// DeleteInstance deletes an instance
+func (m *OVHcloudClient) DeleteInstance(ctx context.Context, instanceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.DeleteInstanceCalls++
+
+	if m.DeleteInstanceFunc != nil {
+		return m.DeleteInstanceFunc(ctx, instanceID)
+	}
+
+	if _, exists := m.instances[instanceID]; !exists {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	delete(m.instances, instanceID)
+	return nil
+}
+
+// GetInstance gets an instance by ID
+func (m *OVHcloudClient) GetInstance(_ context.Context, instanceID string) (*ovhcloud.Instance, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	instance, exists := m.instances[instanceID]
+	if !exists {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	return instance, nil
+}
+
+// GetInstanceByName gets an instance by name, returning nil if it does not exist
+func (m *OVHcloudClient) GetInstanceByName(_ context.Context, name string) (*ovhcloud.Instance, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, instance := range m.instances {
+		if instance.Name == name {
+			return instance, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetOrCreateSecurityGroup mock implementation
+func (m *OVHcloudClient) GetOrCreateSecurityGroup(
+	_ context.Context,
+	name string,
+	_ []ovhcloud.SecurityRule,
+	labels map[string]string,
+) (*ovhcloud.SecurityGroup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if securityGroup, ok := m.securityGroups[name]; ok {
+		return securityGroup, nil
+	}
+
+	securityGroup := &ovhcloud.SecurityGroup{
+		ID:     fmt.Sprintf("security-group-%d", len(m.securityGroups)+1),
+		Name:   name,
+		Labels: labels,
+	}
+	m.securityGroups[name] = securityGroup
+	return securityGroup, nil
+}
+
+// ListSecurityGroups mock implementation
+func (m *OVHcloudClient) ListSecurityGroups(_ context.Context, nodePoolName, namespace string) ([]ovhcloud.SecurityGroup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var securityGroups []ovhcloud.SecurityGroup
+	for _, securityGroup := range m.securityGroups {
+		if securityGroup.Labels["nodepool"] == nodePoolName && securityGroup.Labels["namespace"] == namespace {
+			securityGroups = append(securityGroups, *securityGroup)
+		}
+	}
+	return securityGroups, nil
+}
+
+// DeleteSecurityGroup mock implementation
+func (m *OVHcloudClient) DeleteSecurityGroup(_ context.Context, securityGroupID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.DeleteSecurityGroupCalls++
+	for name, securityGroup := range m.securityGroups {
+		if securityGroup.ID == securityGroupID {
+			delete(m.securityGroups, name)
+		}
+	}
+	return nil
+}
+
+// GetFlavorIDByName mock implementation
+func (m *OVHcloudClient) GetFlavorIDByName(_ context.Context, _, flavorName string) (string, error) {
+	m.mu.Lock()
+	m.GetFlavorIDByNameCalls++
+	m.mu.Unlock()
+
+	return "flavor-" + flavorName, nil
+}
+
+// ValidateFlavor mock implementation
+func (m *OVHcloudClient) ValidateFlavor(ctx context.Context, region, flavor string) error {
+	if m.ValidateFlavorFunc != nil {
+		return m.ValidateFlavorFunc(ctx, region, flavor)
+	}
+	return nil
+}
+
+// GetImageIDByName mock implementation
+func (m *OVHcloudClient) GetImageIDByName(_ context.Context, _, imageName string) (string, error) {
+	m.mu.Lock()
+	m.GetImageIDByNameCalls++
+	m.mu.Unlock()
+
+	return "image-" + imageName, nil
+}
+
+// GetSSHKeyIDByName mock implementation
+func (m *OVHcloudClient) GetSSHKeyIDByName(_ context.Context, sshKeyName string) (string, error) {
+	return "sshkey-" + sshKeyName, nil
+}
+
+// GetNetworkIDByName mock implementation
+func (m *OVHcloudClient) GetNetworkIDByName(_ context.Context, _, networkName string) (string, error) {
+	return "network-" + networkName, nil
+}
+
+// GetPublicNetworkID mock implementation
+func (m *OVHcloudClient) GetPublicNetworkID(_ context.Context, _ string) (string, error) {
+	return "public-network", nil
+}
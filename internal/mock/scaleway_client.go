@@ -0,0 +1,143 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/autokubeio/autokube/internal/scaleway"
+)
+
+// ScalewayClient is a mock implementation of the Scaleway client for testing
+type ScalewayClient struct {
+	mu        sync.RWMutex
+	instances map[string]*scaleway.Instance
+	nextID    int
+
+	// Configurable behaviors for testing
+	ListInstancesFunc  func(ctx context.Context, zone, nodePoolName, namespace string) ([]scaleway.Instance, error)
+	CreateInstanceFunc func(ctx context.Context, config scaleway.InstanceConfig) (*scaleway.Instance, error)
+	DeleteInstanceFunc func(ctx context.Context, zone, instanceID string) error
+
+	// Call tracking for assertions
+	ListInstancesCalls  int
+	CreateInstanceCalls int
+	DeleteInstanceCalls int
+}
+
+// NewMockScalewayClient creates a new mock Scaleway client
+func NewMockScalewayClient() *ScalewayClient {
+	return &ScalewayClient{
+		instances: make(map[string]*scaleway.Instance),
+		nextID:    1,
+	}
+}
+
+// ListInstances lists all instances for a given node pool
+func (m *ScalewayClient) ListInstances(ctx context.Context, zone, nodePoolName, namespace string) ([]scaleway.Instance, error) {
+	m.mu.Lock()
+	m.ListInstancesCalls++
+	m.mu.Unlock()
+
+	if m.ListInstancesFunc != nil {
+		return m.ListInstancesFunc(ctx, zone, nodePoolName, namespace)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var instances []scaleway.Instance
+	for _, instance := range m.instances {
+		instances = append(instances, *instance)
+	}
+
+	return instances, nil
+}
+
+// CreateInstance creates a new instance
+func (m *ScalewayClient) CreateInstance(ctx context.Context, config scaleway.InstanceConfig) (*scaleway.Instance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.CreateInstanceCalls++
+
+	if m.CreateInstanceFunc != nil {
+		return m.CreateInstanceFunc(ctx, config)
+	}
+
+	instance := &scaleway.Instance{
+		ID:        fmt.Sprintf("instance-%d", m.nextID),
+		Name:      config.Name,
+		Zone:      config.Zone,
+		Status:    scaleway.StateRunning,
+		IPv4:      fmt.Sprintf("192.0.2.%d", m.nextID), // TEST-NET-1 address
+		CreatedAt: time.Now(),
+	}
+
+	m.instances[instance.ID] = instance
+	m.nextID++
+
+	return instance, nil
+}
+
+// DeleteInstance deletes an instance
+func (m *ScalewayClient) DeleteInstance(ctx context.Context, zone, instanceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.DeleteInstanceCalls++
+
+	if m.DeleteInstanceFunc != nil {
+		return m.DeleteInstanceFunc(ctx, zone, instanceID)
+	}
+
+	if _, exists := m.instances[instanceID]; !exists {
+		return fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	delete(m.instances, instanceID)
+	return nil
+}
+
+// GetInstance gets an instance by ID
+func (m *ScalewayClient) GetInstance(_ context.Context, _, instanceID string) (*scaleway.Instance, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	instance, exists := m.instances[instanceID]
+	if !exists {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	return instance, nil
+}
+
+// GetInstances returns all instances for assertions
+func (m *ScalewayClient) GetInstances() map[string]*scaleway.Instance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Return a copy to prevent race conditions
+	instances := make(map[string]*scaleway.Instance)
+	for k, v := range m.instances {
+		instances[k] = v
+	}
+	return instances
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultResolverCacheTTL is how long resolved flavor, image, SSH key and network IDs are cached
+const DefaultResolverCacheTTL = 5 * time.Minute
+
+// resolverCache caches name to ID resolutions for a limited time
+// It is safe for concurrent use. A nil cache caches nothing.
+type resolverCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]resolverCacheEntry
+	now     func() time.Time
+}
+
+type resolverCacheEntry struct {
+	id      string
+	expires time.Time
+}
+
+// newResolverCache creates a cache with the given TTL, or nil when the TTL disables caching
+func newResolverCache(ttl time.Duration) *resolverCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &resolverCache{
+		ttl:     ttl,
+		entries: make(map[string]resolverCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached ID for key if it has not expired
+func (rc *resolverCache) get(key string) (string, bool) {
+	if rc == nil {
+		return "", false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return "", false
+	}
+	if !rc.now().Before(entry.expires) {
+		delete(rc.entries, key)
+		return "", false
+	}
+	return entry.id, true
+}
+
+// set caches the ID for key
+func (rc *resolverCache) set(key, id string) {
+	if rc == nil {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.entries[key] = resolverCacheEntry{id: id, expires: rc.now().Add(rc.ttl)}
+}
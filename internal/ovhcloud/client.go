@@ -19,11 +19,20 @@ package ovhcloud
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/autokubeio/autokube/internal/reliability"
 	"github.com/ovh/go-ovh/ovh"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/autokubeio/autokube/internal/reliability"
 )
 
 const (
@@ -33,23 +42,62 @@ const (
 	DirectionEgress = "egress"
 	// StatusActive represents active status
 	StatusActive = "ACTIVE"
+
+	// publicNetworkLookupTimeout bounds resolving the public network when creating an instance
+	publicNetworkLookupTimeout = 30 * time.Second
+
+	// instancePageSize is the number of instances requested per page when listing instances
+	instancePageSize = 100
+)
+
+// UserDataEncoding is how user data is sent to the OVHcloud instance API
+type UserDataEncoding string
+
+const (
+	// UserDataEncodingBase64 sends user data base64 encoded, as the instance API expects it
+	UserDataEncodingBase64 UserDataEncoding = "base64"
+	// UserDataEncodingPlain sends user data as is, for API versions that take plain text
+	UserDataEncodingPlain UserDataEncoding = "plain"
 )
 
+// ErrPublicNetworkUnavailable is returned when the public network of a region can't be
+// resolved for an instance attached to a private network
+var ErrPublicNetworkUnavailable = errors.New("public network unavailable")
+
+// ErrFlavorUnavailable is returned when a flavor does not exist or cannot be ordered in the
+// requested region
+var ErrFlavorUnavailable = errors.New("flavor unavailable")
+
+// errInstanceNotReady is returned while polling a new instance that has no IP address yet
+var errInstanceNotReady = errors.New("instance has no IP address yet")
+
 // ClientInterface defines the interface for interacting with OVHcloud
 type ClientInterface interface {
 	ListInstances(ctx context.Context, nodePoolName, namespace string) ([]Instance, error)
 	CreateInstance(ctx context.Context, config InstanceConfig) (*Instance, error)
 	DeleteInstance(ctx context.Context, instanceID string) error
 	GetInstance(ctx context.Context, instanceID string) (*Instance, error)
-	GetOrCreateSecurityGroup(ctx context.Context, name string, rules []SecurityRule) (*SecurityGroup, error)
+	GetInstanceByName(ctx context.Context, name string) (*Instance, error)
+	GetOrCreateSecurityGroup(ctx context.Context, name string, rules []SecurityRule, labels map[string]string) (*SecurityGroup, error)
+	ListSecurityGroups(ctx context.Context, nodePoolName, namespace string) ([]SecurityGroup, error)
 	DeleteSecurityGroup(ctx context.Context, securityGroupID string) error
 	GetFlavorIDByName(ctx context.Context, region, flavorName string) (string, error)
+	ValidateFlavor(ctx context.Context, region, flavor string) error
 	GetImageIDByName(ctx context.Context, region, imageName string) (string, error)
 	GetSSHKeyIDByName(ctx context.Context, sshKeyName string) (string, error)
 	GetNetworkIDByName(ctx context.Context, region, networkName string) (string, error)
 	GetPublicNetworkID(ctx context.Context, region string) (string, error)
 }
 
+// Credentials are the API credentials of an OVHcloud project
+type Credentials struct {
+	Endpoint          string
+	ApplicationKey    string
+	ApplicationSecret string
+	ConsumerKey       string
+	ProjectID         string
+}
+
 // InstanceCreateError is a custom error type for instance creation failures
 type InstanceCreateError struct {
 	Message string
@@ -68,7 +116,11 @@ type Client struct {
 	projectID         string
 	region            string
 	retryConfig       reliability.RetryConfig
+	pollConfig        reliability.RetryConfig
 	circuitBreaker    *reliability.CircuitBreaker
+	operationTimeout  time.Duration
+	resolverCache     *resolverCache
+	userDataEncoding  UserDataEncoding
 	ovhClient         *ovh.Client
 }
 
@@ -82,6 +134,63 @@ func WithRetryConfig(config reliability.RetryConfig) ClientOption {
 	}
 }
 
+// WithInstancePollConfig sets how a created instance is polled until it has an IP address
+// or is active. RetryableErrors is ignored
+func WithInstancePollConfig(config reliability.RetryConfig) ClientOption {
+	return func(c *Client) {
+		c.pollConfig = config
+	}
+}
+
+// defaultInstancePollConfig polls a created instance for up to about 100 seconds
+func defaultInstancePollConfig() reliability.RetryConfig {
+	return reliability.RetryConfig{
+		MaxRetries:        10,
+		InitialBackoff:    2 * time.Second,
+		MaxBackoff:        15 * time.Second,
+		BackoffMultiplier: 1.5,
+	}
+}
+
+// WithRetryableErrors sets the predicate deciding which failed API requests are retried,
+// including while a created instance is polled, replacing the default IsRetryableError
+func WithRetryableErrors(retryable func(error) bool) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.RetryableErrors = retryable
+	}
+}
+
+// WithRetryBudget sets a retry budget shared with other clients, bounding their retries
+// together during an outage
+func WithRetryBudget(budget *reliability.RetryBudget) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.Budget = budget
+	}
+}
+
+// WithOperationTimeout bounds create, delete and attach operations that are
+// called without a deadline of their own
+func WithOperationTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.operationTimeout = timeout
+	}
+}
+
+// WithResolverCacheTTL sets how long resolved flavor, image, SSH key and network IDs
+// are cached. Zero disables caching.
+func WithResolverCacheTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.resolverCache = newResolverCache(ttl)
+	}
+}
+
+// WithUserDataEncoding sets how user data is sent to the instance API, base64 by default
+func WithUserDataEncoding(encoding UserDataEncoding) ClientOption {
+	return func(c *Client) {
+		c.userDataEncoding = encoding
+	}
+}
+
 // WithCircuitBreaker sets a circuit breaker
 func WithCircuitBreaker(cb *reliability.CircuitBreaker) ClientOption {
 	return func(c *Client) {
@@ -97,6 +206,12 @@ type Instance struct {
 	IPv4      string
 	IPv6      string
 	PrivateIP string
+	// CreatedAt is when the instance was created
+	CreatedAt time.Time
+
+	// PrivateOnly is set by CreateInstance when the public network lookup failed and the
+	// instance was created with the private network only
+	PrivateOnly bool
 }
 
 // SecurityGroup represents an OVHcloud security group
@@ -104,6 +219,7 @@ type SecurityGroup struct {
 	ID          string
 	Name        string
 	Description string
+	Labels      map[string]string
 }
 
 // SecurityRule defines a security group rule
@@ -115,8 +231,33 @@ type SecurityRule struct {
 	SourceCIDR string
 }
 
-// NewClient creates a new OVHcloud client
-func NewClient(endpoint, applicationKey, applicationSecret, consumerKey, projectID, region string, opts ...ClientOption) *Client {
+// ValidateEndpoint checks that endpoint is a known OVHcloud API endpoint name, such as
+// ovh-eu, ovh-us, ovh-ca, kimsufi-* or soyoustart-*, or the URL of an API
+func ValidateEndpoint(endpoint string) error {
+	if _, ok := ovh.Endpoints[endpoint]; ok {
+		return nil
+	}
+	if u, err := url.Parse(endpoint); err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" {
+		return nil
+	}
+
+	names := make([]string, 0, len(ovh.Endpoints))
+	for name := range ovh.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown OVHcloud endpoint %q, expected one of %s or an API URL",
+		endpoint, strings.Join(names, ", "))
+}
+
+// NewClient creates a new OVHcloud client, failing if the endpoint is unknown
+func NewClient(
+	endpoint, applicationKey, applicationSecret, consumerKey, projectID, region string,
+	opts ...ClientOption,
+) (*Client, error) {
+	if err := ValidateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
 	ovhClient, err := ovh.NewClient(
 		endpoint,
 		applicationKey,
@@ -124,8 +265,7 @@ func NewClient(endpoint, applicationKey, applicationSecret, consumerKey, project
 		consumerKey,
 	)
 	if err != nil {
-		// Return client with error logging capability
-		ovhClient = nil
+		return nil, fmt.Errorf("failed to create OVHcloud API client: %w", err)
 	}
 
 	c := &Client{
@@ -136,14 +276,19 @@ func NewClient(endpoint, applicationKey, applicationSecret, consumerKey, project
 		projectID:         projectID,
 		region:            region,
 		retryConfig:       reliability.DefaultRetryConfig(),
+		pollConfig:        defaultInstancePollConfig(),
+		resolverCache:     newResolverCache(DefaultResolverCacheTTL),
+		userDataEncoding:  UserDataEncodingBase64,
 		ovhClient:         ovhClient,
 	}
 
+	c.retryConfig.RetryableErrors = IsRetryableError
+
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	return c
+	return c, nil
 }
 
 // InstanceConfig contains the configuration for creating an instance
@@ -158,61 +303,181 @@ type InstanceConfig struct {
 	UserData        string
 	SecurityGroupID string
 	Labels          map[string]string
+
+	// AllowPrivateOnly creates the instance with the private network only when the public
+	// network can't be resolved, instead of failing. Such instances have no internet access.
+	AllowPrivateOnly bool
+
+	// MonthlyBilling bills the instance monthly instead of hourly
+	MonthlyBilling bool
+}
+
+// InstanceNamePrefix returns the name prefix identifying the instances of a node pool
+//
+// The OVHcloud instance API exposes no metadata or tags to filter on, so pool
+// membership is encoded in the instance name as <namespace>-<nodepool>-<suffix>.
+func InstanceNamePrefix(nodePoolName, namespace string) string {
+	return fmt.Sprintf("%s-%s-", namespace, nodePoolName)
+}
+
+// BelongsToNodePool reports whether an instance name was generated for the given node pool
+// The suffix must not contain dashes, so pool "web" doesn't claim instances of pool "web-api"
+func BelongsToNodePool(instanceName, nodePoolName, namespace string) bool {
+	prefix := InstanceNamePrefix(nodePoolName, namespace)
+	if !strings.HasPrefix(instanceName, prefix) {
+		return false
+	}
+	suffix := strings.TrimPrefix(instanceName, prefix)
+	return suffix != "" && !strings.Contains(suffix, "-")
 }
 
 // ListInstances retrieves all instances for a specific node pool
-func (c *Client) ListInstances(ctx context.Context, _, _ string) ([]Instance, error) {
-	if c.ovhClient == nil {
-		return nil, fmt.Errorf("OVHcloud client not initialized")
+func (c *Client) ListInstances(ctx context.Context, nodePoolName, namespace string) ([]Instance, error) {
+	rawInstances, err := c.listRawInstances(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// API endpoint: GET /cloud/project/{serviceName}/instance
-	var rawInstances []struct {
-		ID          string `json:"id"`
-		Name        string `json:"name"`
-		Status      string `json:"status"`
-		IPAddresses []struct {
-			IP      string `json:"ip"`
-			Type    string `json:"type"`
-			Version int    `json:"version"`
-		} `json:"ipAddresses"`
+	// Filter instances by the node pool name prefix
+	var instances []Instance
+	for _, raw := range rawInstances {
+		if !BelongsToNodePool(raw.Name, nodePoolName, namespace) {
+			continue
+		}
+		instances = append(instances, *raw.toInstance())
 	}
 
-	endpoint := fmt.Sprintf("/cloud/project/%s/instance", c.projectID)
-	if err := c.ovhClient.GetWithContext(ctx, endpoint, &rawInstances); err != nil {
-		return nil, fmt.Errorf("failed to list instances: %w", err)
+	return instances, nil
+}
+
+// GetInstanceByName gets an instance by name, returning nil if it does not exist
+func (c *Client) GetInstanceByName(ctx context.Context, name string) (*Instance, error) {
+	rawInstances, err := c.listRawInstances(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Filter instances by labels (name contains nodepool name for now)
-	var instances []Instance
 	for _, raw := range rawInstances {
-		// Simple filtering: check if instance name contains nodepool name
-		// In production, you'd use proper labels/tags
-		if len(raw.Name) > 0 {
-			instance := Instance{
-				ID:     raw.ID,
-				Name:   raw.Name,
-				Status: raw.Status,
-			}
+		if raw.Name == name {
+			return raw.toInstance(), nil
+		}
+	}
 
-			// Extract IP addresses
-			for _, ip := range raw.IPAddresses {
-				switch ip.Version {
-				case 4:
-					instance.IPv4 = ip.IP
-					if ip.Type == "private" {
-						instance.PrivateIP = ip.IP
-					}
-				case 6:
-					instance.IPv6 = ip.IP
-				}
+	return nil, nil
+}
+
+// rawInstance is an instance as returned by the OVHcloud API
+type rawInstance struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Created     time.Time `json:"created"`
+	IPAddresses []struct {
+		IP      string `json:"ip"`
+		Type    string `json:"type"`
+		Version int    `json:"version"`
+	} `json:"ipAddresses"`
+}
+
+// toInstance converts an API instance to an Instance
+func (raw *rawInstance) toInstance() *Instance {
+	instance := &Instance{
+		ID:        raw.ID,
+		Name:      raw.Name,
+		Status:    raw.Status,
+		CreatedAt: raw.Created,
+	}
+
+	// Extract IP addresses
+	for _, ip := range raw.IPAddresses {
+		switch ip.Version {
+		case 4:
+			instance.IPv4 = ip.IP
+			if ip.Type == "private" {
+				instance.PrivateIP = ip.IP
 			}
+		case 6:
+			instance.IPv6 = ip.IP
+		}
+	}
+
+	return instance
+}
 
-			instances = append(instances, instance)
+// listRawInstances lists all instances in the project. The listing is requested page by
+// page, following the cursor the API returns until the last page; an API that doesn't
+// paginate returns every instance in the first response, without a cursor
+func (c *Client) listRawInstances(ctx context.Context) ([]rawInstance, error) {
+	if c.ovhClient == nil {
+		return nil, fmt.Errorf("OVHcloud client not initialized")
+	}
+
+	// API endpoint: GET /cloud/project/{serviceName}/instance
+	var rawInstances []rawInstance
+	endpoint := fmt.Sprintf("/cloud/project/%s/instance", c.projectID)
+	cursor := ""
+	for {
+		var page []rawInstance
+		var next string
+		err := c.executeWithRetry(ctx, func() error {
+			var err error
+			page, next, err = c.listRawInstancesPage(ctx, endpoint, cursor)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", err)
 		}
+		rawInstances = append(rawInstances, page...)
+		if next == "" || next == cursor {
+			return rawInstances, nil
+		}
+		cursor = next
 	}
+}
 
-	return instances, nil
+// listRawInstancesPage requests the page of instances at the given cursor, the first page
+// for an empty cursor, and returns it along with the cursor of the next page
+func (c *Client) listRawInstancesPage(ctx context.Context, endpoint, cursor string) ([]rawInstance, string, error) {
+	req, err := c.ovhClient.NewRequest(http.MethodGet, endpoint, nil, true)
+	if err != nil {
+		return nil, "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Pagination-Mode", "CachedObjectList-Pages")
+	req.Header.Set("X-Pagination-Size", strconv.Itoa(instancePageSize))
+	if cursor != "" {
+		req.Header.Set("X-Pagination-Cursor", cursor)
+	}
+
+	resp, err := c.ovhClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	var page []rawInstance
+	if err := c.ovhClient.UnmarshalResponse(resp, &page); err != nil {
+		return nil, "", err
+	}
+	return page, resp.Header.Get("X-Pagination-Cursor-Next"), nil
+}
+
+// ValidateUserDataEncoding checks that encoding is a supported user data encoding
+func ValidateUserDataEncoding(encoding UserDataEncoding) error {
+	switch encoding {
+	case UserDataEncodingBase64, UserDataEncodingPlain:
+		return nil
+	default:
+		return fmt.Errorf("unsupported user data encoding %q, use %s or %s",
+			encoding, UserDataEncodingBase64, UserDataEncodingPlain)
+	}
+}
+
+// encodeUserData encodes user data for the instance API. The API stores plain text user data
+// as is, and cloud-init on the instance then fails to parse it in regions expecting base64
+func (c *Client) encodeUserData(userData string) string {
+	if userData == "" || c.userDataEncoding == UserDataEncodingPlain {
+		return userData
+	}
+	return base64.StdEncoding.EncodeToString([]byte(userData))
 }
 
 // CreateInstance creates a new instance in OVHcloud
@@ -221,8 +486,8 @@ func (c *Client) CreateInstance(ctx context.Context, config InstanceConfig) (*In
 		return nil, fmt.Errorf("OVHcloud client not initialized")
 	}
 
-	// OVHcloud expects plain text user data, not base64
-	// The API will handle encoding internally if needed
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
 
 	// Prepare instance creation request dynamically
 	createReq := map[string]interface{}{
@@ -230,7 +495,7 @@ func (c *Client) CreateInstance(ctx context.Context, config InstanceConfig) (*In
 		"flavorId": config.FlavorID,
 		"imageId":  config.ImageID,
 		"region":   config.Region,
-		"userData": config.UserData,
+		"userData": c.encodeUserData(config.UserData),
 	}
 
 	// Add SSH keys if provided and not empty
@@ -241,16 +506,24 @@ func (c *Client) CreateInstance(ctx context.Context, config InstanceConfig) (*In
 	// Note: If no SSH key provided, OVHcloud may still create the instance without SSH access
 
 	// Add network configuration
+	var privateOnly bool
 	// When private network is specified, we need to explicitly include both:
 	// 1. Public network for internet access
 	// 2. Private network for internal communication
 	if config.NetworkID != "" {
 		// Get public network ID for the region
-		publicNetID, err := c.GetPublicNetworkID(ctx, config.Region)
+		lookupCtx, cancel := context.WithTimeout(ctx, publicNetworkLookupTimeout)
+		publicNetID, err := c.GetPublicNetworkID(lookupCtx, config.Region)
+		cancel()
 		if err != nil {
-			// If we can't get public network, continue without it and log
-			// Instance will only have private IP
-			fmt.Printf("Warning: Could not get public network for region %s: %v\n", config.Region, err)
+			if !config.AllowPrivateOnly {
+				return nil, fmt.Errorf("%w: %w", ErrPublicNetworkUnavailable, err)
+			}
+
+			// Instance will only have a private IP and no internet access
+			log.FromContext(ctx).Error(err, "Public network unavailable, creating private-only instance",
+				"instance", config.Name, "region", config.Region)
+			privateOnly = true
 			createReq["networks"] = []map[string]interface{}{
 				{
 					"networkId": config.NetworkID, // Private network only
@@ -270,7 +543,7 @@ func (c *Client) CreateInstance(ctx context.Context, config InstanceConfig) (*In
 	}
 	// If no private network specified, public IP will be assigned by default
 
-	createReq["monthlyBilling"] = false
+	createReq["monthlyBilling"] = config.MonthlyBilling
 
 	// API endpoint: POST /cloud/project/{serviceName}/instance
 	var response struct {
@@ -280,15 +553,51 @@ func (c *Client) CreateInstance(ctx context.Context, config InstanceConfig) (*In
 	}
 
 	endpoint := fmt.Sprintf("/cloud/project/%s/instance", c.projectID)
-	if err := c.ovhClient.PostWithContext(ctx, endpoint, createReq, &response); err != nil {
+	err := c.executeWithRetry(ctx, func() error {
+		return c.ovhClient.PostWithContext(ctx, endpoint, createReq, &response)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create instance: %w", err)
 	}
 
-	// Wait a moment for instance to be created
-	time.Sleep(2 * time.Second)
+	instance, err := c.waitForInstanceAddress(ctx, response.ID)
+	if err != nil {
+		return nil, err
+	}
+	instance.PrivateOnly = privateOnly
+	return instance, nil
+}
+
+// waitForInstanceAddress polls a new instance with exponential backoff until it has an IP
+// address or is active, bounded by the instance poll config and the context. An instance
+// that is still building when polling gives up is returned as last seen
+func (c *Client) waitForInstanceAddress(ctx context.Context, instanceID string) (*Instance, error) {
+	config := c.pollConfig
+	config.RetryableErrors = func(err error) bool {
+		return errors.Is(err, errInstanceNotReady) ||
+			c.retryConfig.RetryableErrors == nil || c.retryConfig.RetryableErrors(err)
+	}
 
-	// Get full instance details
-	return c.GetInstance(ctx, response.ID)
+	var instance *Instance
+	err := reliability.RetryOperation(ctx, config, func() error {
+		current, err := c.getInstance(ctx, instanceID)
+		if err != nil {
+			return err
+		}
+		instance = current
+		if instance.Status != StatusActive && instance.IPv4 == "" && instance.IPv6 == "" && instance.PrivateIP == "" {
+			return errInstanceNotReady
+		}
+		return nil
+	})
+	if err != nil {
+		if instance == nil {
+			return nil, err
+		}
+		log.FromContext(ctx).Info("Instance has no IP address yet, continuing",
+			"instance", instance.Name, "status", instance.Status, "reason", err.Error())
+	}
+	return instance, nil
 }
 
 // DeleteInstance deletes an instance from OVHcloud
@@ -297,9 +606,15 @@ func (c *Client) DeleteInstance(ctx context.Context, instanceID string) error {
 		return fmt.Errorf("OVHcloud client not initialized")
 	}
 
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
 	// API endpoint: DELETE /cloud/project/{serviceName}/instance/{instanceId}
 	endpoint := fmt.Sprintf("/cloud/project/%s/instance/%s", c.projectID, instanceID)
-	if err := c.ovhClient.DeleteWithContext(ctx, endpoint, nil); err != nil {
+	err := c.executeWithRetry(ctx, func() error {
+		return c.ovhClient.DeleteWithContext(ctx, endpoint, nil)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete instance %s: %w", instanceID, err)
 	}
 
@@ -312,47 +627,38 @@ func (c *Client) GetInstance(ctx context.Context, instanceID string) (*Instance,
 		return nil, fmt.Errorf("OVHcloud client not initialized")
 	}
 
-	// API endpoint: GET /cloud/project/{serviceName}/instance/{instanceId}
-	var raw struct {
-		ID          string `json:"id"`
-		Name        string `json:"name"`
-		Status      string `json:"status"`
-		IPAddresses []struct {
-			IP      string `json:"ip"`
-			Type    string `json:"type"`
-			Version int    `json:"version"`
-		} `json:"ipAddresses"`
+	var instance *Instance
+	err := c.executeWithRetry(ctx, func() error {
+		var err error
+		instance, err = c.getInstance(ctx, instanceID)
+		return err
+	})
+	return instance, err
+}
+
+// getInstance retrieves an instance with a single request, for callers retrying on their own
+func (c *Client) getInstance(ctx context.Context, instanceID string) (*Instance, error) {
+	if c.ovhClient == nil {
+		return nil, fmt.Errorf("OVHcloud client not initialized")
 	}
 
+	// API endpoint: GET /cloud/project/{serviceName}/instance/{instanceId}
+	var raw rawInstance
 	endpoint := fmt.Sprintf("/cloud/project/%s/instance/%s", c.projectID, instanceID)
 	if err := c.ovhClient.GetWithContext(ctx, endpoint, &raw); err != nil {
 		return nil, fmt.Errorf("failed to get instance %s: %w", instanceID, err)
 	}
 
-	instance := &Instance{
-		ID:     raw.ID,
-		Name:   raw.Name,
-		Status: raw.Status,
-	}
-
-	// Extract IP addresses
-	for _, ip := range raw.IPAddresses {
-		switch ip.Version {
-		case 4:
-			instance.IPv4 = ip.IP
-			if ip.Type == "private" {
-				instance.PrivateIP = ip.IP
-			}
-		case 6:
-			instance.IPv6 = ip.IP
-		}
-	}
-
-	return instance, nil
+	return raw.toInstance(), nil
 }
 
 // GetOrCreateSecurityGroup gets an existing security group or creates a new one
-func (c *Client) GetOrCreateSecurityGroup(ctx context.Context, name string, _ []SecurityRule) (*SecurityGroup, error) {
+func (c *Client) GetOrCreateSecurityGroup(
+	ctx context.Context,
+	name string,
+	_ []SecurityRule,
+	labels map[string]string,
+) (*SecurityGroup, error) {
 	if c.ovhClient == nil {
 		return nil, fmt.Errorf("OVHcloud client not initialized")
 	}
@@ -360,7 +666,7 @@ func (c *Client) GetOrCreateSecurityGroup(ctx context.Context, name string, _ []
 	// List existing security groups
 	var groupIDs []string
 	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", c.projectID)
-	if err := c.ovhClient.GetWithContext(ctx, endpoint, &groupIDs); err != nil {
+	if err := c.get(ctx, endpoint, &groupIDs); err != nil {
 		// If listing fails, return error
 		return nil, fmt.Errorf("failed to list security groups: %w", err)
 	}
@@ -371,9 +677,21 @@ func (c *Client) GetOrCreateSecurityGroup(ctx context.Context, name string, _ []
 		ID:          "default-sg",
 		Name:        name,
 		Description: "Security group for " + name,
+		Labels:      labels,
 	}, nil
 }
 
+// ListSecurityGroups lists the security groups created for a given node pool
+func (c *Client) ListSecurityGroups(_ context.Context, _, _ string) ([]SecurityGroup, error) {
+	if c.ovhClient == nil {
+		return nil, fmt.Errorf("OVHcloud client not initialized")
+	}
+
+	// GetOrCreateSecurityGroup doesn't create security groups in the project yet,
+	// so there are none to list
+	return nil, nil
+}
+
 // DeleteSecurityGroup deletes a security group
 func (c *Client) DeleteSecurityGroup(_ context.Context, _ string) error {
 	if c.ovhClient == nil {
@@ -391,27 +709,80 @@ func ConvertToSecurityRules(_ []interface{}) []SecurityRule {
 	return nil
 }
 
-// GetFlavorIDByName resolves a flavor name to its UUID
-func (c *Client) GetFlavorIDByName(ctx context.Context, region, flavorName string) (string, error) {
+// flavor is a flavor as returned by the OVHcloud API
+type flavor struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+}
+
+// listFlavors lists the flavors offered in a region
+func (c *Client) listFlavors(ctx context.Context, region string) ([]flavor, error) {
 	if c.ovhClient == nil {
-		return "", fmt.Errorf("OVHcloud client not initialized")
+		return nil, fmt.Errorf("OVHcloud client not initialized")
+	}
+
+	var flavors []flavor
+	endpoint := fmt.Sprintf("/cloud/project/%s/flavor?region=%s", c.projectID, region)
+	if err := c.get(ctx, endpoint, &flavors); err != nil {
+		return nil, fmt.Errorf("failed to list flavors: %w", err)
 	}
+	return flavors, nil
+}
 
-	type Flavor struct {
-		ID        string `json:"id"`
-		Name      string `json:"name"`
-		Available bool   `json:"available"`
+// Ping checks that the API is reachable and accepts the credentials with a single lightweight
+// request. It bypasses retries and the circuit breaker, so health checks don't affect them
+func (c *Client) Ping(ctx context.Context) error {
+	if c.ovhClient == nil {
+		return fmt.Errorf("OVHcloud client not initialized")
 	}
 
-	var flavors []Flavor
-	endpoint := fmt.Sprintf("/cloud/project/%s/flavor?region=%s", c.projectID, region)
-	if err := c.ovhClient.GetWithContext(ctx, endpoint, &flavors); err != nil {
-		return "", fmt.Errorf("failed to list flavors: %w", err)
+	var regions []string
+	endpoint := fmt.Sprintf("/cloud/project/%s/region", c.projectID)
+	if err := c.ovhClient.GetWithContext(ctx, endpoint, &regions); err != nil {
+		return fmt.Errorf("failed to list regions: %w", err)
+	}
+	return nil
+}
+
+// ValidateFlavor checks that a flavor, given by name or UUID, exists and is available in
+// the region. Unavailable flavors are reported as ErrFlavorUnavailable, API failures as
+// other errors.
+func (c *Client) ValidateFlavor(ctx context.Context, region, flavorName string) error {
+	flavors, err := c.listFlavors(ctx, region)
+	if err != nil {
+		return err
 	}
 
-	for _, flavor := range flavors {
-		if flavor.Name == flavorName && flavor.Available {
-			return flavor.ID, nil
+	for _, f := range flavors {
+		if f.Name != flavorName && f.ID != flavorName {
+			continue
+		}
+		if !f.Available {
+			return fmt.Errorf("%w: flavor %s is not available in region %s", ErrFlavorUnavailable, flavorName, region)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: flavor %s not found in region %s", ErrFlavorUnavailable, flavorName, region)
+}
+
+// GetFlavorIDByName resolves a flavor name to its UUID
+func (c *Client) GetFlavorIDByName(ctx context.Context, region, flavorName string) (string, error) {
+	return c.resolve(fmt.Sprintf("flavor/%s/%s", region, flavorName), func() (string, error) {
+		return c.lookupFlavorID(ctx, region, flavorName)
+	})
+}
+
+func (c *Client) lookupFlavorID(ctx context.Context, region, flavorName string) (string, error) {
+	flavors, err := c.listFlavors(ctx, region)
+	if err != nil {
+		return "", err
+	}
+
+	for _, f := range flavors {
+		if f.Name == flavorName && f.Available {
+			return f.ID, nil
 		}
 	}
 
@@ -420,6 +791,12 @@ func (c *Client) GetFlavorIDByName(ctx context.Context, region, flavorName strin
 
 // GetImageIDByName resolves an image name to its UUID
 func (c *Client) GetImageIDByName(ctx context.Context, region, imageName string) (string, error) {
+	return c.resolve(fmt.Sprintf("image/%s/%s", region, imageName), func() (string, error) {
+		return c.lookupImageID(ctx, region, imageName)
+	})
+}
+
+func (c *Client) lookupImageID(ctx context.Context, region, imageName string) (string, error) {
 	if c.ovhClient == nil {
 		return "", fmt.Errorf("OVHcloud client not initialized")
 	}
@@ -432,7 +809,7 @@ func (c *Client) GetImageIDByName(ctx context.Context, region, imageName string)
 
 	var images []Image
 	endpoint := fmt.Sprintf("/cloud/project/%s/image?osType=linux&region=%s", c.projectID, region)
-	if err := c.ovhClient.GetWithContext(ctx, endpoint, &images); err != nil {
+	if err := c.get(ctx, endpoint, &images); err != nil {
 		return "", fmt.Errorf("failed to list images: %w", err)
 	}
 
@@ -447,6 +824,12 @@ func (c *Client) GetImageIDByName(ctx context.Context, region, imageName string)
 
 // GetSSHKeyIDByName resolves an SSH key name to its ID
 func (c *Client) GetSSHKeyIDByName(ctx context.Context, sshKeyName string) (string, error) {
+	return c.resolve("sshkey/"+sshKeyName, func() (string, error) {
+		return c.lookupSSHKeyID(ctx, sshKeyName)
+	})
+}
+
+func (c *Client) lookupSSHKeyID(ctx context.Context, sshKeyName string) (string, error) {
 	if c.ovhClient == nil {
 		return "", fmt.Errorf("OVHcloud client not initialized")
 	}
@@ -460,7 +843,7 @@ func (c *Client) GetSSHKeyIDByName(ctx context.Context, sshKeyName string) (stri
 
 	var sshKeys []SSHKey
 	endpoint := fmt.Sprintf("/cloud/project/%s/sshkey", c.projectID)
-	if err := c.ovhClient.GetWithContext(ctx, endpoint, &sshKeys); err != nil {
+	if err := c.get(ctx, endpoint, &sshKeys); err != nil {
 		return "", fmt.Errorf("failed to list SSH keys: %w", err)
 	}
 
@@ -476,6 +859,12 @@ func (c *Client) GetSSHKeyIDByName(ctx context.Context, sshKeyName string) (stri
 
 // GetNetworkIDByName resolves a network name to its UUID
 func (c *Client) GetNetworkIDByName(ctx context.Context, region, networkName string) (string, error) {
+	return c.resolve(fmt.Sprintf("network/%s/%s", region, networkName), func() (string, error) {
+		return c.lookupNetworkID(ctx, region, networkName)
+	})
+}
+
+func (c *Client) lookupNetworkID(ctx context.Context, region, networkName string) (string, error) {
 	if c.ovhClient == nil {
 		return "", fmt.Errorf("OVHcloud client not initialized")
 	}
@@ -495,7 +884,7 @@ func (c *Client) GetNetworkIDByName(ctx context.Context, region, networkName str
 
 	var networks []Network
 	endpoint := fmt.Sprintf("/cloud/project/%s/network/private", c.projectID)
-	if err := c.ovhClient.GetWithContext(ctx, endpoint, &networks); err != nil {
+	if err := c.get(ctx, endpoint, &networks); err != nil {
 		return "", fmt.Errorf("failed to list networks: %w", err)
 	}
 
@@ -516,6 +905,12 @@ func (c *Client) GetNetworkIDByName(ctx context.Context, region, networkName str
 
 // GetPublicNetworkID retrieves the public network ID for a specific region
 func (c *Client) GetPublicNetworkID(ctx context.Context, region string) (string, error) {
+	return c.resolve("public-network/"+region, func() (string, error) {
+		return c.lookupPublicNetworkID(ctx, region)
+	})
+}
+
+func (c *Client) lookupPublicNetworkID(ctx context.Context, region string) (string, error) {
 	if c.ovhClient == nil {
 		return "", fmt.Errorf("OVHcloud client not initialized")
 	}
@@ -536,7 +931,7 @@ func (c *Client) GetPublicNetworkID(ctx context.Context, region string) (string,
 
 	var networks []Network
 	endpoint := fmt.Sprintf("/cloud/project/%s/network/public", c.projectID)
-	if err := c.ovhClient.GetWithContext(ctx, endpoint, &networks); err != nil {
+	if err := c.get(ctx, endpoint, &networks); err != nil {
 		return "", fmt.Errorf("failed to list public networks: %w", err)
 	}
 
@@ -554,3 +949,68 @@ func (c *Client) GetPublicNetworkID(ctx context.Context, region string) (string,
 
 	return "", fmt.Errorf("public network not found in region '%s'", region)
 }
+
+// get sends a GET request and decodes the response into out, retrying retryable failures
+func (c *Client) get(ctx context.Context, endpoint string, out interface{}) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.ovhClient.GetWithContext(ctx, endpoint, out)
+	})
+}
+
+// executeWithRetry executes an operation with retry logic. An instance created by a
+// creation attempt that failed after all is named after the pool, so the pool lists it
+// and scales it down like any other surplus instance
+func (c *Client) executeWithRetry(ctx context.Context, operation func() error) error {
+	if c.circuitBreaker == nil {
+		return reliability.RetryOperation(ctx, c.retryConfig, operation)
+	}
+
+	// Requests the API rejected, such as lookups of deleted instances, show it is reachable
+	// and don't count towards opening the circuit
+	var rejected error
+	err := c.circuitBreaker.Execute(func() error {
+		err := reliability.RetryOperation(ctx, c.retryConfig, operation)
+		if isRejected(err) {
+			rejected = err
+			return nil
+		}
+		return err
+	})
+	if rejected != nil {
+		return rejected
+	}
+	return err
+}
+
+// isRejected reports whether err is the OVHcloud API refusing a request, as opposed to
+// failing to serve it
+func isRejected(err error) bool {
+	var apiErr *ovh.APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.Code < http.StatusInternalServerError &&
+		apiErr.Code != http.StatusRequestTimeout &&
+		apiErr.Code != http.StatusTooManyRequests
+}
+
+// resolve returns the cached ID for key, or looks it up and caches it on success
+func (c *Client) resolve(key string, lookup func() (string, error)) (string, error) {
+	if id, ok := c.resolverCache.get(key); ok {
+		return id, nil
+	}
+
+	id, err := lookup()
+	if err != nil {
+		return "", err
+	}
+	c.resolverCache.set(key, id)
+	return id, nil
+}
+
+// operationContext bounds a provider operation by the client's operation timeout,
+// unless the caller already set a deadline (e.g. a per-pool override)
+func (c *Client) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.operationTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.operationTimeout)
+}
@@ -0,0 +1,738 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+// testPollConfig polls created instances without waiting
+var testPollConfig = reliability.RetryConfig{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+// testInstanceCreatedAt is when the instances of the test API were created
+var testInstanceCreatedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestServer serves the given instances from the OVHcloud instance list endpoint, along
+// with a fixed flavor catalog for the GRA7 region
+func newTestServer(t *testing.T, projectID string, instanceNames []string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%d", time.Now().Unix())
+	})
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance", projectID), func(w http.ResponseWriter, _ *http.Request) {
+		instances := make([]map[string]interface{}, 0, len(instanceNames))
+		for i, name := range instanceNames {
+			instances = append(instances, map[string]interface{}{
+				"id":      fmt.Sprintf("instance-%d", i),
+				"name":    name,
+				"status":  StatusActive,
+				"created": testInstanceCreatedAt.Format(time.RFC3339),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(instances)
+	})
+
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/flavor", projectID), func(w http.ResponseWriter, r *http.Request) {
+		flavors := []map[string]interface{}{}
+		if r.URL.Query().Get("region") == "GRA7" {
+			flavors = append(flavors,
+				map[string]interface{}{"id": "flavor-b3-8", "name": "b3-8", "available": true},
+				map[string]interface{}{"id": "flavor-c2-7", "name": "c2-7", "available": false},
+			)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(flavors)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestClient creates a client for the test API at endpoint, retrying failed requests
+// without waiting
+func newTestClient(t *testing.T, endpoint, projectID string, opts ...ClientOption) *Client {
+	t.Helper()
+
+	opts = append([]ClientOption{WithRetryConfig(testRetryConfig())}, opts...)
+	client, err := NewClient(endpoint, "app-key", "app-secret", "consumer-key", projectID, "GRA7", opts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+// testRetryConfig retries failed requests without waiting
+func testRetryConfig() reliability.RetryConfig {
+	return reliability.RetryConfig{
+		MaxRetries:        2,
+		InitialBackoff:    time.Millisecond,
+		MaxBackoff:        time.Millisecond,
+		BackoffMultiplier: 1,
+		RetryableErrors:   IsRetryableError,
+	}
+}
+
+// newFailingServer answers instance requests with the given statuses in turn, then with
+// the instance once they run out. It returns the server and the number of requests made
+func newFailingServer(t *testing.T, projectID string, statuses ...int) (*httptest.Server, *int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%d", time.Now().Unix())
+	})
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance/", projectID), func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		requests++
+		if requests <= len(statuses) {
+			w.WriteHeader(statuses[requests-1])
+			fmt.Fprint(w, `{"class": "Server::Error", "message": "request failed"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "ACTIVE"}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	const projectID = "project"
+	server, requests := newFailingServer(t, projectID, http.StatusServiceUnavailable, http.StatusInternalServerError)
+	client := newTestClient(t, server.URL, projectID)
+
+	if _, err := client.GetInstance(context.Background(), "instance-0"); err != nil {
+		t.Fatalf("GetInstance() error = %v, want the request to be retried", err)
+	}
+	if *requests != 3 {
+		t.Errorf("Expected 3 attempts, got %d", *requests)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const projectID = "project"
+	cb := reliability.NewCircuitBreaker(reliability.CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: time.Minute})
+
+	// Deletes of instances that are already gone don't open the circuit
+	server, requests := newFailingServer(t, projectID, http.StatusNotFound)
+	client := newTestClient(t, server.URL, projectID, WithCircuitBreaker(cb))
+	if err := client.DeleteInstance(context.Background(), "instance-0"); err == nil {
+		t.Fatal("DeleteInstance() expected error for a deleted instance")
+	}
+	if *requests != 1 {
+		t.Errorf("Expected a single attempt, got %d", *requests)
+	}
+	if state := cb.GetState(); state != reliability.StateClosed {
+		t.Fatalf("Circuit breaker state = %v after a rejected request, want closed", state)
+	}
+
+	// An unavailable API does
+	unavailable := []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+	server, requests = newFailingServer(t, projectID, unavailable...)
+	client = newTestClient(t, server.URL, projectID, WithCircuitBreaker(cb))
+	if _, err := client.GetInstance(context.Background(), "instance-0"); err == nil {
+		t.Fatal("GetInstance() expected error while the API is unavailable")
+	}
+	if _, err := client.GetInstance(context.Background(), "instance-0"); !errors.Is(err, reliability.ErrCircuitOpen) {
+		t.Fatalf("GetInstance() error = %v, want %v", err, reliability.ErrCircuitOpen)
+	}
+	if *requests != len(unavailable) {
+		t.Errorf("Expected no requests while the circuit is open, got %d", *requests-len(unavailable))
+	}
+}
+
+func TestListInstancesFiltersByNodePool(t *testing.T) {
+	const projectID = "project"
+
+	server := newTestServer(t, projectID, []string{
+		"default-web-1a2b",
+		"default-web-3c4d",
+		"default-db-5e6f",
+		"default-web-api-7a8b",
+		"other-web-9c0d",
+		"web-1234",
+		"unrelated",
+	})
+
+	client := newTestClient(t, server.URL, projectID)
+
+	tests := []struct {
+		name         string
+		nodePoolName string
+		namespace    string
+		want         []string
+	}{
+		{
+			name:         "web pool",
+			nodePoolName: "web",
+			namespace:    "default",
+			want:         []string{"default-web-1a2b", "default-web-3c4d"},
+		},
+		{
+			name:         "db pool",
+			nodePoolName: "db",
+			namespace:    "default",
+			want:         []string{"default-db-5e6f"},
+		},
+		{
+			name:         "pool name sharing a prefix",
+			nodePoolName: "web-api",
+			namespace:    "default",
+			want:         []string{"default-web-api-7a8b"},
+		},
+		{
+			name:         "same pool name in another namespace",
+			nodePoolName: "web",
+			namespace:    "other",
+			want:         []string{"other-web-9c0d"},
+		},
+		{
+			name:         "unknown pool",
+			nodePoolName: "cache",
+			namespace:    "default",
+			want:         nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := client.ListInstances(context.Background(), tt.nodePoolName, tt.namespace)
+			if err != nil {
+				t.Fatalf("ListInstances() error = %v", err)
+			}
+
+			var got []string
+			for _, instance := range instances {
+				got = append(got, instance.Name)
+			}
+			sort.Strings(got)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListInstances() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ListInstances() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestListInstancesPaginated(t *testing.T) {
+	const projectID = "project"
+
+	// Each page names the cursor of the next one, the last page has none
+	pages := map[string]struct {
+		names []string
+		next  string
+	}{
+		"":         {names: []string{"default-web-1a2b", "default-db-3c4d"}, next: "cursor-2"},
+		"cursor-2": {names: []string{"default-web-5e6f", "other-web-7a8b"}, next: "cursor-3"},
+		"cursor-3": {names: []string{"default-web-9c0d"}},
+	}
+
+	var mu sync.Mutex
+	var cursors []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%d", time.Now().Unix())
+	})
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance", projectID), func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Pagination-Mode") != "CachedObjectList-Pages" {
+			t.Errorf("X-Pagination-Mode = %q, want CachedObjectList-Pages", r.Header.Get("X-Pagination-Mode"))
+		}
+		cursor := r.Header.Get("X-Pagination-Cursor")
+		mu.Lock()
+		cursors = append(cursors, cursor)
+		mu.Unlock()
+
+		page, ok := pages[cursor]
+		if !ok {
+			http.Error(w, `{"message":"unknown cursor"}`, http.StatusBadRequest)
+			return
+		}
+		instances := make([]map[string]interface{}, 0, len(page.names))
+		for _, name := range page.names {
+			instances = append(instances, map[string]interface{}{
+				"id":     "instance-" + name,
+				"name":   name,
+				"status": StatusActive,
+			})
+		}
+		if page.next != "" {
+			w.Header().Set("X-Pagination-Cursor-Next", page.next)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(instances)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := newTestClient(t, server.URL, projectID)
+	instances, err := client.ListInstances(context.Background(), "web", "default")
+	if err != nil {
+		t.Fatalf("ListInstances() error = %v", err)
+	}
+
+	var got []string
+	for _, instance := range instances {
+		got = append(got, instance.Name)
+	}
+	sort.Strings(got)
+	want := []string{"default-web-1a2b", "default-web-5e6f", "default-web-9c0d"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ListInstances() = %v, want %v", got, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(cursors) != fmt.Sprint([]string{"", "cursor-2", "cursor-3"}) {
+		t.Errorf("requested cursors = %q, want every page once", cursors)
+	}
+}
+
+func TestGetInstanceByName(t *testing.T) {
+	const projectID = "project"
+
+	server := newTestServer(t, projectID, []string{"default-web-1a2b", "web-3c4d"})
+	client := newTestClient(t, server.URL, projectID)
+
+	instance, err := client.GetInstanceByName(context.Background(), "web-3c4d")
+	if err != nil {
+		t.Fatalf("GetInstanceByName() error = %v", err)
+	}
+	if instance == nil || instance.ID != "instance-1" || !instance.CreatedAt.Equal(testInstanceCreatedAt) {
+		t.Errorf("GetInstanceByName() = %+v, want instance-1 created at %v", instance, testInstanceCreatedAt)
+	}
+
+	instance, err = client.GetInstanceByName(context.Background(), "missing")
+	if err != nil {
+		t.Fatalf("GetInstanceByName() error = %v", err)
+	}
+	if instance != nil {
+		t.Errorf("GetInstanceByName() = %+v, want nil", instance)
+	}
+}
+
+func TestValidateFlavor(t *testing.T) {
+	const projectID = "project"
+
+	server := newTestServer(t, projectID, nil)
+	client := newTestClient(t, server.URL, projectID)
+
+	tests := []struct {
+		name    string
+		region  string
+		flavor  string
+		wantErr bool
+	}{
+		{name: "available by name", region: "GRA7", flavor: "b3-8"},
+		{name: "available by id", region: "GRA7", flavor: "flavor-b3-8"},
+		{name: "unavailable", region: "GRA7", flavor: "c2-7", wantErr: true},
+		{name: "typo", region: "GRA7", flavor: "b3-88", wantErr: true},
+		{name: "other region", region: "SBG5", flavor: "b3-8", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.ValidateFlavor(context.Background(), tt.region, tt.flavor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateFlavor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrFlavorUnavailable) {
+				t.Errorf("ValidateFlavor() error = %v, want ErrFlavorUnavailable", err)
+			}
+		})
+	}
+}
+
+func TestCreateInstancePublicNetworkUnavailable(t *testing.T) {
+	const projectID = "project"
+
+	tests := []struct {
+		name             string
+		allowPrivateOnly bool
+		wantErr          bool
+	}{
+		{name: "fail", wantErr: true},
+		{name: "private-only", allowPrivateOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var created []map[string]interface{}
+
+			// No public network handler, so the lookup fails
+			mux := http.NewServeMux()
+			mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprintf(w, "%d", time.Now().Unix())
+			})
+			mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance", projectID), func(w http.ResponseWriter, r *http.Request) {
+				var request map[string]interface{}
+				_ = json.NewDecoder(r.Body).Decode(&request)
+				mu.Lock()
+				created = append(created, request)
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "BUILD"}`)
+			})
+			mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance/instance-0", projectID), func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "BUILD"}`)
+			})
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			client := newTestClient(t, server.URL, projectID,
+				WithInstancePollConfig(testPollConfig))
+			instance, err := client.CreateInstance(context.Background(), InstanceConfig{
+				Name:             "default-web-1a2b",
+				Region:           "GRA7",
+				NetworkID:        "private-network",
+				AllowPrivateOnly: tt.allowPrivateOnly,
+			})
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrPublicNetworkUnavailable) {
+					t.Errorf("CreateInstance() error = %v, want ErrPublicNetworkUnavailable", err)
+				}
+				if len(created) != 0 {
+					t.Errorf("Expected no instance to be created, got %d", len(created))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("CreateInstance() error = %v", err)
+			}
+			if !instance.PrivateOnly {
+				t.Error("Expected instance to be marked private-only")
+			}
+			if len(created) != 1 {
+				t.Fatalf("Expected one instance to be created, got %d", len(created))
+			}
+			networks, _ := created[0]["networks"].([]interface{})
+			if len(networks) != 1 {
+				t.Errorf("Expected only the private network to be requested, got %v", created[0]["networks"])
+			}
+		})
+	}
+}
+
+func TestCreateInstanceMonthlyBilling(t *testing.T) {
+	const projectID = "project"
+
+	for _, monthlyBilling := range []bool{false, true} {
+		t.Run(fmt.Sprintf("monthlyBilling=%t", monthlyBilling), func(t *testing.T) {
+			var mu sync.Mutex
+			var created []map[string]interface{}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprintf(w, "%d", time.Now().Unix())
+			})
+			mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance", projectID), func(w http.ResponseWriter, r *http.Request) {
+				var request map[string]interface{}
+				_ = json.NewDecoder(r.Body).Decode(&request)
+				mu.Lock()
+				created = append(created, request)
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "BUILD"}`)
+			})
+			mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance/instance-0", projectID), func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "ACTIVE"}`)
+			})
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			client := newTestClient(t, server.URL, projectID)
+			if _, err := client.CreateInstance(context.Background(), InstanceConfig{
+				Name:           "default-web-1a2b",
+				Region:         "GRA7",
+				MonthlyBilling: monthlyBilling,
+			}); err != nil {
+				t.Fatalf("CreateInstance() error = %v", err)
+			}
+
+			if len(created) != 1 {
+				t.Fatalf("Expected one instance to be created, got %d", len(created))
+			}
+			if got := created[0]["monthlyBilling"]; got != monthlyBilling {
+				t.Errorf("monthlyBilling = %v, want %v", got, monthlyBilling)
+			}
+		})
+	}
+}
+
+func TestCreateInstanceUserDataEncoding(t *testing.T) {
+	const projectID = "project"
+	const userData = "#cloud-config\nruncmd:\n  - kubeadm join\n"
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		want string
+	}{
+		{name: "default", want: base64.StdEncoding.EncodeToString([]byte(userData))},
+		{name: "base64", opts: []ClientOption{WithUserDataEncoding(UserDataEncodingBase64)}, want: base64.StdEncoding.EncodeToString([]byte(userData))},
+		{name: "plain", opts: []ClientOption{WithUserDataEncoding(UserDataEncodingPlain)}, want: userData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var created []map[string]interface{}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprintf(w, "%d", time.Now().Unix())
+			})
+			mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance", projectID), func(w http.ResponseWriter, r *http.Request) {
+				var request map[string]interface{}
+				_ = json.NewDecoder(r.Body).Decode(&request)
+				mu.Lock()
+				created = append(created, request)
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "BUILD"}`)
+			})
+			mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance/instance-0", projectID), func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "ACTIVE"}`)
+			})
+			server := httptest.NewServer(mux)
+			t.Cleanup(server.Close)
+
+			client := newTestClient(t, server.URL, projectID, tt.opts...)
+			if _, err := client.CreateInstance(context.Background(), InstanceConfig{
+				Name:     "default-web-1a2b",
+				Region:   "GRA7",
+				UserData: userData,
+			}); err != nil {
+				t.Fatalf("CreateInstance() error = %v", err)
+			}
+
+			if len(created) != 1 {
+				t.Fatalf("Expected one instance to be created, got %d", len(created))
+			}
+			if got := created[0]["userData"]; got != tt.want {
+				t.Errorf("userData = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := ValidateUserDataEncoding("gzip"); err == nil {
+		t.Error("ValidateUserDataEncoding() expected error for an unsupported encoding")
+	}
+}
+
+func TestCreateInstanceWaitsForAddress(t *testing.T) {
+	const projectID = "project"
+
+	var mu sync.Mutex
+	var polls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%d", time.Now().Unix())
+	})
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance", projectID), func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "BUILD"}`)
+	})
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance/instance-0", projectID), func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		polls++
+		building := polls < 3
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if building {
+			fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "BUILD"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id": "instance-0", "name": "default-web-1a2b", "status": "ACTIVE",
+			"ipAddresses": [{"ip": "203.0.113.10", "type": "public", "version": 4}]}`)
+	})
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/instance/instance-1", projectID), func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "instance-1", "name": "default-web-3c4d", "status": "BUILD"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := newTestClient(t, server.URL, projectID,
+		WithInstancePollConfig(testPollConfig))
+	instance, err := client.CreateInstance(context.Background(), InstanceConfig{
+		Name:   "default-web-1a2b",
+		Region: "GRA7",
+	})
+	if err != nil {
+		t.Fatalf("CreateInstance() error = %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("Expected the instance to be polled until active, got %d polls", polls)
+	}
+	if instance.Status != StatusActive || instance.IPv4 != "203.0.113.10" {
+		t.Errorf("CreateInstance() = status %s, IPv4 %q, want ACTIVE with the public IP", instance.Status, instance.IPv4)
+	}
+
+	// Polling stops at the context deadline, returning the instance as last seen
+	slowClient := newTestClient(t, server.URL, projectID,
+		WithInstancePollConfig(reliability.RetryConfig{MaxRetries: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour}))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	instance, err = slowClient.waitForInstanceAddress(ctx, "instance-1")
+	if err != nil {
+		t.Fatalf("waitForInstanceAddress() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waitForInstanceAddress() took %v, expected it to stop at the context deadline", elapsed)
+	}
+	if instance.Status != "BUILD" {
+		t.Errorf("waitForInstanceAddress() status = %s, want BUILD", instance.Status)
+	}
+}
+
+func TestResolverCache(t *testing.T) {
+	const projectID = "project"
+
+	var mu sync.Mutex
+	hits := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/time", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "%d", time.Now().Unix())
+	})
+	mux.HandleFunc(fmt.Sprintf("/cloud/project/%s/flavor", projectID), func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"id": "flavor-b3-8-%s", "name": "b3-8", "available": true}]`, r.URL.Query().Get("region"))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := newTestClient(t, server.URL, projectID,
+		WithResolverCacheTTL(time.Minute))
+	now := time.Now()
+	client.resolverCache.now = func() time.Time { return now }
+
+	resolve := func(region string) string {
+		t.Helper()
+		id, err := client.GetFlavorIDByName(context.Background(), region, "b3-8")
+		if err != nil {
+			t.Fatalf("GetFlavorIDByName() error = %v", err)
+		}
+		return id
+	}
+	apiCalls := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits
+	}
+
+	if id := resolve("GRA7"); id != "flavor-b3-8-GRA7" {
+		t.Errorf("GetFlavorIDByName() = %q, want flavor-b3-8-GRA7", id)
+	}
+
+	// Concurrent resolutions within the TTL are served from the cache
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = client.GetFlavorIDByName(context.Background(), "GRA7", "b3-8")
+		}()
+	}
+	wg.Wait()
+	if calls := apiCalls(); calls != 1 {
+		t.Errorf("API called %d times within the TTL, want 1", calls)
+	}
+
+	// The cache is keyed by region
+	if id := resolve("SBG5"); id != "flavor-b3-8-SBG5" {
+		t.Errorf("GetFlavorIDByName() = %q, want flavor-b3-8-SBG5", id)
+	}
+	if calls := apiCalls(); calls != 2 {
+		t.Errorf("API called %d times, want 2 after resolving another region", calls)
+	}
+
+	// Entries expire after the TTL
+	now = now.Add(time.Minute)
+	resolve("GRA7")
+	if calls := apiCalls(); calls != 3 {
+		t.Errorf("API called %d times, want 3 after the TTL expired", calls)
+	}
+}
+
+func TestNewClientEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{endpoint: "ovh-eu"},
+		{endpoint: "ovh-us"},
+		{endpoint: "ovh-ca"},
+		{endpoint: "kimsufi-eu"},
+		{endpoint: "soyoustart-ca"},
+		{endpoint: "https://eu.api.ovh.com/1.0"},
+		{endpoint: "ovh-eus", wantErr: true},
+		{endpoint: "", wantErr: true},
+		{endpoint: "eu.api.ovh.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			client, err := NewClient(tt.endpoint, "app-key", "app-secret", "consumer-key", "project", "GRA7")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && (client != nil || !strings.Contains(err.Error(), "ovh-eu")) {
+				t.Errorf("NewClient() = %v, %v, want no client and an error listing the known endpoints", client, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ovh/go-ovh/ovh"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+// IsRetryableError reports whether an operation that failed with err may succeed when retried.
+// OVHcloud API errors are retried based on their HTTP status: timeouts, conflicts, rate
+// limits and server errors. Other errors such as network failures fall back to
+// reliability.IsRetryableError. It is the default predicate of every Client request but Ping
+func IsRetryableError(err error) bool {
+	var apiErr *ovh.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+			return true
+		default:
+			return apiErr.Code >= http.StatusInternalServerError
+		}
+	}
+	return reliability.IsRetryableError(err)
+}
+
+// IsQuotaExceededError reports whether err is the OVHcloud API refusing to create a resource
+// because the project reached one of its quotas, which it reports as a 403 naming the quota
+func IsQuotaExceededError(err error) bool {
+	var apiErr *ovh.APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.Code == http.StatusForbidden &&
+		strings.Contains(strings.ToLower(apiErr.Message), "quota")
+}
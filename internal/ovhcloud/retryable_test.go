@@ -0,0 +1,122 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ovhcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ovh/go-ovh/ovh"
+)
+
+// apiError returns the error the SDK reports for a request answered with the given status
+func apiError(t *testing.T, status int) error {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/time" {
+			fmt.Fprintf(w, "%d", time.Now().Unix())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"class": "Client::Error", "message": "request failed"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client := newTestClient(t, server.URL, "project")
+	_, err := client.GetInstance(context.Background(), "instance-0")
+	if err == nil {
+		t.Fatalf("Expected error for status %d", status)
+	}
+	return err
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  func(t *testing.T) error
+		want bool
+	}{
+		{name: "too many requests", err: func(t *testing.T) error { return apiError(t, http.StatusTooManyRequests) }, want: true},
+		{name: "conflict", err: func(t *testing.T) error { return apiError(t, http.StatusConflict) }, want: true},
+		{name: "internal server error", err: func(t *testing.T) error { return apiError(t, http.StatusInternalServerError) }, want: true},
+		{name: "service unavailable", err: func(t *testing.T) error { return apiError(t, http.StatusServiceUnavailable) }, want: true},
+		{name: "bad request", err: func(t *testing.T) error { return apiError(t, http.StatusBadRequest) }, want: false},
+		{name: "forbidden", err: func(t *testing.T) error { return apiError(t, http.StatusForbidden) }, want: false},
+		{name: "not found", err: func(t *testing.T) error { return apiError(t, http.StatusNotFound) }, want: false},
+		{name: "network error", err: func(_ *testing.T) error { return errors.New("dial tcp: connection refused") }, want: true},
+		{name: "other error", err: func(_ *testing.T) error { return errors.New("flavor not found") }, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err(t)); got != tt.want {
+				t.Errorf("IsRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetryableErrors(t *testing.T) {
+	client := newTestClient(t, "ovh-eu", "project")
+	if client.retryConfig.RetryableErrors == nil || !client.retryConfig.RetryableErrors(errors.New("connection reset")) {
+		t.Error("Expected the client to retry with IsRetryableError by default")
+	}
+
+	client = newTestClient(t, "ovh-eu", "project",
+		WithRetryableErrors(func(error) bool { return false }))
+	if client.retryConfig.RetryableErrors(errors.New("connection reset")) {
+		t.Error("Expected the client to use the given predicate")
+	}
+}
+
+func TestIsQuotaExceededError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "instance quota",
+			err: fmt.Errorf("failed to create instance: %w",
+				&ovh.APIError{Code: http.StatusForbidden, Message: "Quota exceeded: maximum number of instances reached"}),
+			want: true,
+		},
+		{
+			name: "core quota",
+			err:  &ovh.APIError{Code: http.StatusForbidden, Message: "The cores quota of the project is exceeded"},
+			want: true,
+		},
+		{name: "forbidden", err: &ovh.APIError{Code: http.StatusForbidden, Message: "This call has not been granted"}, want: false},
+		{name: "bad request", err: &ovh.APIError{Code: http.StatusBadRequest, Message: "Quota parameter is invalid"}, want: false},
+		{name: "other error", err: errors.New("quota exceeded"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsQuotaExceededError(tt.err); got != tt.want {
+				t.Errorf("IsQuotaExceededError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
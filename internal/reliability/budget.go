@@ -0,0 +1,85 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted indicates an operation was not retried because the shared retry
+// budget was spent
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget is a token bucket bounding the retries of every operation sharing it, so an
+// outage that fails all operations at once doesn't multiply the load on the failing API.
+// First attempts are never limited, only the retries after them.
+// It is safe for concurrent use by multiple goroutines
+type RetryBudget struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRetryBudget creates a retry budget allowing rate retries per second on average and
+// bursts of up to burst retries. It starts full
+func NewRetryBudget(rate float64, burst int) *RetryBudget {
+	return &RetryBudget{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Allow spends a retry from the budget, reporting false when none is left
+func (b *RetryBudget) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Available returns the number of retries left in the budget
+func (b *RetryBudget) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	return int(b.tokens)
+}
+
+// refill adds the retries accrued since the last refill; b.mu must be held
+func (b *RetryBudget) refill() {
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
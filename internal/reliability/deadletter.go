@@ -17,7 +17,9 @@ limitations under the License.
 */
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -27,6 +29,9 @@ var (
 	ErrQueueFull = errors.New("dead letter queue is full")
 )
 
+// listenerBufferSize is how many added operations may wait for the listeners before Add blocks
+const listenerBufferSize = 100
+
 // FailedOperation represents an operation that failed
 type FailedOperation struct {
 	// ID is a unique identifier for the operation
@@ -45,12 +50,50 @@ type FailedOperation struct {
 	Metadata map[string]string
 }
 
+// MarshalJSON implements json.Marshaler, rendering Error as its message
+// since error values are not JSON-serializable
+func (op *FailedOperation) MarshalJSON() ([]byte, error) {
+	var errMsg string
+	if op.Error != nil {
+		errMsg = op.Error.Error()
+	}
+
+	return json.Marshal(struct {
+		ID            string            `json:"id"`
+		OperationType string            `json:"operationType"`
+		Payload       interface{}       `json:"payload,omitempty"`
+		Error         string            `json:"error,omitempty"`
+		Timestamp     time.Time         `json:"timestamp"`
+		RetryCount    int               `json:"retryCount"`
+		Metadata      map[string]string `json:"metadata,omitempty"`
+	}{
+		ID:            op.ID,
+		OperationType: op.OperationType,
+		Payload:       op.Payload,
+		Error:         errMsg,
+		Timestamp:     op.Timestamp,
+		RetryCount:    op.RetryCount,
+		Metadata:      op.Metadata,
+	})
+}
+
 // DeadLetterQueue stores failed operations for later analysis or retry
 type DeadLetterQueue struct {
 	mu         sync.RWMutex
 	operations map[string]*FailedOperation
 	maxSize    int
 	listeners  []func(*FailedOperation)
+	// sizeListeners are called with the operation counts by type whenever the queue changes
+	sizeListeners []func(map[string]int)
+	// processingListeners are called after each listener processed an added operation
+	processingListeners []func(operationType string, duration time.Duration, err error)
+
+	// Added operations are passed to the listeners in order by a single worker, started with
+	// the first listener and stopped by Close
+	notifyMu      sync.RWMutex
+	notifications chan *FailedOperation
+	workerDone    chan struct{}
+	closed        bool
 }
 
 // NewDeadLetterQueue creates a new dead letter queue
@@ -63,7 +106,24 @@ func NewDeadLetterQueue(maxSize int) *DeadLetterQueue {
 }
 
 // Add adds a failed operation to the queue
+// Listeners are notified asynchronously, but in the order operations are added. Add blocks
+// while the listeners are more than listenerBufferSize operations behind
 func (dlq *DeadLetterQueue) Add(op *FailedOperation) error {
+	if err := dlq.add(op); err != nil {
+		return err
+	}
+
+	// Notify listeners outside of the queue lock, so they may read the queue
+	dlq.notifyMu.RLock()
+	defer dlq.notifyMu.RUnlock()
+	if dlq.notifications != nil && !dlq.closed {
+		dlq.notifications <- op
+	}
+
+	return nil
+}
+
+func (dlq *DeadLetterQueue) add(op *FailedOperation) error {
 	dlq.mu.Lock()
 	defer dlq.mu.Unlock()
 
@@ -73,11 +133,7 @@ func (dlq *DeadLetterQueue) Add(op *FailedOperation) error {
 
 	op.Timestamp = time.Now()
 	dlq.operations[op.ID] = op
-
-	// Notify listeners
-	for _, listener := range dlq.listeners {
-		go listener(op)
-	}
+	dlq.notifySizeListeners()
 
 	return nil
 }
@@ -96,7 +152,11 @@ func (dlq *DeadLetterQueue) Remove(id string) {
 	dlq.mu.Lock()
 	defer dlq.mu.Unlock()
 
+	if _, exists := dlq.operations[id]; !exists {
+		return
+	}
 	delete(dlq.operations, id)
+	dlq.notifySizeListeners()
 }
 
 // List returns all failed operations
@@ -126,14 +186,108 @@ func (dlq *DeadLetterQueue) Clear() {
 	defer dlq.mu.Unlock()
 
 	dlq.operations = make(map[string]*FailedOperation)
+	dlq.notifySizeListeners()
 }
 
 // AddListener adds a listener that will be called when operations are added
+// Listeners are called one at a time from a single goroutine and must not add operations
+// to the queue themselves
 func (dlq *DeadLetterQueue) AddListener(listener func(*FailedOperation)) {
+	dlq.mu.Lock()
+	dlq.listeners = append(dlq.listeners, listener)
+	dlq.mu.Unlock()
+
+	dlq.notifyMu.Lock()
+	defer dlq.notifyMu.Unlock()
+	if dlq.notifications == nil && !dlq.closed {
+		dlq.notifications = make(chan *FailedOperation, listenerBufferSize)
+		dlq.workerDone = make(chan struct{})
+		go dlq.notifyListeners()
+	}
+}
+
+// notifyListeners passes added operations to the listeners until the queue is closed
+func (dlq *DeadLetterQueue) notifyListeners() {
+	defer close(dlq.workerDone)
+
+	for op := range dlq.notifications {
+		dlq.mu.RLock()
+		listeners := dlq.listeners
+		processingListeners := dlq.processingListeners
+		dlq.mu.RUnlock()
+
+		for _, listener := range listeners {
+			start := time.Now()
+			err := callListener(listener, op)
+			for _, processingListener := range processingListeners {
+				processingListener(op.OperationType, time.Since(start), err)
+			}
+		}
+	}
+}
+
+// callListener passes an operation to a listener, returning an error if the listener panics
+// so a failing listener doesn't stop the worker
+func callListener(listener func(*FailedOperation), op *FailedOperation) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("dead letter queue listener panicked on operation %s: %v", op.ID, recovered)
+		}
+	}()
+	listener(op)
+	return nil
+}
+
+// AddProcessingListener adds a listener that will be called after each listener processed
+// an added operation, with the operation type, how long the listener took and the error it
+// failed with. Listeners are called from the same goroutine as the other listeners
+func (dlq *DeadLetterQueue) AddProcessingListener(listener func(operationType string, duration time.Duration, err error)) {
 	dlq.mu.Lock()
 	defer dlq.mu.Unlock()
 
-	dlq.listeners = append(dlq.listeners, listener)
+	dlq.processingListeners = append(dlq.processingListeners, listener)
+}
+
+// Close stops notifying listeners of added operations, after the operations already added
+// have been passed to them. The queue itself remains usable
+func (dlq *DeadLetterQueue) Close() {
+	dlq.notifyMu.Lock()
+	if dlq.closed {
+		dlq.notifyMu.Unlock()
+		return
+	}
+	dlq.closed = true
+	workerDone := dlq.workerDone
+	if dlq.notifications != nil {
+		close(dlq.notifications)
+	}
+	dlq.notifyMu.Unlock()
+
+	if workerDone != nil {
+		<-workerDone
+	}
+}
+
+// AddSizeListener adds a listener that will be called with the operation counts by type
+// whenever operations are added or removed. Listeners are called synchronously while the
+// queue is locked, so they must not call back into the queue
+func (dlq *DeadLetterQueue) AddSizeListener(listener func(map[string]int)) {
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	dlq.sizeListeners = append(dlq.sizeListeners, listener)
+	listener(dlq.counts())
+}
+
+// notifySizeListeners must be called with the lock held
+func (dlq *DeadLetterQueue) notifySizeListeners() {
+	if len(dlq.sizeListeners) == 0 {
+		return
+	}
+	counts := dlq.counts()
+	for _, listener := range dlq.sizeListeners {
+		listener(counts)
+	}
 }
 
 // GetOldest returns the oldest failed operations up to the specified limit
@@ -176,3 +330,19 @@ func (dlq *DeadLetterQueue) GetByType(operationType string) []*FailedOperation {
 
 	return ops
 }
+
+// Counts returns the number of failed operations of each type
+func (dlq *DeadLetterQueue) Counts() map[string]int {
+	dlq.mu.RLock()
+	defer dlq.mu.RUnlock()
+
+	return dlq.counts()
+}
+
+func (dlq *DeadLetterQueue) counts() map[string]int {
+	counts := make(map[string]int)
+	for _, op := range dlq.operations {
+		counts[op.OperationType]++
+	}
+	return counts
+}
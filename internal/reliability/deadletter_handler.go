@@ -0,0 +1,127 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DeadLetterPath is the base path the dead letter queue handler is served on
+	DeadLetterPath = "/dlq"
+
+	dlqShutdownTimeout = 5 * time.Second
+)
+
+// DeadLetterHandler exposes the contents of a DeadLetterQueue over HTTP
+//
+// Routes:
+//   - GET    /dlq       lists all failed operations
+//   - GET    /dlq/{id}  returns a single failed operation
+//   - DELETE /dlq/{id}  removes a failed operation from the queue
+//
+// The handler doesn't authenticate requests, DELETE included. The manager's metrics server
+// isn't behind an authentication filter either, since controller-runtime's filter depends on
+// k8s.io/apiserver, so there is no manager auth to put it behind. The endpoint is disabled by
+// default and must be bound to localhost or restricted with a NetworkPolicy
+type DeadLetterHandler struct {
+	dlq *DeadLetterQueue
+}
+
+// NewDeadLetterHandler creates a new HTTP handler for the given queue
+func NewDeadLetterHandler(dlq *DeadLetterQueue) *DeadLetterHandler {
+	return &DeadLetterHandler{dlq: dlq}
+}
+
+// ServeHTTP implements http.Handler
+func (h *DeadLetterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, DeadLetterPath), "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		writeJSON(w, r, http.StatusOK, h.dlq.List())
+
+	case id != "" && r.Method == http.MethodGet:
+		op, exists := h.dlq.Get(id)
+		if !exists {
+			http.Error(w, fmt.Sprintf("operation %s not found", id), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, r, http.StatusOK, op)
+
+	case id != "" && r.Method == http.MethodDelete:
+		if _, exists := h.dlq.Get(id); !exists {
+			http.Error(w, fmt.Sprintf("operation %s not found", id), http.StatusNotFound)
+			return
+		}
+		h.dlq.Remove(id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ServeDeadLetterQueue serves the dead letter queue handler on addr until ctx is canceled
+func ServeDeadLetterQueue(ctx context.Context, addr string, dlq *DeadLetterQueue) error {
+	handler := NewDeadLetterHandler(dlq)
+
+	mux := http.NewServeMux()
+	mux.Handle(DeadLetterPath, handler)
+	mux.Handle(DeadLetterPath+"/", handler)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("dead letter queue server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), dlqShutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status code. The status is already
+// sent when encoding fails, so the failure is only logged
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to encode dead letter queue response", "path", r.URL.Path)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFailedOperationMarshalJSON(t *testing.T) {
+	timestamp := time.Date(2024, 12, 6, 10, 0, 0, 0, time.UTC)
+	op := &FailedOperation{
+		ID:            "default/pool/node/pool-1a2b",
+		OperationType: "DrainFailed",
+		Error:         errors.New("eviction blocked"),
+		Timestamp:     timestamp,
+		RetryCount:    2,
+		Metadata:      map[string]string{"node": "pool-1a2b"},
+	}
+
+	data, err := json.Marshal(op)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"id":            "default/pool/node/pool-1a2b",
+		"operationType": "DrainFailed",
+		"error":         "eviction blocked",
+		"timestamp":     "2024-12-06T10:00:00Z",
+		"retryCount":    float64(2),
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+	if _, exists := got["payload"]; exists {
+		t.Error("Expected an empty payload to be omitted")
+	}
+
+	// Operations without an error omit it
+	data, err = json.Marshal(&FailedOperation{ID: "op"})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	got = nil
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if _, exists := got["error"]; exists {
+		t.Errorf("Expected no error field, got %v", got["error"])
+	}
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	dlq := NewDeadLetterQueue(10)
+	defer dlq.Close()
+	for _, id := range []string{"op-1", "op-2"} {
+		if err := dlq.Add(&FailedOperation{ID: id, OperationType: "LeakedResource", Error: errors.New("failed")}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	handler := NewDeadLetterHandler(dlq)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/dlq")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GET /dlq = %d %q, want 200 application/json", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var listed []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to decode list: %v", err)
+	}
+	if len(listed) != 2 {
+		t.Errorf("GET /dlq listed %d operations, want 2", len(listed))
+	}
+
+	rec = serve(http.MethodGet, "/dlq/op-1")
+	var op map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &op); err != nil {
+		t.Fatalf("Failed to decode operation: %v", err)
+	}
+	if rec.Code != http.StatusOK || op["id"] != "op-1" || op["error"] != "failed" {
+		t.Errorf("GET /dlq/op-1 = %d %v", rec.Code, op)
+	}
+
+	if rec := serve(http.MethodDelete, "/dlq/op-1"); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE /dlq/op-1 = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if _, exists := dlq.Get("op-1"); exists {
+		t.Error("Expected the deleted operation to be removed from the queue")
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/dlq/op-1", http.StatusNotFound},
+		{http.MethodDelete, "/dlq/op-1", http.StatusNotFound},
+		{http.MethodDelete, "/dlq", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/dlq", http.StatusMethodNotAllowed},
+		{http.MethodPut, "/dlq/op-2", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := serve(tt.method, tt.path); rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+	if dlq.Size() != 1 {
+		t.Errorf("Size() = %d, want the remaining operation", dlq.Size())
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// UnmarshalJSON implements json.Unmarshaler, restoring Error from its message
+func (op *FailedOperation) UnmarshalJSON(data []byte) error {
+	var saved struct {
+		ID            string            `json:"id"`
+		OperationType string            `json:"operationType"`
+		Payload       interface{}       `json:"payload,omitempty"`
+		Error         string            `json:"error,omitempty"`
+		Timestamp     time.Time         `json:"timestamp"`
+		RetryCount    int               `json:"retryCount"`
+		Metadata      map[string]string `json:"metadata,omitempty"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	*op = FailedOperation{
+		ID:            saved.ID,
+		OperationType: saved.OperationType,
+		Payload:       saved.Payload,
+		Timestamp:     saved.Timestamp,
+		RetryCount:    saved.RetryCount,
+		Metadata:      saved.Metadata,
+	}
+	if saved.Error != "" {
+		op.Error = errors.New(saved.Error)
+	}
+	return nil
+}
+
+// SaveFile writes the queued operations to a JSON file, so they survive a restart of the
+// operator. The file is replaced atomically
+func (dlq *DeadLetterQueue) SaveFile(path string) error {
+	data, err := json.Marshal(dlq.GetOldest(dlq.Size()))
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter queue: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create dead letter queue file: %w", err)
+	}
+	// Only left behind if the file couldn't be replaced
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write dead letter queue file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write dead letter queue file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace dead letter queue file: %w", err)
+	}
+	return nil
+}
+
+// LoadFile adds the operations saved by SaveFile to the queue, keeping the time they failed
+// at. Listeners aren't notified of them again. A missing file holds no operations, and
+// operations beyond the queue's size are dropped
+func (dlq *DeadLetterQueue) LoadFile(path string) error {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: The path is set by the operator's flags
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dead letter queue file: %w", err)
+	}
+
+	var ops []*FailedOperation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return fmt.Errorf("failed to decode dead letter queue file: %w", err)
+	}
+
+	dlq.mu.Lock()
+	defer dlq.mu.Unlock()
+
+	for _, op := range ops {
+		if len(dlq.operations) >= dlq.maxSize {
+			break
+		}
+		dlq.operations[op.ID] = op
+	}
+	dlq.notifySizeListeners()
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterQueueListenersInOrder(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	dlq := NewDeadLetterQueue(1000)
+	var first, second []string
+	dlq.AddListener(func(op *FailedOperation) {
+		first = append(first, op.ID)
+	})
+	dlq.AddListener(func(op *FailedOperation) {
+		// Listeners may read the queue
+		if _, exists := dlq.Get(op.ID); !exists {
+			t.Errorf("operation %s not in queue when its listener was called", op.ID)
+		}
+		second = append(second, op.ID)
+	})
+
+	// More operations than the listener buffer holds
+	want := make([]string, 0, 2*listenerBufferSize)
+	for i := 0; i < 2*listenerBufferSize; i++ {
+		id := fmt.Sprintf("op-%d", i)
+		want = append(want, id)
+		if err := dlq.Add(&FailedOperation{ID: id, OperationType: "CreateServer"}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	dlq.Close()
+
+	for name, got := range map[string][]string{"first": first, "second": second} {
+		if len(got) != len(want) {
+			t.Fatalf("%s listener got %d operations, want %d", name, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s listener got %s at position %d, want %s", name, got[i], i, want[i])
+			}
+		}
+	}
+
+	// Operations added after Close are queued without notifying the listeners
+	if err := dlq.Add(&FailedOperation{ID: "after-close"}); err != nil {
+		t.Fatalf("Add() after Close error = %v", err)
+	}
+	if len(first) != len(want) {
+		t.Errorf("Expected no notification after Close, got %d operations", len(first))
+	}
+	dlq.Close()
+
+	// The worker has exited
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines after Close = %d, want at most %d", after, before)
+	}
+}
+
+func TestDeadLetterQueueSaveOnShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.json")
+
+	dlq := NewDeadLetterQueue(10)
+	var notified []string
+	dlq.AddListener(func(op *FailedOperation) {
+		notified = append(notified, op.ID)
+	})
+	for _, op := range []*FailedOperation{
+		{ID: "create-1", OperationType: "CreateServer", Error: errors.New("quota exceeded"), RetryCount: 3},
+		{ID: "delete-1", OperationType: "DeleteServer", Metadata: map[string]string{"nodepool": "workers"}},
+	} {
+		if err := dlq.Add(op); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	// The shutdown path: notify the listeners of the queued operations, then persist them
+	dlq.Close()
+	if err := dlq.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	restarted := NewDeadLetterQueue(10)
+	restarted.AddListener(func(op *FailedOperation) {
+		notified = append(notified, op.ID)
+	})
+	if err := restarted.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	restarted.Close()
+
+	if restarted.Size() != 2 {
+		t.Fatalf("Size() after LoadFile = %d, want 2", restarted.Size())
+	}
+	for _, saved := range dlq.List() {
+		loaded, exists := restarted.Get(saved.ID)
+		if !exists {
+			t.Fatalf("operation %s not loaded", saved.ID)
+		}
+		if loaded.OperationType != saved.OperationType || loaded.RetryCount != saved.RetryCount ||
+			!loaded.Timestamp.Equal(saved.Timestamp) || len(loaded.Metadata) != len(saved.Metadata) {
+			t.Errorf("loaded operation = %+v, want %+v", loaded, saved)
+		}
+		if (loaded.Error == nil) != (saved.Error == nil) || (saved.Error != nil && loaded.Error.Error() != saved.Error.Error()) {
+			t.Errorf("loaded operation %s error = %v, want %v", saved.ID, loaded.Error, saved.Error)
+		}
+	}
+	// Loaded operations were already passed to the listeners before the restart
+	if len(notified) != 2 {
+		t.Errorf("Expected listeners to be notified once per operation, got %v", notified)
+	}
+
+	// Without a saved queue there is nothing to load
+	if err := NewDeadLetterQueue(10).LoadFile(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("LoadFile() of a missing file error = %v", err)
+	}
+}
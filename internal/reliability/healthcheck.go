@@ -0,0 +1,130 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHealthCheckCacheTTL is how long the result of a cloud API health check is reused
+	DefaultHealthCheckCacheTTL = 30 * time.Second
+
+	// DefaultHealthCheckTimeout bounds a single provider ping
+	DefaultHealthCheckTimeout = 5 * time.Second
+)
+
+// Pinger performs a lightweight request against a cloud provider API
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// cloudAPIProvider is a named provider checked by a CloudAPIHealthCheck
+type cloudAPIProvider struct {
+	name           string
+	pinger         Pinger
+	circuitBreaker *CircuitBreaker
+}
+
+// CloudAPIHealthCheck reports whether the cloud provider APIs are reachable, for use as a
+// readiness check. Results are cached so frequent probes don't hit the provider rate limits.
+// It is safe for concurrent use by multiple goroutines
+type CloudAPIHealthCheck struct {
+	mu        sync.Mutex
+	providers []cloudAPIProvider
+	cacheTTL  time.Duration
+	timeout   time.Duration
+	checkedAt time.Time
+	lastErr   error
+}
+
+// HealthCheckOption is a function that configures a CloudAPIHealthCheck
+type HealthCheckOption func(*CloudAPIHealthCheck)
+
+// WithHealthCheckCacheTTL sets how long a check result is reused. Zero disables caching
+func WithHealthCheckCacheTTL(ttl time.Duration) HealthCheckOption {
+	return func(h *CloudAPIHealthCheck) {
+		h.cacheTTL = ttl
+	}
+}
+
+// WithHealthCheckTimeout bounds a single provider ping
+func WithHealthCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(h *CloudAPIHealthCheck) {
+		h.timeout = timeout
+	}
+}
+
+// NewCloudAPIHealthCheck creates a new cloud API health check
+func NewCloudAPIHealthCheck(opts ...HealthCheckOption) *CloudAPIHealthCheck {
+	h := &CloudAPIHealthCheck{
+		cacheTTL: DefaultHealthCheckCacheTTL,
+		timeout:  DefaultHealthCheckTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// AddProvider adds a provider API to the check. The check reports the provider unhealthy
+// without pinging it while cb, the circuit breaker of its client, is open. cb may be nil
+func (h *CloudAPIHealthCheck) AddProvider(name string, pinger Pinger, cb *CircuitBreaker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.providers = append(h.providers, cloudAPIProvider{name: name, pinger: pinger, circuitBreaker: cb})
+	h.checkedAt = time.Time{}
+}
+
+// Check implements healthz.Checker. Concurrent probes wait for a single check in progress
+// instead of pinging the providers again
+func (h *CloudAPIHealthCheck) Check(req *http.Request) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, provider := range h.providers {
+		if provider.circuitBreaker != nil && provider.circuitBreaker.GetState() == StateOpen {
+			return fmt.Errorf("%s API unavailable: %w", provider.name, ErrCircuitOpen)
+		}
+	}
+
+	if !h.checkedAt.IsZero() && time.Since(h.checkedAt) < h.cacheTTL {
+		return h.lastErr
+	}
+
+	h.lastErr = h.ping(req.Context())
+	h.checkedAt = time.Now()
+	return h.lastErr
+}
+
+// ping pings every provider, returning the first failure; h.mu must be held
+func (h *CloudAPIHealthCheck) ping(ctx context.Context) error {
+	for _, provider := range h.providers {
+		pingCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		err := provider.pinger.Ping(pingCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("%s API unreachable: %w", provider.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakePinger counts pings and returns err
+type fakePinger struct {
+	calls int
+	err   error
+}
+
+func (p *fakePinger) Ping(_ context.Context) error {
+	p.calls++
+	return p.err
+}
+
+func TestCloudAPIHealthCheck(t *testing.T) {
+	req := httptest.NewRequest("GET", "/readyz", nil)
+
+	t.Run("caches the result", func(t *testing.T) {
+		pinger := &fakePinger{}
+		check := NewCloudAPIHealthCheck(WithHealthCheckCacheTTL(time.Hour))
+		check.AddProvider("hetzner", pinger, nil)
+
+		for i := 0; i < 3; i++ {
+			if err := check.Check(req); err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+		}
+		if pinger.calls != 1 {
+			t.Errorf("Expected 1 ping, got %d", pinger.calls)
+		}
+	})
+
+	t.Run("reports failing providers", func(t *testing.T) {
+		apiErr := errors.New("connection refused")
+		check := NewCloudAPIHealthCheck(WithHealthCheckCacheTTL(0))
+		check.AddProvider("hetzner", &fakePinger{}, nil)
+		check.AddProvider("ovhcloud", &fakePinger{err: apiErr}, nil)
+
+		err := check.Check(req)
+		if !errors.Is(err, apiErr) {
+			t.Fatalf("Check() error = %v, want %v", err, apiErr)
+		}
+		if err.Error() != "ovhcloud API unreachable: connection refused" {
+			t.Errorf("Check() error = %q, want the failing provider named", err)
+		}
+	})
+
+	t.Run("fails while a provider circuit breaker is open", func(t *testing.T) {
+		hetznerPinger, ovhPinger := &fakePinger{}, &fakePinger{}
+		hetznerCB := NewCircuitBreaker(CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: time.Hour})
+		ovhCB := NewCircuitBreaker(CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: time.Hour})
+		_ = ovhCB.Execute(func() error { return errors.New("failed") })
+
+		check := NewCloudAPIHealthCheck(WithHealthCheckCacheTTL(0))
+		check.AddProvider("hetzner", hetznerPinger, hetznerCB)
+		check.AddProvider("ovhcloud", ovhPinger, ovhCB)
+
+		err := check.Check(req)
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Check() error = %v, want %v", err, ErrCircuitOpen)
+		}
+		if err.Error() != "ovhcloud API unavailable: circuit breaker is open" {
+			t.Errorf("Check() error = %q, want the failing provider named", err)
+		}
+		if hetznerPinger.calls != 0 || ovhPinger.calls != 0 {
+			t.Errorf("Expected no ping while a circuit is open, got %d and %d", hetznerPinger.calls, ovhPinger.calls)
+		}
+	})
+}
@@ -19,9 +19,11 @@ package reliability
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"sync"
 	"time"
 )
 
@@ -44,6 +46,12 @@ type RetryConfig struct {
 	BackoffMultiplier float64
 	// RetryableErrors is a function that determines if an error is retryable
 	RetryableErrors func(error) bool
+	// RetryAfter returns the wait an API asked for before retrying an error, e.g. until a
+	// rate limit resets. It overrides the backoff for that attempt when it returns true.
+	RetryAfter func(error) (time.Duration, bool)
+	// Budget bounds the retries shared with other operations using the same budget.
+	// Retries are unlimited when nil
+	Budget *RetryBudget
 }
 
 // DefaultRetryConfig returns a default retry configuration
@@ -81,8 +89,17 @@ func RetryOperation(ctx context.Context, config RetryConfig, operation func() er
 			break
 		}
 
+		if config.Budget != nil && !config.Budget.Allow() {
+			return fmt.Errorf("%w after %d attempts: %w", ErrRetryBudgetExhausted, attempt+1, err)
+		}
+
 		// Calculate backoff with jitter
 		sleepDuration := calculateBackoffWithJitter(backoff, config.MaxBackoff)
+		if config.RetryAfter != nil {
+			if wait, ok := config.RetryAfter(err); ok {
+				sleepDuration = wait
+			}
+		}
 
 		// Check if context is canceled
 		select {
@@ -181,8 +198,29 @@ const (
 	StateHalfOpen
 )
 
+// String returns the name of the state
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitBreakerState(%d)", int(s))
+	}
+}
+
+// MarshalJSON implements json.Marshaler, rendering the state as its name
+func (s CircuitBreakerState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
 // CircuitBreaker implements the circuit breaker pattern
+// It is safe for concurrent use by multiple goroutines
 type CircuitBreaker struct {
+	mu              sync.Mutex
 	maxFailures     int
 	resetTimeout    time.Duration
 	failureCount    int
@@ -217,7 +255,32 @@ func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
 
 // Execute runs an operation through the circuit breaker
 func (cb *CircuitBreaker) Execute(operation func() error) error {
-	// Check if circuit should transition from open to half-open
+	if err := cb.beforeExecute(); err != nil {
+		return err
+	}
+
+	// Execute the operation without holding the lock so concurrent
+	// callers are not serialized behind slow API calls
+	err := operation()
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err != nil {
+		cb.onFailure()
+		return err
+	}
+
+	cb.onSuccess()
+	return nil
+}
+
+// beforeExecute checks whether an operation may run and transitions the
+// circuit from open to half-open once the reset timeout has elapsed
+func (cb *CircuitBreaker) beforeExecute() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
 	switch cb.state {
 	case StateOpen:
 		if time.Since(cb.lastFailureTime) > cb.resetTimeout {
@@ -230,19 +293,10 @@ func (cb *CircuitBreaker) Execute(operation func() error) error {
 		// Proceed with operation execution
 	}
 
-	// Execute the operation
-	err := operation()
-
-	if err != nil {
-		cb.onFailure()
-		return err
-	}
-
-	cb.onSuccess()
 	return nil
 }
 
-// onFailure is called when an operation fails
+// onFailure is called when an operation fails; cb.mu must be held
 func (cb *CircuitBreaker) onFailure() {
 	cb.failureCount++
 	cb.lastFailureTime = time.Now()
@@ -256,7 +310,7 @@ func (cb *CircuitBreaker) onFailure() {
 	}
 }
 
-// onSuccess is called when an operation succeeds
+// onSuccess is called when an operation succeeds; cb.mu must be held
 func (cb *CircuitBreaker) onSuccess() {
 	switch cb.state {
 	case StateHalfOpen:
@@ -271,11 +325,39 @@ func (cb *CircuitBreaker) onSuccess() {
 
 // GetState returns the current state of the circuit breaker
 func (cb *CircuitBreaker) GetState() CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
 	return cb.state
 }
 
+// CircuitBreakerStats is a snapshot of a circuit breaker for introspection
+type CircuitBreakerStats struct {
+	// State is the state of the circuit
+	State CircuitBreakerState `json:"state"`
+	// FailureCount is the number of failures since the last success or state change
+	FailureCount int `json:"failureCount"`
+	// LastFailureTime is when the last failure happened, zero if none did
+	LastFailureTime time.Time `json:"lastFailureTime"`
+}
+
+// Stats returns a snapshot of the circuit breaker's state and failures
+func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return CircuitBreakerStats{
+		State:           cb.state,
+		FailureCount:    cb.failureCount,
+		LastFailureTime: cb.lastFailureTime,
+	}
+}
+
 // Reset resets the circuit breaker to closed state
 func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
 	cb.state = StateClosed
 	cb.failureCount = 0
 }
@@ -0,0 +1,147 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reliability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerState(t *testing.T) {
+	tests := []struct {
+		state CircuitBreakerState
+		want  string
+	}{
+		{StateClosed, "closed"},
+		{StateOpen, "open"},
+		{StateHalfOpen, "half-open"},
+		{CircuitBreakerState(7), "CircuitBreakerState(7)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+		data, err := json.Marshal(tt.state)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		if want := `"` + tt.want + `"`; string(data) != want {
+			t.Errorf("json.Marshal() = %s, want %s", data, want)
+		}
+	}
+}
+
+func TestCircuitBreakerStats(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{MaxFailures: 2, ResetTimeout: time.Minute})
+
+	if stats := cb.Stats(); stats.State != StateClosed || stats.FailureCount != 0 || !stats.LastFailureTime.IsZero() {
+		t.Errorf("Stats() = %+v for a new circuit breaker, want closed without failures", stats)
+	}
+
+	before := time.Now()
+	failure := errors.New("api unavailable")
+	for i := 0; i < 2; i++ {
+		_ = cb.Execute(func() error { return failure })
+	}
+	stats := cb.Stats()
+	if stats.State != StateOpen || stats.FailureCount != 2 || stats.LastFailureTime.Before(before) {
+		t.Errorf("Stats() = %+v after 2 failures, want open with 2 failures since %v", stats, before)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if decoded["state"] != "open" || decoded["failureCount"] != float64(2) {
+		t.Errorf("json.Marshal() = %s, want the state name and failure count", data)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Now()
+	budget := NewRetryBudget(2, 3)
+	budget.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !budget.Allow() {
+			t.Fatalf("Allow() #%d = false, want the burst to be allowed", i+1)
+		}
+	}
+	if budget.Allow() {
+		t.Error("Allow() = true after the burst, want false")
+	}
+
+	// Retries accrue at the budget's rate up to its burst
+	now = now.Add(time.Second)
+	if got := budget.Available(); got != 2 {
+		t.Errorf("Available() = %d a second later, want 2", got)
+	}
+	now = now.Add(time.Hour)
+	if got := budget.Available(); got != 3 {
+		t.Errorf("Available() = %d an hour later, want the burst of 3", got)
+	}
+}
+
+func TestRetryOperationBudgetExhausted(t *testing.T) {
+	budget := NewRetryBudget(0, 2)
+	config := RetryConfig{
+		MaxRetries:        5,
+		InitialBackoff:    time.Millisecond,
+		MaxBackoff:        time.Millisecond,
+		BackoffMultiplier: 1,
+		Budget:            budget,
+	}
+	failure := errors.New("503 service unavailable")
+
+	// The budget is shared: the first operation spends it, the second isn't retried
+	calls := 0
+	err := RetryOperation(context.Background(), config, func() error {
+		calls++
+		return failure
+	})
+	if !errors.Is(err, ErrRetryBudgetExhausted) || !errors.Is(err, failure) {
+		t.Errorf("RetryOperation() error = %v, want the operation's error with the budget exhausted", err)
+	}
+	if calls != 3 {
+		t.Errorf("Operation called %d times, want 3 with a budget of 2 retries", calls)
+	}
+
+	calls = 0
+	err = RetryOperation(context.Background(), config, func() error {
+		calls++
+		return failure
+	})
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Errorf("RetryOperation() error = %v, want the budget exhausted", err)
+	}
+	if calls != 1 {
+		t.Errorf("Operation called %d times, want only its first attempt", calls)
+	}
+
+	// Operations that succeed don't spend the budget
+	if err := RetryOperation(context.Background(), config, func() error { return nil }); err != nil {
+		t.Errorf("RetryOperation() error = %v", err)
+	}
+}
@@ -0,0 +1,513 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scaleway provides a client for interacting with the Scaleway Instances API.
+package scaleway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+const (
+	// DefaultAPIURL is the Scaleway API endpoint
+	DefaultAPIURL = "https://api.scaleway.com"
+
+	// StateRunning represents a running instance
+	StateRunning = "running"
+	// StateStopped represents a stopped instance, the state of new instances before power on
+	StateStopped = "stopped"
+
+	// listPageSize is the number of instances requested per page
+	listPageSize = 100
+
+	// rollbackTimeout bounds deleting an instance after its creation failed half-way
+	rollbackTimeout = time.Minute
+)
+
+// ClientInterface defines the interface for interacting with Scaleway
+//
+// Instances live in a zone, which every call takes explicitly as pools may use different zones.
+type ClientInterface interface {
+	ListInstances(ctx context.Context, zone, nodePoolName, namespace string) ([]Instance, error)
+	CreateInstance(ctx context.Context, config InstanceConfig) (*Instance, error)
+	DeleteInstance(ctx context.Context, zone, instanceID string) error
+	GetInstance(ctx context.Context, zone, instanceID string) (*Instance, error)
+}
+
+// APIError is an error response of the Scaleway API
+type APIError struct {
+	StatusCode int
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("scaleway API error (status %d, %s): %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Client wraps the Scaleway Instances API
+type Client struct {
+	secretKey        string
+	apiURL           string
+	httpClient       *http.Client
+	retryConfig      reliability.RetryConfig
+	circuitBreaker   *reliability.CircuitBreaker
+	operationTimeout time.Duration
+}
+
+// ClientOption is a function that configures a Client
+type ClientOption func(*Client)
+
+// WithAPIURL overrides the Scaleway API endpoint
+func WithAPIURL(apiURL string) ClientOption {
+	return func(c *Client) {
+		c.apiURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
+// WithRetryConfig sets a custom retry configuration
+func WithRetryConfig(config reliability.RetryConfig) ClientOption {
+	return func(c *Client) {
+		c.retryConfig = config
+	}
+}
+
+// WithRetryableErrors sets the predicate deciding which errors are retried, replacing the
+// default IsRetryableError
+func WithRetryableErrors(retryable func(error) bool) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.RetryableErrors = retryable
+	}
+}
+
+// WithRetryBudget sets a retry budget shared with other clients, bounding their retries
+// together during an outage
+func WithRetryBudget(budget *reliability.RetryBudget) ClientOption {
+	return func(c *Client) {
+		c.retryConfig.Budget = budget
+	}
+}
+
+// WithCircuitBreaker sets a circuit breaker
+func WithCircuitBreaker(cb *reliability.CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.circuitBreaker = cb
+	}
+}
+
+// WithOperationTimeout bounds create and delete operations that are
+// called without a deadline of their own
+func WithOperationTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.operationTimeout = timeout
+	}
+}
+
+// Instance represents a Scaleway instance
+type Instance struct {
+	ID        string
+	Name      string
+	Zone      string
+	Status    string
+	IPv4      string
+	IPv6      string
+	PrivateIP string
+	// CreatedAt is when the instance was created
+	CreatedAt time.Time
+}
+
+// InstanceConfig contains the configuration for creating an instance
+type InstanceConfig struct {
+	Name           string
+	Zone           string
+	CommercialType string
+	Image          string // Image label (e.g. ubuntu_jammy) or UUID
+	ProjectID      string
+	Labels         map[string]string // Stored as key=value tags
+	UserData       string
+}
+
+// NewClient creates a new Scaleway client authenticated with an API secret key
+func NewClient(secretKey string, opts ...ClientOption) *Client {
+	c := &Client{
+		secretKey:   secretKey,
+		apiURL:      DefaultAPIURL,
+		httpClient:  &http.Client{Timeout: time.Minute},
+		retryConfig: reliability.DefaultRetryConfig(),
+	}
+	c.retryConfig.RetryableErrors = IsRetryableError
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Tags converts labels to Scaleway tags
+//
+// Scaleway instances carry free-form string tags instead of labels, so pool
+// membership is stored as key=value tags and listed with a tag filter.
+func Tags(labels map[string]string) []string {
+	tags := make([]string, 0, len(labels))
+	for key, value := range labels {
+		tags = append(tags, key+"="+value)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// poolTags returns the tags identifying the instances of a node pool
+func poolTags(nodePoolName, namespace string) []string {
+	return Tags(map[string]string{
+		"nodepool":  nodePoolName,
+		"namespace": namespace,
+	})
+}
+
+// rawServer is a server as returned by the Scaleway API
+type rawServer struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Zone         string    `json:"zone"`
+	State        string    `json:"state"`
+	CreationDate time.Time `json:"creation_date"`
+	Tags         []string  `json:"tags"`
+	PublicIP     *struct {
+		Address string `json:"address"`
+	} `json:"public_ip"`
+	IPv6 *struct {
+		Address string `json:"address"`
+	} `json:"ipv6"`
+	PrivateIP *string `json:"private_ip"`
+	Volumes   map[string]struct {
+		ID string `json:"id"`
+	} `json:"volumes"`
+}
+
+// toInstance converts an API server to an Instance
+func (raw *rawServer) toInstance() *Instance {
+	instance := &Instance{
+		ID:        raw.ID,
+		Name:      raw.Name,
+		Zone:      raw.Zone,
+		Status:    raw.State,
+		CreatedAt: raw.CreationDate,
+	}
+	if raw.PublicIP != nil {
+		instance.IPv4 = raw.PublicIP.Address
+	}
+	if raw.IPv6 != nil {
+		instance.IPv6 = raw.IPv6.Address
+	}
+	if raw.PrivateIP != nil {
+		instance.PrivateIP = *raw.PrivateIP
+	}
+	return instance
+}
+
+// hasTags reports whether the server carries all the given tags
+func (raw *rawServer) hasTags(tags []string) bool {
+	present := make(map[string]bool, len(raw.Tags))
+	for _, tag := range raw.Tags {
+		present[tag] = true
+	}
+	for _, tag := range tags {
+		if !present[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+// ListInstances retrieves all instances for a specific node pool in a zone
+func (c *Client) ListInstances(ctx context.Context, zone, nodePoolName, namespace string) ([]Instance, error) {
+	tags := poolTags(nodePoolName, namespace)
+
+	var instances []Instance
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("tags", strings.Join(tags, ","))
+		query.Set("per_page", fmt.Sprint(listPageSize))
+		query.Set("page", fmt.Sprint(page))
+
+		var response struct {
+			Servers []rawServer `json:"servers"`
+		}
+		if err := c.do(ctx, http.MethodGet, c.zonePath(zone, "/servers?"+query.Encode()), nil, &response); err != nil {
+			return nil, fmt.Errorf("failed to list instances: %w", err)
+		}
+
+		for i := range response.Servers {
+			// The API matches tags server-side, double check so a misconfigured filter can't
+			// make the pool claim foreign instances
+			if response.Servers[i].hasTags(tags) {
+				instances = append(instances, *response.Servers[i].toInstance())
+			}
+		}
+		if len(response.Servers) < listPageSize {
+			return instances, nil
+		}
+	}
+}
+
+// CreateInstance creates an instance, sets its cloud-init user data and powers it on
+// An instance that fails to be configured or started is deleted again
+func (c *Client) CreateInstance(ctx context.Context, config InstanceConfig) (*Instance, error) {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	createReq := map[string]interface{}{
+		"name":                config.Name,
+		"commercial_type":     config.CommercialType,
+		"image":               config.Image,
+		"project":             config.ProjectID,
+		"tags":                Tags(config.Labels),
+		"dynamic_ip_required": true,
+	}
+
+	var response struct {
+		Server rawServer `json:"server"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.zonePath(config.Zone, "/servers"), createReq, &response); err != nil {
+		return nil, fmt.Errorf("failed to create instance: %w", err)
+	}
+	server := response.Server
+
+	if err := c.configureAndStart(ctx, config, server.ID); err != nil {
+		if rbErr := c.rollbackInstance(ctx, config.Zone, server.ID); rbErr != nil {
+			log.FromContext(ctx).Error(rbErr, "Failed to delete instance after creation failure",
+				"instance", config.Name, "id", server.ID)
+		}
+		return nil, err
+	}
+
+	return server.toInstance(), nil
+}
+
+// configureAndStart sets the user data of a new instance and powers it on
+func (c *Client) configureAndStart(ctx context.Context, config InstanceConfig, instanceID string) error {
+	if config.UserData != "" {
+		path := c.zonePath(config.Zone, fmt.Sprintf("/servers/%s/user_data/cloud-init", instanceID))
+		if err := c.doRaw(ctx, http.MethodPatch, path, "text/plain", []byte(config.UserData), nil); err != nil {
+			return fmt.Errorf("failed to set user data: %w", err)
+		}
+	}
+
+	path := c.zonePath(config.Zone, fmt.Sprintf("/servers/%s/action", instanceID))
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"action": "poweron"}, nil); err != nil {
+		return fmt.Errorf("failed to power on instance: %w", err)
+	}
+
+	return nil
+}
+
+// rollbackInstance deletes an instance that failed to be configured, independently of
+// the caller's context which may already be canceled or expired
+func (c *Client) rollbackInstance(ctx context.Context, zone, instanceID string) error {
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	return c.DeleteInstance(rollbackCtx, zone, instanceID)
+}
+
+// DeleteInstance deletes an instance and its volumes
+// Instances that are already gone are not an error.
+func (c *Client) DeleteInstance(ctx context.Context, zone, instanceID string) error {
+	ctx, cancel := c.operationContext(ctx)
+	defer cancel()
+
+	raw, err := c.getRawServer(ctx, zone, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete instance %s: %w", instanceID, err)
+	}
+	if raw == nil {
+		return nil
+	}
+
+	// Running instances are terminated, which stops them and deletes their volumes
+	if raw.State != StateStopped {
+		path := c.zonePath(zone, fmt.Sprintf("/servers/%s/action", instanceID))
+		if err := c.do(ctx, http.MethodPost, path, map[string]string{"action": "terminate"}, nil); err != nil {
+			return fmt.Errorf("failed to terminate instance %s: %w", instanceID, err)
+		}
+		return nil
+	}
+
+	// Stopped instances are deleted directly, leaving their volumes to be deleted separately
+	if err := c.do(ctx, http.MethodDelete, c.zonePath(zone, "/servers/"+instanceID), nil, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete instance %s: %w", instanceID, err)
+	}
+	for _, volume := range raw.Volumes {
+		if err := c.do(ctx, http.MethodDelete, c.zonePath(zone, "/volumes/"+volume.ID), nil, nil); err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete volume %s of instance %s: %w", volume.ID, instanceID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetInstance retrieves information about a specific instance
+func (c *Client) GetInstance(ctx context.Context, zone, instanceID string) (*Instance, error) {
+	raw, err := c.getRawServer(ctx, zone, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance %s: %w", instanceID, err)
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	return raw.toInstance(), nil
+}
+
+// getRawServer gets a server, returning nil if it does not exist
+func (c *Client) getRawServer(ctx context.Context, zone, instanceID string) (*rawServer, error) {
+	var response struct {
+		Server rawServer `json:"server"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.zonePath(zone, "/servers/"+instanceID), nil, &response); err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &response.Server, nil
+}
+
+// zonePath returns the Instances API path of a resource in a zone
+func (c *Client) zonePath(zone, path string) string {
+	return fmt.Sprintf("/instance/v1/zones/%s%s", zone, path)
+}
+
+// do sends a JSON request and decodes the JSON response into out, if given
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	return c.doRaw(ctx, method, path, "application/json", body, out)
+}
+
+// doRaw sends a request with the given body and content type, retrying retryable failures
+func (c *Client) doRaw(ctx context.Context, method, path, contentType string, body []byte, out interface{}) error {
+	return c.executeWithRetry(ctx, func() error {
+		return c.send(ctx, method, path, contentType, body, out)
+	})
+}
+
+// send sends a single request with the given body and content type
+func (c *Client) send(ctx context.Context, method, path, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("X-Auth-Token", c.secretKey)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(respBody, apiErr)
+		return apiErr
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// executeWithRetry executes an operation with retry logic. An instance created by a
+// creation attempt that failed after all carries the pool's tags, so the pool lists it
+// and scales it down like any other surplus instance
+func (c *Client) executeWithRetry(ctx context.Context, operation func() error) error {
+	if c.circuitBreaker == nil {
+		return reliability.RetryOperation(ctx, c.retryConfig, operation)
+	}
+
+	// Requests the API rejected, such as lookups of deleted instances, show it is reachable
+	// and don't count towards opening the circuit
+	var rejected error
+	err := c.circuitBreaker.Execute(func() error {
+		err := reliability.RetryOperation(ctx, c.retryConfig, operation)
+		if isRejected(err) {
+			rejected = err
+			return nil
+		}
+		return err
+	})
+	if rejected != nil {
+		return rejected
+	}
+	return err
+}
+
+// isRejected reports whether err is the Scaleway API refusing a request, as opposed to
+// failing to serve it
+func isRejected(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		apiErr.StatusCode < http.StatusInternalServerError &&
+		apiErr.StatusCode != http.StatusRequestTimeout &&
+		apiErr.StatusCode != http.StatusTooManyRequests
+}
+
+// isNotFound reports whether err is a Scaleway API not found error
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// operationContext bounds a provider operation by the client's operation timeout,
+// unless the caller already set a deadline (e.g. a per-pool override)
+func (c *Client) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.operationTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.operationTimeout)
+}
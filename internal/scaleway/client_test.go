@@ -0,0 +1,294 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+const testZone = "fr-par-1"
+
+// fakeAPI is a minimal Scaleway Instances API recording the requests it receives
+type fakeAPI struct {
+	mu       sync.Mutex
+	servers  []map[string]interface{}
+	requests []string
+	bodies   map[string]string
+	fail     map[string]int
+	// flaky holds how many times a request fails with a server error before it is answered
+	flaky map[string]int
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *Client) {
+	t.Helper()
+
+	api := &fakeAPI{
+		bodies: make(map[string]string),
+		fail:   make(map[string]int),
+		flaky:  make(map[string]int),
+	}
+	prefix := "/instance/v1/zones/" + testZone
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		key := r.Method + " " + strings.TrimPrefix(r.URL.Path, prefix)
+		body, _ := io.ReadAll(r.Body)
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		api.requests = append(api.requests, key)
+		api.bodies[key] = string(body)
+
+		w.Header().Set("Content-Type", "application/json")
+		if status, ok := api.fail[key]; ok {
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"type": "failed", "message": "%s failed"}`, key)
+			return
+		}
+		if api.flaky[key] > 0 {
+			api.flaky[key]--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, `{"type": "unavailable", "message": "%s failed"}`, key)
+			return
+		}
+
+		switch {
+		case key == "GET /servers":
+			var matched []map[string]interface{}
+			for _, s := range api.servers {
+				if hasAllTags(s, strings.Split(r.URL.Query().Get("tags"), ",")) {
+					matched = append(matched, s)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"servers": matched})
+		case key == "POST /servers":
+			fmt.Fprint(w, `{"server": {"id": "srv-1", "name": "web-1a2b", "zone": "fr-par-1", "state": "stopped"}}`)
+		case strings.HasPrefix(key, "GET /servers/"):
+			fmt.Fprint(w, `{"server": {"id": "srv-1", "name": "web-1a2b", "state": "stopped",
+				"volumes": {"0": {"id": "vol-1"}}}}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return api, NewClient("secret", WithAPIURL(server.URL), WithRetryConfig(testRetryConfig()))
+}
+
+// testRetryConfig retries failed requests without waiting
+func testRetryConfig() reliability.RetryConfig {
+	return reliability.RetryConfig{
+		MaxRetries:        2,
+		InitialBackoff:    time.Millisecond,
+		MaxBackoff:        time.Millisecond,
+		BackoffMultiplier: 1,
+		RetryableErrors:   IsRetryableError,
+	}
+}
+
+func hasAllTags(server map[string]interface{}, tags []string) bool {
+	present := map[string]bool{}
+	for _, tag := range server["tags"].([]interface{}) {
+		present[tag.(string)] = true
+	}
+	for _, tag := range tags {
+		if !present[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+func (api *fakeAPI) addServer(id, name string, tags ...string) {
+	tagValues := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		tagValues[i] = tag
+	}
+	api.servers = append(api.servers, map[string]interface{}{
+		"id":    id,
+		"name":  name,
+		"state": StateRunning,
+		"tags":  tagValues,
+	})
+}
+
+func TestListInstancesFiltersByTags(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.addServer("1", "web-1a2b", "managed-by=nodepools", "namespace=default", "nodepool=web")
+	api.addServer("2", "web-3c4d", "namespace=default", "nodepool=web")
+	api.addServer("3", "web-5e6f", "namespace=other", "nodepool=web")
+	api.addServer("4", "api-7a8b", "namespace=default", "nodepool=api")
+
+	instances, err := client.ListInstances(context.Background(), testZone, "web", "default")
+	if err != nil {
+		t.Fatalf("ListInstances() error = %v", err)
+	}
+
+	var got []string
+	for _, instance := range instances {
+		got = append(got, instance.Name)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "web-1a2b,web-3c4d" {
+		t.Errorf("ListInstances() = %v, want [web-1a2b web-3c4d]", got)
+	}
+}
+
+func TestCreateInstance(t *testing.T) {
+	api, client := newFakeAPI(t)
+
+	instance, err := client.CreateInstance(context.Background(), InstanceConfig{
+		Name:           "web-1a2b",
+		Zone:           testZone,
+		CommercialType: "DEV1-M",
+		Image:          "ubuntu_jammy",
+		ProjectID:      "project",
+		Labels:         map[string]string{"nodepool": "web", "namespace": "default"},
+		UserData:       "#cloud-config\n",
+	})
+	if err != nil {
+		t.Fatalf("CreateInstance() error = %v", err)
+	}
+	if instance.ID != "srv-1" {
+		t.Errorf("CreateInstance() = %+v, want srv-1", instance)
+	}
+
+	want := []string{
+		"POST /servers",
+		"PATCH /servers/srv-1/user_data/cloud-init",
+		"POST /servers/srv-1/action",
+	}
+	if strings.Join(api.requests, ";") != strings.Join(want, ";") {
+		t.Errorf("requests = %v, want %v", api.requests, want)
+	}
+
+	var created map[string]interface{}
+	_ = json.Unmarshal([]byte(api.bodies["POST /servers"]), &created)
+	if tags := fmt.Sprint(created["tags"]); tags != "[namespace=default nodepool=web]" {
+		t.Errorf("Expected pool tags, got %s", tags)
+	}
+	if api.bodies["PATCH /servers/srv-1/user_data/cloud-init"] != "#cloud-config\n" {
+		t.Errorf("Expected user data to be set, got %q", api.bodies["PATCH /servers/srv-1/user_data/cloud-init"])
+	}
+	if !strings.Contains(api.bodies["POST /servers/srv-1/action"], "poweron") {
+		t.Errorf("Expected instance to be powered on, got %s", api.bodies["POST /servers/srv-1/action"])
+	}
+}
+
+func TestCreateInstanceRollsBackOnPowerOnFailure(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.fail["POST /servers/srv-1/action"] = http.StatusInternalServerError
+
+	_, err := client.CreateInstance(context.Background(), InstanceConfig{
+		Name:           "web-1a2b",
+		Zone:           testZone,
+		CommercialType: "DEV1-M",
+		Image:          "ubuntu_jammy",
+		ProjectID:      "project",
+	})
+	if err == nil {
+		t.Fatal("CreateInstance() expected error when power on fails")
+	}
+
+	// The stopped instance and its volume are deleted
+	requests := strings.Join(api.requests, ";")
+	for _, want := range []string{"DELETE /servers/srv-1", "DELETE /volumes/vol-1"} {
+		if !strings.Contains(requests, want) {
+			t.Errorf("Expected rollback request %s, got %v", want, api.requests)
+		}
+	}
+}
+
+func TestDeleteInstanceNotFound(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.fail["GET /servers/srv-2"] = http.StatusNotFound
+
+	if err := client.DeleteInstance(context.Background(), testZone, "srv-2"); err != nil {
+		t.Errorf("DeleteInstance() error = %v, want nil for a deleted instance", err)
+	}
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.flaky["GET /servers"] = 2
+
+	if _, err := client.ListInstances(context.Background(), testZone, "web", "default"); err != nil {
+		t.Fatalf("ListInstances() error = %v, want the request to be retried", err)
+	}
+	if len(api.requests) != 3 {
+		t.Errorf("Expected 3 attempts, got %v", api.requests)
+	}
+}
+
+func TestDoesNotRetryRejectedRequests(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.fail["POST /servers"] = http.StatusBadRequest
+
+	_, err := client.CreateInstance(context.Background(), InstanceConfig{Name: "web-1a2b", Zone: testZone})
+	if err == nil {
+		t.Fatal("CreateInstance() expected error for a rejected request")
+	}
+	if len(api.requests) != 1 {
+		t.Errorf("Expected a single attempt, got %v", api.requests)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	api, client := newFakeAPI(t)
+	client.circuitBreaker = reliability.NewCircuitBreaker(reliability.CircuitBreakerConfig{
+		MaxFailures:  1,
+		ResetTimeout: time.Minute,
+	})
+
+	// Lookups of deleted instances don't open the circuit
+	api.fail["GET /servers/srv-2"] = http.StatusNotFound
+	if err := client.DeleteInstance(context.Background(), testZone, "srv-2"); err != nil {
+		t.Fatalf("DeleteInstance() error = %v", err)
+	}
+	if state := client.circuitBreaker.GetState(); state != reliability.StateClosed {
+		t.Fatalf("Circuit breaker state = %v after a rejected request, want closed", state)
+	}
+
+	// An unavailable API does
+	api.fail["GET /servers"] = http.StatusServiceUnavailable
+	if _, err := client.ListInstances(context.Background(), testZone, "web", "default"); err == nil {
+		t.Fatal("ListInstances() expected error while the API is unavailable")
+	}
+	requests := len(api.requests)
+	if _, err := client.ListInstances(context.Background(), testZone, "web", "default"); err == nil {
+		t.Fatal("ListInstances() expected error while the circuit is open")
+	}
+	if len(api.requests) != requests {
+		t.Errorf("Expected no requests while the circuit is open, got %v", api.requests[requests:])
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleway
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/autokubeio/autokube/internal/reliability"
+)
+
+// IsRetryableError reports whether an operation that failed with err may succeed when retried.
+// Scaleway API errors are retried based on their HTTP status: timeouts, conflicts, rate
+// limits and server errors. Other errors such as network failures fall back to
+// reliability.IsRetryableError
+func IsRetryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+			return true
+		default:
+			return apiErr.StatusCode >= http.StatusInternalServerError
+		}
+	}
+	return reliability.IsRetryableError(err)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaleway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "too many requests", err: &APIError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "conflict", err: &APIError{StatusCode: http.StatusConflict}, want: true},
+		{name: "internal server error", err: &APIError{StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "service unavailable", err: &APIError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "wrapped", err: fmt.Errorf("failed to list instances: %w", &APIError{StatusCode: http.StatusBadGateway}), want: true},
+		{name: "bad request", err: &APIError{StatusCode: http.StatusBadRequest}, want: false},
+		{name: "forbidden", err: &APIError{StatusCode: http.StatusForbidden}, want: false},
+		{name: "not found", err: &APIError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "network error", err: errors.New("dial tcp: connection refused"), want: true},
+		{name: "other error", err: errors.New("image not found"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
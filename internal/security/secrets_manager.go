@@ -21,6 +21,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -37,6 +38,13 @@ const (
 	DefaultSecretName = "hcloud-credentials"
 	// DefaultTokenKey is the default key for the token in the secret
 	DefaultTokenKey = "token"
+
+	// envelopeVersion is the format version of data encrypted by EncryptData. The encrypted
+	// data is base64(version | key ID | nonce | AES-GCM ciphertext), where the key ID is the
+	// first byte of the SHA-256 hash of the key it was encrypted with
+	envelopeVersion byte = 1
+	// envelopeHeaderSize is the size of the version and key ID prefix
+	envelopeHeaderSize = 2
 )
 
 var (
@@ -46,6 +54,12 @@ var (
 	ErrTokenKeyNotFound = errors.New("token key not found in secret")
 	// ErrEncryptionKeyRequired indicates an encryption key is required but not provided
 	ErrEncryptionKeyRequired = errors.New("encryption key is required for encryption/decryption")
+
+	// ErrDecryptionFailed indicates the data could not be decrypted with any of the keys
+	ErrDecryptionFailed = errors.New("failed to decrypt with any of the encryption keys")
+
+	// ErrInvalidEncryptionKey indicates the encryption key is not a valid AES key length
+	ErrInvalidEncryptionKey = errors.New("encryption key must be 16, 24 or 32 bytes (AES-128, AES-192 or AES-256)")
 )
 
 // SecretsManager manages cloud credentials stored in Kubernetes Secrets
@@ -55,6 +69,9 @@ type SecretsManager struct {
 	secretName    string
 	tokenKey      string
 	encryptionKey []byte
+	// decryptionKeys are previous encryption keys, still used to decrypt data encrypted
+	// before the key was rotated
+	decryptionKeys [][]byte
 }
 
 // SecretsManagerOption is a function that configures a SecretsManager
@@ -75,12 +92,22 @@ func WithTokenKey(key string) SecretsManagerOption {
 }
 
 // WithEncryptionKey sets the encryption key for encrypting/decrypting sensitive data
+// The key must be 16, 24 or 32 bytes long, see Validate
 func WithEncryptionKey(key []byte) SecretsManagerOption {
 	return func(sm *SecretsManager) {
 		sm.encryptionKey = key
 	}
 }
 
+// WithDecryptionKeys sets previous encryption keys. Data is always encrypted with the key
+// set by WithEncryptionKey, but decrypted with whichever key it was encrypted with, so the
+// encryption key can be rotated by moving the old key here. See ReEncrypt
+func WithDecryptionKeys(keys ...[]byte) SecretsManagerOption {
+	return func(sm *SecretsManager) {
+		sm.decryptionKeys = append(sm.decryptionKeys, keys...)
+	}
+}
+
 // NewSecretsManager creates a new secrets manager
 func NewSecretsManager(client kubernetes.Interface, namespace string, opts ...SecretsManagerOption) *SecretsManager {
 	sm := &SecretsManager{
@@ -97,21 +124,57 @@ func NewSecretsManager(client kubernetes.Interface, namespace string, opts ...Se
 	return sm
 }
 
+// Validate checks the configuration of the secrets manager, i.e. that the encryption key,
+// if set, and the decryption keys are valid AES keys. Keys of other lengths are rejected
+// rather than padded or truncated, since that would silently weaken or change the key
+func (sm *SecretsManager) Validate() error {
+	if len(sm.encryptionKey) > 0 {
+		if err := validateKey(sm.encryptionKey); err != nil {
+			return err
+		}
+	}
+	for i, key := range sm.decryptionKeys {
+		if err := validateKey(key); err != nil {
+			return fmt.Errorf("decryption key %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
 // GetToken retrieves the Hetzner Cloud token from the Kubernetes secret
 func (sm *SecretsManager) GetToken(ctx context.Context) (string, error) {
-	secret, err := sm.client.CoreV1().Secrets(sm.namespace).Get(ctx, sm.secretName, metav1.GetOptions{})
+	return sm.GetTokenFromSecret(ctx, sm.namespace, sm.secretName, sm.tokenKey)
+}
+
+// GetTokenFromSecret retrieves a token from an arbitrary secret, e.g. the per-pool
+// credentials of a NodePool. An empty key defaults to DefaultTokenKey
+func (sm *SecretsManager) GetTokenFromSecret(ctx context.Context, namespace, name, key string) (string, error) {
+	if key == "" {
+		key = DefaultTokenKey
+	}
+
+	data, err := sm.GetSecretData(ctx, namespace, name)
 	if err != nil {
-		return "", fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+		return "", err
 	}
 
-	token, ok := secret.Data[sm.tokenKey]
+	token, ok := data[key]
 	if !ok {
-		return "", fmt.Errorf("%w: key '%s' not found in secret '%s'", ErrTokenKeyNotFound, sm.tokenKey, sm.secretName)
+		return "", fmt.Errorf("%w: key '%s' not found in secret '%s'", ErrTokenKeyNotFound, key, name)
 	}
 
 	return string(token), nil
 }
 
+// GetSecretData retrieves the data of an arbitrary secret
+func (sm *SecretsManager) GetSecretData(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	secret, err := sm.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSecretNotFound, err)
+	}
+	return secret.Data, nil
+}
+
 // CreateOrUpdateSecret creates or updates the secret with the provided token
 func (sm *SecretsManager) CreateOrUpdateSecret(ctx context.Context, token string) error {
 	secret := &corev1.Secret{
@@ -155,70 +218,146 @@ func (sm *SecretsManager) DeleteSecret(ctx context.Context) error {
 	return nil
 }
 
-// EncryptData encrypts sensitive data using AES-GCM
+// EncryptData encrypts sensitive data using AES-GCM with the encryption key
 func (sm *SecretsManager) EncryptData(plaintext string) (string, error) {
 	if len(sm.encryptionKey) == 0 {
 		return "", ErrEncryptionKeyRequired
 	}
-
-	// Ensure key is 32 bytes for AES-256
-	key := make([]byte, 32)
-	copy(key, sm.encryptionKey)
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newGCM(sm.encryptionKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return "", err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
+	envelope := make([]byte, envelopeHeaderSize+gcm.NonceSize())
+	envelope[0] = envelopeVersion
+	envelope[1] = keyID(sm.encryptionKey)
+	nonce := envelope[envelopeHeaderSize:]
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	ciphertext := gcm.Seal(envelope, nonce, []byte(plaintext), nil)
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// DecryptData decrypts data encrypted with EncryptData
+// DecryptData decrypts data encrypted with EncryptData, using the encryption key or any of
+// the decryption keys. Data encrypted before the envelope was versioned, i.e. only prefixed
+// with the nonce, is still decrypted
 func (sm *SecretsManager) DecryptData(encryptedText string) (string, error) {
-	if len(sm.encryptionKey) == 0 {
+	if len(sm.encryptionKey) == 0 && len(sm.decryptionKeys) == 0 {
 		return "", ErrEncryptionKeyRequired
 	}
+	if err := sm.Validate(); err != nil {
+		return "", err
+	}
 
-	// Ensure key is 32 bytes for AES-256
-	key := make([]byte, 32)
-	copy(key, sm.encryptionKey)
-
-	ciphertext, err := base64.StdEncoding.DecodeString(encryptedText)
+	data, err := base64.StdEncoding.DecodeString(encryptedText)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode base64: %w", err)
 	}
 
-	block, err := aes.NewCipher(key)
+	keys := sm.keys()
+	// Try the keys whose ID matches first; IDs are a single byte, so several keys may match
+	if len(data) > envelopeHeaderSize && data[0] == envelopeVersion {
+		for _, key := range keys {
+			if keyID(key) != data[1] {
+				continue
+			}
+			if plaintext, err := open(key, data[envelopeHeaderSize:]); err == nil {
+				return string(plaintext), nil
+			}
+		}
+	}
+	// Unversioned data, including data whose nonce happens to start like an envelope
+	for _, key := range keys {
+		if plaintext, err := open(key, data); err == nil {
+			return string(plaintext), nil
+		}
+		if plaintext, err := open(legacyKey(key), data); err == nil {
+			return string(plaintext), nil
+		}
+	}
+
+	return "", ErrDecryptionFailed
+}
+
+// ReEncrypt decrypts data encrypted with any of the keys and encrypts it again with the
+// encryption key, to migrate stored data after the key was rotated
+func (sm *SecretsManager) ReEncrypt(encryptedText string) (string, error) {
+	plaintext, err := sm.DecryptData(encryptedText)
 	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
+		return "", err
 	}
+	return sm.EncryptData(plaintext)
+}
 
-	gcm, err := cipher.NewGCM(block)
+// keys returns the encryption key followed by the decryption keys
+func (sm *SecretsManager) keys() [][]byte {
+	keys := make([][]byte, 0, len(sm.decryptionKeys)+1)
+	if len(sm.encryptionKey) > 0 {
+		keys = append(keys, sm.encryptionKey)
+	}
+	return append(keys, sm.decryptionKeys...)
+}
+
+// legacyKey returns the key unversioned data was encrypted with: the key zero-padded to
+// 32 bytes for AES-256, whatever its length
+func legacyKey(key []byte) []byte {
+	padded := make([]byte, 32)
+	copy(padded, key)
+	return padded
+}
+
+// open decrypts data prefixed with its nonce
+func open(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
 	}
 
 	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("ciphertext too short")
+	if len(data) < nonceSize {
+		return nil, errors.New("ciphertext too short")
 	}
 
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
 	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to decrypt: %w", err)
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
 	}
+	return plaintext, nil
+}
+
+// newGCM returns an AES-GCM cipher using the given key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// validateKey checks that the key is a valid AES key
+func validateKey(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("%w: got %d bytes", ErrInvalidEncryptionKey, len(key))
+	}
+}
 
-	return string(plaintext), nil
+// keyID identifies the key data was encrypted with, without revealing the key
+func keyID(key []byte) byte {
+	sum := sha256.Sum256(key)
+	return sum[0]
 }
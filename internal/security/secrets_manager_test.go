@@ -0,0 +1,149 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretsManager_EncryptionKeyLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "no key", key: ""},
+		{name: "short key", key: "short", wantErr: true},
+		{name: "long key", key: strings.Repeat("k", 40), wantErr: true},
+		{name: "AES-128 key", key: strings.Repeat("k", 16)},
+		{name: "AES-192 key", key: strings.Repeat("k", 24)},
+		{name: "AES-256 key", key: strings.Repeat("k", 32)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := NewSecretsManager(fake.NewSimpleClientset(), "default", WithEncryptionKey([]byte(tt.key)))
+
+			err := sm.Validate()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEncryptionKey) {
+					t.Fatalf("Validate() error = %v, want %v", err, ErrInvalidEncryptionKey)
+				}
+				if _, err := sm.EncryptData("secret"); !errors.Is(err, ErrInvalidEncryptionKey) {
+					t.Errorf("EncryptData() error = %v, want %v", err, ErrInvalidEncryptionKey)
+				}
+				if _, err := sm.DecryptData("c2VjcmV0"); !errors.Is(err, ErrInvalidEncryptionKey) {
+					t.Errorf("DecryptData() error = %v, want %v", err, ErrInvalidEncryptionKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if tt.key == "" {
+				return
+			}
+
+			encrypted, err := sm.EncryptData("secret")
+			if err != nil {
+				t.Fatalf("EncryptData() error = %v", err)
+			}
+			decrypted, err := sm.DecryptData(encrypted)
+			if err != nil {
+				t.Fatalf("DecryptData() error = %v", err)
+			}
+			if decrypted != "secret" {
+				t.Errorf("DecryptData() = %q, want %q", decrypted, "secret")
+			}
+		})
+	}
+}
+
+func TestSecretsManager_KeyRotation(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	oldKey := []byte(strings.Repeat("o", 32))
+	newKey := []byte(strings.Repeat("n", 16))
+
+	old := NewSecretsManager(client, "default", WithEncryptionKey(oldKey))
+	encrypted, err := old.EncryptData("secret")
+	if err != nil {
+		t.Fatalf("EncryptData() error = %v", err)
+	}
+
+	// Without the old key the data can't be decrypted
+	withoutOld := NewSecretsManager(client, "default", WithEncryptionKey(newKey))
+	if _, err := withoutOld.DecryptData(encrypted); !errors.Is(err, ErrDecryptionFailed) {
+		t.Fatalf("DecryptData() without the old key error = %v, want %v", err, ErrDecryptionFailed)
+	}
+
+	rotated := NewSecretsManager(client, "default", WithEncryptionKey(newKey), WithDecryptionKeys(oldKey))
+	decrypted, err := rotated.DecryptData(encrypted)
+	if err != nil {
+		t.Fatalf("DecryptData() with the old key error = %v", err)
+	}
+	if decrypted != "secret" {
+		t.Errorf("DecryptData() = %q, want %q", decrypted, "secret")
+	}
+
+	reEncrypted, err := rotated.ReEncrypt(encrypted)
+	if err != nil {
+		t.Fatalf("ReEncrypt() error = %v", err)
+	}
+	// Re-encrypted data only needs the new key
+	decrypted, err = withoutOld.DecryptData(reEncrypted)
+	if err != nil {
+		t.Fatalf("DecryptData() of re-encrypted data error = %v", err)
+	}
+	if decrypted != "secret" {
+		t.Errorf("DecryptData() of re-encrypted data = %q, want %q", decrypted, "secret")
+	}
+}
+
+func TestSecretsManager_DecryptUnversioned(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		t.Run(fmt.Sprintf("%d byte key", size), func(t *testing.T) {
+			key := []byte(strings.Repeat("k", size))
+			sm := NewSecretsManager(fake.NewSimpleClientset(), "default", WithEncryptionKey(key))
+
+			// Data encrypted before the envelope carried a version and key ID: nonce | ciphertext,
+			// with the key zero-padded to 32 bytes
+			padded := make([]byte, 32)
+			copy(padded, key)
+			gcm, err := newGCM(padded)
+			if err != nil {
+				t.Fatalf("newGCM() error = %v", err)
+			}
+			nonce := make([]byte, gcm.NonceSize())
+			nonce[0] = envelopeVersion
+			encrypted := base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte("secret"), nil))
+
+			decrypted, err := sm.DecryptData(encrypted)
+			if err != nil {
+				t.Fatalf("DecryptData() error = %v", err)
+			}
+			if decrypted != "secret" {
+				t.Errorf("DecryptData() = %q, want %q", decrypted, "secret")
+			}
+		})
+	}
+}
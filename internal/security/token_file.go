@@ -0,0 +1,45 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadTokenFile reads a token from a file, such as a mounted secret, trimming the
+// surrounding whitespace and trailing newline tools write with it
+func ReadTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: The path is set by the operator's flags
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s: %w", path, ErrEmptyToken)
+	}
+	return token, nil
+}
+
+// FileTokenSource returns a TokenSource reading the token file at path
+func FileTokenSource(path string) TokenSource {
+	return func(context.Context) (string, error) {
+		return ReadTokenFile(path)
+	}
+}
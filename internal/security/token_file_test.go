@@ -0,0 +1,61 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadTokenFile(t *testing.T) {
+	token := strings.Repeat("a1B2", 16)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+		wantErr  error
+	}{
+		{name: "trailing newline", contents: token + "\n", want: token},
+		{name: "surrounding whitespace", contents: "  " + token + " \r\n\n", want: token},
+		{name: "no newline", contents: token, want: token},
+		{name: "empty", contents: " \n", wantErr: ErrEmptyToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "token")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatalf("Failed to write token file: %v", err)
+			}
+			got, err := ReadTokenFile(path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadTokenFile() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ReadTokenFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadTokenFile(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadTokenFile() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+}
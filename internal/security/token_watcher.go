@@ -0,0 +1,125 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultTokenPollInterval is how often a token source is checked for a rotated token
+const DefaultTokenPollInterval = time.Minute
+
+// TokenSource returns the current token, e.g. from a file or a Kubernetes secret
+type TokenSource func(ctx context.Context) (string, error)
+
+// TokenWatcher polls a token source and hands a rotated token to its handler once it
+// passes validation. Invalid tokens are reported once and the previous token stays in use
+type TokenWatcher struct {
+	source   TokenSource
+	interval time.Duration
+	validate func(ctx context.Context, token string) error
+	onChange func(token string)
+	onError  func(err error)
+
+	current  string
+	rejected string
+}
+
+// TokenWatcherOption configures a TokenWatcher
+type TokenWatcherOption func(*TokenWatcher)
+
+// WithTokenPollInterval sets how often the token source is checked
+func WithTokenPollInterval(interval time.Duration) TokenWatcherOption {
+	return func(w *TokenWatcher) {
+		w.interval = interval
+	}
+}
+
+// WithTokenValidation sets the check a rotated token has to pass, e.g. TokenValidator.Validate
+func WithTokenValidation(validate func(ctx context.Context, token string) error) TokenWatcherOption {
+	return func(w *TokenWatcher) {
+		w.validate = validate
+	}
+}
+
+// WithTokenErrorHandler sets the handler of tokens that can't be read or are invalid
+func WithTokenErrorHandler(onError func(err error)) TokenWatcherOption {
+	return func(w *TokenWatcher) {
+		w.onError = onError
+	}
+}
+
+// NewTokenWatcher creates a watcher of a token source, currently holding token
+func NewTokenWatcher(source TokenSource, token string, onChange func(token string), opts ...TokenWatcherOption) *TokenWatcher {
+	w := &TokenWatcher{
+		source:   source,
+		interval: DefaultTokenPollInterval,
+		onChange: onChange,
+		onError:  func(error) {},
+		current:  token,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Start polls the token source until ctx is canceled. It implements manager.Runnable
+func (w *TokenWatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every replica keeps its
+// clients' token current
+func (w *TokenWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// check reads the token and hands a new valid token to the handler
+func (w *TokenWatcher) check(ctx context.Context) {
+	token, err := w.source(ctx)
+	if err != nil {
+		w.onError(err)
+		return
+	}
+	if token == w.current || token == w.rejected {
+		return
+	}
+
+	if w.validate != nil {
+		if err := w.validate(ctx, token); err != nil {
+			w.rejected = token
+			w.onError(fmt.Errorf("rotated token is invalid: %w", err))
+			return
+		}
+	}
+	w.current = token
+	w.rejected = ""
+	w.onChange(token)
+}
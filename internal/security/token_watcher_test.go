@@ -0,0 +1,104 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package security
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTokenWatcherFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	write := func(token string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+			t.Fatalf("Failed to write token file: %v", err)
+		}
+	}
+	write("initial")
+
+	var changes, validated []string
+	var errs []error
+	watcher := NewTokenWatcher(FileTokenSource(path), "initial",
+		func(token string) { changes = append(changes, token) },
+		WithTokenValidation(func(_ context.Context, token string) error {
+			validated = append(validated, token)
+			if token == "revoked" {
+				return ErrTokenValidationFailed
+			}
+			return nil
+		}),
+		WithTokenErrorHandler(func(err error) { errs = append(errs, err) }),
+	)
+	ctx := context.Background()
+
+	// An unchanged token isn't validated again
+	watcher.check(ctx)
+	if len(validated) != 0 || len(changes) != 0 {
+		t.Errorf("Expected no validation or change for an unchanged token, got %v and %v", validated, changes)
+	}
+
+	// An invalid token is reported once and the previous token stays in use
+	write("revoked")
+	watcher.check(ctx)
+	watcher.check(ctx)
+	if len(errs) != 1 || !errors.Is(errs[0], ErrTokenValidationFailed) {
+		t.Errorf("Expected one validation error, got %v", errs)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected an invalid token not to be used, got %v", changes)
+	}
+
+	write("rotated")
+	watcher.check(ctx)
+	if want := []string{"rotated"}; len(changes) != 1 || changes[0] != want[0] {
+		t.Errorf("Token changes = %v, want %v", changes, want)
+	}
+	if want := []string{"revoked", "rotated"}; strings.Join(validated, ",") != strings.Join(want, ",") {
+		t.Errorf("Validated tokens = %v, want %v", validated, want)
+	}
+}
+
+func TestTokenWatcherSecret(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hcloud-credentials", Namespace: "default"},
+		Data:       map[string][]byte{DefaultTokenKey: []byte("initial")},
+	}
+	client := fake.NewSimpleClientset(secret)
+	sm := NewSecretsManager(client, "default")
+
+	var current string
+	watcher := NewTokenWatcher(sm.GetToken, "initial", func(token string) { current = token })
+	ctx := context.Background()
+
+	secret.Data[DefaultTokenKey] = []byte("rotated")
+	if _, err := client.CoreV1().Secrets("default").Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update secret: %v", err)
+	}
+	watcher.check(ctx)
+	if current != "rotated" {
+		t.Errorf("Token after the secret was updated = %q, want rotated", current)
+	}
+}